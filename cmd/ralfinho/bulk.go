@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+)
+
+// Bulk actions requested by the session browser. Each reports a one-line
+// summary to w and keeps going past per-run failures, which are returned
+// together at the end.
+
+// bulkDeleteRuns deletes the given runs through the configured store, so
+// an index drops them together with their directories.
+func bulkDeleteRuns(runsDir string, runIDs []string, w io.Writer) error {
+	store := openRunStore(runsDir)
+	defer store.Close()
+
+	var errs []error
+	deleted := 0
+	for _, runID := range runIDs {
+		if err := store.DeleteRun(runID); err != nil {
+			errs = append(errs, fmt.Errorf("deleting %s: %w", runID, err))
+			continue
+		}
+		deleted++
+	}
+	fmt.Fprintf(w, "Deleted %d run(s)\n", deleted)
+	return errors.Join(errs...)
+}
+
+// bulkExportRuns exports each run as an HTML report into the current
+// directory, the same as "ralfinho export <run-id>".
+func bulkExportRuns(runsDir string, runIDs []string, w io.Writer) error {
+	var errs []error
+	var paths []string
+	for _, runID := range runIDs {
+		path, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: runID, ExportFormat: "html"})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("exporting %s: %w", runID, err))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	fmt.Fprintf(w, "Exported %d run(s)", len(paths))
+	if len(paths) > 0 {
+		fmt.Fprintf(w, ": %s", strings.Join(paths, ", "))
+	}
+	fmt.Fprintln(w)
+	return errors.Join(errs...)
+}
+
+// bulkTagRuns adds tag to each run's meta.json, or removes it when tag
+// starts with "-".
+func bulkTagRuns(runsDir string, runIDs []string, tag string, w io.Writer) error {
+	store := openRunStore(runsDir)
+	defer store.Close()
+
+	var errs []error
+	tagged := 0
+	for _, runID := range runIDs {
+		meta, err := store.ReadMeta(runID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tagging %s: %w", runID, err))
+			continue
+		}
+		meta.Tags = applyTag(meta.Tags, tag)
+		if err := store.WriteMeta(meta); err != nil {
+			errs = append(errs, fmt.Errorf("tagging %s: %w", runID, err))
+			continue
+		}
+		tagged++
+	}
+	verb := "Tagged"
+	if strings.HasPrefix(tag, "-") {
+		verb = "Untagged"
+	}
+	fmt.Fprintf(w, "%s %d run(s) %s\n", verb, tagged, strings.TrimPrefix(tag, "-"))
+	return errors.Join(errs...)
+}
+
+// applyTag returns tags with tag added, or with it removed when tag starts
+// with "-". Adding a tag the run already has is a no-op.
+func applyTag(tags []string, tag string) []string {
+	if name, ok := strings.CutPrefix(tag, "-"); ok {
+		return slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == name })
+	}
+	if slices.Contains(tags, tag) {
+		return tags
+	}
+	return append(slices.Clone(tags), tag)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func TestApplyTag(t *testing.T) {
+	tests := []struct {
+		tags []string
+		tag  string
+		want string
+	}{
+		{nil, "nightly", "nightly"},
+		{[]string{"a"}, "nightly", "a,nightly"},
+		{[]string{"a", "nightly"}, "nightly", "a,nightly"},
+		{[]string{"a", "nightly"}, "-nightly", "a"},
+		{[]string{"a"}, "-nightly", "a"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(applyTag(tt.tags, tt.tag), ","); got != tt.want {
+			t.Errorf("applyTag(%q, %q) = %q, want %q", tt.tags, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestBulkTagRuns(t *testing.T) {
+	runsDir := t.TempDir()
+	for _, id := range []string{"run-a", "run-b"} {
+		writeMetaOnlyRun(t, runsDir, id, runner.RunMeta{RunID: id, Agent: "pi", Status: string(runner.StatusFailed)})
+	}
+
+	var out bytes.Buffer
+	err := bulkTagRuns(runsDir, []string{"run-a", "run-b", "missing"}, "flaky", &out)
+	if err == nil || !strings.Contains(err.Error(), "tagging missing") {
+		t.Errorf("err = %v, want the missing run reported", err)
+	}
+	if !strings.Contains(out.String(), "Tagged 2 run(s) flaky") {
+		t.Errorf("output = %q", out.String())
+	}
+	for _, id := range []string{"run-a", "run-b"} {
+		meta, err := runner.NewFSStore(runsDir).ReadMeta(id)
+		if err != nil || strings.Join(meta.Tags, ",") != "flaky" || meta.Agent != "pi" {
+			t.Errorf("%s meta = %+v, %v", id, meta, err)
+		}
+	}
+
+	out.Reset()
+	if err := bulkTagRuns(runsDir, []string{"run-a"}, "-flaky", &out); err != nil {
+		t.Fatalf("removing tag: %v", err)
+	}
+	if meta, _ := runner.NewFSStore(runsDir).ReadMeta("run-a"); len(meta.Tags) != 0 {
+		t.Errorf("tags after removal = %q", meta.Tags)
+	}
+	if !strings.Contains(out.String(), "Untagged 1 run(s) flaky") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestBulkDeleteRuns(t *testing.T) {
+	useStorageBackend(t, "sqlite")
+	runsDir := t.TempDir()
+	outside := t.TempDir()
+	for _, id := range []string{"run-a", "run-b", "run-c"} {
+		writeMetaOnlyRun(t, runsDir, id, runner.RunMeta{RunID: id})
+	}
+	// Index the runs before deleting them.
+	if summaries, err := listRunSummaries(runsDir); err != nil || len(summaries) != 3 {
+		t.Fatalf("listRunSummaries = %d runs, %v", len(summaries), err)
+	}
+
+	var out bytes.Buffer
+	err := bulkDeleteRuns(runsDir, []string{"run-a", "run-b", filepath.Join("..", filepath.Base(outside))}, &out)
+	if err == nil || !strings.Contains(err.Error(), "invalid run ID") {
+		t.Errorf("bulkDeleteRuns error = %v, want the ID outside the runs dir rejected", err)
+	}
+	if out.String() != "Deleted 2 run(s)\n" {
+		t.Errorf("output = %q", out.String())
+	}
+	for _, id := range []string{"run-a", "run-b"} {
+		if _, err := os.Stat(filepath.Join(runsDir, id)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", id)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("directory outside the runs dir was touched: %v", err)
+	}
+
+	// The index forgot the deleted runs without waiting for a rescan.
+	store := openRunStore(runsDir)
+	defer store.Close()
+	runs, err := store.ListRuns(runner.RunQuery{})
+	if err != nil || len(runs) != 1 || runs[0].RunID != "run-c" {
+		t.Errorf("indexed runs = %+v, %v; want only run-c", runs, err)
+	}
+}
+
+func TestBulkExportRuns(t *testing.T) {
+	runsDir := t.TempDir()
+	for _, id := range []string{"run-a", "run-b"} {
+		writeMetaOnlyRun(t, runsDir, id, runner.RunMeta{RunID: id, Agent: "pi", Status: string(runner.StatusCompleted)})
+		writeRunEventsArtifact(t, runsDir, id, `{"type":"turn_end"}`)
+	}
+	t.Chdir(t.TempDir())
+
+	var out bytes.Buffer
+	if err := bulkExportRuns(runsDir, []string{"run-a", "run-b"}, &out); err != nil {
+		t.Fatalf("bulkExportRuns: %v", err)
+	}
+	if out.String() != "Exported 2 run(s): run-a.html, run-b.html\n" {
+		t.Errorf("output = %q", out.String())
+	}
+	for _, name := range []string{"run-a.html", "run-b.html"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s not written: %v", name, err)
+		}
+	}
+}
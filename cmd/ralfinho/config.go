@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/config"
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
+)
+
+// configDefaults are the built-in values "config show" reports for the
+// settings neither a flag, a config file nor the environment sets.
+var configDefaults = []config.Setting{
+	{Key: "agent", Value: `"pi"`},
+	{Key: "max-iterations", Value: "0"},
+	{Key: "inactivity-timeout", Value: `"5m"`},
+	{Key: "runs-dir", Value: `".ralfinho/runs"`},
+	{Key: "no-tui", Value: "false"},
+	{Key: "templates.plan", Value: "(built-in)"},
+	{Key: "templates.default", Value: "(built-in)"},
+	{Key: "retry.max-retries", Value: "0"},
+	{Key: "retry.backoff", Value: `"0"`},
+	{Key: "retry.max-backoff", Value: `"0"`},
+	{Key: "retry.retryable", Value: `["crash", "exit", "scanner", "network"]`},
+	{Key: "retry.limits.timeout", Value: "1"},
+	{Key: "storage.backend", Value: `"fs"`},
+	{Key: "storage.compress", Value: `"none"`},
+	{Key: "storage.raw-log-max-size", Value: `"0"`},
+	{Key: "storage.raw-log-max-files", Value: "0"},
+}
+
+// runConfig implements "ralfinho config show|validate". It runs before the
+// regular config loading so that validate can report every problem instead
+// of exiting on the first one.
+func runConfig(cfg *cli.Config, args []string) {
+	layers, err := config.LoadLayers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho config: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch cfg.ConfigAction {
+	case "show":
+		settings, err := configSettings(cfg, layers, parseFlagSet(args))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ralfinho config: %v\n", err)
+			os.Exit(1)
+		}
+		writeConfigSettings(os.Stdout, layers, settings)
+	case "validate":
+		var problems []string
+		library, err := config.LoadTemplateLibrary()
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		problems = append(problems, validateConfig(layers, cfg.Profile, library)...)
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "ralfinho config: %d problem(s) found\n", len(problems))
+			os.Exit(1)
+		}
+		fmt.Println("config ok")
+	}
+}
+
+// configSettings lists every effective setting with its source: the config
+// file layers and profile via config.Explain, then the explicitly passed
+// flags on top, then the built-in defaults for whatever is still unset.
+func configSettings(cfg *cli.Config, layers []config.Layer, explicit map[string]bool) ([]config.Setting, error) {
+	settings, err := config.Explain(layers, cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	set := func(key, value, source string) {
+		for i := range settings {
+			if settings[i].Key == key {
+				settings[i] = config.Setting{Key: key, Value: value, Source: source}
+				return
+			}
+		}
+		settings = append(settings, config.Setting{Key: key, Value: value, Source: source})
+	}
+	if explicit["agent"] || explicit["a"] {
+		set("agent", strconv.Quote(cfg.Agent), "flag")
+	}
+	if explicit["max-iterations"] || explicit["m"] {
+		set("max-iterations", strconv.Itoa(cfg.MaxIterations), "flag")
+	}
+	if cfg.InactivityTimeout != nil {
+		set("inactivity-timeout", strconv.Quote(cfg.InactivityTimeout.String()), "flag")
+	}
+	if explicit["runs-dir"] {
+		set("runs-dir", strconv.Quote(cfg.RunsDir), "flag")
+	}
+	if explicit["no-tui"] {
+		set("no-tui", strconv.FormatBool(cfg.NoTUI), "flag")
+	}
+
+	for _, d := range configDefaults {
+		if !slices.ContainsFunc(settings, func(s config.Setting) bool { return s.Key == d.Key }) {
+			settings = append(settings, config.Setting{Key: d.Key, Value: d.Value, Source: "default"})
+		}
+	}
+	slices.SortFunc(settings, func(a, b config.Setting) int {
+		switch {
+		case a.Key < b.Key:
+			return -1
+		case a.Key > b.Key:
+			return 1
+		}
+		return 0
+	})
+	return settings, nil
+}
+
+// writeConfigSettings prints the config files consulted followed by one
+// "key value source" line per setting.
+func writeConfigSettings(w io.Writer, layers []config.Layer, settings []config.Setting) {
+	for _, l := range layers {
+		if l.Path == "" {
+			continue
+		}
+		state := ""
+		if l.Config == nil {
+			state = " (not found)"
+		}
+		fmt.Fprintf(w, "# %s file: %s%s\n", l.Source, l.Path, state)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range settings {
+		source := s.Source
+		if source == "global" || source == "local" {
+			source += " file"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, source)
+	}
+	tw.Flush()
+}
+
+// validateConfig checks each config file, and each profile it defines, for
+// problems the loader would otherwise ignore or only report at run time.
+// Problems are prefixed with the file path, or "environment" for RALFINHO_*
+// variables. A non-empty profile must also exist in the merged config. Every
+// library template must render with the others as partials.
+func validateConfig(layers []config.Layer, profile string, library []config.LibraryTemplate) []string {
+	var problems []string
+	partials := partialsOf(library)
+	for _, l := range layers {
+		for _, key := range l.Unknown {
+			if l.Path == "" {
+				problems = append(problems, fmt.Sprintf("%s: unknown variable %s", l.Origin(), key))
+			} else {
+				problems = append(problems, fmt.Sprintf("%s: unknown key %q", l.Origin(), key))
+			}
+		}
+		if l.Config == nil {
+			continue
+		}
+		for _, p := range checkConfigLayer(l.Config, partials) {
+			problems = append(problems, fmt.Sprintf("%s: %s", l.Origin(), p))
+		}
+		for _, name := range config.ProfileNames(l.Config) {
+			layer, _ := l.Config.Profile(name)
+			for _, p := range checkConfigLayer(layer, partials) {
+				problems = append(problems, fmt.Sprintf("%s: profile %s: %s", l.Origin(), name, p))
+			}
+		}
+	}
+	for _, t := range library {
+		if err := prompt.CheckTemplate(t.Text, partials); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", t.Path, err))
+		}
+	}
+	if profile != "" {
+		if _, err := config.ApplyProfile(config.MergeLayers(layers), profile); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// checkConfigLayer validates the values of a single config file or profile.
+// Templates may include the partials.
+func checkConfigLayer(c *config.FileConfig, partials prompt.Library) []string {
+	var problems []string
+	if c.MaxIterations != nil && *c.MaxIterations < 0 {
+		problems = append(problems, fmt.Sprintf("max-iterations must not be negative, got %d", *c.MaxIterations))
+	}
+	if _, err := config.ParseInactivityTimeout(c); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := config.ParseRetryPolicy(c); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := config.ParseStoragePolicy(c); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if c.Agent != "" && !agent.IsValid(c.Agent) {
+		problems = append(problems, fmt.Sprintf("unknown agent %q (supported: pi, kiro, claude)", c.Agent))
+	}
+	names := make([]string, 0, len(c.Agents))
+	for name := range c.Agents {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !agent.IsValid(name) {
+			problems = append(problems, fmt.Sprintf("unknown agent %q in [agents.%s] (supported: pi, kiro, claude)", name, name))
+		}
+		problems = append(problems, checkAgentConfig(name, c.Agents[name])...)
+	}
+
+	templates, err := config.ResolveTemplates(c)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	if templates.Plan != "" {
+		if err := prompt.CheckTemplate(templates.Plan, partials); err != nil {
+			problems = append(problems, fmt.Sprintf("templates.plan: %v", err))
+		}
+	}
+	if templates.Default != "" {
+		if err := prompt.CheckTemplate(templates.Default, partials); err != nil {
+			problems = append(problems, fmt.Sprintf("templates.default: %v", err))
+		}
+	}
+	return problems
+}
+
+// checkAgentConfig validates the subprocess settings of one [agents.<name>]
+// table: the binary must be runnable, the working directory must exist and
+// passthrough patterns must be well-formed.
+func checkAgentConfig(name string, ac config.AgentConfig) []string {
+	var problems []string
+	if ac.Binary != "" {
+		if _, err := exec.LookPath(ac.Binary); err != nil {
+			problems = append(problems, fmt.Sprintf("agents.%s.binary: %v", name, err))
+		}
+	}
+	if ac.Cwd != "" {
+		if info, err := os.Stat(ac.Cwd); err != nil {
+			problems = append(problems, fmt.Sprintf("agents.%s.cwd: %v", name, err))
+		} else if !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("agents.%s.cwd: %s is not a directory", name, ac.Cwd))
+		}
+	}
+	for _, pattern := range ac.EnvPassthrough {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("agents.%s.env-passthrough: invalid pattern %q", name, pattern))
+		}
+	}
+	return problems
+}
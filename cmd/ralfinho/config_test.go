@@ -0,0 +1,271 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/config"
+)
+
+// loadConfigLayers writes the given global and local config files ("" skips
+// one), switches into the project directory and loads the layers.
+func loadConfigLayers(t *testing.T, global, local string) []config.Layer {
+	t.Helper()
+	dir := t.TempDir()
+	xdg := filepath.Join(dir, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	files := map[string]string{
+		filepath.Join(xdg, "ralfinho", "config.toml"):             global,
+		filepath.Join(dir, "project", ".ralfinho", "config.toml"): local,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile(%q): %v", path, err)
+		}
+	}
+	t.Chdir(filepath.Join(dir, "project"))
+
+	layers, err := config.LoadLayers()
+	if err != nil {
+		t.Fatalf("LoadLayers: %v", err)
+	}
+	return layers
+}
+
+func TestConfigSettingsReportsSources(t *testing.T) {
+	layers := loadConfigLayers(t, `
+agent = "claude"
+max-iterations = 10
+[retry]
+backoff = "1s"
+`, `
+runs-dir = "runs"
+[profiles.nightly]
+max-iterations = 50
+`)
+
+	cfg, err := cli.Parse([]string{"config", "show", "--profile", "nightly", "-a", "kiro"})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	settings, err := configSettings(cfg, layers, parseFlagSet([]string{"show", "--profile", "nightly", "-a", "kiro"}))
+	if err != nil {
+		t.Fatalf("configSettings: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, s := range settings {
+		got[s.Key] = s.Value + " " + s.Source
+	}
+	for key, want := range map[string]string{
+		"agent":                `"kiro" flag`,
+		"max-iterations":       "50 profile nightly",
+		"runs-dir":             `"runs" local`,
+		"retry.backoff":        `"1s" global`,
+		"inactivity-timeout":   `"5m" default`,
+		"templates.plan":       "(built-in) default",
+		"storage.backend":      `"fs" default`,
+		"retry.max-backoff":    `"0" default`,
+		"retry.retryable":      `["crash", "exit", "scanner", "network"] default`,
+		"retry.limits.timeout": "1 default",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %q, want %q", key, got[key], want)
+		}
+	}
+
+	var b strings.Builder
+	writeConfigSettings(&b, layers, settings)
+	out := b.String()
+	if !strings.Contains(out, "# global file: ") || !strings.Contains(out, "# local file: .ralfinho/config.toml\n") {
+		t.Errorf("output header missing file list:\n%s", out)
+	}
+	if !strings.Contains(out, "retry.backoff") || !strings.Contains(out, "global file") {
+		t.Errorf("output = %q, want attributed settings", out)
+	}
+
+	cfg.Profile = "weekly"
+	if _, err := configSettings(cfg, layers, nil); err == nil {
+		t.Error("configSettings with an unknown profile succeeded, want error")
+	}
+}
+
+func TestValidateConfigReportsProblems(t *testing.T) {
+	layers := loadConfigLayers(t, `
+max-iteration = 3
+[retry]
+backoff = "soon"
+`, `
+agent = "codex"
+inactivity-timeout = "5 minutes"
+[agents.cursor]
+extra-args = ["-x"]
+[templates]
+plan = "file:missing.md"
+default = "{{.NotesPath"
+[profiles.nightly]
+agent = "claude"
+[profiles.nightly.templates]
+default = "{{.Plan}}"
+`)
+
+	problems := validateConfig(layers, "weekly", nil)
+	want := []string{
+		`unknown key "max-iteration"`,
+		`parsing retry.backoff "soon"`,
+		`parsing inactivity-timeout "5 minutes"`,
+		`unknown agent "codex"`,
+		`unknown agent "cursor" in [agents.cursor]`,
+		`resolving templates.plan: reading template file`,
+		`profile nightly: templates.default: executing template`,
+		`unknown profile "weekly" (defined: nightly)`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems =\n%s\nwant %d", strings.Join(problems, "\n"), len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i], w) {
+			t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], w)
+		}
+	}
+	if !strings.HasPrefix(problems[0], filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "ralfinho", "config.toml")+": ") {
+		t.Errorf("problem %q is not prefixed with the global config path", problems[0])
+	}
+
+	layers = loadConfigLayers(t, "", `
+agent = "claude"
+[templates]
+default = "Notes at {{.NotesPath}}"
+[profiles.quick]
+max-iterations = 3
+`)
+	if problems := validateConfig(layers, "quick", nil); len(problems) != 0 {
+		t.Errorf("valid config problems = %q", problems)
+	}
+}
+
+func TestMainConfigValidateExitsNonZeroOnProblems(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".ralfinho"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".ralfinho", "config.toml"), []byte("max-iteration = 3\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	env := map[string]string{"XDG_CONFIG_HOME": filepath.Join(dir, "xdg")}
+
+	stdout, stderr, exitCode := runMainHelperProcess(t, dir, []string{"config", "validate"}, env)
+	if exitCode != 1 {
+		t.Fatalf("exit code = %d, want 1 (stderr %q)", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, `unknown key "max-iteration"`) || !strings.Contains(stderr, "1 problem(s) found") {
+		t.Errorf("stdout = %q, stderr = %q", stdout, stderr)
+	}
+
+	stdout, stderr, exitCode = runMainHelperProcess(t, dir, []string{"config", "show", "-m", "4"}, env)
+	if exitCode != 0 {
+		t.Fatalf("show exit code = %d, stderr %q", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "max-iterations") || !strings.Contains(stdout, "flag") {
+		t.Errorf("show stdout = %q, want the flag-provided limit", stdout)
+	}
+}
+
+func TestConfigReportsEnvironmentLayer(t *testing.T) {
+	t.Setenv("RALFINHO_AGENT", "kiro")
+	t.Setenv("RALFINHO_INACTIVITY_TIMEOUT", "later")
+	t.Setenv("RALFINHO_MAX_ITERATION", "3")
+	layers := loadConfigLayers(t, "", "agent = \"claude\"\n")
+
+	cfg, err := cli.Parse([]string{"config", "show"})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	settings, err := configSettings(cfg, layers, nil)
+	if err != nil {
+		t.Fatalf("configSettings: %v", err)
+	}
+	for _, s := range settings {
+		if s.Key == "agent" && (s.Value != `"kiro"` || s.Source != "env") {
+			t.Errorf("agent = %+v, want kiro from env", s)
+		}
+	}
+
+	problems := validateConfig(layers, "", nil)
+	want := []string{
+		"environment: unknown variable RALFINHO_MAX_ITERATION",
+		`environment: parsing inactivity-timeout "later"`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %q, want %d", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(problems[i], w) {
+			t.Errorf("problem %d = %q, want prefix %q", i, problems[i], w)
+		}
+	}
+}
+
+func TestValidateConfigChecksAgentProcessSettings(t *testing.T) {
+	layers := loadConfigLayers(t, "", `
+[agents.pi]
+binary = "/nonexistent/pi"
+cwd = "missing-dir"
+env-passthrough = ["PATH", "[A-"]
+[agents.claude]
+binary = "sh"
+cwd = "."
+`)
+
+	problems := validateConfig(layers, "", nil)
+	want := []string{
+		"agents.pi.binary: ",
+		"agents.pi.cwd: ",
+		`agents.pi.env-passthrough: invalid pattern "[A-"`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems =\n%s\nwant %d", strings.Join(problems, "\n"), len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i], w) {
+			t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], w)
+		}
+	}
+}
+
+func TestValidateConfigChecksTemplateLibrary(t *testing.T) {
+	layers := loadConfigLayers(t, "", `
+[templates]
+plan = '{{template "rules/commit" .}} {{template "rules/missing" .}}'
+`)
+	library := []config.LibraryTemplate{
+		{Name: "rules/commit", Path: "templates/rules/commit.md", Text: "Commit for {{.Agent}}."},
+		{Name: "review", Path: "templates/review.md", Text: `{{template "rules/commit" .}} {{.Reviewer}}`},
+	}
+
+	problems := validateConfig(layers, "", library)
+	want := []string{
+		`templates.plan: executing template: `,
+		`templates/review.md: executing template: `,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems =\n%s\nwant %d", strings.Join(problems, "\n"), len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i], w) {
+			t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], w)
+		}
+	}
+	if !strings.Contains(problems[0], `"rules/missing" not defined`) || !strings.Contains(problems[1], "Reviewer") {
+		t.Errorf("problems = %q, want the missing partial and the unknown field", problems)
+	}
+}
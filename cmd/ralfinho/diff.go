@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/tui"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// runDiff implements "ralfinho diff <run-a> <run-b>".
+func runDiff(cfg *cli.Config) {
+	if err := diffRuns(cfg, os.Stdout, !cfg.NoTUI && isViewInteractiveTerminal()); err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho diff: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// diffRuns compares the two runs named in cfg, opening the side-by-side TUI
+// when interactive and writing a text diff to w otherwise.
+func diffRuns(cfg *cli.Config, w io.Writer, interactive bool) error {
+	diff, err := loadRunDiff(cfg.RunsDir, cfg.DiffRunA, cfg.DiffRunB)
+	if err != nil {
+		return err
+	}
+	if interactive {
+		return openRunDiff(diff)
+	}
+	writeRunDiff(w, diff)
+	return nil
+}
+
+// loadRunDiff resolves and loads two runs and compares them.
+func loadRunDiff(runsDir, a, b string) (viewer.RunDiff, error) {
+	var runs [2]*viewer.SavedRun
+	for i, ref := range []string{a, b} {
+		runID, err := resolveRunRef(runsDir, ref)
+		if err != nil {
+			return viewer.RunDiff{}, err
+		}
+		if runs[i], err = viewer.LoadRun(runsDir, runID); err != nil {
+			return viewer.RunDiff{}, err
+		}
+	}
+	return viewer.CompareRuns(runs[0], runs[1]), nil
+}
+
+// openRunDiff shows diff in the side-by-side TUI and returns when the user
+// leaves it.
+func openRunDiff(diff viewer.RunDiff) error {
+	p := newTeaProgram(tui.NewDiffModel(diff), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("TUI error: %v", err)
+	}
+	return nil
+}
+
+// writeRunDiff prints diff as text: one block per section, unchanged lines
+// indented, and lines that differ as "-" (first run) and "+" (second run).
+func writeRunDiff(w io.Writer, diff viewer.RunDiff) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", diff.A.RunID, diff.B.RunID)
+	for _, s := range diff.Sections {
+		noun := "differences"
+		if s.Changes() == 1 {
+			noun = "difference"
+		}
+		fmt.Fprintf(w, "\n== %s (%d %s) ==\n", s.Title, s.Changes(), noun)
+		for _, row := range s.Rows {
+			switch row.Op {
+			case viewer.DiffSame:
+				fmt.Fprintf(w, "  %s\n", row.Left)
+			case viewer.DiffRemoved:
+				fmt.Fprintf(w, "- %s\n", row.Left)
+			case viewer.DiffAdded:
+				fmt.Fprintf(w, "+ %s\n", row.Right)
+			case viewer.DiffChanged:
+				fmt.Fprintf(w, "- %s\n+ %s\n", row.Left, row.Right)
+			}
+		}
+	}
+}
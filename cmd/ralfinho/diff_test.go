@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/tui"
+)
+
+// writeDiffTestRuns saves two runs that differ in agent, tool calls and
+// PROGRESS.md but share their prompt.
+func writeDiffTestRuns(t *testing.T, runsDir string) {
+	t.Helper()
+	for _, run := range []struct {
+		id, agent, progress string
+		events              []string
+	}{
+		{"11111111-left", "pi", "step 1 done\n", []string{
+			`{"type":"iteration","id":"iteration-1"}`,
+			`{"type":"tool_execution_start","toolCallId":"t1","toolName":"bash","args":{"command":"go test ./..."}}`,
+		}},
+		{"22222222-right", "claude", "step 1 done\nstep 2 done\n", []string{
+			`{"type":"iteration","id":"iteration-1"}`,
+			`{"type":"tool_execution_start","toolCallId":"t1","toolName":"bash","args":{"command":"go vet ./..."}}`,
+		}},
+	} {
+		writeMetaOnlyRun(t, runsDir, run.id, runner.RunMeta{
+			RunID:               run.id,
+			Agent:               run.agent,
+			Status:              string(runner.StatusCompleted),
+			PromptSource:        "default",
+			IterationsCompleted: 1,
+		})
+		writeRunEventsArtifact(t, runsDir, run.id, run.events...)
+		dir := filepath.Join(runsDir, run.id)
+		for name, content := range map[string]string{"effective-prompt.md": "Do the work.\n", "PROGRESS.md": run.progress} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("WriteFile(%s): %v", name, err)
+			}
+		}
+	}
+}
+
+func TestDiffRunsText(t *testing.T) {
+	runsDir := t.TempDir()
+	writeDiffTestRuns(t, runsDir)
+
+	var out bytes.Buffer
+	if err := diffRuns(&cli.Config{RunsDir: runsDir, DiffRunA: "1111", DiffRunB: "2222"}, &out, false); err != nil {
+		t.Fatalf("diffRuns: %v", err)
+	}
+	for _, want := range []string{
+		"--- 11111111-left\n+++ 22222222-right\n",
+		"== Meta (2 differences) ==\n",
+		"- agent: pi\n+ agent: claude\n",
+		"== Tool calls (1 difference) ==\n  ── iteration 1\n- bash: go test ./...\n+ bash: go vet ./...\n",
+		"== Prompt (0 differences) ==\n  Do the work.\n",
+		"== PROGRESS.md (1 difference) ==\n  step 1 done\n+ step 2 done\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := diffRuns(&cli.Config{RunsDir: runsDir, DiffRunA: "1111", DiffRunB: "9999"}, &out, false); err == nil {
+		t.Error("diffRuns with unknown run-id succeeded")
+	}
+}
+
+func TestDiffRunsOpensTUI(t *testing.T) {
+	runsDir := t.TempDir()
+	writeDiffTestRuns(t, runsDir)
+
+	var opened tea.Model
+	useTeaProgramFactory(t, func(model tea.Model, _ ...tea.ProgramOption) teaProgram {
+		opened = model
+		return &scriptedTeaProgram{run: func() (tea.Model, error) { return model, nil }}
+	})
+
+	var out bytes.Buffer
+	if err := diffRuns(&cli.Config{RunsDir: runsDir, DiffRunA: "1111", DiffRunB: "2222"}, &out, true); err != nil {
+		t.Fatalf("diffRuns: %v", err)
+	}
+	if _, ok := opened.(tui.DiffModel); !ok {
+		t.Fatalf("opened %T, want tui.DiffModel", opened)
+	}
+	if out.Len() != 0 {
+		t.Errorf("interactive diff printed %q", out.String())
+	}
+}
+
+func TestRunBrowserDiffAction(t *testing.T) {
+	runsDir := t.TempDir()
+	writeDiffTestRuns(t, runsDir)
+
+	var models []string
+	useTeaProgramFactory(t, func(model tea.Model, _ ...tea.ProgramOption) teaProgram {
+		switch model.(type) {
+		case tui.BrowserModel:
+			models = append(models, "browser")
+			if len(models) > 1 {
+				return &scriptedTeaProgram{run: func() (tea.Model, error) { return noopTeaModel{}, nil }}
+			}
+			return &scriptedTeaProgram{run: func() (tea.Model, error) {
+				m := model
+				for _, key := range []string{"m", "j", "D"} {
+					m, _ = m.Update(tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune(key)}))
+				}
+				return m, nil
+			}}
+		case tui.DiffModel:
+			models = append(models, "diff")
+		default:
+			t.Fatalf("unexpected model %T", model)
+		}
+		return &scriptedTeaProgram{run: func() (tea.Model, error) { return model, nil }}
+	})
+
+	_, stderr := captureCommandOutput(t, func() {
+		runBrowser(&cli.Config{RunsDir: runsDir})
+	})
+	if stderr != "" {
+		t.Fatalf("stderr = %q, want empty", stderr)
+	}
+	if got := strings.Join(models, " "); got != "browser diff browser" {
+		t.Errorf("programs = %q, want browser diff browser", got)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/export"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// runExport implements "ralfinho export <run-id>".
+func runExport(cfg *cli.Config) {
+	path, err := exportRun(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho export: %v\n", err)
+		os.Exit(1)
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Exported run to %s\n", path)
+	}
+}
+
+// exportRun writes the export and returns the file written, or "" when the
+// output went to stdout.
+func exportRun(cfg *cli.Config) (string, error) {
+	format, err := export.ParseFormat(cfg.ExportFormat)
+	if err != nil {
+		return "", err
+	}
+	runID, err := resolveRunRef(cfg.RunsDir, cfg.ExportRunID)
+	if err != nil {
+		return "", err
+	}
+	run, err := viewer.LoadRun(cfg.RunsDir, runID)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.ExportOutput == "-" {
+		return "", writeExport(os.Stdout, run, format)
+	}
+
+	path := cfg.ExportOutput
+	if path == "" {
+		path = run.Meta.RunID + "." + format.Extension()
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("creating %s: %w", path, err)
+	}
+	if err := writeExport(f, run, format); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func writeExport(w io.Writer, run *viewer.SavedRun, format export.Format) error {
+	bw := bufio.NewWriter(w)
+	if err := export.Write(bw, run, format); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
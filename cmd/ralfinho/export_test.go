@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func TestExportRun(t *testing.T) {
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "11111111-export", runner.RunMeta{
+		RunID:  "11111111-export",
+		Agent:  "pi",
+		Status: string(runner.StatusCompleted),
+		Tags:   []string{"nightly"},
+	})
+	eventsPath := filepath.Join(runsDir, "11111111-export", "events.jsonl")
+	if err := os.WriteFile(eventsPath, []byte(`{"type":"iteration","id":"iteration-1"}`+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile(events.jsonl): %v", err)
+	}
+
+	t.Run("default output path", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		path, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "1111", ExportFormat: "html"})
+		if err != nil {
+			t.Fatalf("exportRun: %v", err)
+		}
+		if path != "11111111-export.html" {
+			t.Fatalf("path = %q, want %q", path, "11111111-export.html")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if !strings.Contains(string(data), `<section id="iteration-1">`) {
+			t.Errorf("export missing iteration section:\n%s", data)
+		}
+	})
+
+	t.Run("explicit output path", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.html")
+		path, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "1111", ExportFormat: "html", ExportOutput: out})
+		if err != nil {
+			t.Fatalf("exportRun: %v", err)
+		}
+		if path != out {
+			t.Fatalf("path = %q, want %q", path, out)
+		}
+	})
+
+	t.Run("markdown uses md extension", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		path, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "1111", ExportFormat: "markdown"})
+		if err != nil {
+			t.Fatalf("exportRun: %v", err)
+		}
+		if path != "11111111-export.md" {
+			t.Fatalf("path = %q, want %q", path, "11111111-export.md")
+		}
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		var path string
+		var err error
+		stdout, _ := captureCommandOutput(t, func() {
+			path, err = exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "1111", ExportFormat: "json", ExportOutput: "-"})
+		})
+		if err != nil {
+			t.Fatalf("exportRun: %v", err)
+		}
+		if path != "" {
+			t.Errorf("path = %q, want empty for stdout", path)
+		}
+		if !strings.Contains(stdout, `"schema_version": 1`) || !strings.Contains(stdout, `"run_id": "11111111-export"`) {
+			t.Errorf("stdout = %q, want JSON document", stdout)
+		}
+	})
+
+	t.Run("label reference", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		path, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "@nightly", ExportFormat: "markdown"})
+		if err != nil || path != "11111111-export.md" {
+			t.Fatalf("exportRun(@nightly) = %q, %v; want 11111111-export.md", path, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "1111", ExportFormat: "pdf"}); err == nil || !strings.Contains(err.Error(), "unsupported export format") {
+			t.Errorf("unknown format error = %v", err)
+		}
+		if _, err := exportRun(&cli.Config{RunsDir: runsDir, ExportRunID: "missing", ExportFormat: "html"}); err == nil || !strings.Contains(err.Error(), "no run found") {
+			t.Errorf("missing run error = %v", err)
+		}
+	})
+}
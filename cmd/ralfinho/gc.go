@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// runGC implements "ralfinho gc".
+func runGC(cfg *cli.Config) {
+	if err := gcRuns(cfg, os.Stderr, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho gc: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// gcRuns deletes the runs selected by the configured retention policy and,
+// with --compress, compresses the finished runs that remain. Each action is
+// reported to w. A failure on one run does not stop the others; all of them
+// are returned together at the end.
+func gcRuns(cfg *cli.Config, w io.Writer, now time.Time) error {
+	summaries, err := listRunSummaries(cfg.RunsDir)
+	if err != nil {
+		return err
+	}
+
+	verb := func(done, planned string) string {
+		if cfg.GCDryRun {
+			return planned
+		}
+		return done
+	}
+
+	policy := viewer.GCPolicy{
+		KeepLast:  cfg.GCKeepLast,
+		OlderThan: cfg.GCOlderThan,
+		Statuses:  cfg.GCStatuses,
+	}
+	// Deletes go through the store so an index forgets the runs together
+	// with their directories.
+	store := openRunStore(cfg.RunsDir)
+	defer store.Close()
+
+	deleted := make(map[string]bool)
+	var errs []error
+	var freed int64
+	for _, s := range viewer.SelectForGC(summaries, policy, now) {
+		if !isSubdir(cfg.RunsDir, s.Dir) {
+			continue
+		}
+		size := viewer.DirSize(s.Dir)
+		if !cfg.GCDryRun {
+			if err := store.DeleteRun(s.RunID); err != nil {
+				errs = append(errs, fmt.Errorf("deleting %s: %w", s.RunID, err))
+				continue
+			}
+		}
+		deleted[s.RunID] = true
+		freed += size
+		fmt.Fprintf(w, "%s %s (%s, started %s, %s)\n", verb("deleted", "would delete"), s.RunID, s.Status, gcStartedLabel(s), viewer.FormatBytes(size))
+	}
+
+	var compressed int
+	var saved int64
+	if cfg.GCCompress {
+		for _, s := range summaries {
+			if deleted[s.RunID] || s.Status == string(runner.StatusRunning) {
+				continue
+			}
+			if cfg.GCDryRun {
+				size := plainArtifactSize(s.Dir)
+				if size == 0 {
+					continue
+				}
+				compressed++
+				fmt.Fprintf(w, "would compress %s (%s uncompressed)\n", s.RunID, viewer.FormatBytes(size))
+				continue
+			}
+			before, after, err := artifact.CompressRun(s.Dir, gcCompressFormat())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("compressing %s: %w", s.RunID, err))
+			}
+			if before == 0 {
+				continue
+			}
+			compressed++
+			saved += before - after
+			fmt.Fprintf(w, "compressed %s (%s -> %s)\n", s.RunID, viewer.FormatBytes(before), viewer.FormatBytes(after))
+		}
+	}
+
+	summary := fmt.Sprintf("%s %d run(s), %s %s", verb("Deleted", "Would delete"), len(deleted), verb("freed", "freeing"), viewer.FormatBytes(freed))
+	if cfg.GCCompress {
+		if cfg.GCDryRun {
+			summary += fmt.Sprintf("; would compress %d run(s)", compressed)
+		} else {
+			summary += fmt.Sprintf("; compressed %d run(s), saved %s", compressed, viewer.FormatBytes(saved))
+		}
+	}
+	fmt.Fprintln(w, summary)
+
+	return errors.Join(errs...)
+}
+
+// gcStartedLabel describes when a run started for the gc report.
+func gcStartedLabel(s viewer.RunSummary) string {
+	if s.SortTime.IsZero() {
+		return "unknown"
+	}
+	return s.SortTime.Local().Format("2006-01-02 15:04")
+}
+
+// gcCompressFormat is the format gc --compress uses: storage.compress when
+// it names one, gzip otherwise.
+func gcCompressFormat() artifact.Format {
+	if storagePolicy.Compress != "" {
+		return storagePolicy.Compress
+	}
+	return artifact.Gzip
+}
+
+// plainArtifactSize returns the size of the run's artifacts that
+// artifact.CompressRun would compress.
+func plainArtifactSize(dir string) int64 {
+	var total int64
+	for _, name := range artifact.Compressible {
+		files, err := artifact.Segments(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if _, ok := artifact.FormatOf(f); ok {
+				continue
+			}
+			if info, err := os.Stat(f); err == nil {
+				total += info.Size()
+			}
+		}
+	}
+	return total
+}
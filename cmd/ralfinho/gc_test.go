@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// writeGCRun writes a run with meta.json and a plain events.jsonl.
+func writeGCRun(t *testing.T, runsDir, runID string, status runner.Status, startedAt time.Time) string {
+	t.Helper()
+	writeMetaOnlyRun(t, runsDir, runID, runner.RunMeta{
+		RunID:     runID,
+		Agent:     "pi",
+		Status:    string(status),
+		StartedAt: startedAt.Format(time.RFC3339),
+	})
+	dir := filepath.Join(runsDir, runID)
+	events := strings.Repeat(`{"type":"message_update","delta":"padding padding padding"}`+"\n", 50)
+	if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte(events), 0644); err != nil {
+		t.Fatalf("WriteFile(events.jsonl): %v", err)
+	}
+	return dir
+}
+
+func gcFixture(t *testing.T, now time.Time) string {
+	t.Helper()
+	runsDir := t.TempDir()
+	day := 24 * time.Hour
+	writeGCRun(t, runsDir, "run-new", runner.StatusCompleted, now.Add(-1*day))
+	writeGCRun(t, runsDir, "run-failed", runner.StatusFailed, now.Add(-2*day))
+	writeGCRun(t, runsDir, "run-old", runner.StatusCompleted, now.Add(-40*day))
+	writeGCRun(t, runsDir, "run-old-failed", runner.StatusFailed, now.Add(-50*day))
+	writeGCRun(t, runsDir, "run-stale", runner.StatusRunning, now.Add(-60*day))
+	return runsDir
+}
+
+func remainingRuns(t *testing.T, runsDir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(runsDir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestGCRunsDeletesSelectedRuns(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	runsDir := gcFixture(t, now)
+
+	var out bytes.Buffer
+	cfg := &cli.Config{RunsDir: runsDir, GCOlderThan: 30 * 24 * time.Hour}
+	if err := gcRuns(cfg, &out, now); err != nil {
+		t.Fatalf("gcRuns: %v", err)
+	}
+	if got := remainingRuns(t, runsDir); got != "run-failed,run-new,run-stale" {
+		t.Errorf("remaining runs = %s", got)
+	}
+	for _, want := range []string{"deleted run-old (completed", "deleted run-old-failed (failed", "Deleted 2 run(s), freed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestGCRunsCombinesCriteria(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	runsDir := gcFixture(t, now)
+
+	cfg := &cli.Config{RunsDir: runsDir, GCKeepLast: 1, GCStatuses: []string{"failed"}}
+	if err := gcRuns(cfg, &bytes.Buffer{}, now); err != nil {
+		t.Fatalf("gcRuns: %v", err)
+	}
+	if got := remainingRuns(t, runsDir); got != "run-new,run-old,run-stale" {
+		t.Errorf("remaining runs = %s", got)
+	}
+}
+
+func TestGCRunsDryRunChangesNothing(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	runsDir := gcFixture(t, now)
+
+	var out bytes.Buffer
+	cfg := &cli.Config{RunsDir: runsDir, GCKeepLast: 2, GCCompress: true, GCDryRun: true}
+	if err := gcRuns(cfg, &out, now); err != nil {
+		t.Fatalf("gcRuns: %v", err)
+	}
+	if got := remainingRuns(t, runsDir); got != "run-failed,run-new,run-old,run-old-failed,run-stale" {
+		t.Errorf("remaining runs = %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, "run-new", "events.jsonl")); err != nil {
+		t.Errorf("dry run touched events.jsonl: %v", err)
+	}
+	for _, want := range []string{"would delete run-old ", "would compress run-new ", "Would delete 2 run(s)", "would compress 2 run(s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestGCRunsCompressesKeptFinishedRuns(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	runsDir := gcFixture(t, now)
+
+	var out bytes.Buffer
+	cfg := &cli.Config{RunsDir: runsDir, GCKeepLast: 2, GCCompress: true}
+	if err := gcRuns(cfg, &out, now); err != nil {
+		t.Fatalf("gcRuns: %v", err)
+	}
+	for _, runID := range []string{"run-new", "run-failed"} {
+		if _, err := os.Stat(filepath.Join(runsDir, runID, "events.jsonl.gz")); err != nil {
+			t.Errorf("%s not compressed: %v", runID, err)
+		}
+	}
+	// Running runs may still be appending to their artifacts.
+	if _, err := os.Stat(filepath.Join(runsDir, "run-stale", "events.jsonl")); err != nil {
+		t.Errorf("running run was compressed: %v", err)
+	}
+	if !strings.Contains(out.String(), "compressed 2 run(s), saved") {
+		t.Errorf("output missing compression summary:\n%s", out.String())
+	}
+
+	// A second pass has nothing left to compress.
+	out.Reset()
+	cfg.GCKeepLast = 10
+	if err := gcRuns(cfg, &out, now); err != nil {
+		t.Fatalf("second gcRuns: %v", err)
+	}
+	if !strings.Contains(out.String(), "Deleted 0 run(s), freed 0 B; compressed 0 run(s), saved 0 B") {
+		t.Errorf("second pass output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// runImport implements "ralfinho import <log-file>". The new run ID goes to
+// stdout so scripts can pipe it into "ralfinho view" or "ralfinho export".
+func runImport(cfg *cli.Config) {
+	meta, err := importRun(cfg, os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho import: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d iteration(s) from %s (%s)\n", meta.IterationsCompleted, cfg.ImportLog, meta.Status)
+	fmt.Println(meta.RunID)
+}
+
+// importRun opens the log named by cfg ("-" reads stdin) and imports it.
+func importRun(cfg *cli.Config, stdin io.Reader) (runner.RunMeta, error) {
+	log := stdin
+	source := "stdin"
+	if cfg.ImportLog != "-" {
+		f, err := os.Open(cfg.ImportLog)
+		if err != nil {
+			return runner.RunMeta{}, err
+		}
+		defer f.Close()
+		log = f
+		source = cfg.ImportLog
+	}
+	return runner.Import(runner.ImportConfig{
+		Agent:   cfg.Agent,
+		Log:     log,
+		Source:  source,
+		RunsDir: cfg.RunsDir,
+		RunID:   cfg.ImportRunID,
+	})
+}
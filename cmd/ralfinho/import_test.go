@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+const importClaudeLog = `{"type":"system","subtype":"init"}
+{"type":"stream_event","event":{"type":"message_start","message":{"role":"assistant","model":"m"}}}
+{"type":"stream_event","event":{"type":"content_block_start","content_block":{"type":"text"}}}
+{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"done <promise>COMPLETE</promise>"}}}
+{"type":"stream_event","event":{"type":"content_block_stop"}}
+{"type":"stream_event","event":{"type":"message_stop"}}
+{"type":"result","subtype":"success"}
+`
+
+func TestImportRun(t *testing.T) {
+	runsDir := t.TempDir()
+	logPath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(logPath, []byte(importClaudeLog), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	meta, err := importRun(&cli.Config{RunsDir: runsDir, Agent: "claude", ImportLog: logPath, ImportRunID: "imported"}, nil)
+	if err != nil {
+		t.Fatalf("importRun: %v", err)
+	}
+	if meta.RunID != "imported" || meta.Status != string(runner.StatusCompleted) {
+		t.Errorf("meta = %+v, want completed run %q", meta, "imported")
+	}
+
+	// The imported run loads like any other.
+	run, err := viewer.LoadRun(runsDir, "imported")
+	if err != nil {
+		t.Fatalf("LoadRun: %v", err)
+	}
+	if run.Meta.Agent != "claude" || len(run.Events) == 0 || run.Events[0].Type != runner.EventIteration {
+		t.Errorf("loaded run = %+v, want claude events starting with an iteration marker", run.Meta)
+	}
+}
+
+func TestImportRunFromStdin(t *testing.T) {
+	runsDir := t.TempDir()
+	meta, err := importRun(&cli.Config{RunsDir: runsDir, Agent: "claude", ImportLog: "-"}, strings.NewReader(importClaudeLog))
+	if err != nil {
+		t.Fatalf("importRun: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, meta.RunID, "events.jsonl")); err != nil {
+		t.Errorf("events.jsonl missing: %v", err)
+	}
+}
+
+func TestImportRunErrors(t *testing.T) {
+	runsDir := t.TempDir()
+	if _, err := importRun(&cli.Config{RunsDir: runsDir, Agent: "claude", ImportLog: filepath.Join(runsDir, "missing.log")}, nil); err == nil {
+		t.Error("importRun with missing log succeeded")
+	}
+	if _, err := importRun(&cli.Config{RunsDir: runsDir, Agent: "codex", ImportLog: "-"}, strings.NewReader("")); err == nil {
+		t.Error("importRun with unknown agent succeeded")
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// updateRunMeta applies edit to a run's metadata through the run store, so
+// the run index stays in sync with meta.json. The browser uses it to save
+// tag and annotation edits.
+func updateRunMeta(runsDir, runID string, edit func(*runner.RunMeta)) error {
+	store := openRunStore(runsDir)
+	defer store.Close()
+
+	meta, err := store.ReadMeta(runID)
+	if err != nil {
+		return fmt.Errorf("labeling %s: %w", runID, err)
+	}
+	edit(&meta)
+	if err := store.WriteMeta(meta); err != nil {
+		return fmt.Errorf("labeling %s: %w", runID, err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func TestUpdateRunMeta(t *testing.T) {
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "run-a", runner.RunMeta{RunID: "run-a", Agent: "pi", Tags: []string{"nightly"}})
+
+	err := updateRunMeta(runsDir, "run-a", func(meta *runner.RunMeta) {
+		meta.Annotation = "flaky network"
+	})
+	if err != nil {
+		t.Fatalf("updateRunMeta: %v", err)
+	}
+	meta, err := runner.NewFSStore(runsDir).ReadMeta("run-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Annotation != "flaky network" || strings.Join(meta.Tags, ",") != "nightly" || meta.Agent != "pi" {
+		t.Errorf("meta = %+v, want the annotation added and the rest kept", meta)
+	}
+
+	if err := updateRunMeta(runsDir, "missing", func(*runner.RunMeta) {}); err == nil || !strings.Contains(err.Error(), "labeling missing") {
+		t.Errorf("err = %v, want the missing run reported", err)
+	}
+}
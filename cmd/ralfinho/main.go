@@ -3,6 +3,8 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +16,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
 	"github.com/fsmiamoto/ralfinho/internal/cli"
 	"github.com/fsmiamoto/ralfinho/internal/config"
 	"github.com/fsmiamoto/ralfinho/internal/prompt"
@@ -32,12 +35,26 @@ var fileCfg *config.FileConfig
 // the run starts so prompt-building helpers can use stable template content.
 var configuredTemplates config.ResolvedTemplates
 
+// templateLibrary holds the templates found in the global and local template
+// directories at startup. Each is available to prompt templates as a partial
+// and can be selected for a run with --template.
+var templateLibrary []config.LibraryTemplate
+
 // inactivityTimeout holds the resolved inactivity timeout passed to the
 // runner. nil means "not set" (the runner applies a default of 5 minutes);
 // a non-nil zero disables the watchdog; a positive value sets a custom
 // timeout. CLI flag overrides config file overrides nil.
 var inactivityTimeout *time.Duration
 
+// retryPolicy holds the retry policy parsed from the [retry] config table.
+// The zero value keeps the runner's defaults (agent errors fail the run,
+// inactivity timeouts are retried once).
+var retryPolicy runner.RetryPolicy
+
+// storagePolicy holds the artifact compression and rotation settings parsed
+// from the [storage] config table. The zero value stores artifacts plain.
+var storagePolicy runner.StoragePolicy
+
 // teaProgram captures the Bubble Tea methods command flows need. Keeping
 // program construction behind a tiny interface makes the interactive command
 // paths testable without requiring a real terminal.
@@ -61,6 +78,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if cfg.Command == cli.CommandConfig {
+		runConfig(cfg, os.Args[2:])
+		return
+	}
+	if cfg.Command == cli.CommandTemplates {
+		runTemplates(cfg)
+		return
+	}
+
 	// Load config file defaults (global + local, merged). Missing files are
 	// silently skipped; a parse error is fatal.
 	fileCfg, err = config.Load()
@@ -68,17 +94,48 @@ func main() {
 		fmt.Fprintf(os.Stderr, "ralfinho: config: %v\n", err)
 		os.Exit(1)
 	}
+	if cfg.Profile != "" {
+		fileCfg, err = config.ApplyProfile(fileCfg, cfg.Profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ralfinho: config: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	configuredTemplates, err = config.ResolveTemplates(fileCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ralfinho: config: %v\n", err)
 		os.Exit(1)
 	}
+	templateLibrary, err = config.LoadTemplateLibrary()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho: %v\n", err)
+		os.Exit(1)
+	}
 	tomlInactivityTimeout, err := config.ParseInactivityTimeout(fileCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ralfinho: config: %v\n", err)
 		os.Exit(1)
 	}
 
+	parsedRetry, err := config.ParseRetryPolicy(fileCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho: config: %v\n", err)
+		os.Exit(1)
+	}
+	retryPolicy = runnerRetryPolicy(parsedRetry)
+
+	parsedStorage, err := config.ParseStoragePolicy(fileCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho: config: %v\n", err)
+		os.Exit(1)
+	}
+	storagePolicy = runner.StoragePolicy{
+		Compress:       artifact.Format(parsedStorage.Compress),
+		RawLogMaxSize:  parsedStorage.RawLogMaxSize,
+		RawLogMaxFiles: parsedStorage.RawLogMaxFiles,
+	}
+	storageBackend = parsedStorage.Backend
+
 	// CLI flag wins; config file fills in when the flag was omitted.
 	inactivityTimeout = cfg.InactivityTimeout
 	if inactivityTimeout == nil {
@@ -94,6 +151,30 @@ func main() {
 		return
 	}
 
+	switch cfg.Command {
+	case cli.CommandStats:
+		runStats(cfg)
+		return
+	case cli.CommandExport:
+		runExport(cfg)
+		return
+	case cli.CommandImport:
+		runImport(cfg)
+		return
+	case cli.CommandReprocess:
+		runReprocess(cfg)
+		return
+	case cli.CommandGC:
+		runGC(cfg)
+		return
+	case cli.CommandDiff:
+		runDiff(cfg)
+		return
+	case cli.CommandRerun:
+		runRerun(cfg)
+		return
+	}
+
 	// Handle "view" subcommand.
 	switch cfg.ResolveViewMode(isViewInteractiveTerminal()) {
 	case cli.ViewModeBrowser:
@@ -120,7 +201,7 @@ func main() {
 	progressPath := filepath.Join(cfg.RunsDir, runID, "PROGRESS.md")
 
 	// Resolve the prompt text.
-	promptText, err := resolvePrompt(cfg, notesPath, progressPath)
+	promptText, promptTmpl, err := resolvePrompt(cfg, firstIteration(runID, cfg.Agent, cfg.MaxIterations), notesPath, progressPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ralfinho: %v\n", err)
 		os.Exit(1)
@@ -132,47 +213,69 @@ func main() {
 	}
 
 	if cfg.NoTUI {
-		runPlain(cfg, promptText, runID)
+		runPlain(cfg, promptText, promptTmpl, runID)
 	} else {
-		runTUI(cfg, promptText, runID)
+		runTUI(cfg, promptText, promptTmpl, runID)
 	}
 }
 
 // runPlain runs the agent with plain stderr output (original behavior).
-func runPlain(cfg *cli.Config, promptText, runID string) {
+func runPlain(cfg *cli.Config, promptText string, promptTmpl *prompt.Template, runID string) {
+	store := openRunStore(cfg.RunsDir)
 	r := runner.New(runner.RunConfig{
 		Agent:             cfg.Agent,
 		Prompt:            promptText,
+		PromptTemplate:    promptTmpl,
 		MaxIterations:     cfg.MaxIterations,
 		InactivityTimeout: inactivityTimeout,
 		RunsDir:           cfg.RunsDir,
 		PromptSource:      cfg.InputMode,
 		PromptFile:        cfg.PromptFile,
 		PlanFile:          cfg.PlanFile,
+		Template:          cfg.Template,
 		AgentExtraArgs:    extraArgsForAgent(cfg.Agent),
+		AgentProcess:      agentProcessFor(cfg.Agent),
 		RunID:             runID,
+		Version:           cli.Version,
+		Name:              cfg.RunName,
+		Tags:              cfg.RunTags,
+		Retry:             retryPolicy,
+		Storage:           storagePolicy,
+		Store:             store,
 	})
 
 	result := r.Run(context.Background())
+	store.Close()
 
 	printRunSummary("run summary", result)
 	exitForStatus(result.Status)
 }
 
 // runTUI runs the agent with the Bubble Tea TUI.
-func runTUI(cfg *cli.Config, promptText, runID string) {
+func runTUI(cfg *cli.Config, promptText string, promptTmpl *prompt.Template, runID string) {
+	store := openRunStore(cfg.RunsDir)
 	result, err := runAgentWithTUI(runner.RunConfig{
 		Agent:             cfg.Agent,
 		Prompt:            promptText,
+		PromptTemplate:    promptTmpl,
 		MaxIterations:     cfg.MaxIterations,
 		InactivityTimeout: inactivityTimeout,
 		RunsDir:           cfg.RunsDir,
 		PromptSource:      cfg.InputMode,
 		PromptFile:        cfg.PromptFile,
 		PlanFile:          cfg.PlanFile,
+		Template:          cfg.Template,
 		AgentExtraArgs:    extraArgsForAgent(cfg.Agent),
+		AgentProcess:      agentProcessFor(cfg.Agent),
 		RunID:             runID,
+		Version:           cli.Version,
+		Name:              cfg.RunName,
+		Tags:              cfg.RunTags,
+		Retry:             retryPolicy,
+		Storage:           storagePolicy,
+		Store:             store,
 	})
+	store.Close()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ralfinho: %v\n", err)
 		os.Exit(1)
@@ -245,6 +348,12 @@ func printRunSummary(label string, result runner.RunResult) {
 	fmt.Fprintf(os.Stderr, "agent:      %s\n", result.Agent)
 	fmt.Fprintf(os.Stderr, "iterations: %d\n", result.Iterations)
 	fmt.Fprintf(os.Stderr, "status:     %s\n", result.Status)
+	if result.Failure != nil {
+		fmt.Fprintf(os.Stderr, "failure:    %s\n", result.Failure.Category)
+		if result.Failure.ExitCode != nil {
+			fmt.Fprintf(os.Stderr, "exit-code:  %d\n", *result.Failure.ExitCode)
+		}
+	}
 	if result.Error != "" {
 		fmt.Fprintf(os.Stderr, "error:      %s\n", result.Error)
 	}
@@ -261,30 +370,36 @@ func exitForStatus(status runner.Status) {
 
 // runViewer loads a saved run and opens it in a read-only TUI.
 func runViewer(cfg *cli.Config) {
-	if err := openRunViewer(cfg.RunsDir, cfg.ViewRunID); err != nil {
+	runID, err := resolveRunRef(cfg.RunsDir, cfg.ViewRunID)
+	if err == nil {
+		err = openRunViewer(cfg.RunsDir, runID)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "ralfinho view: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-// openRunViewer loads a single saved run and opens the replay TUI.
-// It returns when the user exits the viewer.
+// openRunViewer loads the saved run runID, a full run ID, and opens the
+// replay TUI. It returns when the user exits the viewer.
 func openRunViewer(runsDir, runID string) error {
+	return openRunViewerAt(runsDir, runID, -1)
+}
+
+// openRunViewerAt is openRunViewer with the main view scrolled to the given
+// block; -1 opens it at the top.
+func openRunViewerAt(runsDir, runID string, focusBlock int) error {
 	saved, err := viewer.LoadRun(runsDir, runID)
 	if err != nil {
 		return err
 	}
 
-	conv := tui.NewEventConverter()
-	var displayEvents []tui.DisplayEvent
-	for i := range saved.Events {
-		des := conv.Convert(&saved.Events[i])
-		displayEvents = append(displayEvents, des...)
-	}
+	displayEvents := tui.ConvertEvents(saved.Events)
 
 	viewNotesPath := filepath.Join(runsDir, runID, "NOTES.md")
 	viewProgressPath := filepath.Join(runsDir, runID, "PROGRESS.md")
-	model := tui.NewViewerModel(displayEvents, saved.Meta, saved.Prompt, viewNotesPath, viewProgressPath)
+	model := tui.NewViewerModel(displayEvents, saved.Meta, saved.Prompt, viewNotesPath, viewProgressPath).
+		WithFocusBlock(focusBlock)
 	p := newTeaProgram(model, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -298,15 +413,29 @@ func openRunViewer(runsDir, runID string) error {
 // and re-opens the browser afterward with the same session selected.
 func runBrowser(cfg *cli.Config) {
 	var lastSelectedRunID string
+	// The content index outlives each browser so reopening it after the
+	// viewer only re-reads runs that changed.
+	contentIndex := tui.NewContentIndex(viewer.LoadEvents)
+	if dir := contentCacheDir(cfg.RunsDir); dir != "" {
+		contentIndex.UseCache(dir)
+	}
+	// Bulk export writes into the working directory; the browser names it
+	// when asking for confirmation.
+	exportDir, _ := os.Getwd()
 
 	for {
-		summaries, err := viewer.ListRunSummaries(cfg.RunsDir)
+		summaries, err := listRunSummaries(cfg.RunsDir)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ralfinho view: %v\n", err)
 			os.Exit(1)
 		}
 
-		model := tui.NewBrowserModel(summaries)
+		model := tui.NewBrowserModel(summaries).
+			WithContentIndex(contentIndex).
+			WithExportDir(exportDir).
+			WithRunQuery(func(q runner.RunQuery) ([]viewer.RunSummary, error) {
+				return queryRunSummaries(cfg.RunsDir, q)
+			})
 		if lastSelectedRunID != "" {
 			model = model.WithSelectedRunID(lastSelectedRunID)
 		}
@@ -328,7 +457,11 @@ func runBrowser(cfg *cli.Config) {
 		switch result.Action {
 		case tui.BrowserActionOpen:
 			lastSelectedRunID = result.RunID
-			if err := openRunViewer(cfg.RunsDir, result.RunID); err != nil {
+			focusBlock := -1
+			if result.SearchHit != nil {
+				focusBlock = result.SearchHit.Block
+			}
+			if err := openRunViewerAt(cfg.RunsDir, result.RunID, focusBlock); err != nil {
 				fmt.Fprintf(os.Stderr, "ralfinho view: %v\n", err)
 				// Don't exit — return to the browser so the user can try
 				// another session or quit normally.
@@ -341,24 +474,85 @@ func runBrowser(cfg *cli.Config) {
 			}
 			// Loop back to re-open the browser (the new run now appears
 			// in the session list after the rescan).
+		case tui.BrowserActionRerun:
+			lastSelectedRunID = result.RunID
+			runResult, err := rerunRun(cfg, result.RunID, true)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ralfinho view: rerun: %v\n", err)
+			} else {
+				printRunSummary("rerun summary", runResult)
+			}
 		case tui.BrowserActionDelete:
 			if result.DeleteDir != "" && isSubdir(cfg.RunsDir, result.DeleteDir) {
-				if err := os.RemoveAll(result.DeleteDir); err != nil {
+				if err := deleteRun(cfg.RunsDir, result.RunID); err != nil {
 					fmt.Fprintf(os.Stderr, "ralfinho view: delete: %v\n", err)
 				}
 			}
 			lastSelectedRunID = result.DeleteNextRunID
 			// Loop back to re-open the browser; the deleted run
 			// disappears after the rescan.
+		case tui.BrowserActionBulkDelete:
+			if err := bulkDeleteRuns(cfg.RunsDir, result.RunIDs, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "ralfinho view: delete: %v\n", err)
+			}
+			lastSelectedRunID = result.DeleteNextRunID
+		case tui.BrowserActionExport:
+			if err := bulkExportRuns(cfg.RunsDir, result.RunIDs, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "ralfinho view: export: %v\n", err)
+			}
+			lastSelectedRunID = result.RunID
+		case tui.BrowserActionTag:
+			if err := bulkTagRuns(cfg.RunsDir, result.RunIDs, result.Tag, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "ralfinho view: tag: %v\n", err)
+			}
+			lastSelectedRunID = result.RunID
+		case tui.BrowserActionEditTags, tui.BrowserActionAnnotate:
+			err := updateRunMeta(cfg.RunsDir, result.RunID, func(meta *runner.RunMeta) {
+				if result.Action == tui.BrowserActionEditTags {
+					meta.Tags = result.Tags
+				} else {
+					meta.Annotation = result.Annotation
+				}
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ralfinho view: %v\n", err)
+			}
+			lastSelectedRunID = result.RunID
+		case tui.BrowserActionDiff:
+			lastSelectedRunID = result.DiffRunID
+			diff, err := loadRunDiff(cfg.RunsDir, result.RunID, result.DiffRunID)
+			if err == nil {
+				err = openRunDiff(diff)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ralfinho view: diff: %v\n", err)
+			}
+			// Loop back to re-open the browser.
 		default:
 			return
 		}
 	}
 }
 
+// contentCacheDir returns where the browser saves its content search index
+// for runsDir: a directory under the user cache directory named after
+// runsDir's absolute path. "" disables the cache.
+func contentCacheDir(runsDir string) string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(runsDir)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(base, "ralfinho", "content-index", hex.EncodeToString(sum[:8]))
+}
+
 // listRuns prints a readable summary of all available runs.
 func listRuns(cfg *cli.Config) {
-	summaries, err := viewer.ListRunSummaries(cfg.RunsDir)
+	summaries, err := listRunSummaries(cfg.RunsDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ralfinho view: %v\n", err)
 		os.Exit(1)
@@ -385,6 +579,12 @@ func formatRunSummary(summary viewer.RunSummary) string {
 	if summary.ArtifactError != "" {
 		details = summary.ArtifactError
 	}
+	if name := summary.Meta.Name; name != "" {
+		details = name + "  " + details
+	}
+	for _, tag := range summary.Meta.Tags {
+		details += "  #" + tag
+	}
 
 	return fmt.Sprintf("  %s  %s  %-5s %-22s %s",
 		id,
@@ -438,11 +638,6 @@ func resumeRunFromBrowser(cfg *cli.Config, result tui.BrowserResult) error {
 	copyFileIfExists(filepath.Join(oldRunDir, "NOTES.md"), notesPath)
 	copyFileIfExists(filepath.Join(oldRunDir, "PROGRESS.md"), progressPath)
 
-	promptText, err := resolveResumePrompt(result.ResumeSource, result.ResumePath, notesPath, progressPath)
-	if err != nil {
-		return fmt.Errorf("resolving prompt: %w", err)
-	}
-
 	agentName := result.ResumeAgent
 	if agentName == "" || agentName == "unknown" {
 		agentName = cfg.Agent
@@ -451,13 +646,21 @@ func resumeRunFromBrowser(cfg *cli.Config, result tui.BrowserResult) error {
 		return fmt.Errorf("unknown agent %q from saved run", agentName)
 	}
 
+	promptText, promptTmpl, err := resolveResumePrompt(result.ResumeSource, result.ResumePath, firstIteration(runID, agentName, cfg.MaxIterations), notesPath, progressPath)
+	if err != nil {
+		return fmt.Errorf("resolving prompt: %w", err)
+	}
+
 	// Map the resume source to runner metadata so the new run's meta.json
 	// accurately describes how the prompt was obtained.
 	inputMode, promptFile, planFile := resumePromptMeta(result.ResumeSource, result.ResumePath)
 
+	store := openRunStore(cfg.RunsDir)
+	defer store.Close()
 	runResult, err := runAgentWithTUI(runner.RunConfig{
 		Agent:             agentName,
 		Prompt:            promptText,
+		PromptTemplate:    promptTmpl,
 		MaxIterations:     cfg.MaxIterations,
 		InactivityTimeout: inactivityTimeout,
 		RunsDir:           cfg.RunsDir,
@@ -465,7 +668,13 @@ func resumeRunFromBrowser(cfg *cli.Config, result tui.BrowserResult) error {
 		PromptFile:        promptFile,
 		PlanFile:          planFile,
 		AgentExtraArgs:    extraArgsForAgent(agentName),
+		AgentProcess:      agentProcessFor(agentName),
 		RunID:             runID,
+		Version:           cli.Version,
+		ResumedFrom:       result.RunID,
+		Retry:             retryPolicy,
+		Storage:           storagePolicy,
+		Store:             store,
 	})
 	if err != nil {
 		return err
@@ -477,24 +686,31 @@ func resumeRunFromBrowser(cfg *cli.Config, result tui.BrowserResult) error {
 
 // resolveResumePrompt reads the prompt text for a resumed run based on the
 // saved artifact source. It never tries to restore an in-progress backend
-// session; the result is always a fresh prompt string for a new run.
-func resolveResumePrompt(source viewer.ResumeSource, path, notesPath, progressPath string) (string, error) {
+// session; the result is always a fresh prompt string for a new run. Plan
+// and default sources also return the template to re-render per iteration.
+func resolveResumePrompt(source viewer.ResumeSource, path string, first prompt.Iteration, notesPath, progressPath string) (string, *prompt.Template, error) {
+	var (
+		tmpl *prompt.Template
+		err  error
+	)
 	switch source {
 	case viewer.ResumeSourceEffectivePrompt:
 		data, err := os.ReadFile(path)
 		if err != nil {
-			return "", fmt.Errorf("reading effective prompt %q: %w", path, err)
+			return "", nil, fmt.Errorf("reading effective prompt %q: %w", path, err)
 		}
-		return string(data), nil
+		return string(data), nil, nil
 	case viewer.ResumeSourcePromptFile:
-		return prompt.BuildFromPromptFile(path)
+		text, err := prompt.BuildFromPromptFile(path)
+		return text, nil, err
 	case viewer.ResumeSourcePlanFile:
-		return prompt.BuildFromPlan(path, configuredTemplates.Plan, notesPath, progressPath)
+		tmpl, err = prompt.PlanTemplate(path, configuredTemplates.Plan, notesPath, progressPath, partialsOf(templateLibrary))
 	case viewer.ResumeSourceDefault:
-		return prompt.BuildDefault(configuredTemplates.Default, notesPath, progressPath)
+		tmpl, err = prompt.DefaultTemplate(configuredTemplates.Default, notesPath, progressPath, partialsOf(templateLibrary))
 	default:
-		return "", fmt.Errorf("unknown resume source %q", source)
+		return "", nil, fmt.Errorf("unknown resume source %q", source)
 	}
+	return renderFirst(tmpl, err, first)
 }
 
 // resumePromptMeta maps a resume source to the runner metadata fields so the
@@ -542,6 +758,29 @@ func applyFileConfig(cfg *cli.Config, fileCfg *config.FileConfig, args []string)
 	}
 }
 
+// runnerRetryPolicy converts the parsed [retry] config table into the
+// runner's typed policy.
+func runnerRetryPolicy(p config.RetryPolicy) runner.RetryPolicy {
+	policy := runner.RetryPolicy{
+		MaxRetries: p.MaxRetries,
+		Backoff:    p.Backoff,
+		MaxBackoff: p.MaxBackoff,
+	}
+	if p.Retryable != nil {
+		policy.Retryable = make([]runner.RetryClass, 0, len(p.Retryable))
+		for _, class := range p.Retryable {
+			policy.Retryable = append(policy.Retryable, runner.RetryClass(class))
+		}
+	}
+	if len(p.Limits) > 0 {
+		policy.Limits = make(map[runner.RetryClass]int, len(p.Limits))
+		for class, n := range p.Limits {
+			policy.Limits[runner.RetryClass(class)] = n
+		}
+	}
+	return policy
+}
+
 // parseFlagSet returns the set of flag names that were explicitly present in
 // the given CLI argument slice. It recognises --flag, -flag, --flag=value,
 // and -flag=value patterns, but does not attempt full flag parsing — it is
@@ -577,18 +816,98 @@ func extraArgsForAgent(agentName string) []string {
 	return nil
 }
 
-// resolvePrompt reads the prompt content based on the CLI config.
-func resolvePrompt(cfg *cli.Config, notesPath, progressPath string) (string, error) {
+// agentProcessFor returns the binary, environment and working directory
+// configured for agentName in the loaded TOML config file. The zero value
+// (no config or no entry) starts the agent's default binary in place.
+func agentProcessFor(agentName string) agent.Process {
+	if fileCfg == nil {
+		return agent.Process{}
+	}
+	ac := fileCfg.Agents[agentName]
+	return agent.Process{
+		Binary:         ac.Binary,
+		Env:            ac.Env,
+		EnvPassthrough: ac.EnvPassthrough,
+		Dir:            ac.Cwd,
+	}
+}
+
+// resolvePrompt reads the prompt content based on the CLI config. Plan and
+// default mode also return their template, which the runner renders again
+// for every iteration; the text is its render for the first one. --template
+// replaces the configured or built-in template with a library one.
+func resolvePrompt(cfg *cli.Config, first prompt.Iteration, notesPath, progressPath string) (string, *prompt.Template, error) {
+	var (
+		tmpl *prompt.Template
+		err  error
+	)
+	planOverride, defaultOverride := configuredTemplates.Plan, configuredTemplates.Default
+	if cfg.Template != "" {
+		t, ok := findLibraryTemplate(cfg.Template)
+		if !ok {
+			return "", nil, fmt.Errorf("unknown template %q (see \"ralfinho templates list\")", cfg.Template)
+		}
+		planOverride, defaultOverride = t.Text, t.Text
+	}
 	switch cfg.InputMode {
 	case "prompt":
-		return prompt.BuildFromPromptFile(cfg.PromptFile)
+		text, err := prompt.BuildFromPromptFile(cfg.PromptFile)
+		return text, nil, err
 	case "plan":
-		return prompt.BuildFromPlan(cfg.PlanFile, configuredTemplates.Plan, notesPath, progressPath)
+		tmpl, err = prompt.PlanTemplate(cfg.PlanFile, planOverride, notesPath, progressPath, partialsOf(templateLibrary))
 	case "default":
-		return prompt.BuildDefault(configuredTemplates.Default, notesPath, progressPath)
+		tmpl, err = prompt.DefaultTemplate(defaultOverride, notesPath, progressPath, partialsOf(templateLibrary))
 	default:
-		return "", fmt.Errorf("unknown input mode %q", cfg.InputMode)
+		return "", nil, fmt.Errorf("unknown input mode %q", cfg.InputMode)
+	}
+	return renderFirst(tmpl, err, first)
+}
+
+// findLibraryTemplate looks up a template library entry by name.
+func findLibraryTemplate(name string) (config.LibraryTemplate, bool) {
+	for _, t := range templateLibrary {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return config.LibraryTemplate{}, false
+}
+
+// partialsOf returns library as partials for prompt templates.
+func partialsOf(library []config.LibraryTemplate) prompt.Library {
+	if len(library) == 0 {
+		return nil
+	}
+	lib := make(prompt.Library, len(library))
+	for _, t := range library {
+		lib[t.Name] = t.Text
+	}
+	return lib
+}
+
+// firstIteration describes the first iteration of a new run, for rendering
+// the prompt shown in the TUI and recorded in effective-prompt.md.
+func firstIteration(runID, agentName string, maxIterations int) prompt.Iteration {
+	return prompt.Iteration{
+		RunID:         runID,
+		Number:        1,
+		MaxIterations: maxIterations,
+		Agent:         agentName,
+		Dir:           agentProcessFor(agentName).Dir,
+	}
+}
+
+// renderFirst renders a freshly parsed template for the first iteration,
+// passing through the parse error if there was one.
+func renderFirst(tmpl *prompt.Template, err error, first prompt.Iteration) (string, *prompt.Template, error) {
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := tmpl.Render(context.Background(), first)
+	if err != nil {
+		return "", nil, err
 	}
+	return text, tmpl, nil
 }
 
 // isSubdir reports whether child is a direct subdirectory of parent.
@@ -301,6 +301,160 @@ extra-args = ["--test-flag", "from-config"]
 	}
 }
 
+func TestMainEnvironmentOverridesConfiguredExtraArgs(t *testing.T) {
+	dir := t.TempDir()
+	argvFile := filepath.Join(dir, "pi-argv.txt")
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", binDir, err)
+	}
+	writeFakePiBinary(t, filepath.Join(binDir, "pi"))
+
+	configDir := filepath.Join(dir, ".ralfinho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", configDir, err)
+	}
+	configText := `agent = "claude"
+[agents.pi]
+extra-args = ["--test-flag", "from-config"]
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configText), 0644); err != nil {
+		t.Fatalf("WriteFile(config.toml): %v", err)
+	}
+
+	stdout, stderr, exitCode := runMainHelperProcess(t, dir, nil, map[string]string{
+		"XDG_CONFIG_HOME":               filepath.Join(dir, "xdg"),
+		"PATH":                          binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"RALFINHO_ARGV_FILE":            argvFile,
+		"RALFINHO_AGENT":                "pi",
+		"RALFINHO_NO_TUI":               "true",
+		"RALFINHO_AGENTS_PI_EXTRA_ARGS": "--test-flag from-env",
+	})
+	if exitCode != 0 {
+		t.Fatalf("exit code = %d, want 0\nstdout=%q\nstderr=%q", exitCode, stdout, stderr)
+	}
+	if !strings.Contains(stderr, "agent:      pi") {
+		t.Fatalf("stderr = %q, want the env agent", stderr)
+	}
+
+	argvBytes, err := os.ReadFile(argvFile)
+	if err != nil {
+		t.Fatalf("ReadFile(argv): %v", err)
+	}
+	argv := strings.Split(strings.TrimSpace(string(argvBytes)), "\n")
+	if tail := argv[len(argv)-2:]; tail[0] != "--test-flag" || tail[1] != "from-env" {
+		t.Fatalf("argv tail = %#v, want extra args from the environment", tail)
+	}
+
+	_, stderr, exitCode = runMainHelperProcess(t, dir, nil, map[string]string{
+		"XDG_CONFIG_HOME":         filepath.Join(dir, "xdg"),
+		"RALFINHO_MAX_ITERATIONS": "many",
+	})
+	if exitCode != 1 || !strings.Contains(stderr, `ralfinho: config: environment: RALFINHO_MAX_ITERATIONS: invalid integer "many"`) {
+		t.Fatalf("exit code = %d, stderr = %q, want a readable env error", exitCode, stderr)
+	}
+}
+
+func TestMainRunsConfiguredAgentBinaryWithEnvAndCwd(t *testing.T) {
+	dir := t.TempDir()
+	argvFile := filepath.Join(dir, "pi-argv.txt")
+	pinned := filepath.Join(dir, "tools", "pi-pinned")
+	if err := os.MkdirAll(filepath.Dir(pinned), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	writeFakePiBinary(t, pinned)
+	workDir := filepath.Join(dir, "service")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	configDir := filepath.Join(dir, ".ralfinho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", configDir, err)
+	}
+	configText := `no-tui = true
+[agents.pi]
+binary = "tools/pi-pinned"
+cwd = "service"
+env-passthrough = ["PATH", "HOME"]
+[agents.pi.env]
+RALFINHO_ARGV_FILE = "` + argvFile + `"
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configText), 0644); err != nil {
+		t.Fatalf("WriteFile(config.toml): %v", err)
+	}
+
+	stdout, stderr, exitCode := runMainHelperProcess(t, dir, nil, map[string]string{
+		"XDG_CONFIG_HOME": filepath.Join(dir, "xdg"),
+	})
+	if exitCode != 0 {
+		t.Fatalf("exit code = %d, want 0\nstdout=%q\nstderr=%q", exitCode, stdout, stderr)
+	}
+	if _, err := os.Stat(argvFile); err != nil {
+		t.Fatalf("pinned binary did not receive the configured env: %v\nstderr=%q", err, stderr)
+	}
+
+	runs, err := os.ReadDir(filepath.Join(dir, ".ralfinho", "runs"))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("runs = %v, %v; want one run", runs, err)
+	}
+	meta, err := runner.NewFSStore(filepath.Join(dir, ".ralfinho", "runs")).ReadMeta(runs[0].Name())
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if meta.Environment == nil || !strings.HasSuffix(meta.Environment.AgentBinary, "tools/pi-pinned") {
+		t.Errorf("environment = %+v, want the pinned binary", meta.Environment)
+	}
+	if got, _ := filepath.EvalSymlinks(meta.Environment.WorkDir); !strings.HasSuffix(got, "service") {
+		t.Errorf("work_dir = %q, want the configured cwd", meta.Environment.WorkDir)
+	}
+}
+
+func TestMainRunsLibraryTemplateWithPartials(t *testing.T) {
+	dir := t.TempDir()
+	capturePath := filepath.Join(dir, "captured-prompt.md")
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", binDir, err)
+	}
+	writeFakePiBinary(t, filepath.Join(binDir, "pi"))
+
+	// A global template includes a local partial.
+	globalTemplates := filepath.Join(dir, "xdg", "ralfinho", "templates")
+	localTemplates := filepath.Join(dir, ".ralfinho", "templates", "rules")
+	for _, d := range []string{globalTemplates, localTemplates} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("MkdirAll(%q): %v", d, err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(globalTemplates, "review.md"): `Review {{.PlanPath}} with {{.Agent}}. {{template "rules/commit" .}}`,
+		filepath.Join(localTemplates, "commit.md"):  "Keep commits small.",
+		filepath.Join(dir, "PLAN.md"):               "- task",
+	}
+	for path, text := range files {
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatalf("WriteFile(%q): %v", path, err)
+		}
+	}
+
+	stdout, stderr, exitCode := runMainHelperProcess(t, dir, []string{"--template", "review", "--plan", "PLAN.md", "--no-tui"}, map[string]string{
+		"XDG_CONFIG_HOME":              filepath.Join(dir, "xdg"),
+		"PATH":                         binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"RALFINHO_CAPTURE_PROMPT_FILE": capturePath,
+	})
+	if exitCode != 0 {
+		t.Fatalf("exit code = %d, want 0\nstdout=%q\nstderr=%q", exitCode, stdout, stderr)
+	}
+	got, err := os.ReadFile(capturePath)
+	if err != nil {
+		t.Fatalf("ReadFile(captured prompt): %v", err)
+	}
+	if want := "Review PLAN.md with pi. Keep commits small."; string(got) != want {
+		t.Errorf("prompt = %q, want %q", got, want)
+	}
+}
+
 func TestMainHelperProcess(t *testing.T) {
 	if os.Getenv("GO_WANT_MAIN_HELPER_PROCESS") != "1" {
 		return
@@ -63,6 +63,32 @@ func TestPrintRunSummaryWritesExpectedLines(t *testing.T) {
 			t.Fatalf("stderr = %q, want %q", stderr, want)
 		}
 	})
+
+	t.Run("with structured failure", func(t *testing.T) {
+		code := 137
+		_, stderr := captureCommandOutput(t, func() {
+			printRunSummary("run summary", runner.RunResult{
+				RunID:      "run-9",
+				Agent:      "pi",
+				Iterations: 1,
+				Status:     runner.StatusFailed,
+				Error:      "agent exited with error: exit status 137",
+				Failure:    &runner.Failure{Category: runner.FailureAgentExit, ExitCode: &code},
+			})
+		})
+
+		want := "\n=== run summary ===\n" +
+			"run-id:     run-9\n" +
+			"agent:      pi\n" +
+			"iterations: 1\n" +
+			"status:     failed\n" +
+			"failure:    agent_exit\n" +
+			"exit-code:  137\n" +
+			"error:      agent exited with error: exit status 137\n"
+		if stderr != want {
+			t.Fatalf("stderr = %q, want %q", stderr, want)
+		}
+	})
 }
 
 func TestListRunsPrintsReadableOutput(t *testing.T) {
@@ -133,7 +159,7 @@ func TestOpenRunViewerReturnsLoadErrorBeforeStartingTUI(t *testing.T) {
 	if err == nil {
 		t.Fatal("openRunViewer() error = nil, want error")
 	}
-	if !strings.Contains(err.Error(), `no run found matching "missing"`) {
+	if !strings.Contains(err.Error(), "reading meta.json") {
 		t.Fatalf("openRunViewer() error = %q, want missing-run message", err)
 	}
 }
@@ -274,6 +300,7 @@ JSONL
 func TestRunTUIInterruptedRunPrintsSummaryAndExitsTwo(t *testing.T) {
 	clearFileConfig(t)
 	installFakePIBinary(t, `#!/bin/sh
+[ "$1" = --version ] && echo "fake-pi 1.0" && exit 0
 cat <<'JSONL'
 {"type":"message_start","message":{"role":"assistant","model":"fake-pi"}}
 {"type":"message_update","assistantMessageEvent":{"type":"text_delta","contentIndex":0,"delta":"still working"}}
@@ -326,12 +353,12 @@ func TestCommandHelperProcess(t *testing.T) {
 		newTeaProgram = func(model tea.Model, _ ...tea.ProgramOption) teaProgram {
 			return newDoneAwareTeaProgramWithError(model, errors.New(os.Getenv("HELPER_TUI_ERROR")))
 		}
-		runTUI(&cli.Config{Agent: "pi", RunsDir: os.Getenv("HELPER_RUNS_DIR")}, "finish immediately", "")
+		runTUI(&cli.Config{Agent: "pi", RunsDir: os.Getenv("HELPER_RUNS_DIR")}, "finish immediately", nil, "")
 	case "run-tui-interrupted":
 		newTeaProgram = func(model tea.Model, _ ...tea.ProgramOption) teaProgram {
 			return &scriptedTeaProgram{run: func() (tea.Model, error) { return model, nil }}
 		}
-		runTUI(&cli.Config{Agent: "pi", RunsDir: os.Getenv("HELPER_RUNS_DIR")}, "keep working", "")
+		runTUI(&cli.Config{Agent: "pi", RunsDir: os.Getenv("HELPER_RUNS_DIR")}, "keep working", nil, "")
 	default:
 		t.Fatalf("unknown HELPER_ACTION %q", os.Getenv("HELPER_ACTION"))
 	}
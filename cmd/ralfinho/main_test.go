@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -8,8 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fsmiamoto/ralfinho/internal/agent"
 	"github.com/fsmiamoto/ralfinho/internal/cli"
 	"github.com/fsmiamoto/ralfinho/internal/config"
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
 	"github.com/fsmiamoto/ralfinho/internal/viewer"
 )
 
@@ -151,6 +155,19 @@ func TestFormatRunSummary(t *testing.T) {
 			},
 			checks: []string{"corrupt meta.json"},
 		},
+		{
+			name: "shows name and tags",
+			summary: viewer.RunSummary{
+				RunID:               "abcdef12-3456",
+				Agent:               "pi",
+				Status:              "completed",
+				IterationsCompleted: 2,
+				PromptLabel:         "default",
+				Meta:                runner.RunMeta{Name: "auth-refactor", Tags: []string{"nightly", "infra"}},
+				StartedAt:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			checks: []string{"auth-refactor  2 iterations", "#nightly  #infra"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -180,7 +197,7 @@ func TestResolvePrompt(t *testing.T) {
 		}
 
 		cfg := &cli.Config{InputMode: "prompt", PromptFile: path}
-		got, err := resolvePrompt(cfg, "", "")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -197,7 +214,7 @@ func TestResolvePrompt(t *testing.T) {
 		}
 
 		cfg := &cli.Config{InputMode: "plan", PlanFile: path}
-		got, err := resolvePrompt(cfg, "", "")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -211,7 +228,7 @@ func TestResolvePrompt(t *testing.T) {
 
 	t.Run("default mode returns built-in prompt", func(t *testing.T) {
 		cfg := &cli.Config{InputMode: "default"}
-		got, err := resolvePrompt(cfg, "", "")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -231,7 +248,7 @@ func TestResolvePrompt(t *testing.T) {
 		}
 
 		cfg := &cli.Config{InputMode: "plan", PlanFile: path}
-		got, err := resolvePrompt(cfg, "", "")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -248,7 +265,7 @@ func TestResolvePrompt(t *testing.T) {
 		configuredTemplates = config.ResolvedTemplates{Default: "Configured default prompt"}
 
 		cfg := &cli.Config{InputMode: "default"}
-		got, err := resolvePrompt(cfg, "", "")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -259,7 +276,7 @@ func TestResolvePrompt(t *testing.T) {
 
 	t.Run("unknown mode returns error", func(t *testing.T) {
 		cfg := &cli.Config{InputMode: "bogus"}
-		_, err := resolvePrompt(cfg, "", "")
+		_, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err == nil {
 			t.Fatal("expected error for unknown input mode")
 		}
@@ -270,7 +287,7 @@ func TestResolvePrompt(t *testing.T) {
 
 	t.Run("prompt mode with missing file returns error", func(t *testing.T) {
 		cfg := &cli.Config{InputMode: "prompt", PromptFile: "/nonexistent/file.md"}
-		_, err := resolvePrompt(cfg, "", "")
+		_, _, err := resolvePrompt(cfg, prompt.Iteration{}, "", "")
 		if err == nil {
 			t.Fatal("expected error for missing prompt file")
 		}
@@ -285,7 +302,7 @@ func TestResolvePrompt(t *testing.T) {
 		}
 
 		cfg := &cli.Config{InputMode: "plan", PlanFile: path}
-		got, err := resolvePrompt(cfg, "/runs/abc/NOTES.md", "/runs/abc/PROGRESS.md")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "/runs/abc/NOTES.md", "/runs/abc/PROGRESS.md")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -300,7 +317,7 @@ func TestResolvePrompt(t *testing.T) {
 	t.Run("default mode embeds memory file paths", func(t *testing.T) {
 		clearConfiguredTemplates(t)
 		cfg := &cli.Config{InputMode: "default"}
-		got, err := resolvePrompt(cfg, "/runs/xyz/NOTES.md", "/runs/xyz/PROGRESS.md")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "/runs/xyz/NOTES.md", "/runs/xyz/PROGRESS.md")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -321,7 +338,7 @@ func TestResolvePrompt(t *testing.T) {
 		}
 
 		cfg := &cli.Config{InputMode: "prompt", PromptFile: path}
-		got, err := resolvePrompt(cfg, "/runs/abc/NOTES.md", "/runs/abc/PROGRESS.md")
+		got, _, err := resolvePrompt(cfg, prompt.Iteration{}, "/runs/abc/NOTES.md", "/runs/abc/PROGRESS.md")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -331,6 +348,47 @@ func TestResolvePrompt(t *testing.T) {
 	})
 }
 
+func TestResolvePromptReturnsTemplateForLoop(t *testing.T) {
+	clearConfiguredTemplates(t)
+	configuredTemplates = config.ResolvedTemplates{Default: "{{.RunID}} {{.Iteration}}/{{.MaxIterations}} {{.Agent}}"}
+
+	text, tmpl, err := resolvePrompt(&cli.Config{InputMode: "default"}, firstIteration("run-7", "claude", 4), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "run-7 1/4 claude" {
+		t.Errorf("first render = %q, want %q", text, "run-7 1/4 claude")
+	}
+	if tmpl == nil {
+		t.Fatal("default mode returned no template for the runner")
+	}
+	if got, _ := tmpl.Render(context.Background(), prompt.Iteration{RunID: "run-7", Number: 2, MaxIterations: 4, Agent: "claude"}); got != "run-7 2/4 claude" {
+		t.Errorf("second render = %q", got)
+	}
+
+	templateLibrary = []config.LibraryTemplate{
+		{Name: "review", Text: `Review as {{.Agent}}. {{template "rules" .}}`},
+		{Name: "rules", Text: "Small commits."},
+	}
+	t.Cleanup(func() { templateLibrary = nil })
+	text, _, err = resolvePrompt(&cli.Config{InputMode: "default", Template: "review"}, firstIteration("run-7", "claude", 4), "", "")
+	if err != nil || text != "Review as claude. Small commits." {
+		t.Errorf("--template review = %q, %v", text, err)
+	}
+	if _, _, err := resolvePrompt(&cli.Config{InputMode: "default", Template: "nope"}, prompt.Iteration{}, "", ""); err == nil || !strings.Contains(err.Error(), `unknown template "nope"`) {
+		t.Errorf("unknown --template error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(path, []byte("{{.RunID}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	text, tmpl, err = resolvePrompt(&cli.Config{InputMode: "prompt", PromptFile: path}, firstIteration("run-7", "claude", 4), "", "")
+	if err != nil || text != "{{.RunID}}" || tmpl != nil {
+		t.Errorf("prompt mode = %q, %v, %v; want the file verbatim and no template", text, tmpl, err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // resolveResumePrompt
 // ---------------------------------------------------------------------------
@@ -346,7 +404,7 @@ func TestResolveResumePrompt(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		got, err := resolveResumePrompt(viewer.ResumeSourceEffectivePrompt, path, "", "")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourceEffectivePrompt, path, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -362,7 +420,7 @@ func TestResolveResumePrompt(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		got, err := resolveResumePrompt(viewer.ResumeSourcePromptFile, path, "", "")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourcePromptFile, path, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -378,7 +436,7 @@ func TestResolveResumePrompt(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		got, err := resolveResumePrompt(viewer.ResumeSourcePlanFile, path, "", "")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourcePlanFile, path, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -388,7 +446,7 @@ func TestResolveResumePrompt(t *testing.T) {
 	})
 
 	t.Run("default source", func(t *testing.T) {
-		got, err := resolveResumePrompt(viewer.ResumeSourceDefault, "", "", "")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourceDefault, "", prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -407,7 +465,7 @@ func TestResolveResumePrompt(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		got, err := resolveResumePrompt(viewer.ResumeSourcePlanFile, path, "", "")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourcePlanFile, path, prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -420,7 +478,7 @@ func TestResolveResumePrompt(t *testing.T) {
 		clearConfiguredTemplates(t)
 		configuredTemplates = config.ResolvedTemplates{Default: "Resume default prompt"}
 
-		got, err := resolveResumePrompt(viewer.ResumeSourceDefault, "", "", "")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourceDefault, "", prompt.Iteration{}, "", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -430,14 +488,14 @@ func TestResolveResumePrompt(t *testing.T) {
 	})
 
 	t.Run("unknown source returns error", func(t *testing.T) {
-		_, err := resolveResumePrompt("unknown_source", "", "", "")
+		_, _, err := resolveResumePrompt("unknown_source", "", prompt.Iteration{}, "", "")
 		if err == nil {
 			t.Fatal("expected error for unknown resume source")
 		}
 	})
 
 	t.Run("missing effective prompt file returns error", func(t *testing.T) {
-		_, err := resolveResumePrompt(viewer.ResumeSourceEffectivePrompt, "/nonexistent/file.md", "", "")
+		_, _, err := resolveResumePrompt(viewer.ResumeSourceEffectivePrompt, "/nonexistent/file.md", prompt.Iteration{}, "", "")
 		if err == nil {
 			t.Fatal("expected error for missing file")
 		}
@@ -451,7 +509,7 @@ func TestResolveResumePrompt(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		got, err := resolveResumePrompt(viewer.ResumeSourcePlanFile, path, "/runs/r1/NOTES.md", "/runs/r1/PROGRESS.md")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourcePlanFile, path, prompt.Iteration{}, "/runs/r1/NOTES.md", "/runs/r1/PROGRESS.md")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -465,7 +523,7 @@ func TestResolveResumePrompt(t *testing.T) {
 
 	t.Run("default source embeds memory file paths", func(t *testing.T) {
 		clearConfiguredTemplates(t)
-		got, err := resolveResumePrompt(viewer.ResumeSourceDefault, "", "/runs/r2/NOTES.md", "/runs/r2/PROGRESS.md")
+		got, _, err := resolveResumePrompt(viewer.ResumeSourceDefault, "", prompt.Iteration{}, "/runs/r2/NOTES.md", "/runs/r2/PROGRESS.md")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -689,6 +747,63 @@ func TestExtraArgsForAgentUsesLoadedFileConfig(t *testing.T) {
 	}
 }
 
+func TestAgentProcessForUsesLoadedFileConfig(t *testing.T) {
+	prev := fileCfg
+	t.Cleanup(func() { fileCfg = prev })
+
+	fileCfg = nil
+	if got := agentProcessFor("claude"); !reflect.DeepEqual(got, agent.Process{}) {
+		t.Fatalf("agentProcessFor() with nil fileCfg = %#v, want the zero value", got)
+	}
+
+	fileCfg = &config.FileConfig{
+		Agents: map[string]config.AgentConfig{
+			"claude": {
+				Binary:         "/opt/claude-1.2/bin/claude",
+				Env:            map[string]string{"ANTHROPIC_MODEL": "opus"},
+				EnvPassthrough: []string{"PATH", "HOME"},
+				Cwd:            "services/api",
+			},
+		},
+	}
+	want := agent.Process{
+		Binary:         "/opt/claude-1.2/bin/claude",
+		Env:            map[string]string{"ANTHROPIC_MODEL": "opus"},
+		EnvPassthrough: []string{"PATH", "HOME"},
+		Dir:            "services/api",
+	}
+	if got := agentProcessFor("claude"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("agentProcessFor(claude) = %#v, want %#v", got, want)
+	}
+	if got := agentProcessFor("pi"); !reflect.DeepEqual(got, agent.Process{}) {
+		t.Fatalf("agentProcessFor(pi) = %#v, want the zero value", got)
+	}
+}
+
+func TestRunnerRetryPolicyConvertsConfig(t *testing.T) {
+	if got := runnerRetryPolicy(config.RetryPolicy{}); !reflect.DeepEqual(got, runner.RetryPolicy{}) {
+		t.Fatalf("runnerRetryPolicy(zero) = %#v, want zero value", got)
+	}
+
+	got := runnerRetryPolicy(config.RetryPolicy{
+		MaxRetries: 2,
+		Backoff:    time.Second,
+		MaxBackoff: time.Minute,
+		Retryable:  []string{"network"},
+		Limits:     map[string]int{"timeout": 0},
+	})
+	want := runner.RetryPolicy{
+		MaxRetries: 2,
+		Backoff:    time.Second,
+		MaxBackoff: time.Minute,
+		Retryable:  []runner.RetryClass{runner.RetryNetwork},
+		Limits:     map[runner.RetryClass]int{runner.RetryTimeout: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("runnerRetryPolicy() = %#v, want %#v", got, want)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // isSubdir
 // ---------------------------------------------------------------------------
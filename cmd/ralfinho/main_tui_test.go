@@ -203,6 +203,7 @@ JSONL
 `
 
 	const slowPI = `#!/bin/sh
+[ "$1" = --version ] && echo "fake-pi 1.0" && exit 0
 cat <<'JSONL'
 {"type":"message_start","message":{"role":"assistant","model":"fake-pi"}}
 {"type":"message_update","assistantMessageEvent":{"type":"text_delta","contentIndex":0,"delta":"still working"}}
@@ -304,7 +305,7 @@ JSONL
 	})
 
 	stdout, stderr := captureCommandOutput(t, func() {
-		runTUI(&cli.Config{Agent: "pi", RunsDir: t.TempDir()}, "finish immediately", "")
+		runTUI(&cli.Config{Agent: "pi", RunsDir: t.TempDir()}, "finish immediately", nil, "")
 	})
 
 	if stdout != "" {
@@ -338,7 +339,7 @@ func TestOpenRunViewerWrapsTUIErrors(t *testing.T) {
 		}
 	})
 
-	err := openRunViewer(runsDir, "saved")
+	err := openRunViewer(runsDir, "saved-run")
 	if err == nil {
 		t.Fatal("openRunViewer() error = nil, want wrapped TUI error")
 	}
@@ -757,6 +758,69 @@ JSONL
 	})
 }
 
+func TestRunBrowserOpensViewerAtContentSearchHit(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "content-run", runner.RunMeta{
+		RunID:               "content-run",
+		StartedAt:           "2026-03-08T10:00:00Z",
+		Status:              string(runner.StatusCompleted),
+		Agent:               "pi",
+		PromptSource:        "default",
+		IterationsCompleted: 2,
+	})
+	writeRunEventsArtifact(t, runsDir, "content-run", `{"type":"iteration","id":"iteration-1"}
+{"type":"tool_execution_start","toolName":"bash","toolCallId":"t1","args":{"command":"ls"}}
+{"type":"iteration","id":"iteration-2"}
+{"type":"tool_execution_start","toolName":"edit","toolCallId":"t2","args":{"path":"db/migrations.go"}}`)
+
+	var viewerStatus string
+	var calls int
+	useTeaProgramFactory(t, func(model tea.Model, _ ...tea.ProgramOption) teaProgram {
+		calls++
+		switch calls {
+		case 1:
+			return &scriptedTeaProgram{run: func() (tea.Model, error) {
+				// / indexes run content in the background; deliver its
+				// result as the event loop would.
+				model, sync := model.Update(keyRune('/'))
+				if sync == nil {
+					t.Fatal("/ did not start indexing run content")
+				}
+				model, _ = model.Update(sync())
+				var keys []tea.KeyMsg
+				for _, r := range "migrations" {
+					keys = append(keys, keyRune(r))
+				}
+				keys = append(keys, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+				return applyKeySequence(model, keys...), nil
+			}}
+		case 2:
+			viewer, ok := model.(tui.Model)
+			if !ok {
+				t.Fatalf("model = %T, want tui.Model", model)
+			}
+			sized, _ := viewer.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+			viewerStatus = sized.View()
+			return &scriptedTeaProgram{run: func() (tea.Model, error) { return model, nil }}
+		default:
+			return &scriptedTeaProgram{run: func() (tea.Model, error) {
+				return applyKeySequence(model, keyRune('q')), nil
+			}}
+		}
+	})
+
+	runBrowser(&cli.Config{RunsDir: runsDir})
+
+	if !strings.Contains(viewerStatus, "search match in iteration 2") {
+		t.Fatalf("viewer did not open at the content search hit:\n%s", viewerStatus)
+	}
+	// The index is saved for the next browser session.
+	if _, err := os.Stat(filepath.Join(contentCacheDir(runsDir), "content-run.gob")); err != nil {
+		t.Errorf("content index not cached: %v", err)
+	}
+}
+
 func TestRunBrowserEdgeBranches(t *testing.T) {
 	t.Run("non-browser final model returns without reopening", func(t *testing.T) {
 		runsDir := t.TempDir()
@@ -947,4 +1011,7 @@ JSONL
 	if meta.Status != string(runner.StatusCompleted) {
 		t.Fatalf("new run Status = %q, want %q", meta.Status, runner.StatusCompleted)
 	}
+	if meta.ResumedFrom != "source-run" {
+		t.Fatalf("new run ResumedFrom = %q, want %q", meta.ResumedFrom, "source-run")
+	}
 }
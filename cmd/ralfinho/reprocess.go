@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// runReprocess implements "ralfinho reprocess <run-id>".
+func runReprocess(cfg *cli.Config) {
+	if err := reprocessRun(cfg, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho reprocess: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// reprocessRun rebuilds the run's events.jsonl and reports what changed to w.
+func reprocessRun(cfg *cli.Config, w io.Writer) error {
+	runID, err := resolveRunRef(cfg.RunsDir, cfg.ReprocessRunID)
+	if err != nil {
+		return err
+	}
+	result, err := runner.Reprocess(cfg.RunsDir, runID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Rebuilt events.jsonl for %s: %d events in %d iteration(s)\n", runID, result.Events, result.Iterations)
+	if result.Backup != "" {
+		fmt.Fprintf(w, "Previous events saved to %s\n", result.Backup)
+	}
+	if result.Inferred {
+		fmt.Fprintln(w, "note: raw-output.log has no iteration markers; iteration boundaries were inferred")
+	}
+	if result.Pruned > 0 {
+		fmt.Fprintf(w, "warning: %d raw-output.log segment(s) were deleted by storage.raw-log-max-files during the run; the rebuilt events.jsonl is missing everything before them\n", result.Pruned)
+	}
+	return nil
+}
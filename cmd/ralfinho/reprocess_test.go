@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func TestReprocessRun(t *testing.T) {
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "22222222-reprocess", runner.RunMeta{
+		RunID:        "22222222-reprocess",
+		Agent:        "pi",
+		Status:       string(runner.StatusCompleted),
+		RawLogPruned: 3,
+	})
+	dir := filepath.Join(runsDir, "22222222-reprocess")
+	if err := os.WriteFile(filepath.Join(dir, "raw-output.log"), []byte(`{"type":"session","id":"s1"}`+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile(raw-output.log): %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := reprocessRun(&cli.Config{RunsDir: runsDir, ReprocessRunID: "2222"}, &out); err != nil {
+		t.Fatalf("reprocessRun: %v", err)
+	}
+	for _, want := range []string{
+		"Rebuilt events.jsonl for 22222222-reprocess: 2 events in 1 iteration(s)",
+		"iteration boundaries were inferred",
+		"warning: 3 raw-output.log segment(s) were deleted",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	// There was no events.jsonl to back up.
+	if strings.Contains(out.String(), "Previous events") {
+		t.Errorf("output mentions a backup that was not made:\n%s", out.String())
+	}
+
+	if err := reprocessRun(&cli.Config{RunsDir: runsDir, ReprocessRunID: "9999"}, &out); err == nil {
+		t.Error("reprocessRun with unknown run-id succeeded")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// runRerun implements "ralfinho rerun <run-id>".
+func runRerun(cfg *cli.Config) {
+	// Auto-disable TUI when not connected to a terminal.
+	if !cfg.NoTUI && !isTerminal() {
+		cfg.NoTUI = true
+	}
+	result, err := rerunRun(cfg, cfg.RerunRunID, !cfg.NoTUI)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho rerun: %v\n", err)
+		os.Exit(1)
+	}
+	printRunSummary("rerun summary", result)
+	exitForStatus(result.Status)
+}
+
+// rerunRun starts a new run with the settings the run parentRef recorded
+// and blocks until it finishes.
+func rerunRun(cfg *cli.Config, parentRef string, withTUI bool) (runner.RunResult, error) {
+	store := openRunStore(cfg.RunsDir)
+	defer store.Close()
+
+	runCfg, err := rerunConfig(cfg, store, parentRef)
+	if err != nil {
+		return runner.RunResult{}, err
+	}
+	if withTUI {
+		return runAgentWithTUI(runCfg)
+	}
+	return runner.New(runCfg).Run(context.Background()), nil
+}
+
+// rerunConfig rebuilds the configuration the run parentRef started with:
+// its agent with the binary, working directory and extra arguments it ran
+// with, iteration limit, inactivity timeout, prompt or prompt template and
+// the persistent reminders still active when it ended. The rerun, name and tag flags in cfg
+// override the recorded values.
+func rerunConfig(cfg *cli.Config, store runner.RunStore, parentRef string) (runner.RunConfig, error) {
+	parentID, err := viewer.ResolveStoredRunID(cfg.RunsDir, store, parentRef)
+	if err != nil {
+		return runner.RunConfig{}, err
+	}
+	meta, err := store.ReadMeta(parentID)
+	if err != nil {
+		return runner.RunConfig{}, fmt.Errorf("reading meta.json of %s: %w", parentID, err)
+	}
+	parentDir := store.RunDir(parentID)
+	promptData, err := os.ReadFile(filepath.Join(parentDir, "effective-prompt.md"))
+	if err != nil {
+		return runner.RunConfig{}, fmt.Errorf("run %s has no effective prompt to replay: %w", parentID, err)
+	}
+
+	agentName := meta.Agent
+	if cfg.RerunAgent != "" {
+		agentName = cfg.RerunAgent
+	}
+	if !agent.IsValid(agentName) {
+		return runner.RunConfig{}, fmt.Errorf("unknown agent %q (supported: pi, kiro, claude)", agentName)
+	}
+
+	// The prompt names the parent's memory files; point it at the new
+	// run's instead, which start out empty just like the parent's did.
+	runID := runner.NewRunID()
+	promptText := string(promptData)
+	for _, name := range []string{"NOTES.md", "PROGRESS.md"} {
+		promptText = strings.ReplaceAll(promptText, filepath.Join(parentID, name), filepath.Join(runID, name))
+	}
+
+	env := meta.Environment
+	extraArgs := extraArgsForAgent(agentName)
+	process := agentProcessFor(agentName)
+	if env != nil && agentName == meta.Agent {
+		extraArgs = env.AgentExtraArgs
+		process = parentAgentProcess(process, env)
+	}
+
+	timeout := inactivityTimeout
+	switch {
+	case cfg.InactivityTimeout != nil:
+		timeout = cfg.InactivityTimeout
+	case env != nil && env.InactivityTimeout != "":
+		d, err := time.ParseDuration(env.InactivityTimeout)
+		if err != nil {
+			return runner.RunConfig{}, fmt.Errorf("recorded inactivity timeout %q: %w", env.InactivityTimeout, err)
+		}
+		timeout = &d
+	}
+
+	maxIterations := meta.MaxIterations
+	if cfg.RerunMaxIterations != nil {
+		maxIterations = *cfg.RerunMaxIterations
+	}
+
+	// A plan or default template was rendered again for every iteration of
+	// the parent, so rebuild it for the rerun; effective-prompt.md only holds
+	// its first render. When it cannot be rebuilt (the plan or library
+	// template is gone) that first render is replayed as-is.
+	var promptTmpl *prompt.Template
+	if meta.PromptSource == "plan" || meta.PromptSource == "default" {
+		first := firstIteration(runID, agentName, maxIterations)
+		first.Dir = process.Dir
+		runDir := store.RunDir(runID)
+		text, tmpl, err := resolvePrompt(&cli.Config{
+			InputMode: meta.PromptSource,
+			PlanFile:  meta.PlanFile,
+			Template:  meta.Template,
+		}, first, filepath.Join(runDir, "NOTES.md"), filepath.Join(runDir, "PROGRESS.md"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ralfinho rerun: warning: cannot rebuild the prompt template of %s (%v); replaying its first-iteration prompt\n", parentID, err)
+		} else {
+			promptText, promptTmpl = text, tmpl
+		}
+	}
+
+	tags := meta.Tags
+	if cfg.RunTags != nil {
+		tags = cfg.RunTags
+	}
+
+	return runner.RunConfig{
+		Agent:             agentName,
+		Prompt:            promptText,
+		PromptTemplate:    promptTmpl,
+		MaxIterations:     maxIterations,
+		InactivityTimeout: timeout,
+		RunsDir:           cfg.RunsDir,
+		PromptSource:      meta.PromptSource,
+		PromptFile:        meta.PromptFile,
+		PlanFile:          meta.PlanFile,
+		Template:          meta.Template,
+		AgentExtraArgs:    extraArgs,
+		AgentProcess:      process,
+		RunID:             runID,
+		Version:           cli.Version,
+		Name:              cfg.RunName,
+		Tags:              tags,
+		ParentRunID:       parentID,
+		Reminders:         runner.PersistentReminders(viewer.LoadOperatorLog(parentDir)),
+		Retry:             retryPolicy,
+		Storage:           storagePolicy,
+		Store:             store,
+	}, nil
+}
+
+// parentAgentProcess starts the agent the way the parent run did: the binary
+// and working directory it recorded replace the configured ones. The agent
+// environment is not recorded, so it still comes from the config file. A
+// recorded working directory that no longer exists is ignored.
+func parentAgentProcess(p agent.Process, env *runner.RunEnvironment) agent.Process {
+	if env.AgentBinary != "" {
+		p.Binary = env.AgentBinary
+	}
+	if env.WorkDir != "" {
+		if info, err := os.Stat(env.WorkDir); err == nil && info.IsDir() {
+			p.Dir = env.WorkDir
+		}
+	}
+	return p
+}
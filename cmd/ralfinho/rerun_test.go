@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/config"
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// writeRerunParent saves a finished run with an effective prompt naming its
+// memory files and an operator log that leaves one persistent reminder.
+func writeRerunParent(t *testing.T, runsDir string) {
+	t.Helper()
+	writeMetaOnlyRun(t, runsDir, "parent-run", runner.RunMeta{
+		RunID:         "parent-run",
+		StartedAt:     "2026-03-08T10:00:00Z",
+		Status:        string(runner.StatusMaxIterationsReached),
+		Agent:         "pi",
+		PromptSource:  "plan",
+		PlanFile:      "PLAN.md",
+		MaxIterations: 3,
+		Name:          "auth-refactor",
+		Tags:          []string{"nightly"},
+		Environment: &runner.RunEnvironment{
+			AgentExtraArgs:    []string{"--model", "fast"},
+			InactivityTimeout: "0s",
+		},
+	})
+	notes := filepath.Join(runsDir, "parent-run", "NOTES.md")
+	writeEffectivePromptArtifact(t, runsDir, "parent-run", "Plan prompt. Notes go to "+notes+".")
+	log := `{"ts":"2026-03-08T10:01:00Z","action":"reminder_add","kind":"persistent","id":"a","text":"keep tests green"}
+{"ts":"2026-03-08T10:02:00Z","action":"reminder_add","kind":"persistent","id":"b","text":"no new deps"}
+{"ts":"2026-03-08T10:03:00Z","action":"reminder_add","kind":"oneoff","id":"c","text":"fix auth"}
+{"ts":"2026-03-08T10:04:00Z","action":"reminder_remove","id":"b"}
+`
+	if err := os.WriteFile(filepath.Join(runsDir, "parent-run", "operator-log.jsonl"), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRerunConfigReplaysRecordedSettings(t *testing.T) {
+	clearFileConfig(t)
+	runsDir := t.TempDir()
+	writeRerunParent(t, runsDir)
+	store := runner.NewFSStore(runsDir)
+
+	runCfg, err := rerunConfig(&cli.Config{RunsDir: runsDir}, store, "parent")
+	if err != nil {
+		t.Fatalf("rerunConfig() error = %v", err)
+	}
+	if runCfg.ParentRunID != "parent-run" || runCfg.Agent != "pi" || runCfg.MaxIterations != 3 {
+		t.Errorf("runCfg = %+v, want pi with 3 iterations re-running parent-run", runCfg)
+	}
+	if runCfg.PromptSource != "plan" || runCfg.PlanFile != "PLAN.md" {
+		t.Errorf("prompt source = %q %q, want the parent's plan", runCfg.PromptSource, runCfg.PlanFile)
+	}
+	if got := strings.Join(runCfg.AgentExtraArgs, " "); got != "--model fast" {
+		t.Errorf("AgentExtraArgs = %q, want the recorded ones", got)
+	}
+	if runCfg.InactivityTimeout == nil || *runCfg.InactivityTimeout != 0 {
+		t.Errorf("InactivityTimeout = %v, want the recorded disabled watchdog", runCfg.InactivityTimeout)
+	}
+	wantPrompt := "Plan prompt. Notes go to " + filepath.Join(runsDir, runCfg.RunID, "NOTES.md") + "."
+	if runCfg.Prompt != wantPrompt {
+		t.Errorf("Prompt = %q, want %q", runCfg.Prompt, wantPrompt)
+	}
+	if len(runCfg.Reminders) != 1 || runCfg.Reminders[0].Text != "keep tests green" {
+		t.Errorf("Reminders = %+v, want the persistent reminder left at the end", runCfg.Reminders)
+	}
+	if runCfg.Name != "" || strings.Join(runCfg.Tags, ",") != "nightly" {
+		t.Errorf("labels = %q %q, want no name and the parent's tags", runCfg.Name, runCfg.Tags)
+	}
+
+	maxIterations := 0
+	timeout := 10 * time.Minute
+	runCfg, err = rerunConfig(&cli.Config{
+		RunsDir:            runsDir,
+		RerunAgent:         "claude",
+		RerunMaxIterations: &maxIterations,
+		InactivityTimeout:  &timeout,
+		RunName:            "retry",
+		RunTags:            []string{"flaky"},
+	}, store, "parent-run")
+	if err != nil {
+		t.Fatalf("rerunConfig() with overrides error = %v", err)
+	}
+	if runCfg.Agent != "claude" || runCfg.MaxIterations != 0 || *runCfg.InactivityTimeout != timeout {
+		t.Errorf("runCfg = %+v, want the overrides", runCfg)
+	}
+	if runCfg.AgentExtraArgs != nil {
+		t.Errorf("AgentExtraArgs = %q, want none recorded for a different agent", runCfg.AgentExtraArgs)
+	}
+	if runCfg.Name != "retry" || strings.Join(runCfg.Tags, ",") != "flaky" {
+		t.Errorf("labels = %q %q, want the overrides", runCfg.Name, runCfg.Tags)
+	}
+}
+
+func TestRerunConfigStartsTheParentsAgentProcess(t *testing.T) {
+	prev := fileCfg
+	t.Cleanup(func() { fileCfg = prev })
+	fileCfg = &config.FileConfig{
+		Agents: map[string]config.AgentConfig{
+			"pi":     {Binary: "/usr/local/bin/pi", Env: map[string]string{"PI_MODEL": "fast"}, Cwd: "elsewhere"},
+			"claude": {Binary: "/opt/claude/bin/claude", Cwd: "services/api"},
+		},
+	}
+
+	runsDir := t.TempDir()
+	workDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "parent-run", runner.RunMeta{
+		RunID: "parent-run",
+		Agent: "pi",
+		Environment: &runner.RunEnvironment{
+			AgentBinary: "/opt/pi-0.9/bin/pi",
+			WorkDir:     workDir,
+		},
+	})
+	writeEffectivePromptArtifact(t, runsDir, "parent-run", "prompt")
+	store := runner.NewFSStore(runsDir)
+
+	runCfg, err := rerunConfig(&cli.Config{RunsDir: runsDir}, store, "parent-run")
+	if err != nil {
+		t.Fatalf("rerunConfig() error = %v", err)
+	}
+	want := agent.Process{Binary: "/opt/pi-0.9/bin/pi", Env: map[string]string{"PI_MODEL": "fast"}, Dir: workDir}
+	if !reflect.DeepEqual(runCfg.AgentProcess, want) {
+		t.Errorf("AgentProcess = %#v, want the parent's binary and directory with the configured env %#v", runCfg.AgentProcess, want)
+	}
+
+	// A different agent starts as configured.
+	runCfg, err = rerunConfig(&cli.Config{RunsDir: runsDir, RerunAgent: "claude"}, store, "parent-run")
+	if err != nil {
+		t.Fatalf("rerunConfig() with another agent error = %v", err)
+	}
+	if want := agentProcessFor("claude"); !reflect.DeepEqual(runCfg.AgentProcess, want) {
+		t.Errorf("AgentProcess = %#v, want the configured %#v", runCfg.AgentProcess, want)
+	}
+
+	// A working directory that is gone falls back to the configured one.
+	if err := os.Remove(workDir); err != nil {
+		t.Fatal(err)
+	}
+	runCfg, err = rerunConfig(&cli.Config{RunsDir: runsDir}, store, "parent-run")
+	if err != nil {
+		t.Fatalf("rerunConfig() error = %v", err)
+	}
+	if runCfg.AgentProcess.Dir != "elsewhere" {
+		t.Errorf("AgentProcess.Dir = %q, want the configured one", runCfg.AgentProcess.Dir)
+	}
+}
+
+func TestRerunConfigRebuildsPromptTemplate(t *testing.T) {
+	clearFileConfig(t)
+	clearConfiguredTemplates(t)
+	templateLibrary = []config.LibraryTemplate{
+		{Name: "review", Text: "Review {{.PlanContent}} ({{.Iteration}}/{{.MaxIterations}}), notes in {{.NotesPath}}."},
+	}
+	t.Cleanup(func() { templateLibrary = nil })
+
+	runsDir := t.TempDir()
+	plan := filepath.Join(t.TempDir(), "PLAN.md")
+	if err := os.WriteFile(plan, []byte("the plan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeMetaOnlyRun(t, runsDir, "parent-run", runner.RunMeta{
+		RunID:         "parent-run",
+		Agent:         "pi",
+		PromptSource:  "plan",
+		PlanFile:      plan,
+		Template:      "review",
+		MaxIterations: 3,
+	})
+	writeEffectivePromptArtifact(t, runsDir, "parent-run", "Review the old plan (1/3).")
+	store := runner.NewFSStore(runsDir)
+
+	runCfg, err := rerunConfig(&cli.Config{RunsDir: runsDir}, store, "parent-run")
+	if err != nil {
+		t.Fatalf("rerunConfig() error = %v", err)
+	}
+	notes := filepath.Join(runsDir, runCfg.RunID, "NOTES.md")
+	if want := "Review the plan (1/3), notes in " + notes + "."; runCfg.Prompt != want {
+		t.Errorf("Prompt = %q, want the template rendered again: %q", runCfg.Prompt, want)
+	}
+	if runCfg.PromptTemplate == nil || runCfg.Template != "review" {
+		t.Fatalf("PromptTemplate = %v, Template = %q; want the parent's library template", runCfg.PromptTemplate, runCfg.Template)
+	}
+	got, err := runCfg.PromptTemplate.Render(context.Background(), prompt.Iteration{Number: 2, MaxIterations: 3})
+	if want := "Review the plan (2/3), notes in " + notes + "."; err != nil || got != want {
+		t.Errorf("second render = %q, %v; want %q", got, err, want)
+	}
+
+	// Without its plan the template cannot be rebuilt, and the recorded
+	// first render is replayed.
+	if err := os.Remove(plan); err != nil {
+		t.Fatal(err)
+	}
+	runCfg, err = rerunConfig(&cli.Config{RunsDir: runsDir}, store, "parent-run")
+	if err != nil {
+		t.Fatalf("rerunConfig() without the plan error = %v", err)
+	}
+	if runCfg.PromptTemplate != nil || runCfg.Prompt != "Review the old plan (1/3)." {
+		t.Errorf("Prompt = %q, PromptTemplate = %v; want the recorded prompt", runCfg.Prompt, runCfg.PromptTemplate)
+	}
+}
+
+func TestRerunConfigErrors(t *testing.T) {
+	clearFileConfig(t)
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "no-prompt", runner.RunMeta{RunID: "no-prompt", Agent: "pi"})
+	writeMetaOnlyRun(t, runsDir, "odd-agent", runner.RunMeta{RunID: "odd-agent", Agent: "unknown"})
+	writeEffectivePromptArtifact(t, runsDir, "odd-agent", "prompt")
+	store := runner.NewFSStore(runsDir)
+
+	for ref, want := range map[string]string{
+		"missing":   "no run found",
+		"no-prompt": "no effective prompt",
+		"odd-agent": `unknown agent "unknown"`,
+	} {
+		_, err := rerunConfig(&cli.Config{RunsDir: runsDir}, store, ref)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("rerunConfig(%q) error = %v, want %q", ref, err, want)
+		}
+	}
+}
+
+func TestRerunRunRecordsParent(t *testing.T) {
+	clearFileConfig(t)
+	promptOut := filepath.Join(t.TempDir(), "prompt.txt")
+	t.Setenv("RERUN_PROMPT_OUT", promptOut)
+	installFakePIBinary(t, `#!/bin/sh
+[ "$1" = --version ] && echo "fake-pi 1.0" && exit 0
+for arg; do
+  case "$arg" in @*) cat "${arg#@}" > "$RERUN_PROMPT_OUT" ;; esac
+done
+cat <<'JSONL'
+{"type":"message_start","message":{"role":"assistant","model":"fake-pi"}}
+{"type":"message_update","assistantMessageEvent":{"type":"text_delta","contentIndex":0,"delta":"<promise>COMPLETE</promise>"}}
+{"type":"message_end"}
+{"type":"turn_end"}
+JSONL
+`)
+	runsDir := t.TempDir()
+	writeRerunParent(t, runsDir)
+
+	result, err := rerunRun(&cli.Config{RunsDir: runsDir}, "@auth-refactor", false)
+	if err != nil {
+		t.Fatalf("rerunRun() error = %v", err)
+	}
+	if result.Status != runner.StatusCompleted {
+		t.Fatalf("Status = %q, want completed", result.Status)
+	}
+
+	meta := readRunMetaFile(t, filepath.Join(runsDir, result.RunID, "meta.json"))
+	if meta.ParentRunID != "parent-run" || meta.MaxIterations != 3 || meta.PlanFile != "PLAN.md" {
+		t.Errorf("meta = %+v, want parent-run's settings and parent link", meta)
+	}
+	if got := strings.Join(meta.Environment.AgentExtraArgs, " "); got != "--model fast" {
+		t.Errorf("recorded extra args = %q, want the parent's", got)
+	}
+
+	data, err := os.ReadFile(promptOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt := string(data)
+	if !strings.Contains(prompt, filepath.Join(result.RunID, "NOTES.md")) || strings.Contains(prompt, "parent-run") {
+		t.Errorf("prompt = %q, want the new run's notes path", prompt)
+	}
+	if !strings.Contains(prompt, "keep tests green") || strings.Contains(prompt, "no new deps") {
+		t.Errorf("prompt = %q, want only the reminder still active in the parent", prompt)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// runStats implements "ralfinho stats": aggregate every saved run and print
+// either a human-readable report or JSON.
+func runStats(cfg *cli.Config) {
+	summaries, err := listRunSummaries(cfg.RunsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	stats := viewer.ComputeStats(summaries)
+	if cfg.StatsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			fmt.Fprintf(os.Stderr, "ralfinho stats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(summaries) == 0 {
+		fmt.Println("No runs found.")
+		return
+	}
+	writeStatsReport(os.Stdout, stats)
+}
+
+// writeStatsReport renders Stats as plain-text tables.
+func writeStatsReport(w io.Writer, stats viewer.Stats) {
+	o := stats.Overall
+	fmt.Fprintf(w, "Runs: %d (completed %d, failed %d, interrupted %d, max iterations %d, running %d)\n",
+		o.Runs, o.Completed, o.Failed, o.Interrupted, o.MaxIterationsReached, o.Running)
+
+	writeStatsTable(w, "By agent", stats.ByAgent)
+	writeStatsTable(w, "By prompt source", stats.ByPromptSource)
+	writeStatsTable(w, "By week", stats.ByWeek)
+
+	if len(o.ToolCalls) > 0 {
+		fmt.Fprintf(w, "\nTool calls (%d total, %s errors)\n", o.TotalToolCalls(), formatPercent(o.ToolErrorRate))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, name := range sortedCountKeys(o.ToolCalls) {
+			fmt.Fprintf(tw, "  %s\t%d\n", name, o.ToolCalls[name])
+		}
+		tw.Flush()
+	}
+
+	if len(o.FailureCategories) > 0 {
+		fmt.Fprintln(w, "\nFailure reasons")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, category := range sortedCountKeys(o.FailureCategories) {
+			fmt.Fprintf(tw, "  %s\t%d\n", category, o.FailureCategories[category])
+		}
+		tw.Flush()
+	}
+}
+
+func writeStatsTable(w io.Writer, title string, groups []viewer.StatsGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  \tRUNS\tSUCCESS\tERRORS\tITERS TO DONE\tTIME/ITER\tTOOL CALLS\tTOOL ERRORS\tTOKENS\t")
+	for _, g := range groups {
+		fmt.Fprintf(tw, "  %s\t%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t\n",
+			g.Key,
+			g.Runs,
+			formatPercent(g.SuccessRate),
+			formatPercent(g.ErrorRate),
+			formatAverage(g.AvgIterationsToCompletion),
+			formatSeconds(g.AvgIterationSeconds),
+			g.TotalToolCalls(),
+			formatPercent(g.ToolErrorRate),
+			formatTokens(g.Tokens),
+		)
+	}
+	tw.Flush()
+}
+
+func formatPercent(f float64) string {
+	return fmt.Sprintf("%.1f%%", f*100)
+}
+
+func formatAverage(f float64) string {
+	if f == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", f)
+}
+
+func formatSeconds(s float64) string {
+	switch {
+	case s == 0:
+		return "-"
+	case s < 60:
+		return fmt.Sprintf("%.0fs", s)
+	default:
+		return fmt.Sprintf("%.1fm", s/60)
+	}
+}
+
+func formatTokens(u viewer.TokenUsage) string {
+	if u.RunsWithUsage == 0 {
+		return "-"
+	}
+	total := u.Total()
+	switch {
+	case total >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(total)/1_000_000)
+	case total >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(total)/1_000)
+	default:
+		return fmt.Sprintf("%d", total)
+	}
+}
+
+// sortedCountKeys orders map keys by descending count, then name.
+func sortedCountKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return strings.Compare(keys[i], keys[j]) < 0
+	})
+	return keys
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+func TestRunStats(t *testing.T) {
+	t.Run("empty runs dir", func(t *testing.T) {
+		stdout, stderr := captureCommandOutput(t, func() {
+			runStats(&cli.Config{Command: cli.CommandStats, RunsDir: t.TempDir()})
+		})
+		if stdout != "No runs found.\n" {
+			t.Fatalf("stdout = %q, want %q", stdout, "No runs found.\\n")
+		}
+		if stderr != "" {
+			t.Fatalf("stderr = %q, want empty", stderr)
+		}
+	})
+
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "11111111-done", runner.RunMeta{
+		RunID:               "11111111-done",
+		StartedAt:           "2026-03-07T10:00:00Z",
+		EndedAt:             "2026-03-07T10:02:00Z",
+		Status:              string(runner.StatusCompleted),
+		Agent:               "pi",
+		PromptSource:        "plan",
+		IterationsCompleted: 2,
+	})
+	writeMetaOnlyRun(t, runsDir, "22222222-failed", runner.RunMeta{
+		RunID:               "22222222-failed",
+		StartedAt:           "2026-03-08T11:30:00Z",
+		Status:              string(runner.StatusFailed),
+		Agent:               "kiro",
+		PromptSource:        "prompt",
+		IterationsCompleted: 1,
+		Failure:             &runner.Failure{Category: runner.FailureAgentExit, Message: "boom"},
+	})
+
+	t.Run("text report", func(t *testing.T) {
+		stdout, stderr := captureCommandOutput(t, func() {
+			runStats(&cli.Config{Command: cli.CommandStats, RunsDir: runsDir})
+		})
+		if stderr != "" {
+			t.Fatalf("stderr = %q, want empty", stderr)
+		}
+		for _, want := range []string{
+			"Runs: 2 (completed 1, failed 1, interrupted 0, max iterations 0, running 0)",
+			"By agent",
+			"By prompt source",
+			"By week",
+			"2026-W10",
+			"Failure reasons",
+			"agent_exit",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("stdout missing %q:\n%s", want, stdout)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		stdout, stderr := captureCommandOutput(t, func() {
+			runStats(&cli.Config{Command: cli.CommandStats, RunsDir: runsDir, StatsJSON: true})
+		})
+		if stderr != "" {
+			t.Fatalf("stderr = %q, want empty", stderr)
+		}
+		var stats viewer.Stats
+		if err := json.Unmarshal([]byte(stdout), &stats); err != nil {
+			t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout)
+		}
+		if stats.SchemaVersion != viewer.StatsSchemaVersion {
+			t.Errorf("schema_version = %d, want %d", stats.SchemaVersion, viewer.StatsSchemaVersion)
+		}
+		if stats.Overall.Runs != 2 || stats.Overall.SuccessRate != 0.5 {
+			t.Errorf("overall = %+v, want 2 runs at 50%% success", stats.Overall)
+		}
+		if len(stats.ByAgent) != 2 {
+			t.Errorf("by_agent = %+v, want 2 groups", stats.ByAgent)
+		}
+	})
+}
+
+func TestStatsFormatting(t *testing.T) {
+	if got := formatPercent(0.125); got != "12.5%" {
+		t.Errorf("formatPercent = %q", got)
+	}
+	if got := formatAverage(0); got != "-" {
+		t.Errorf("formatAverage(0) = %q, want -", got)
+	}
+	if got := formatSeconds(45); got != "45s" {
+		t.Errorf("formatSeconds(45) = %q", got)
+	}
+	if got := formatSeconds(150); got != "2.5m" {
+		t.Errorf("formatSeconds(150) = %q", got)
+	}
+	if got := formatTokens(viewer.TokenUsage{}); got != "-" {
+		t.Errorf("formatTokens(no usage) = %q, want -", got)
+	}
+	if got := formatTokens(viewer.TokenUsage{Input: 1_200_000, Output: 300_000, RunsWithUsage: 1}); got != "1.5M" {
+		t.Errorf("formatTokens = %q, want 1.5M", got)
+	}
+	if got := sortedCountKeys(map[string]int{"b": 2, "a": 2, "c": 5}); strings.Join(got, ",") != "c,a,b" {
+		t.Errorf("sortedCountKeys = %v, want [c a b]", got)
+	}
+}
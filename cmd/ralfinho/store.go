@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/runner/sqlitestore"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// storageBackend is the run store selected by storage.backend: "fs" or
+// "sqlite".
+var storageBackend = "fs"
+
+// openRunStore opens the run store selected by storage.backend for runsDir.
+// The SQLite index is only a cache of the run directories, so when it cannot
+// be opened ralfinho warns and falls back to the filesystem store.
+func openRunStore(runsDir string) runner.RunStore {
+	if storageBackend == "sqlite" {
+		store, err := sqlitestore.Open(runsDir)
+		if err == nil {
+			return store
+		}
+		fmt.Fprintf(os.Stderr, "ralfinho: warning: %v; reading runs from the filesystem\n", err)
+	}
+	return runner.NewFSStore(runsDir)
+}
+
+// resolveRunRef resolves a run-id prefix or "@label" reference against the
+// runs in runsDir through the configured store.
+func resolveRunRef(runsDir, ref string) (string, error) {
+	if _, err := os.Stat(runsDir); err != nil {
+		return "", fmt.Errorf("reading runs directory: %w", err)
+	}
+	store := openRunStore(runsDir)
+	defer store.Close()
+	return viewer.ResolveStoredRunID(runsDir, store, ref)
+}
+
+// deleteRun deletes runID through the configured store, so an index drops
+// it together with its directory.
+func deleteRun(runsDir, runID string) error {
+	store := openRunStore(runsDir)
+	defer store.Close()
+	return store.DeleteRun(runID)
+}
+
+// listRunSummaries lists the saved runs in runsDir through the configured
+// store. A missing runs directory lists nothing and is not created.
+func listRunSummaries(runsDir string) ([]viewer.RunSummary, error) {
+	return queryRunSummaries(runsDir, runner.RunQuery{})
+}
+
+// queryRunSummaries lists the saved runs in runsDir that match q, letting
+// the configured store do the filtering.
+func queryRunSummaries(runsDir string, q runner.RunQuery) ([]viewer.RunSummary, error) {
+	if _, err := os.Stat(runsDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	store := openRunStore(runsDir)
+	defer store.Close()
+	return viewer.QueryRunSummaries(store, q)
+}
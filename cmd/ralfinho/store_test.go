@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/runner/sqlitestore"
+)
+
+func useStorageBackend(t *testing.T, backend string) {
+	t.Helper()
+	prev := storageBackend
+	storageBackend = backend
+	t.Cleanup(func() { storageBackend = prev })
+}
+
+func TestListRunSummariesWithSQLiteBackend(t *testing.T) {
+	useStorageBackend(t, "sqlite")
+
+	missing := filepath.Join(t.TempDir(), "runs")
+	if summaries, err := listRunSummaries(missing); err != nil || summaries != nil {
+		t.Errorf("listRunSummaries(missing) = %v, %v; want nil, nil", summaries, err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("listing created the runs dir: %v", err)
+	}
+
+	runsDir := t.TempDir()
+	writeMetaOnlyRun(t, runsDir, "run-a", runner.RunMeta{RunID: "run-a", Status: "completed"})
+	summaries, err := listRunSummaries(runsDir)
+	if err != nil || len(summaries) != 1 || summaries[0].RunID != "run-a" {
+		t.Fatalf("listRunSummaries = %+v, %v; want run-a", summaries, err)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, sqlitestore.IndexFile)); err != nil {
+		t.Errorf("index not created: %v", err)
+	}
+}
+
+func TestOpenRunStoreFallsBackToFilesystem(t *testing.T) {
+	useStorageBackend(t, "sqlite")
+
+	runsDir := t.TempDir()
+	// A directory where the index should be makes it impossible to open.
+	if err := os.Mkdir(filepath.Join(runsDir, sqlitestore.IndexFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	store := openRunStore(runsDir)
+	defer store.Close()
+	if _, ok := store.(*runner.FSStore); !ok {
+		t.Errorf("openRunStore = %T, want *runner.FSStore fallback", store)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fsmiamoto/ralfinho/internal/cli"
+	"github.com/fsmiamoto/ralfinho/internal/config"
+)
+
+// runTemplates implements "ralfinho templates list|show". Like config, it
+// runs before the regular config loading: the library does not depend on it.
+func runTemplates(cfg *cli.Config) {
+	library, err := config.LoadTemplateLibrary()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ralfinho templates: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch cfg.TemplatesAction {
+	case "list":
+		writeTemplateList(os.Stdout, library, config.TemplateDirs())
+	case "show":
+		for _, t := range library {
+			if t.Name == cfg.TemplateName {
+				fmt.Print(t.Text)
+				return
+			}
+		}
+		fmt.Fprintf(os.Stderr, "ralfinho templates: unknown template %q\n", cfg.TemplateName)
+		os.Exit(1)
+	}
+}
+
+// writeTemplateList prints one "name source path" line per library template,
+// or where templates are looked for when there are none.
+func writeTemplateList(w io.Writer, library []config.LibraryTemplate, dirs []string) {
+	if len(library) == 0 {
+		fmt.Fprintf(w, "no templates found in %s\n", strings.Join(dirs, " or "))
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, t := range library {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Name, t.Source, t.Path)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/config"
+)
+
+func TestWriteTemplateList(t *testing.T) {
+	var buf bytes.Buffer
+	writeTemplateList(&buf, []config.LibraryTemplate{
+		{Name: "review", Source: "global", Path: "/home/me/.config/ralfinho/templates/review.md"},
+		{Name: "rules/commit", Source: "local", Path: ".ralfinho/templates/rules/commit.md"},
+	}, nil)
+	want := "review        global  /home/me/.config/ralfinho/templates/review.md\n" +
+		"rules/commit  local   .ralfinho/templates/rules/commit.md\n"
+	if buf.String() != want {
+		t.Errorf("list =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	writeTemplateList(&buf, nil, []string{"/xdg/ralfinho/templates", ".ralfinho/templates"})
+	if got := buf.String(); !strings.HasPrefix(got, "no templates found in /xdg/ralfinho/templates or .ralfinho/templates") {
+		t.Errorf("empty list = %q", got)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -153,10 +154,11 @@ func (c *acpClient) getReadErr() error {
 //
 // If rawWriter is non-nil, raw JSON-RPC messages from stdout are tee'd to it
 // for debugging (raw-output.log). extraArgs, if non-empty, are appended to
-// the kiro-cli command line after the built-in flags.
-func newACPClient(ctx context.Context, rawWriter io.Writer, logWriter io.Writer, extraArgs []string) (*acpClient, error) {
+// the kiro-cli command line after the built-in flags. proc can replace the
+// kiro-cli binary and set its environment and working directory.
+func newACPClient(ctx context.Context, rawWriter io.Writer, logWriter io.Writer, extraArgs []string, proc Process) (*acpClient, error) {
 	args := append([]string{"acp", "--trust-all-tools"}, extraArgs...)
-	cmd := exec.CommandContext(ctx, "kiro-cli", args...)
+	cmd := proc.command(ctx, "kiro-cli", args...)
 	stderrBuf := newLimitedBuffer(4096)
 	cmd.Stderr = stderrBuf // capture last 4KB of kiro-cli stderr for diagnostics
 	// Use a process group so we can kill kiro-cli and all its children.
@@ -176,10 +178,10 @@ func newACPClient(ctx context.Context, rawWriter io.Writer, logWriter io.Writer,
 	if err := cmd.Start(); err != nil {
 		stdin.Close()
 		stdout.Close()
-		if errors.Is(err, exec.ErrNotFound) {
+		if errors.Is(err, exec.ErrNotFound) && proc.Binary == "" {
 			return nil, fmt.Errorf("kiro-cli not found in PATH. Install from https://kiro.dev/cli/")
 		}
-		return nil, fmt.Errorf("acp: start kiro-cli: %w", err)
+		return nil, fmt.Errorf("acp: start %s: %w", cmd.Path, err)
 	}
 
 	// Optionally tee raw stdout for debugging.
@@ -620,6 +622,20 @@ func (c *acpClient) autoApprovePermissions(ctx context.Context) {
 // Teardown
 // ---------------------------------------------------------------------------
 
+// iterationError tears down the subprocess and wraps err as an agent *Error
+// carrying kiro-cli's exit code and stderr tail. Close must run first so the
+// stderr buffer is no longer being written to.
+func (c *acpClient) iterationError(kind ErrorKind, err error) *Error {
+	c.Close()
+	e := newError(kind, strings.TrimSpace(c.stderrBuf.String()), err)
+	if c.cmd != nil && c.cmd.ProcessState != nil {
+		if ws, ok := c.cmd.ProcessState.Sys().(syscall.WaitStatus); ok && !ws.Signaled() {
+			e.ExitCode = ws.ExitStatus()
+		}
+	}
+	return e
+}
+
 // Close terminates the kiro-cli subprocess (and all its children) and waits
 // for cleanup. Safe to call multiple times.
 func (c *acpClient) Close() error {
@@ -1034,7 +1034,7 @@ func TestNewACPClient_KiroNotFound(t *testing.T) {
 	// even if it happens to be installed on the test machine.
 	t.Setenv("PATH", "/nonexistent-dir-for-test")
 
-	_, err := newACPClient(ctx, nil, io.Discard, nil)
+	_, err := newACPClient(ctx, nil, io.Discard, nil, Process{})
 	if err == nil {
 		t.Fatal("expected error when kiro-cli is not in PATH, got nil")
 	}
@@ -60,6 +60,31 @@ type Options struct {
 	// ExtraArgs is appended verbatim to the agent subprocess command line
 	// after all built-in flags. Sourced from per-agent config file settings.
 	ExtraArgs []string
+
+	// Process customises the binary, environment and working directory of
+	// the agent subprocess. The zero value runs the default binary in the
+	// current directory with the inherited environment.
+	Process Process
+}
+
+// Process describes how an agent subprocess is started. Sourced from
+// per-agent config file settings.
+type Process struct {
+	// Binary replaces the agent's default executable ("pi", "claude",
+	// "kiro-cli"). A relative path containing a separator resolves against
+	// ralfinho's working directory, not Dir.
+	Binary string
+
+	// Env sets extra variables, overriding inherited ones of the same name.
+	Env map[string]string
+
+	// EnvPassthrough, when non-nil, limits the inherited environment to the
+	// listed variables. Entries may be path.Match patterns such as
+	// "ANTHROPIC_*". Env is applied on top.
+	EnvPassthrough []string
+
+	// Dir is the subprocess working directory; "" keeps ralfinho's.
+	Dir string
 }
 
 // WithRawWriter returns an Option that sets the raw output writer.
@@ -85,6 +110,14 @@ func WithExtraArgs(args []string) Option {
 	}
 }
 
+// WithProcess returns an Option that sets how the agent subprocess is
+// started.
+func WithProcess(p Process) Option {
+	return func(o *Options) {
+		o.Process = p
+	}
+}
+
 // applyOptions applies the given options to an Options struct and returns it.
 func applyOptions(opts []Option) Options {
 	var o Options
@@ -107,6 +140,20 @@ func IsValid(name string) bool {
 	}
 }
 
+// Executable returns the default program the named agent runs, e.g.
+// "kiro-cli" for "kiro", or "" for unknown names. Process.Binary overrides
+// it.
+func Executable(name string) string {
+	switch name {
+	case "pi", "claude":
+		return name
+	case "kiro":
+		return "kiro-cli"
+	default:
+		return ""
+	}
+}
+
 // Resolve maps an agent name to a concrete Agent implementation.
 //
 // Supported names:
@@ -25,7 +25,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 
 	"github.com/fsmiamoto/ralfinho/internal/events"
@@ -46,7 +45,7 @@ type ClaudeAgent struct {
 }
 
 // NewClaudeAgent creates a ClaudeAgent with the given options.
-// The binary defaults to "claude". Pass WithRawWriter to capture raw
+// The binary defaults to "claude"; WithProcess can replace it. Pass WithRawWriter to capture raw
 // stream-json lines for debugging.
 func NewClaudeAgent(opts ...Option) *ClaudeAgent {
 	return &ClaudeAgent{
@@ -69,7 +68,7 @@ func (a *ClaudeAgent) RunIteration(ctx context.Context, prompt string, onEvent f
 	}
 	cmdArgs = append(cmdArgs, a.opts.ExtraArgs...)
 
-	cmd := exec.CommandContext(ctx, a.binary, cmdArgs...)
+	cmd := a.opts.Process.command(ctx, a.binary, cmdArgs...)
 	stderrBuf := newLimitedBuffer(4096)
 	cmd.Stderr = stderrBuf
 
@@ -80,7 +79,7 @@ func (a *ClaudeAgent) RunIteration(ctx context.Context, prompt string, onEvent f
 
 	if err := cmd.Start(); err != nil {
 		stdout.Close()
-		return "", fmt.Errorf("claude: starting agent: %w", err)
+		return "", newError(ErrorKindStart, "", fmt.Errorf("claude: starting agent: %w", err))
 	}
 
 	// Optionally tee raw stdout to RawWriter.
@@ -114,7 +113,7 @@ func (a *ClaudeAgent) RunIteration(ctx context.Context, prompt string, onEvent f
 
 	if err := scanner.Err(); err != nil {
 		mapper.finalize()
-		return mapper.assistantText(), fmt.Errorf("claude: reading agent output: %w", err)
+		return mapper.assistantText(), newError(ErrorKindOutput, strings.TrimSpace(stderrBuf.String()), fmt.Errorf("claude: reading agent output: %w", err))
 	}
 
 	// Ensure proper lifecycle closure after scan loop.
@@ -130,9 +129,9 @@ func (a *ClaudeAgent) RunIteration(ctx context.Context, prompt string, onEvent f
 	if waitErr != nil {
 		stderr := strings.TrimSpace(stderrBuf.String())
 		if stderr != "" {
-			return mapper.assistantText(), fmt.Errorf("claude: agent exited with error: %w\nstderr: %s", waitErr, stderr)
+			return mapper.assistantText(), newWaitError(waitErr, stderr, fmt.Errorf("claude: agent exited with error: %w\nstderr: %s", waitErr, stderr))
 		}
-		return mapper.assistantText(), fmt.Errorf("claude: agent exited with error: %w", waitErr)
+		return mapper.assistantText(), newWaitError(waitErr, "", fmt.Errorf("claude: agent exited with error: %w", waitErr))
 	}
 
 	return mapper.assistantText(), nil
@@ -144,7 +143,8 @@ func (a *ClaudeAgent) RunIteration(ctx context.Context, prompt string, onEvent f
 
 // claudeLine is the top-level envelope for each stream-json line.
 type claudeLine struct {
-	Type string `json:"type"`
+	Type    string `json:"type"`
+	Subtype string `json:"subtype,omitempty"`
 }
 
 // claudeStreamEventLine wraps a stream_event line with its nested event.
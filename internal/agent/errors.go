@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// ErrorKind classifies why an agent iteration failed. The runner uses it to
+// decide whether a failed iteration is worth retrying and to build the
+// structured failure record persisted with the run.
+type ErrorKind string
+
+const (
+	// ErrorKindStart means the agent process could not be started (binary
+	// missing, pipe setup failed, handshake rejected).
+	ErrorKindStart ErrorKind = "start"
+	// ErrorKindExit means the agent process exited with a non-zero status.
+	ErrorKindExit ErrorKind = "exit"
+	// ErrorKindCrash means the agent process died unexpectedly: it was
+	// killed by a signal or closed its output stream mid-conversation.
+	ErrorKindCrash ErrorKind = "crash"
+	// ErrorKindOutput means reading the agent's output stream failed (for
+	// example a line exceeded the scanner buffer).
+	ErrorKindOutput ErrorKind = "output"
+	// ErrorKindProtocol means the agent spoke an unexpected protocol
+	// message, such as a JSON-RPC error response.
+	ErrorKindProtocol ErrorKind = "protocol"
+)
+
+// Error is returned by RunIteration implementations when the agent itself
+// failed. It keeps the original error text (so logs read the same as
+// before) while exposing the failure kind, exit code, and stderr tail.
+type Error struct {
+	Kind     ErrorKind
+	ExitCode int    // process exit code; -1 when unknown or not applicable
+	Stderr   string // last few KB of the agent's stderr, trimmed
+	Err      error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError wraps err with the given kind and no exit code.
+func newError(kind ErrorKind, stderr string, err error) *Error {
+	return &Error{Kind: kind, ExitCode: -1, Stderr: stderr, Err: err}
+}
+
+// newWaitError wraps the error returned by exec.Cmd.Wait, distinguishing a
+// plain non-zero exit from a process killed by a signal.
+func newWaitError(waitErr error, stderr string, err error) *Error {
+	e := newError(ErrorKindExit, stderr, err)
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		e.ExitCode = exitErr.ExitCode()
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			e.Kind = ErrorKindCrash
+		}
+	}
+	return e
+}
+
+// ErrorKindOf returns the kind of an agent error, or "" when err is not (and
+// does not wrap) an *Error.
+func ErrorKindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ""
+}
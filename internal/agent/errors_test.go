@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorKindOf_PlainError(t *testing.T) {
+	if got := ErrorKindOf(errors.New("boom")); got != "" {
+		t.Errorf("ErrorKindOf(plain) = %q, want empty", got)
+	}
+	if got := ErrorKindOf(nil); got != "" {
+		t.Errorf("ErrorKindOf(nil) = %q, want empty", got)
+	}
+}
+
+func TestErrorKindOf_Wrapped(t *testing.T) {
+	inner := newError(ErrorKindOutput, "", errors.New("token too long"))
+	wrapped := fmt.Errorf("outer: %w", inner)
+	if got := ErrorKindOf(wrapped); got != ErrorKindOutput {
+		t.Errorf("ErrorKindOf(wrapped) = %q, want %q", got, ErrorKindOutput)
+	}
+	if wrapped.Error() != "outer: token too long" {
+		t.Errorf("Error() = %q, want original text preserved", wrapped.Error())
+	}
+}
+
+func TestPiAgent_Errors_CarryKindExitCodeAndStderr(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantKind   ErrorKind
+		wantCode   int
+		wantStderr string
+	}{
+		{"non-zero exit", "echo 'rate limited' >&2; exit 3\n", ErrorKindExit, 3, "rate limited"},
+		{"killed by signal", "kill -9 $$\n", ErrorKindCrash, -1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewPiAgent(makeScript(t, tt.body))
+			onEvent, _ := collectEvents()
+
+			_, err := a.RunIteration(context.Background(), "test", onEvent)
+			var ae *Error
+			if !errors.As(err, &ae) {
+				t.Fatalf("error = %v (%T), want *agent.Error", err, err)
+			}
+			if ae.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", ae.Kind, tt.wantKind)
+			}
+			if ae.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", ae.ExitCode, tt.wantCode)
+			}
+			if ae.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", ae.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestPiAgent_BinaryNotFound_IsStartError(t *testing.T) {
+	a := NewPiAgent("/nonexistent/binary/that-does-not-exist-12345")
+	onEvent, _ := collectEvents()
+
+	_, err := a.RunIteration(context.Background(), "hello", onEvent)
+	if got := ErrorKindOf(err); got != ErrorKindStart {
+		t.Errorf("ErrorKindOf = %q, want %q", got, ErrorKindStart)
+	}
+}
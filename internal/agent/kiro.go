@@ -17,7 +17,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/fsmiamoto/ralfinho/internal/events"
@@ -45,21 +44,21 @@ func NewKiroAgent(options ...Option) *KiroAgent {
 // text.
 func (a *KiroAgent) RunIteration(ctx context.Context, prompt string, onEvent func(events.Event)) (string, error) {
 	// Spawn ACP client (includes initialize handshake).
-	client, err := newACPClient(ctx, a.opts.RawWriter, a.opts.LogWriter, a.opts.ExtraArgs)
+	client, err := newACPClient(ctx, a.opts.RawWriter, a.opts.LogWriter, a.opts.ExtraArgs, a.opts.Process)
 	if err != nil {
-		return "", fmt.Errorf("kiro: %w", err)
+		return "", newError(ErrorKindStart, "", fmt.Errorf("kiro: %w", err))
 	}
 	defer client.Close()
 
-	// Create a session with the current working directory.
-	cwd, err := os.Getwd()
+	// Create a session in the directory the agent process runs in.
+	cwd, err := a.opts.Process.workDir()
 	if err != nil {
 		return "", fmt.Errorf("kiro: getwd: %w", err)
 	}
 
 	sessionID, err := client.sessionNew(ctx, cwd)
 	if err != nil {
-		return "", fmt.Errorf("kiro: %w", err)
+		return "", client.iterationError(ErrorKindProtocol, fmt.Errorf("kiro: %w", err))
 	}
 
 	// Start the permission auto-approve handler so tool use is unblocked.
@@ -84,7 +83,13 @@ func (a *KiroAgent) RunIteration(ctx context.Context, prompt string, onEvent fun
 		return mapper.assistantText(), ctx.Err()
 	}
 	if err != nil {
-		return mapper.assistantText(), fmt.Errorf("kiro: %w", err)
+		kind := ErrorKindProtocol
+		if client.getReadErr() != nil {
+			// The read loop only stops when kiro-cli's stdout closes, i.e.
+			// the process went away in the middle of the prompt.
+			kind = ErrorKindCrash
+		}
+		return mapper.assistantText(), client.iterationError(kind, fmt.Errorf("kiro: %w", err))
 	}
 
 	return mapper.assistantText(), nil
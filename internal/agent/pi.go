@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/fsmiamoto/ralfinho/internal/events"
@@ -26,6 +25,7 @@ type PiAgent struct {
 
 // NewPiAgent creates a PiAgent that invokes the given binary name.
 // Typically binary is "pi" (the default agent), but it can be an absolute path.
+// A Process.Binary option takes precedence over it.
 func NewPiAgent(binary string, options ...Option) *PiAgent {
 	return &PiAgent{
 		binary: binary,
@@ -58,7 +58,7 @@ func (a *PiAgent) RunIteration(ctx context.Context, prompt string, onEvent func(
 	// Build command: <binary> --mode json -p --no-session @<tempfile> [extra-args...]
 	cmdArgs := []string{"--mode", "json", "-p", "--no-session", "@" + tmpPath}
 	cmdArgs = append(cmdArgs, a.opts.ExtraArgs...)
-	cmd := exec.CommandContext(ctx, a.binary, cmdArgs...)
+	cmd := a.opts.Process.command(ctx, a.binary, cmdArgs...)
 	stderrBuf := newLimitedBuffer(4096)
 	cmd.Stderr = stderrBuf
 
@@ -69,7 +69,7 @@ func (a *PiAgent) RunIteration(ctx context.Context, prompt string, onEvent func(
 
 	if err := cmd.Start(); err != nil {
 		stdout.Close()
-		return "", fmt.Errorf("starting agent: %w", err)
+		return "", newError(ErrorKindStart, "", fmt.Errorf("starting agent: %w", err))
 	}
 
 	// Process JSONL output. Optionally tee raw stdout to RawWriter.
@@ -82,39 +82,20 @@ func (a *PiAgent) RunIteration(ctx context.Context, prompt string, onEvent func(
 	// Allow large lines (pi can produce big JSON).
 	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
 
-	var assistantText strings.Builder
+	mapper := newPiEventMapper(onEvent)
 
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
 			continue
 		}
-
-		var ev events.Event
-		if err := json.Unmarshal([]byte(line), &ev); err != nil {
-			// Skip unparseable lines silently — the runner logs warnings
-			// through its own logging, and we don't want to couple the
-			// agent to a logger.
-			continue
-		}
-
-		// Accumulate assistant text from text_delta events for the return value.
-		if ev.Type == events.EventMessageUpdate && ev.AssistantMessageEvent != nil {
-			var ae events.AssistantEvent
-			if err := json.Unmarshal(ev.AssistantMessageEvent, &ae); err == nil {
-				if ae.Type == "text_delta" {
-					assistantText.WriteString(ae.Delta)
-				}
-			}
-		}
-
-		onEvent(ev)
+		mapper.handleLine([]byte(line))
 	}
 
 	waitErr := cmd.Wait()
 
 	if err := scanner.Err(); err != nil {
-		return assistantText.String(), fmt.Errorf("reading agent output: %w", err)
+		return mapper.assistantText(), newError(ErrorKindOutput, strings.TrimSpace(stderrBuf.String()), fmt.Errorf("reading agent output: %w", err))
 	}
 
 	// Surface context cancellation so the runner knows the iteration was
@@ -122,16 +103,60 @@ func (a *PiAgent) RunIteration(ctx context.Context, prompt string, onEvent func(
 	// because CommandContext SIGKILLs the process, making cmd.Wait()
 	// return "signal: killed" — that's expected, not an agent error.
 	if ctx.Err() != nil {
-		return assistantText.String(), ctx.Err()
+		return mapper.assistantText(), ctx.Err()
 	}
 
 	if waitErr != nil {
 		stderr := strings.TrimSpace(stderrBuf.String())
 		if stderr != "" {
-			return assistantText.String(), fmt.Errorf("agent exited with error: %w\nstderr: %s", waitErr, stderr)
+			return mapper.assistantText(), newWaitError(waitErr, stderr, fmt.Errorf("agent exited with error: %w\nstderr: %s", waitErr, stderr))
+		}
+		return mapper.assistantText(), newWaitError(waitErr, "", fmt.Errorf("agent exited with error: %w", waitErr))
+	}
+
+	return mapper.assistantText(), nil
+}
+
+// piEventMapper decodes pi's JSONL output. Pi already speaks the runner's
+// event protocol, so each line is forwarded as-is; the mapper only
+// accumulates assistant text for the return value.
+type piEventMapper struct {
+	onEvent func(events.Event)
+	text    strings.Builder
+}
+
+// newPiEventMapper creates a mapper that forwards events through onEvent.
+func newPiEventMapper(onEvent func(events.Event)) *piEventMapper {
+	return &piEventMapper{onEvent: onEvent}
+}
+
+// assistantText returns the accumulated text from all text_delta events.
+func (m *piEventMapper) assistantText() string {
+	return m.text.String()
+}
+
+// handleLine decodes one JSONL line and forwards it.
+func (m *piEventMapper) handleLine(raw []byte) {
+	var ev events.Event
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		// Skip unparseable lines silently — the runner logs warnings
+		// through its own logging, and we don't want to couple the
+		// agent to a logger.
+		return
+	}
+
+	// Accumulate assistant text from text_delta events for the return value.
+	if ev.Type == events.EventMessageUpdate && ev.AssistantMessageEvent != nil {
+		var ae events.AssistantEvent
+		if err := json.Unmarshal(ev.AssistantMessageEvent, &ae); err == nil {
+			if ae.Type == "text_delta" {
+				m.text.WriteString(ae.Delta)
+			}
 		}
-		return assistantText.String(), fmt.Errorf("agent exited with error: %w", waitErr)
 	}
 
-	return assistantText.String(), nil
+	m.onEvent(ev)
 }
+
+// finalize is a no-op: pi emits its own turn and agent lifecycle events.
+func (m *piEventMapper) finalize() {}
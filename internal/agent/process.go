@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// command builds the agent subprocess for binary (unless p.Binary overrides
+// it) with p's working directory and environment applied.
+func (p Process) command(ctx context.Context, binary string, args ...string) *exec.Cmd {
+	if p.Binary != "" {
+		binary = p.Binary
+	}
+	// exec resolves relative paths against cmd.Dir; pin them to our own
+	// working directory so Binary means the same with or without Dir.
+	if p.Dir != "" && strings.ContainsRune(binary, filepath.Separator) && !filepath.IsAbs(binary) {
+		if abs, err := filepath.Abs(binary); err == nil {
+			binary = abs
+		}
+	}
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Dir = p.Dir
+	cmd.Env = p.environ(os.Environ())
+	return cmd
+}
+
+// Version runs binary (unless p.Binary overrides it) with --version the way
+// the agent itself is started, in p's working directory and environment, and
+// returns the first line of its output.
+func (p Process) Version(ctx context.Context, binary string) (string, error) {
+	cmd := p.command(ctx, binary, "--version")
+	// Don't wait on children that outlive a killed command and keep its
+	// output pipe open.
+	cmd.WaitDelay = 100 * time.Millisecond
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line, nil
+}
+
+// workDir returns the directory the agent works in: Dir resolved to an
+// absolute path, or ralfinho's working directory.
+func (p Process) workDir() (string, error) {
+	if p.Dir == "" {
+		return os.Getwd()
+	}
+	return filepath.Abs(p.Dir)
+}
+
+// environ returns the subprocess environment built from base (in
+// os.Environ form), or nil to inherit it unchanged.
+func (p Process) environ(base []string) []string {
+	if p.EnvPassthrough == nil && len(p.Env) == 0 {
+		return nil
+	}
+
+	env := make([]string, 0, len(base)+len(p.Env))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, overridden := p.Env[name]; overridden {
+			continue
+		}
+		if p.EnvPassthrough != nil && !p.passes(name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	names := make([]string, 0, len(p.Env))
+	for name := range p.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+p.Env[name])
+	}
+	return env
+}
+
+// passes reports whether EnvPassthrough lets the inherited variable name
+// through.
+func (p Process) passes(name string) bool {
+	for _, pattern := range p.EnvPassthrough {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProcessEnviron(t *testing.T) {
+	t.Parallel()
+
+	base := []string{"PATH=/bin", "HOME=/home/me", "ANTHROPIC_API_KEY=global", "ANTHROPIC_MODEL=old", "SECRET=x"}
+	tests := []struct {
+		name string
+		proc Process
+		want []string
+	}{
+		{name: "zero value inherits", proc: Process{}, want: nil},
+		{
+			name: "env overrides and adds",
+			proc: Process{Env: map[string]string{"ANTHROPIC_MODEL": "opus", "EXTRA": "1"}},
+			want: []string{"PATH=/bin", "HOME=/home/me", "ANTHROPIC_API_KEY=global", "SECRET=x", "ANTHROPIC_MODEL=opus", "EXTRA=1"},
+		},
+		{
+			name: "passthrough filters with patterns",
+			proc: Process{EnvPassthrough: []string{"PATH", "ANTHROPIC_*"}},
+			want: []string{"PATH=/bin", "ANTHROPIC_API_KEY=global", "ANTHROPIC_MODEL=old"},
+		},
+		{
+			name: "passthrough plus env",
+			proc: Process{EnvPassthrough: []string{"PATH"}, Env: map[string]string{"ANTHROPIC_API_KEY": "project"}},
+			want: []string{"PATH=/bin", "ANTHROPIC_API_KEY=project"},
+		},
+		{
+			name: "empty passthrough inherits nothing",
+			proc: Process{EnvPassthrough: []string{}},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.proc.environ(base)
+			if (got == nil) != (tt.want == nil) || !slices.Equal(got, tt.want) {
+				t.Errorf("environ = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPiAgent_RunIteration_Process(t *testing.T) {
+	binDir := t.TempDir()
+	script := "#!/bin/sh\npwd > \"$OUT_DIR/pwd\"\nenv > \"$OUT_DIR/env\"\necho '{\"type\":\"turn_end\"}'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "custom-pi"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(binDir)
+	t.Setenv("RALFINHO_TEST_HIDDEN", "leak")
+	t.Setenv("RALFINHO_TEST_SHOWN", "kept")
+
+	workDir := t.TempDir()
+	outDir := t.TempDir()
+	a := NewPiAgent("pi", WithLogWriter(io.Discard), WithProcess(Process{
+		Binary:         "./custom-pi",
+		Env:            map[string]string{"OUT_DIR": outDir, "API_KEY": "project-key"},
+		EnvPassthrough: []string{"PATH", "RALFINHO_TEST_S*"},
+		Dir:            workDir,
+	}))
+	onEvent, _ := collectEvents()
+	if _, err := a.RunIteration(context.Background(), "prompt", onEvent); err != nil {
+		t.Fatalf("RunIteration error: %v", err)
+	}
+
+	pwd, err := os.ReadFile(filepath.Join(outDir, "pwd"))
+	if err != nil {
+		t.Fatalf("custom binary did not run: %v", err)
+	}
+	wantDir, _ := filepath.EvalSymlinks(workDir)
+	if gotDir, _ := filepath.EvalSymlinks(strings.TrimSpace(string(pwd))); gotDir != wantDir {
+		t.Errorf("agent ran in %q, want %q", gotDir, wantDir)
+	}
+	env, err := os.ReadFile(filepath.Join(outDir, "env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"API_KEY=project-key", "RALFINHO_TEST_SHOWN=kept"} {
+		if !strings.Contains(string(env), want) {
+			t.Errorf("agent env missing %q:\n%s", want, env)
+		}
+	}
+	if strings.Contains(string(env), "RALFINHO_TEST_HIDDEN") {
+		t.Errorf("agent env contains a variable outside the passthrough list:\n%s", env)
+	}
+}
+
+func TestKiroAgent_RunIteration_ProcessBinaryAndDir(t *testing.T) {
+	_, _, cwdFile := setupFakeKiroCLI(t, "success")
+	custom := filepath.Join(t.TempDir(), "kiro-nightly")
+	writeFakeKiroCLI(t, custom)
+	t.Setenv("PATH", "/usr/bin:/bin")
+	workDir := t.TempDir()
+
+	a := NewKiroAgent(WithLogWriter(io.Discard), WithProcess(Process{Binary: custom, Dir: workDir}))
+	onEvent, _ := collectEvents()
+	if _, err := a.RunIteration(context.Background(), "test prompt", onEvent); err != nil {
+		t.Fatalf("RunIteration error: %v", err)
+	}
+	cwd, err := os.ReadFile(cwdFile)
+	if err != nil {
+		t.Fatalf("ReadFile(cwd): %v", err)
+	}
+	if strings.TrimSpace(string(cwd)) != workDir {
+		t.Errorf("session cwd = %q, want %q", cwd, workDir)
+	}
+}
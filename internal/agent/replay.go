@@ -0,0 +1,243 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
+
+// replayMapper is the part of a backend's event mapper that Replay drives.
+// Each backend wraps its own line decoding around it.
+type replayMapper interface {
+	finalize()
+	assistantText() string
+}
+
+// MarkerType is the "type" of the lines the runner interleaves with agent
+// stdout in raw-output.log. No backend emits it, so Replay can tell them
+// apart from agent output.
+const MarkerType = "ralfinho_marker"
+
+// Marker is a runner annotation in raw-output.log. It carries an event the
+// runner synthesized itself (such as EventIteration) so Replay can put it
+// back at the same position in the stream.
+type Marker struct {
+	Type  string       `json:"type"`
+	Event events.Event `json:"event"`
+}
+
+// EncodeMarker returns the raw-output.log line for ev, newline included.
+func EncodeMarker(ev events.Event) ([]byte, error) {
+	data, err := json.Marshal(Marker{Type: MarkerType, Event: ev})
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Replay feeds a previously captured agent log — the stdout that
+// Options.RawWriter tees into raw-output.log — through the named backend's
+// event mapper, exactly as RunIteration would have seen it live, and
+// forwards the result to onEvent with EventIteration events at iteration
+// boundaries.
+//
+// Logs written by the runner carry Marker lines, and their events are
+// forwarded as-is; iteration markers are then the only iteration
+// boundaries. Logs without markers are split heuristically: pi at each
+// "session" header (or an agent_start following agent_end), claude at each
+// system/init line, and kiro at each prompt or initialize response.
+//
+// The returned slice holds each iteration's assistant text, which callers
+// use to detect the completion marker. Unparseable lines are skipped,
+// matching RunIteration.
+func Replay(name string, r io.Reader, onEvent func(events.Event)) ([]string, error) {
+	rp := &replayer{onEvent: onEvent}
+
+	var handle func(line []byte)
+	switch name {
+	case "pi":
+		handle = rp.piLine
+	case "claude":
+		handle = rp.claudeLine
+	case "kiro":
+		handle = rp.kiroLine
+	default:
+		return nil, fmt.Errorf("unknown agent %q (supported: pi, kiro, claude)", name)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if m, ok := parseMarker(line); ok {
+			rp.marker(m.Event)
+			continue
+		}
+		handle(line)
+	}
+	rp.endSession()
+
+	if err := scanner.Err(); err != nil {
+		return rp.texts, fmt.Errorf("reading agent log: %w", err)
+	}
+	return rp.texts, nil
+}
+
+// parseMarker decodes line if it is a runner Marker.
+func parseMarker(line []byte) (Marker, bool) {
+	if !bytes.Contains(line, []byte(MarkerType)) {
+		return Marker{}, false
+	}
+	var m Marker
+	if err := json.Unmarshal(line, &m); err != nil || m.Type != MarkerType {
+		return Marker{}, false
+	}
+	return m, true
+}
+
+// replayer tracks iteration boundaries while replaying a log.
+type replayer struct {
+	onEvent func(events.Event)
+
+	mapper        replayMapper // mapper for the current agent session; nil between sessions
+	mapperStarted bool         // mapper has emitted at least one event
+	agentEnd      bool         // pi: current session has seen agent_end
+
+	marked      bool // log carries iteration markers; only they delimit iterations
+	inIteration bool
+	iteration   int
+	texts       []string // assistant text per iteration
+}
+
+// emit forwards a mapped event, opening a new iteration before the first
+// event of an unmarked log's iteration.
+func (rp *replayer) emit(ev events.Event) {
+	if !rp.inIteration && !rp.marked {
+		rp.startIteration(events.Event{
+			Type: events.EventIteration,
+			ID:   fmt.Sprintf("iteration-%d", rp.iteration+1),
+		})
+	}
+	rp.mapperStarted = true
+	rp.onEvent(ev)
+}
+
+// marker forwards a runner event, treating iteration markers as the
+// authoritative boundaries from then on. The runner only writes markers
+// between agent sessions (iteration starts, retries, timeouts, restarts), so
+// the current session is finished first.
+func (rp *replayer) marker(ev events.Event) {
+	rp.closeMapper()
+	if ev.Type != events.EventIteration {
+		rp.onEvent(ev)
+		return
+	}
+	rp.marked = true
+	rp.startIteration(ev)
+}
+
+func (rp *replayer) startIteration(ev events.Event) {
+	rp.iteration++
+	rp.inIteration = true
+	rp.texts = append(rp.texts, "")
+	rp.onEvent(ev)
+}
+
+// closeMapper finalizes the current agent session, if it produced any
+// events, and records its text as the iteration's assistant text. Retried
+// iterations hold several sessions; the last one decides completion, as it
+// does in the runner.
+func (rp *replayer) closeMapper() {
+	if rp.mapper != nil && rp.mapperStarted && len(rp.texts) > 0 {
+		rp.mapper.finalize()
+		rp.texts[len(rp.texts)-1] = rp.mapper.assistantText()
+	}
+	rp.mapper = nil
+	rp.mapperStarted = false
+	rp.agentEnd = false
+}
+
+// endSession is called at a backend's session boundary. In unmarked logs
+// it also ends the iteration; empty iterations are never opened.
+func (rp *replayer) endSession() {
+	rp.closeMapper()
+	if !rp.marked {
+		rp.inIteration = false
+	}
+}
+
+func (rp *replayer) piLine(line []byte) {
+	var head struct {
+		Type events.EventType `json:"type"`
+	}
+	if err := json.Unmarshal(line, &head); err != nil {
+		return
+	}
+	if head.Type == events.EventSession || (head.Type == events.EventAgentStart && rp.agentEnd) {
+		rp.endSession()
+	}
+	if rp.mapper == nil {
+		rp.mapper = newPiEventMapper(rp.emit)
+	}
+	rp.mapper.(*piEventMapper).handleLine(line)
+	if head.Type == events.EventAgentEnd {
+		rp.agentEnd = true
+	}
+}
+
+func (rp *replayer) claudeLine(line []byte) {
+	var cl claudeLine
+	if err := json.Unmarshal(line, &cl); err != nil {
+		return
+	}
+	if cl.Type == "system" && cl.Subtype == "init" {
+		rp.endSession()
+		return
+	}
+	if rp.mapper == nil {
+		rp.mapper = newClaudeEventMapper(rp.emit)
+	}
+	rp.mapper.(*claudeEventMapper).handleLine(cl.Type, line)
+	if cl.Type == "result" {
+		rp.endSession()
+	}
+}
+
+func (rp *replayer) kiroLine(line []byte) {
+	var msg rpcMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return
+	}
+
+	if msg.IsResponse() {
+		// Both the initialize response (a new agent process) and the
+		// session/prompt response (stopReason) delimit an agent session.
+		var result struct {
+			ProtocolVersion json.RawMessage `json:"protocolVersion"`
+			StopReason      string          `json:"stopReason"`
+		}
+		if json.Unmarshal(msg.Result, &result) == nil && (result.ProtocolVersion != nil || result.StopReason != "") {
+			rp.endSession()
+		}
+		return
+	}
+
+	if !msg.IsNotification() || msg.Method != "session/update" {
+		return
+	}
+	u, err := parseSessionUpdate(&msg)
+	if err != nil {
+		return
+	}
+	if rp.mapper == nil {
+		rp.mapper = newKiroEventMapper(rp.emit)
+	}
+	rp.mapper.(*kiroEventMapper).handleUpdate(u)
+}
@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
+
+// replayLog runs Replay and records the stream as a list of event types,
+// with iteration events recorded by their ID.
+func replayLog(t *testing.T, name, log string) ([]string, []string) {
+	t.Helper()
+	var got []string
+	texts, err := Replay(name, strings.NewReader(log), func(ev events.Event) {
+		if ev.Type == events.EventIteration {
+			got = append(got, ev.ID)
+			return
+		}
+		got = append(got, string(ev.Type))
+	})
+	if err != nil {
+		t.Fatalf("Replay(%s): %v", name, err)
+	}
+	return got, texts
+}
+
+func assertSequence(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("sequence:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestReplay_Pi(t *testing.T) {
+	log := strings.Join([]string{
+		`{"type":"session","id":"s1"}`,
+		`{"type":"agent_start"}`,
+		`{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"first"}}`,
+		`{"type":"agent_end"}`,
+		`not json`,
+		``,
+		`{"type":"agent_start"}`,
+		`{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"<promise>COMPLETE</promise>"}}`,
+		`{"type":"agent_end"}`,
+	}, "\n")
+
+	got, texts := replayLog(t, "pi", log)
+	assertSequence(t, got, []string{
+		"iteration-1", "session", "agent_start", "message_update", "agent_end",
+		"iteration-2", "agent_start", "message_update", "agent_end",
+	})
+	if len(texts) != 2 || texts[0] != "first" || texts[1] != "<promise>COMPLETE</promise>" {
+		t.Errorf("texts = %q", texts)
+	}
+}
+
+func TestReplay_Claude(t *testing.T) {
+	log := strings.Join([]string{
+		`{"type":"system","subtype":"init","session_id":"a"}`,
+		`{"type":"stream_event","event":{"type":"message_start","message":{"role":"assistant","model":"m"}}}`,
+		`{"type":"stream_event","event":{"type":"content_block_start","content_block":{"type":"text"}}}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"hello"}}}`,
+		`{"type":"stream_event","event":{"type":"content_block_stop"}}`,
+		`{"type":"stream_event","event":{"type":"message_stop"}}`,
+		`{"type":"result","subtype":"success"}`,
+		`{"type":"system","subtype":"init","session_id":"b"}`,
+		`{"type":"stream_event","event":{"type":"message_start","message":{"role":"assistant","model":"m"}}}`,
+	}, "\n")
+
+	got, texts := replayLog(t, "claude", log)
+	if len(texts) != 2 || texts[0] != "hello" || texts[1] != "" {
+		t.Fatalf("texts = %q, want [hello, \"\"]", texts)
+	}
+	if got[0] != "iteration-1" || got[len(got)-1] != string(events.EventTurnEnd) {
+		t.Errorf("sequence = %v, want to start with iteration-1 and end with turn_end", got)
+	}
+	var turnEnds, iterations int
+	for _, g := range got {
+		switch {
+		case g == string(events.EventTurnEnd):
+			turnEnds++
+		case strings.HasPrefix(g, "iteration-"):
+			iterations++
+		}
+	}
+	// The truncated second iteration is still closed by finalize.
+	if turnEnds != 2 || iterations != 2 {
+		t.Errorf("got %d turn_end and %d iterations, want 2 each: %v", turnEnds, iterations, got)
+	}
+}
+
+func TestReplay_Kiro(t *testing.T) {
+	update := func(u string) string {
+		return `{"jsonrpc":"2.0","method":"session/update","params":{"sessionId":"s","update":` + u + `}}`
+	}
+	log := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":1}}`,
+		`{"jsonrpc":"2.0","id":2,"result":{"sessionId":"s"}}`,
+		update(`{"sessionUpdate":"agent_message_chunk","content":{"type":"text","text":"Hi"}}`),
+		update(`{"sessionUpdate":"tool_call","title":"shell","toolCallId":"tc-1","kind":"execute","status":"in_progress","rawInput":{"command":"ls"}}`),
+		update(`{"sessionUpdate":"tool_call","toolCallId":"tc-1","status":"completed","rawOutput":"ok"}`),
+		`{"jsonrpc":"2.0","id":77,"method":"session/request_permission","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"result":{"stopReason":"end_turn"}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":1}}`,
+		update(`{"sessionUpdate":"agent_message_chunk","content":{"type":"text","text":"again"}}`),
+	}, "\n")
+
+	got, texts := replayLog(t, "kiro", log)
+	if len(texts) != 2 || texts[0] != "Hi" || texts[1] != "again" {
+		t.Fatalf("texts = %q, want [Hi again]", texts)
+	}
+	if got[0] != "iteration-1" {
+		t.Errorf("sequence = %v, want iteration-1 first", got)
+	}
+	var sawToolStart, sawToolEnd bool
+	for _, g := range got {
+		sawToolStart = sawToolStart || g == string(events.EventToolExecutionStart)
+		sawToolEnd = sawToolEnd || g == string(events.EventToolExecutionEnd)
+	}
+	if !sawToolStart || !sawToolEnd {
+		t.Errorf("sequence = %v, want tool start and end", got)
+	}
+}
+
+func TestReplay_EmptyLog(t *testing.T) {
+	got, texts := replayLog(t, "claude", "")
+	if len(got) != 0 || len(texts) != 0 {
+		t.Errorf("empty log produced %v / %q", got, texts)
+	}
+}
+
+func TestReplay_UnknownAgent(t *testing.T) {
+	_, err := Replay("codex", strings.NewReader(""), func(events.Event) {})
+	if err == nil || !strings.Contains(err.Error(), "supported: pi, kiro, claude") {
+		t.Fatalf("err = %v, want unknown agent error", err)
+	}
+}
+
+func TestReplay_MarkersAreAuthoritative(t *testing.T) {
+	marker := func(ev events.Event) string {
+		line, err := EncodeMarker(ev)
+		if err != nil {
+			t.Fatalf("EncodeMarker: %v", err)
+		}
+		return strings.TrimSuffix(string(line), "\n")
+	}
+	// Iteration 1 was retried, so it holds two pi sessions; only the last
+	// one's text counts.
+	log := strings.Join([]string{
+		marker(events.Event{Type: events.EventIteration, ID: "iteration-1", Timestamp: "2026-03-02T10:00:00Z"}),
+		`{"type":"session","id":"s1"}`,
+		`{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"crashed"}}`,
+		`{"type":"session","id":"s2"}`,
+		`{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"retried"}}`,
+		marker(events.Event{Type: events.EventIteration, ID: "iteration-2"}),
+		`{"type":"session","id":"s3"}`,
+	}, "\n")
+
+	var iterations []events.Event
+	var got []string
+	texts, err := Replay("pi", strings.NewReader(log), func(ev events.Event) {
+		if ev.Type == events.EventIteration {
+			iterations = append(iterations, ev)
+		}
+		got = append(got, string(ev.Type))
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	assertSequence(t, got, []string{
+		"iteration", "session", "message_update", "session", "message_update",
+		"iteration", "session",
+	})
+	if len(iterations) != 2 || iterations[0].Timestamp != "2026-03-02T10:00:00Z" {
+		t.Errorf("iterations = %+v, want the marker events verbatim", iterations)
+	}
+	if len(texts) != 2 || texts[0] != "retried" || texts[1] != "" {
+		t.Errorf("texts = %q, want [retried \"\"]", texts)
+	}
+}
+
+func TestReplay_RunnerMarkersBetweenSessions(t *testing.T) {
+	marker := func(ev events.Event) string {
+		line, err := EncodeMarker(ev)
+		if err != nil {
+			t.Fatalf("EncodeMarker: %v", err)
+		}
+		return strings.TrimSuffix(string(line), "\n")
+	}
+	log := strings.Join([]string{
+		marker(events.Event{Type: events.EventIteration, ID: "iteration-1"}),
+		`{"type":"session","id":"s1"}`,
+		`{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"stalled"}}`,
+		marker(events.Event{Type: events.EventInactivityTimeout, ID: "timeout-1"}),
+		marker(events.Event{Type: events.EventIteration, ID: "iteration-1"}),
+		`{"type":"session","id":"s2"}`,
+	}, "\n")
+
+	got, texts := replayLog(t, "pi", log)
+	assertSequence(t, got, []string{
+		"iteration-1", "session", "message_update", "inactivity_timeout",
+		"iteration-1", "session",
+	})
+	if len(texts) != 2 || texts[0] != "stalled" {
+		t.Errorf("texts = %q, want the timed-out session's text kept on its attempt", texts)
+	}
+}
@@ -116,6 +116,23 @@ func TestIsValid_ConsistentWithResolve(t *testing.T) {
 	}
 }
 
+func TestExecutable(t *testing.T) {
+	tests := map[string]string{
+		"pi":      "pi",
+		"kiro":    "kiro-cli",
+		"claude":  "claude",
+		"unknown": "",
+	}
+	for name, want := range tests {
+		if got := Executable(name); got != want {
+			t.Errorf("Executable(%q) = %q, want %q", name, got, want)
+		}
+		if (Executable(name) != "") != IsValid(name) {
+			t.Errorf("Executable(%q) and IsValid disagree", name)
+		}
+	}
+}
+
 func TestWithLogWriter(t *testing.T) {
 	var buf bytes.Buffer
 	opts := applyOptions([]Option{WithLogWriter(&buf)})
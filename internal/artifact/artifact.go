@@ -0,0 +1,309 @@
+// Package artifact reads and writes run artifact files that may have been
+// compressed after the run finished or split into rotated segments while it
+// was running. Readers pass the plain artifact path (e.g.
+// "<run>/events.jsonl") and get the whole content back regardless of how it
+// is stored on disk.
+package artifact
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is a compression format for run artifacts.
+type Format string
+
+const (
+	Gzip Format = "gzip"
+	Zstd Format = "zstd"
+)
+
+// Formats lists the supported compression formats.
+var Formats = []Format{Gzip, Zstd}
+
+// Ext returns the suffix appended to the name of an artifact compressed
+// with f.
+func (f Format) Ext() string {
+	if f == Zstd {
+		return ".zst"
+	}
+	return ".gz"
+}
+
+// FormatOf reports the format name was compressed with, judging by its
+// suffix; ok is false for plain files.
+func FormatOf(name string) (f Format, ok bool) {
+	for _, f := range Formats {
+		if strings.HasSuffix(name, f.Ext()) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// Compressible lists the run artifacts that grow with the transcript. Only
+// these are compressed; small files such as meta.json and the memory files
+// stay plain so other tools can keep reading them.
+var Compressible = []string{"events.jsonl", "raw-output.log"}
+
+// Resolve returns the file that holds the artifact at path: path itself if
+// it exists, otherwise its compressed form. The error for a missing
+// artifact is the one from stat'ing path, so os.IsNotExist works on it.
+func Resolve(path string) (string, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return path, nil
+	}
+	for _, f := range Formats {
+		if _, zErr := os.Stat(path + f.Ext()); zErr == nil {
+			return path + f.Ext(), nil
+		}
+	}
+	return "", err
+}
+
+// Segments returns the files that make up the artifact at path, in reading
+// order: rotated segments (path.1, path.2, …) oldest first, then the
+// current file. Any of them may be compressed.
+func Segments(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type segment struct {
+		n    int
+		name string
+	}
+	var rotated []segment
+	for _, e := range entries {
+		if n, ok := segmentNumber(base, e.Name()); ok {
+			rotated = append(rotated, segment{n, e.Name()})
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].n < rotated[j].n })
+
+	files := make([]string, 0, len(rotated)+1)
+	for _, s := range rotated {
+		files = append(files, filepath.Join(dir, s.name))
+	}
+	if current, err := Resolve(path); err == nil {
+		files = append(files, current)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return files, nil
+}
+
+// segmentNumber parses name as rotated segment "<base>.<n>", optionally
+// followed by a compression suffix ("<base>.<n>.gz").
+func segmentNumber(base, name string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, base+".")
+	if !ok {
+		return 0, false
+	}
+	if f, ok := FormatOf(rest); ok {
+		rest = strings.TrimSuffix(rest, f.Ext())
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Open opens the artifact at path for reading, concatenating its rotated
+// segments and decompressing them as needed.
+func Open(path string) (io.ReadCloser, error) {
+	files, err := Segments(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &multiReader{}
+	for _, name := range files {
+		rc, err := openFile(name)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.readers = append(r.readers, rc)
+	}
+	return r, nil
+}
+
+// ReadFile reads the whole artifact at path, like os.ReadFile.
+func ReadFile(path string) ([]byte, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// openFile opens a single file, decompressing it if its name says so.
+func openFile(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	format, ok := FormatOf(name)
+	if !ok {
+		return f, nil
+	}
+	var dr io.ReadCloser
+	switch format {
+	case Zstd:
+		var zr *zstd.Decoder
+		if zr, err = zstd.NewReader(f); err == nil {
+			dr = zr.IOReadCloser()
+		}
+	default:
+		dr, err = gzip.NewReader(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return &compressedFile{ReadCloser: dr, f: f}, nil
+}
+
+// compressedFile closes both the decompressor and the file under it.
+type compressedFile struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (c *compressedFile) Close() error {
+	return errors.Join(c.ReadCloser.Close(), c.f.Close())
+}
+
+// multiReader reads its readers in sequence and closes all of them.
+type multiReader struct {
+	readers []io.ReadCloser
+	current int
+}
+
+func (m *multiReader) Read(p []byte) (int, error) {
+	for m.current < len(m.readers) {
+		n, err := m.readers[m.current].Read(p)
+		if err == io.EOF {
+			m.current++
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+func (m *multiReader) Close() error {
+	var errs []error
+	for _, r := range m.readers {
+		errs = append(errs, r.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// CompressFile compresses path into path plus format's suffix and removes
+// path, returning the sizes before and after. The original is only removed
+// once the compressed copy has been written completely.
+func CompressFile(path string, format Format) (before, after int64, err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	dst := path + format.Ext()
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return 0, 0, err
+	}
+	zw, err := newCompressor(out, format)
+	if err == nil {
+		_, err = io.Copy(zw, in)
+		err = errors.Join(err, zw.Close())
+	}
+	err = errors.Join(err, out.Close())
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, 0, fmt.Errorf("compressing %s: %w", filepath.Base(path), err)
+	}
+	in.Close()
+	if err := os.Remove(path); err != nil {
+		return 0, 0, err
+	}
+
+	zinfo, err := os.Stat(dst)
+	if err != nil {
+		return 0, 0, err
+	}
+	return info.Size(), zinfo.Size(), nil
+}
+
+func newCompressor(w io.Writer, format Format) (io.WriteCloser, error) {
+	switch format {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown compression format %q", format)
+	}
+}
+
+// CompressRun compresses every plain Compressible artifact in the run
+// directory dir with format, rotated segments included, and returns the
+// total sizes before and after. Artifacts that are missing or already
+// compressed (in any format) are skipped, so it is safe to call repeatedly.
+func CompressRun(dir string, format Format) (before, after int64, err error) {
+	for _, name := range Compressible {
+		files, err := Segments(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return before, after, err
+		}
+		for _, f := range files {
+			if _, ok := FormatOf(f); ok {
+				continue
+			}
+			b, a, err := CompressFile(f, format)
+			if err != nil {
+				return before, after, err
+			}
+			before += b
+			after += a
+		}
+	}
+	return before, after, nil
+}
@@ -0,0 +1,138 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", path, err)
+	}
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", path, err)
+	}
+	return string(data)
+}
+
+func TestCompressFileRoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "events.jsonl")
+			content := strings.Repeat(`{"type":"message_update"}`+"\n", 200)
+			writeFile(t, path, content)
+
+			before, after, err := CompressFile(path, format)
+			if err != nil {
+				t.Fatalf("CompressFile: %v", err)
+			}
+			if before != int64(len(content)) || after <= 0 || after >= before {
+				t.Errorf("sizes = %d -> %d, want compression of %d bytes", before, after, len(content))
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("plain file still present: %v", err)
+			}
+			if resolved, err := Resolve(path); err != nil || resolved != path+format.Ext() {
+				t.Errorf("Resolve = %q, %v; want compressed file", resolved, err)
+			}
+			if got := readString(t, path); got != content {
+				t.Errorf("ReadFile after compression returned %d bytes, want %d", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]Format{
+		"events.jsonl.gz":      Gzip,
+		"raw-output.log.3.zst": Zstd,
+		"events.jsonl":         "",
+		"raw-output.log.2":     "",
+	}
+	for name, want := range tests {
+		got, ok := FormatOf(name)
+		if got != want || ok != (want != "") {
+			t.Errorf("FormatOf(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+}
+
+func TestOpenConcatenatesSegments(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "raw-output.log")
+	writeFile(t, path+".10", "third\n")
+	writeFile(t, path+".2", "sec")
+	writeFile(t, path, "current\n")
+	writeFile(t, path+".bak", "not a segment\n")
+	writeFile(t, path+".1", "first\n")
+	if _, _, err := CompressFile(path+".2", Gzip); err != nil {
+		t.Fatalf("CompressFile: %v", err)
+	}
+	if _, _, err := CompressFile(path+".10", Zstd); err != nil {
+		t.Fatalf("CompressFile: %v", err)
+	}
+	writeFile(t, path+".2.extra", "not a segment\n")
+
+	// Segments are ordered numerically and split lines are rejoined.
+	if got := readString(t, path); got != "first\nsec"+"third\ncurrent\n" {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestOpenMissingArtifact(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Open(filepath.Join(dir, "events.jsonl"))
+	if !os.IsNotExist(err) {
+		t.Errorf("Open(missing) error = %v, want not-exist", err)
+	}
+	if _, err := Resolve(filepath.Join(dir, "events.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Resolve(missing) error = %v, want not-exist", err)
+	}
+	if _, err := Open(filepath.Join(dir, "gone", "events.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Open(missing dir) error = %v, want not-exist", err)
+	}
+}
+
+func TestCompressRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "events.jsonl"), strings.Repeat("event\n", 100))
+	writeFile(t, filepath.Join(dir, "raw-output.log"), strings.Repeat("raw\n", 100))
+	writeFile(t, filepath.Join(dir, "raw-output.log.1"), strings.Repeat("old\n", 100))
+	writeFile(t, filepath.Join(dir, "meta.json"), "{}")
+
+	before, after, err := CompressRun(dir, Gzip)
+	if err != nil {
+		t.Fatalf("CompressRun: %v", err)
+	}
+	if before != 600+400+400 || after == 0 {
+		t.Errorf("sizes = %d -> %d", before, after)
+	}
+	for _, name := range []string{"events.jsonl.gz", "raw-output.log.gz", "raw-output.log.1.gz", "meta.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing after CompressRun: %v", name, err)
+		}
+	}
+
+	// A second pass has nothing left to do.
+	if before, after, err := CompressRun(dir, Zstd); err != nil || before != 0 || after != 0 {
+		t.Errorf("second CompressRun = %d, %d, %v; want no-op", before, after, err)
+	}
+	if got := readString(t, filepath.Join(dir, "raw-output.log")); got != strings.Repeat("old\n", 100)+strings.Repeat("raw\n", 100) {
+		t.Errorf("raw log content changed after compression")
+	}
+}
+
+func TestCompressRunSkipsMissingArtifacts(t *testing.T) {
+	if _, _, err := CompressRun(t.TempDir(), Gzip); err != nil {
+		t.Errorf("CompressRun(empty dir) = %v", err)
+	}
+}
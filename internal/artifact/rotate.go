@@ -0,0 +1,131 @@
+package artifact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// RotatingWriter appends to a log file and, once the file has reached
+// maxSize bytes, moves it aside to the next numbered segment (path.1,
+// path.2, …) and starts a new one. Segments are cut at write boundaries, not
+// line boundaries; Open concatenates them, so readers still see the whole
+// stream. When maxFiles is positive, only that many rotated segments are
+// kept and older ones are deleted.
+//
+// A RotatingWriter is safe for concurrent use.
+type RotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	f      *os.File
+	size   int64
+	next   int // number of the next rotated segment
+	pruned int // rotated segments deleted so far
+}
+
+// CreateRotating creates or truncates path, like os.Create, and returns a
+// writer that rotates it every maxSize bytes. maxSize must be positive.
+func CreateRotating(path string, maxSize int64, maxFiles int) (*RotatingWriter, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("rotation size must be positive, got %d", maxSize)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RotatingWriter{path: path, maxSize: maxSize, maxFiles: maxFiles, f: f, next: 1}, nil
+}
+
+// Write appends p to the current file, rotating first if the file is
+// already full. A single write is never split across segments.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+func (w *RotatingWriter) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("rotating %s: %w", filepath.Base(w.path), err)
+	}
+	w.f = nil
+	if err := os.Rename(w.path, fmt.Sprintf("%s.%d", w.path, w.next)); err != nil {
+		return fmt.Errorf("rotating %s: %w", filepath.Base(w.path), err)
+	}
+	w.next++
+	w.prune()
+
+	f, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("rotating %s: %w", filepath.Base(w.path), err)
+	}
+	w.f = f
+	w.size = 0
+	return nil
+}
+
+// prune deletes the oldest rotated segments beyond maxFiles. Failures are
+// ignored; a leftover segment only costs disk space.
+func (w *RotatingWriter) prune() {
+	if w.maxFiles <= 0 {
+		return
+	}
+	dir, base := filepath.Split(w.path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var numbers []int
+	for _, e := range entries {
+		if n, ok := segmentNumber(base, e.Name()); ok {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	for len(numbers) > w.maxFiles {
+		if os.Remove(fmt.Sprintf("%s.%d", w.path, numbers[0])) == nil {
+			w.pruned++
+		}
+		numbers = numbers[1:]
+	}
+}
+
+// Pruned returns how many rotated segments have been deleted because of
+// maxFiles. Once it is non-zero the file no longer holds the whole stream.
+func (w *RotatingWriter) Pruned() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pruned
+}
@@ -0,0 +1,80 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriterRotatesAtSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "raw-output.log")
+	w, err := CreateRotating(path, 10, 0)
+	if err != nil {
+		t.Fatalf("CreateRotating: %v", err)
+	}
+	for _, chunk := range []string{"aaaa\n", "bbbb\n", "cccc\n", "a much longer line\n", "d\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, err := Segments(path)
+	if err != nil {
+		t.Fatalf("Segments: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	// Writes are never split, so an oversized write gets a segment of its own.
+	want := "raw-output.log.1,raw-output.log.2,raw-output.log.3,raw-output.log"
+	if strings.Join(names, ",") != want {
+		t.Errorf("segments = %v, want %s", names, want)
+	}
+	if got := readString(t, path); got != "aaaa\nbbbb\ncccc\na much longer line\nd\n" {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestRotatingWriterKeepsMaxFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "raw-output.log")
+	w, err := CreateRotating(path, 4, 2)
+	if err != nil {
+		t.Fatalf("CreateRotating: %v", err)
+	}
+	for _, chunk := range []string{"one\n", "two\n", "thr\n", "fou\n", "fiv\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	w.Close()
+
+	if got := readString(t, path); got != "thr\nfou\nfiv\n" {
+		t.Errorf("content = %q, want the two newest segments plus the current file", got)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("oldest segment not pruned: %v", err)
+	}
+	if n := w.Pruned(); n != 2 {
+		t.Errorf("Pruned() = %d, want 2", n)
+	}
+}
+
+func TestRotatingWriterErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "raw-output.log")
+	if _, err := CreateRotating(path, 0, 0); err == nil {
+		t.Error("CreateRotating with zero size succeeded")
+	}
+	w, err := CreateRotating(path, 10, 0)
+	if err != nil {
+		t.Fatalf("CreateRotating: %v", err)
+	}
+	w.Close()
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
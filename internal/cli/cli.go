@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
 )
 
 // Config holds the parsed CLI configuration.
@@ -23,13 +27,75 @@ type Config struct {
 	InactivityTimeout *time.Duration // nil = not provided on CLI; 0 = disabled; >0 = custom
 	NoTUI             bool           // disable TUI / browser TUI when viewing runs
 	RunsDir           string         // directory for run storage
+	RunName           string         // --name: human-readable run name
+	RunTags           []string       // --tag: run labels, repeatable and comma-separated
+	Profile           string         // --profile: config profile applied over the file defaults
+	Template          string         // --template: library template used instead of the plan/default one
 
 	// Subcommand
-	ViewRunID   string // non-empty means "view <run-id>" replay mode
-	ViewList    bool   // true means "view" without a run-id
-	ShowVersion bool   // true means --version was requested
+	Command     Command // non-empty for standalone subcommands such as "stats"
+	ViewRunID   string  // non-empty means "view <run-id>" replay mode
+	ViewList    bool    // true means "view" without a run-id
+	ShowVersion bool    // true means --version was requested
+
+	// stats
+	StatsJSON bool // emit machine-readable JSON instead of a table
+
+	// export
+	ExportRunID  string // run-id (or prefix) to export
+	ExportFormat string // output format: "html", "markdown" or "json"
+	ExportOutput string // output path; "" = <run-id>.<ext>, "-" = stdout
+
+	// import
+	ImportLog   string // path to the agent log; "-" = stdin
+	ImportRunID string // run ID for the new run; "" = generated
+
+	// reprocess
+	ReprocessRunID string // run-id (or prefix) whose events.jsonl to rebuild
+
+	// gc
+	GCKeepLast  int           // never delete the N newest runs
+	GCOlderThan time.Duration // only delete runs older than this; 0 = any age
+	GCStatuses  []string      // only delete runs with these statuses; empty = any
+	GCCompress  bool          // compress the artifacts of the finished runs that are kept
+	GCDryRun    bool          // report what would be done without changing anything
+
+	// diff
+	DiffRunA string // run-id (or prefix) shown on the left
+	DiffRunB string // run-id (or prefix) shown on the right
+
+	// rerun; InactivityTimeout, RunName, RunTags and NoTUI apply too, with
+	// nil tags inheriting the parent's
+	RerunRunID         string // run-id (or prefix) whose recorded settings to replay
+	RerunAgent         string // --agent override; "" = the parent's agent
+	RerunMaxIterations *int   // --max-iterations override; nil = the parent's limit
+
+	// config; show also takes Agent, MaxIterations, InactivityTimeout,
+	// RunsDir and NoTUI to report them as flag-provided, and both take Profile
+	ConfigAction string // "show" or "validate"
+
+	// templates
+	TemplatesAction string // "list" or "show"
+	TemplateName    string // template to print for "show"
 }
 
+// Command identifies a standalone subcommand. The "view" subcommand predates
+// it and is still resolved through ResolveViewMode.
+type Command string
+
+const (
+	CommandNone      Command = ""
+	CommandStats     Command = "stats"
+	CommandExport    Command = "export"
+	CommandImport    Command = "import"
+	CommandReprocess Command = "reprocess"
+	CommandGC        Command = "gc"
+	CommandDiff      Command = "diff"
+	CommandRerun     Command = "rerun"
+	CommandConfig    Command = "config"
+	CommandTemplates Command = "templates"
+)
+
 // ViewMode is the resolved execution mode for the "view" subcommand.
 type ViewMode string
 
@@ -42,6 +108,17 @@ const (
 
 const usage = `Usage: ralfinho [flags] [PROMPT_FILE]
        ralfinho view [--runs-dir <path>] [--no-tui] [<run-id>]
+       ralfinho stats [--runs-dir <path>] [--json]
+       ralfinho export <run-id> [--format html|markdown|json] [-o <file>] [--runs-dir <path>]
+       ralfinho import <log-file> --agent pi|kiro|claude [--run-id <id>] [--runs-dir <path>]
+       ralfinho reprocess <run-id> [--runs-dir <path>]
+       ralfinho diff <run-a> <run-b> [--runs-dir <path>] [--no-tui]
+       ralfinho rerun <run-id> [-a <agent>] [-m <n>] [--inactivity-timeout <d>] [--name <name>] [--tag <tag>] [--no-tui] [--runs-dir <path>]
+       ralfinho config show [--profile <name>] [run flags]
+       ralfinho config validate [--profile <name>]
+       ralfinho templates list
+       ralfinho templates show <name>
+       ralfinho gc [--keep-last <n>] [--older-than <age>] [--status <s>] [--compress] [--dry-run] [--runs-dir <path>]
 
 An autonomous coding agent runner.
 
@@ -54,6 +131,12 @@ Flags:
                           watchdog fires (e.g. "10m", "1h"). Pass "0" to disable the
                           watchdog entirely — useful when an agent step is expected
                           to be slow. Omit the flag to use the default (5m).
+  --name <name>           Name the run, e.g. "auth-refactor"
+  --tag <tag>             Tag the run; repeat or comma-separate for several
+                          (e.g. --tag nightly,infra)
+  --profile <name>        Use the [profiles.<name>] settings from the config file
+  --template <name>       Render the prompt with a template from the template
+                          library instead of the plan/default template
   --no-tui                Disable TUI, use plain stderr output
   --runs-dir <path>       Runs directory (default: ".ralfinho/runs")
   -v, --version           Show version
@@ -63,16 +146,70 @@ Subcommands:
   view                    Open the session browser TUI (interactive terminals)
                           or list saved runs (non-TTY / --no-tui)
   view <run-id>           Replay a specific run (supports prefix matching)
+                          Anywhere a run-id is accepted, @<label> names the
+                          only run with that name or tag and @<label>-latest
+                          the newest one (e.g. "view @nightly-latest")
+  stats                   Summarize saved runs: success rate, iterations, tool
+                          calls, errors and token usage by agent, prompt source
+                          and week. --json prints machine-readable output.
+  export <run-id>         Export a run to <run-id>.<ext>, or to -o <file> ("-" for
+                          stdout). --format html (default) writes a self-contained
+                          report, markdown a transcript for PRs, json a
+                          schema-versioned document merging meta, events and
+                          the operator log
+  import <log-file>       Build a run from a raw agent log (the format of
+                          raw-output.log; "-" reads stdin) so it can be viewed,
+                          browsed and exported. --agent names the log's format
+  reprocess <run-id>      Rebuild events.jsonl from raw-output.log with the
+                          current event mapper, keeping the old file as a backup
+  diff <run-a> <run-b>    Compare two runs side by side: meta, iterations,
+                          tool-call sequences, effective prompts and the final
+                          NOTES.md/PROGRESS.md. Prints a text diff when not on a
+                          terminal or with --no-tui
+  rerun <run-id>          Start a new run with the exact settings a saved run
+                          recorded: agent, extra args, limits, prompt and the
+                          persistent reminders active at its end. --agent,
+                          --max-iterations, --inactivity-timeout, --name and
+                          --tag override them. The new run records the old
+                          one as its parent
+  config show             Print the effective configuration and where each value
+                          comes from: global file, local file, env, profile, flag
+                          or built-in default. Accepts --profile and the run flags
+                          (-a, -m, --inactivity-timeout, --runs-dir, --no-tui)
+  config validate         Check the config files and RALFINHO_* variables for
+                          unknown keys, invalid durations and sizes, unknown
+                          agents, unreadable file: templates and template errors
+  templates list          List the template library: .ralfinho/templates and
+                          the templates directory next to the global config.
+                          Templates can include each other with
+                          {{template "<name>" .}}
+  templates show <name>   Print a library template
+  gc                      Delete old runs. --keep-last protects the newest N runs,
+                          --older-than (e.g. "72h", "30d", "2w") and --status
+                          (comma-separated, e.g. "failed,stuck") narrow what is
+                          deleted; all given criteria must match. --compress
+                          compresses the events and raw logs of finished runs
+                          that are kept (storage.compress format, default gzip).
+                          --dry-run only prints what would happen
 
 Session browser keybindings:
   j/k, arrows             Navigate sessions
   Enter, o                Open selected session in replay viewer
   r                       Resume: start a new run from saved prompt artifacts
+  R                       Rerun: start a new run with the session's exact settings
   x                       Delete selected session (with confirmation)
+  m                       Mark session for diff (up to two)
+  D                       Diff the two marked sessions, or the marked and selected
+  Space, V                Select a session / a range for bulk actions
+  X, E, #                 Delete, export or tag the selection; with nothing
+                          selected, every session the search/filters show
+  e, A                    Edit the selected session's tags / annotation
   Tab                     Switch focus between sessions and preview panes
   s                       Cycle sort mode (newest/oldest/run id/agent/status/prompt)
   /                       Search sessions by text
-  a/t/p/d                 Filter by agent/status/prompt source/date
+  a/t/p/d/T               Filter by agent/status/prompt source/date/tag
+  L                       Toggle the job tree: chains of resumed runs grouped
+                          with their total iterations, duration and status
   c                       Clear all filters and search
   g/G                     Jump to first/last session
   Ctrl+d/u, PgDn/PgUp    Half-page scroll
@@ -100,8 +237,29 @@ func (c Config) ResolveViewMode(interactive bool) ViewMode {
 // if the arguments are invalid. A nil error with showHelp=true
 // means the caller should exit 0.
 func Parse(args []string) (*Config, error) {
-	if len(args) > 0 && args[0] == "view" {
-		return parseView(args[1:])
+	if len(args) > 0 {
+		switch args[0] {
+		case "view":
+			return parseView(args[1:])
+		case "stats":
+			return parseStats(args[1:])
+		case "export":
+			return parseExport(args[1:])
+		case "import":
+			return parseImport(args[1:])
+		case "reprocess":
+			return parseReprocess(args[1:])
+		case "gc":
+			return parseGC(args[1:])
+		case "diff":
+			return parseDiff(args[1:])
+		case "rerun":
+			return parseRerun(args[1:])
+		case "config":
+			return parseConfig(args[1:])
+		case "templates":
+			return parseTemplates(args[1:])
+		}
 	}
 
 	fs := flag.NewFlagSet("ralfinho", flag.ContinueOnError)
@@ -117,6 +275,10 @@ func Parse(args []string) (*Config, error) {
 		inactivityFlag string
 		noTUI          bool
 		runsDir        string
+		name           string
+		tags           []string
+		profile        string
+		templateName   string
 		help           bool
 		helpShort      bool
 		version        bool
@@ -132,6 +294,10 @@ func Parse(args []string) (*Config, error) {
 	fs.StringVar(&inactivityFlag, "inactivity-timeout", "", "")
 	fs.BoolVar(&noTUI, "no-tui", false, "")
 	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.StringVar(&name, "name", "", "")
+	fs.Func("tag", "", tagFlag(&tags))
+	fs.StringVar(&profile, "profile", "", "")
+	fs.StringVar(&templateName, "template", "", "")
 	fs.BoolVar(&help, "help", false, "")
 	fs.BoolVar(&helpShort, "h", false, "")
 	fs.BoolVar(&version, "version", false, "")
@@ -167,26 +333,18 @@ func Parse(args []string) (*Config, error) {
 		raw = maxShort
 	}
 	if raw != "" {
-		n, err := strconv.Atoi(raw)
-		if err != nil || n < 0 {
-			return nil, fmt.Errorf("--max-iterations must be a non-negative integer, got %q", raw)
+		n, err := parseMaxIterations(raw)
+		if err != nil {
+			return nil, err
 		}
 		maxIterations = n
 	}
 
 	// Resolve inactivity timeout. Empty = flag omitted (caller falls back to
-	// config/default). Otherwise parse as a Go duration; "0" explicitly
-	// disables the watchdog.
-	var inactivityTimeout *time.Duration
-	if inactivityFlag != "" {
-		d, err := time.ParseDuration(inactivityFlag)
-		if err != nil {
-			return nil, fmt.Errorf("--inactivity-timeout %q: %w", inactivityFlag, err)
-		}
-		if d < 0 {
-			return nil, fmt.Errorf("--inactivity-timeout must be zero or positive, got %q", inactivityFlag)
-		}
-		inactivityTimeout = &d
+	// config/default).
+	inactivityTimeout, err := parseInactivityTimeout(inactivityFlag)
+	if err != nil {
+		return nil, err
 	}
 
 	// Conflict check.
@@ -212,6 +370,10 @@ func Parse(args []string) (*Config, error) {
 		InactivityTimeout: inactivityTimeout,
 		NoTUI:             noTUI,
 		RunsDir:           runsDir,
+		RunName:           strings.TrimSpace(name),
+		RunTags:           tags,
+		Profile:           strings.TrimSpace(profile),
+		Template:          strings.TrimSpace(templateName),
 	}
 
 	switch {
@@ -233,10 +395,55 @@ func Parse(args []string) (*Config, error) {
 			cfg.InputMode = "default"
 		}
 	}
+	if cfg.Template != "" && cfg.InputMode == "prompt" {
+		return nil, fmt.Errorf("--template cannot be used with a prompt file")
+	}
 
 	return cfg, nil
 }
 
+// tagFlag returns a flag.Func callback that appends each comma-separated
+// tag to tags, rejecting invalid ones and skipping duplicates.
+func tagFlag(tags *[]string) func(string) error {
+	return func(v string) error {
+		for _, tag := range strings.Split(v, ",") {
+			tag = strings.TrimSpace(tag)
+			if !runner.ValidTag(tag) {
+				return fmt.Errorf("invalid tag %q", tag)
+			}
+			if !slices.Contains(*tags, tag) {
+				*tags = append(*tags, tag)
+			}
+		}
+		return nil
+	}
+}
+
+func parseMaxIterations(raw string) (int, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("--max-iterations must be a non-negative integer, got %q", raw)
+	}
+	return n, nil
+}
+
+// parseInactivityTimeout parses --inactivity-timeout as a Go duration; "0"
+// explicitly disables the watchdog. An empty value means the flag was
+// omitted and yields nil.
+func parseInactivityTimeout(raw string) (*time.Duration, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return nil, fmt.Errorf("--inactivity-timeout %q: %w", raw, err)
+	}
+	if d < 0 {
+		return nil, fmt.Errorf("--inactivity-timeout must be zero or positive, got %q", raw)
+	}
+	return &d, nil
+}
+
 func parseView(args []string) (*Config, error) {
 	fs := flag.NewFlagSet("view", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
@@ -270,3 +477,423 @@ func parseView(args []string) (*Config, error) {
 		RunsDir:   runsDir,
 	}, nil
 }
+
+func parseStats(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		runsDir  string
+		jsonFlag bool
+	)
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.BoolVar(&jsonFlag, "json", false, "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid stats flags: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q for stats", fs.Arg(0))
+	}
+
+	return &Config{
+		Command:   CommandStats,
+		RunsDir:   runsDir,
+		StatsJSON: jsonFlag,
+	}, nil
+}
+
+func parseExport(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		runsDir string
+		format  string
+		output  string
+	)
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.StringVar(&format, "format", "html", "")
+	fs.StringVar(&output, "output", "", "")
+	fs.StringVar(&output, "o", "", "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export flags: %w", err)
+	}
+	switch len(positional) {
+	case 0:
+		return nil, errors.New("export requires a run-id")
+	case 1:
+	default:
+		return nil, fmt.Errorf("expected exactly one run-id, got %d", len(positional))
+	}
+
+	return &Config{
+		Command:      CommandExport,
+		RunsDir:      runsDir,
+		ExportRunID:  positional[0],
+		ExportFormat: format,
+		ExportOutput: output,
+	}, nil
+}
+
+func parseImport(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		runsDir    string
+		agentFlag  string
+		agentShort string
+		runID      string
+	)
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.StringVar(&agentFlag, "agent", "", "")
+	fs.StringVar(&agentShort, "a", "", "")
+	fs.StringVar(&runID, "run-id", "", "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid import flags: %w", err)
+	}
+	switch len(positional) {
+	case 0:
+		return nil, errors.New("import requires a log file")
+	case 1:
+	default:
+		return nil, fmt.Errorf("expected exactly one log file, got %d", len(positional))
+	}
+
+	agent := agentFlag
+	if agentShort != "" {
+		agent = agentShort
+	}
+	if agent == "" {
+		return nil, errors.New("import requires --agent (pi, kiro or claude)")
+	}
+
+	return &Config{
+		Command:     CommandImport,
+		RunsDir:     runsDir,
+		Agent:       agent,
+		ImportLog:   positional[0],
+		ImportRunID: runID,
+	}, nil
+}
+
+func parseReprocess(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("reprocess", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var runsDir string
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reprocess flags: %w", err)
+	}
+	switch len(positional) {
+	case 0:
+		return nil, errors.New("reprocess requires a run-id")
+	case 1:
+	default:
+		return nil, fmt.Errorf("expected exactly one run-id, got %d", len(positional))
+	}
+
+	return &Config{
+		Command:        CommandReprocess,
+		RunsDir:        runsDir,
+		ReprocessRunID: positional[0],
+	}, nil
+}
+
+func parseGC(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		runsDir   string
+		keepLast  int
+		olderThan string
+		statuses  []string
+		compress  bool
+		dryRun    bool
+	)
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.IntVar(&keepLast, "keep-last", 0, "")
+	fs.StringVar(&olderThan, "older-than", "", "")
+	fs.Func("status", "", func(v string) error {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				statuses = append(statuses, s)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&compress, "compress", false, "")
+	fs.BoolVar(&dryRun, "dry-run", false, "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid gc flags: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q for gc", fs.Arg(0))
+	}
+	if keepLast < 0 {
+		return nil, errors.New("--keep-last must not be negative")
+	}
+
+	var age time.Duration
+	if olderThan != "" {
+		var err error
+		age, err = parseAge(olderThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --older-than %q: %w", olderThan, err)
+		}
+	}
+	if keepLast == 0 && age == 0 && len(statuses) == 0 && !compress {
+		return nil, errors.New("gc needs a retention policy (--keep-last, --older-than, --status) or --compress")
+	}
+
+	return &Config{
+		Command:     CommandGC,
+		RunsDir:     runsDir,
+		GCKeepLast:  keepLast,
+		GCOlderThan: age,
+		GCStatuses:  statuses,
+		GCCompress:  compress,
+		GCDryRun:    dryRun,
+	}, nil
+}
+
+func parseDiff(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		runsDir string
+		noTUI   bool
+	)
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.BoolVar(&noTUI, "no-tui", false, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid diff flags: %w", err)
+	}
+	if len(positional) != 2 {
+		return nil, fmt.Errorf("diff requires exactly two run-ids, got %d", len(positional))
+	}
+
+	return &Config{
+		Command:  CommandDiff,
+		RunsDir:  runsDir,
+		NoTUI:    noTUI,
+		DiffRunA: positional[0],
+		DiffRunB: positional[1],
+	}, nil
+}
+
+func parseRerun(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("rerun", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		runsDir        string
+		agentFlag      string
+		agentShort     string
+		maxIter        string
+		maxShort       string
+		inactivityFlag string
+		name           string
+		tags           []string
+		noTUI          bool
+	)
+	fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+	fs.StringVar(&agentFlag, "agent", "", "")
+	fs.StringVar(&agentShort, "a", "", "")
+	fs.StringVar(&maxIter, "max-iterations", "", "")
+	fs.StringVar(&maxShort, "m", "", "")
+	fs.StringVar(&inactivityFlag, "inactivity-timeout", "", "")
+	fs.StringVar(&name, "name", "", "")
+	fs.Func("tag", "", tagFlag(&tags))
+	fs.BoolVar(&noTUI, "no-tui", false, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rerun flags: %w", err)
+	}
+	switch len(positional) {
+	case 0:
+		return nil, errors.New("rerun requires a run-id")
+	case 1:
+	default:
+		return nil, fmt.Errorf("expected exactly one run-id, got %d", len(positional))
+	}
+
+	cfg := &Config{
+		Command:    CommandRerun,
+		RunsDir:    runsDir,
+		NoTUI:      noTUI,
+		RerunRunID: positional[0],
+		RerunAgent: agentFlag,
+		RunName:    strings.TrimSpace(name),
+		RunTags:    tags,
+	}
+	if agentShort != "" {
+		cfg.RerunAgent = agentShort
+	}
+	if maxShort != "" {
+		maxIter = maxShort
+	}
+	if maxIter != "" {
+		n, err := parseMaxIterations(maxIter)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RerunMaxIterations = &n
+	}
+	if cfg.InactivityTimeout, err = parseInactivityTimeout(inactivityFlag); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func parseConfig(args []string) (*Config, error) {
+	if len(args) == 0 || (args[0] != "show" && args[0] != "validate") {
+		return nil, errors.New("config requires an action: show or validate")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("config "+action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		profile        string
+		agentFlag      string
+		agentShort     string
+		maxIter        string
+		maxShort       string
+		inactivityFlag string
+		runsDir        string
+		noTUI          bool
+	)
+	fs.StringVar(&profile, "profile", "", "")
+	if action == "show" {
+		fs.StringVar(&agentFlag, "agent", "", "")
+		fs.StringVar(&agentShort, "a", "", "")
+		fs.StringVar(&maxIter, "max-iterations", "", "")
+		fs.StringVar(&maxShort, "m", "", "")
+		fs.StringVar(&inactivityFlag, "inactivity-timeout", "", "")
+		fs.StringVar(&runsDir, "runs-dir", ".ralfinho/runs", "")
+		fs.BoolVar(&noTUI, "no-tui", false, "")
+	}
+
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s flags: %w", action, err)
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("unexpected argument %q to config %s", positional[0], action)
+	}
+
+	cfg := &Config{
+		Command:      CommandConfig,
+		ConfigAction: action,
+		Profile:      strings.TrimSpace(profile),
+		Agent:        "pi",
+		RunsDir:      runsDir,
+		NoTUI:        noTUI,
+	}
+	if agentFlag != "" {
+		cfg.Agent = agentFlag
+	}
+	if agentShort != "" {
+		cfg.Agent = agentShort
+	}
+	if maxShort != "" {
+		maxIter = maxShort
+	}
+	if maxIter != "" {
+		if cfg.MaxIterations, err = parseMaxIterations(maxIter); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.InactivityTimeout, err = parseInactivityTimeout(inactivityFlag); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseTemplates parses "templates list" and "templates show <name>".
+func parseTemplates(args []string) (*Config, error) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "show") {
+		return nil, errors.New("templates requires an action: list or show")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("templates "+action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid templates %s flags: %w", action, err)
+	}
+
+	cfg := &Config{Command: CommandTemplates, TemplatesAction: action}
+	switch {
+	case action == "show" && len(positional) != 1:
+		return nil, errors.New("templates show requires exactly one template name")
+	case action == "show":
+		cfg.TemplateName = positional[0]
+	case len(positional) > 0:
+		return nil, fmt.Errorf("unexpected argument %q to templates list", positional[0])
+	}
+	return cfg, nil
+}
+
+// parseAge parses a Go duration, additionally accepting whole days ("30d")
+// and weeks ("2w"), which are the natural units for run retention.
+func parseAge(s string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	var d time.Duration
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days or weeks %q", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return d, nil
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments (e.g. "export <run-id> --format html"), returning the positionals.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -229,6 +230,28 @@ func TestParseNoTUI(t *testing.T) {
 	}
 }
 
+func TestParseNameAndTags(t *testing.T) {
+	cfg, err := Parse([]string{"--name", " auth-refactor ", "--tag", "nightly,infra", "--tag", "nightly", "prompt.md"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RunName != "auth-refactor" {
+		t.Errorf("RunName = %q, want %q", cfg.RunName, "auth-refactor")
+	}
+	if got := strings.Join(cfg.RunTags, ","); got != "nightly,infra" {
+		t.Errorf("RunTags = %q, want nightly,infra", got)
+	}
+	if cfg.PromptFile != "prompt.md" {
+		t.Errorf("PromptFile = %q, want prompt.md", cfg.PromptFile)
+	}
+
+	for _, tag := range []string{"two words", "-nightly", "@nightly", "a#b", ""} {
+		if _, err := Parse([]string{"--tag", tag}); err == nil {
+			t.Errorf("Parse(--tag %q): expected error, got nil", tag)
+		}
+	}
+}
+
 func TestParseHelp(t *testing.T) {
 	for _, flag := range []string{"--help", "-h"} {
 		_, err := Parse([]string{flag})
@@ -505,3 +528,354 @@ func TestParseInactivityTimeout_Invalid(t *testing.T) {
 		t.Fatal("expected error for invalid duration string")
 	}
 }
+
+func TestParseStats(t *testing.T) {
+	cfg, err := Parse([]string{"stats"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandStats {
+		t.Errorf("Command = %q, want %q", cfg.Command, CommandStats)
+	}
+	if cfg.RunsDir != ".ralfinho/runs" {
+		t.Errorf("RunsDir = %q, want default", cfg.RunsDir)
+	}
+	if cfg.StatsJSON {
+		t.Error("StatsJSON = true, want false")
+	}
+	if mode := cfg.ResolveViewMode(true); mode != ViewModeNone {
+		t.Errorf("ResolveViewMode = %q, want none", mode)
+	}
+}
+
+func TestParseStatsFlags(t *testing.T) {
+	cfg, err := Parse([]string{"stats", "--runs-dir", "/tmp/runs", "--json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RunsDir != "/tmp/runs" {
+		t.Errorf("RunsDir = %q, want %q", cfg.RunsDir, "/tmp/runs")
+	}
+	if !cfg.StatsJSON {
+		t.Error("StatsJSON = false, want true")
+	}
+}
+
+func TestParseStatsRejectsArguments(t *testing.T) {
+	if _, err := Parse([]string{"stats", "run-1"}); err == nil || !strings.Contains(err.Error(), `unexpected argument "run-1"`) {
+		t.Fatalf("error = %v, want unexpected argument", err)
+	}
+	if _, err := Parse([]string{"stats", "--bogus"}); err == nil {
+		t.Fatal("expected error for invalid stats flag, got nil")
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	cfg, err := Parse([]string{"export", "abc-123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandExport {
+		t.Errorf("Command = %q, want %q", cfg.Command, CommandExport)
+	}
+	if cfg.ExportRunID != "abc-123" {
+		t.Errorf("ExportRunID = %q, want %q", cfg.ExportRunID, "abc-123")
+	}
+	if cfg.ExportFormat != "html" {
+		t.Errorf("ExportFormat = %q, want default html", cfg.ExportFormat)
+	}
+	if cfg.ExportOutput != "" {
+		t.Errorf("ExportOutput = %q, want empty", cfg.ExportOutput)
+	}
+}
+
+func TestParseExportFlagsAfterRunID(t *testing.T) {
+	cfg, err := Parse([]string{"export", "abc-123", "--format", "html", "-o", "out.html", "--runs-dir", "/tmp/runs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ExportRunID != "abc-123" || cfg.ExportOutput != "out.html" || cfg.RunsDir != "/tmp/runs" {
+		t.Errorf("cfg = %+v, want run-id, output and runs-dir parsed", cfg)
+	}
+
+	cfg, err = Parse([]string{"export", "--output", "-", "abc-123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ExportRunID != "abc-123" || cfg.ExportOutput != "-" {
+		t.Errorf("cfg = %+v, want flags before run-id parsed", cfg)
+	}
+}
+
+func TestParseExportRequiresOneRunID(t *testing.T) {
+	if _, err := Parse([]string{"export"}); err == nil {
+		t.Fatal("expected error without run-id, got nil")
+	}
+	if _, err := Parse([]string{"export", "a", "b"}); err == nil {
+		t.Fatal("expected error for multiple run-ids, got nil")
+	}
+	if _, err := Parse([]string{"export", "a", "--bogus"}); err == nil {
+		t.Fatal("expected error for invalid export flag, got nil")
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	cfg, err := Parse([]string{"import", "claude.jsonl", "--agent", "claude", "--run-id", "old-session", "--runs-dir", "/tmp/runs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandImport {
+		t.Errorf("Command = %q, want %q", cfg.Command, CommandImport)
+	}
+	if cfg.ImportLog != "claude.jsonl" || cfg.Agent != "claude" || cfg.ImportRunID != "old-session" || cfg.RunsDir != "/tmp/runs" {
+		t.Errorf("cfg = %+v, want log, agent, run-id and runs-dir parsed", cfg)
+	}
+
+	cfg, err = Parse([]string{"import", "-a", "kiro", "-"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ImportLog != "-" || cfg.Agent != "kiro" || cfg.RunsDir != ".ralfinho/runs" {
+		t.Errorf("cfg = %+v, want stdin log with kiro agent and default runs-dir", cfg)
+	}
+}
+
+func TestParseImportErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"import", "--agent", "pi"},
+		{"import", "a.log", "b.log", "--agent", "pi"},
+		{"import", "a.log"},
+		{"import", "a.log", "--agent", "pi", "--bogus"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseReprocess(t *testing.T) {
+	cfg, err := Parse([]string{"reprocess", "abc", "--runs-dir", "/tmp/runs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandReprocess || cfg.ReprocessRunID != "abc" || cfg.RunsDir != "/tmp/runs" {
+		t.Errorf("cfg = %+v, want reprocess of abc in /tmp/runs", cfg)
+	}
+
+	for _, args := range [][]string{
+		{"reprocess"},
+		{"reprocess", "a", "b"},
+		{"reprocess", "a", "--bogus"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseGC(t *testing.T) {
+	cfg, err := Parse([]string{"gc", "--keep-last", "5", "--older-than", "30d", "--status", "failed,stuck", "--status", "interrupted", "--compress", "--dry-run", "--runs-dir", "/tmp/runs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandGC || cfg.RunsDir != "/tmp/runs" {
+		t.Errorf("cfg = %+v, want gc in /tmp/runs", cfg)
+	}
+	if cfg.GCKeepLast != 5 || cfg.GCOlderThan != 30*24*time.Hour || !cfg.GCCompress || !cfg.GCDryRun {
+		t.Errorf("cfg = %+v, want keep-last 5, 30 days, compress, dry-run", cfg)
+	}
+	if got := strings.Join(cfg.GCStatuses, ","); got != "failed,stuck,interrupted" {
+		t.Errorf("GCStatuses = %q", got)
+	}
+
+	cfg, err = Parse([]string{"gc", "--compress"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GCKeepLast != 0 || cfg.GCOlderThan != 0 || cfg.GCStatuses != nil || cfg.RunsDir != ".ralfinho/runs" {
+		t.Errorf("cfg = %+v, want compress-only defaults", cfg)
+	}
+}
+
+func TestParseGCAges(t *testing.T) {
+	for arg, want := range map[string]time.Duration{
+		"90m": 90 * time.Minute,
+		"72h": 72 * time.Hour,
+		"3d":  3 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+	} {
+		cfg, err := Parse([]string{"gc", "--older-than", arg})
+		if err != nil {
+			t.Errorf("--older-than %s: %v", arg, err)
+			continue
+		}
+		if cfg.GCOlderThan != want {
+			t.Errorf("--older-than %s = %v, want %v", arg, cfg.GCOlderThan, want)
+		}
+	}
+}
+
+func TestParseGCErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"gc"},
+		{"gc", "--dry-run"},
+		{"gc", "--keep-last", "-1"},
+		{"gc", "--older-than", "soon"},
+		{"gc", "--older-than", "0d"},
+		{"gc", "--older-than", "-1h"},
+		{"gc", "--keep-last", "3", "extra"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseDiff(t *testing.T) {
+	cfg, err := Parse([]string{"diff", "abc", "--no-tui", "def", "--runs-dir", "/tmp/runs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandDiff || cfg.DiffRunA != "abc" || cfg.DiffRunB != "def" || !cfg.NoTUI || cfg.RunsDir != "/tmp/runs" {
+		t.Errorf("cfg = %+v, want diff of abc and def in /tmp/runs without TUI", cfg)
+	}
+
+	for _, args := range [][]string{
+		{"diff"},
+		{"diff", "a"},
+		{"diff", "a", "b", "c"},
+		{"diff", "a", "b", "--bogus"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseRerun(t *testing.T) {
+	cfg, err := Parse([]string{"rerun", "abc", "--runs-dir", "/tmp/runs", "--no-tui"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandRerun || cfg.RerunRunID != "abc" || cfg.RunsDir != "/tmp/runs" || !cfg.NoTUI {
+		t.Errorf("cfg = %+v, want rerun of abc in /tmp/runs without TUI", cfg)
+	}
+	if cfg.RerunAgent != "" || cfg.RerunMaxIterations != nil || cfg.InactivityTimeout != nil || cfg.RunName != "" || cfg.RunTags != nil {
+		t.Errorf("cfg = %+v, want no overrides", cfg)
+	}
+
+	cfg, err = Parse([]string{"rerun", "-a", "claude", "@nightly-latest", "-m", "0", "--inactivity-timeout", "0", "--name", "retry", "--tag", "a,b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RerunRunID != "@nightly-latest" || cfg.RerunAgent != "claude" || cfg.RunName != "retry" || strings.Join(cfg.RunTags, ",") != "a,b" {
+		t.Errorf("cfg = %+v, want overridden agent, name and tags", cfg)
+	}
+	if cfg.RerunMaxIterations == nil || *cfg.RerunMaxIterations != 0 {
+		t.Errorf("RerunMaxIterations = %v, want explicit 0", cfg.RerunMaxIterations)
+	}
+	if cfg.InactivityTimeout == nil || *cfg.InactivityTimeout != 0 {
+		t.Errorf("InactivityTimeout = %v, want explicit 0", cfg.InactivityTimeout)
+	}
+
+	for _, args := range [][]string{
+		{"rerun"},
+		{"rerun", "a", "b"},
+		{"rerun", "a", "-m", "-1"},
+		{"rerun", "a", "--inactivity-timeout", "soon"},
+		{"rerun", "a", "--tag", "-x"},
+		{"rerun", "a", "--bogus"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	cfg, err := Parse([]string{"--profile", "nightly", "prompt.md"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Profile != "nightly" || cfg.PromptFile != "prompt.md" {
+		t.Errorf("cfg = %+v, want profile nightly for prompt.md", cfg)
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	cfg, err := Parse([]string{"--template", "review", "--plan", "PLAN.md"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Template != "review" || cfg.InputMode != "plan" {
+		t.Errorf("cfg = %+v, want template review for the plan", cfg)
+	}
+
+	if _, err := Parse([]string{"--template", "review", "prompt.md"}); err == nil || !strings.Contains(err.Error(), "--template") {
+		t.Errorf("--template with a prompt file: err = %v, want a conflict", err)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := Parse([]string{"config", "show", "--profile", "nightly", "-a", "claude", "-m", "7", "--no-tui"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandConfig || cfg.ConfigAction != "show" || cfg.Profile != "nightly" {
+		t.Errorf("cfg = %+v, want config show with profile nightly", cfg)
+	}
+	if cfg.Agent != "claude" || cfg.MaxIterations != 7 || !cfg.NoTUI || cfg.RunsDir != ".ralfinho/runs" {
+		t.Errorf("cfg = %+v, want the run flags parsed", cfg)
+	}
+
+	cfg, err = Parse([]string{"config", "validate"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ConfigAction != "validate" || cfg.Profile != "" {
+		t.Errorf("cfg = %+v, want plain config validate", cfg)
+	}
+
+	for _, args := range [][]string{
+		{"config"},
+		{"config", "edit"},
+		{"config", "show", "extra"},
+		{"config", "show", "--inactivity-timeout", "soon"},
+		{"config", "validate", "-a", "claude"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseTemplates(t *testing.T) {
+	cfg, err := Parse([]string{"templates", "list"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Command != CommandTemplates || cfg.TemplatesAction != "list" {
+		t.Errorf("cfg = %+v, want templates list", cfg)
+	}
+
+	cfg, err = Parse([]string{"templates", "show", "rules/commit"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TemplatesAction != "show" || cfg.TemplateName != "rules/commit" {
+		t.Errorf("cfg = %+v, want templates show rules/commit", cfg)
+	}
+
+	for _, args := range [][]string{
+		{"templates"},
+		{"templates", "edit"},
+		{"templates", "list", "extra"},
+		{"templates", "show"},
+		{"templates", "show", "a", "b"},
+		{"templates", "show", "--all", "a"},
+	} {
+		if _, err := Parse(args); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", args)
+		}
+	}
+}
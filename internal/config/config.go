@@ -1,17 +1,20 @@
 // Package config handles loading and merging TOML configuration files
-// for ralfinho. Two files are loaded and merged:
+// for ralfinho. Two files are loaded and merged, followed by the environment:
 //
 //   - Global:  ~/.config/ralfinho/config.toml  (XDG / os.UserConfigDir)
 //   - Local:   .ralfinho/config.toml            (project-level)
+//   - Env:     RALFINHO_* variables             (see EnvVar)
 //
-// Local values take precedence over global values. Fields absent from a file
+// Later sources take precedence over earlier ones. Fields absent from a file
 // are left at their zero value and are therefore skippable during merge.
 package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,14 +34,65 @@ import (
 // directories internally so merged global/local configs can still resolve
 // file-based template references relative to the file that defined each field.
 type FileConfig struct {
-	Agent             string                 `toml:"agent"`
-	MaxIterations     *int                   `toml:"max-iterations"`
-	InactivityTimeout *string                `toml:"inactivity-timeout"`
-	RunsDir           string                 `toml:"runs-dir"`
-	NoTUI             *bool                  `toml:"no-tui"`
-	Agents            map[string]AgentConfig `toml:"agents"`
-	Templates         TemplatesConfig        `toml:"templates"`
-	Dir               string                 `toml:"-"`
+	Agent             string                   `toml:"agent"`
+	MaxIterations     *int                     `toml:"max-iterations"`
+	InactivityTimeout *string                  `toml:"inactivity-timeout"`
+	RunsDir           string                   `toml:"runs-dir"`
+	NoTUI             *bool                    `toml:"no-tui"`
+	Agents            map[string]AgentConfig   `toml:"agents"`
+	Templates         TemplatesConfig          `toml:"templates"`
+	Retry             RetryConfig              `toml:"retry"`
+	Storage           StorageConfig            `toml:"storage"`
+	Profiles          map[string]ProfileConfig `toml:"profiles"`
+	Dir               string                   `toml:"-"`
+}
+
+// RetryConfig holds the optional [retry] table controlling how failed
+// iterations are retried. Every field is optional; see ParseRetryPolicy for
+// defaults and validation.
+type RetryConfig struct {
+	MaxRetries *int           `toml:"max-retries"`
+	Backoff    *string        `toml:"backoff"`
+	MaxBackoff *string        `toml:"max-backoff"`
+	Retryable  []string       `toml:"retryable"`
+	Limits     map[string]int `toml:"limits"`
+}
+
+// RetryClasses lists the failure classes accepted in retry.retryable and
+// retry.limits.
+var RetryClasses = []string{"crash", "exit", "scanner", "network", "timeout"}
+
+// RetryPolicy is the parsed form of RetryConfig. Durations are parsed and
+// class names validated; a nil Retryable means "not configured".
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Retryable  []string
+	Limits     map[string]int
+}
+
+// StorageConfig holds the optional [storage] table controlling how run
+// artifacts are kept on disk. See ParseStoragePolicy for defaults.
+type StorageConfig struct {
+	Backend        *string `toml:"backend"`
+	Compress       *string `toml:"compress"`
+	RawLogMaxSize  *string `toml:"raw-log-max-size"`
+	RawLogMaxFiles *int    `toml:"raw-log-max-files"`
+}
+
+// StorageBackends lists the values accepted in storage.backend.
+var StorageBackends = []string{"fs", "sqlite"}
+
+// CompressionFormats lists the values accepted in storage.compress.
+var CompressionFormats = []string{"none", "gzip", "zstd"}
+
+// StoragePolicy is the parsed form of StorageConfig.
+type StoragePolicy struct {
+	Backend        string // "fs" or "sqlite"
+	Compress       string // "gzip" or "zstd"; "" = uncompressed
+	RawLogMaxSize  int64  // bytes; 0 = no rotation
+	RawLogMaxFiles int    // 0 = keep all rotated segments
 }
 
 // TemplatesConfig holds optional prompt template overrides loaded from TOML.
@@ -55,6 +109,16 @@ type TemplatesConfig struct {
 	defaultDir string
 }
 
+// setDir records dir as the origin of the template fields that are set.
+func (t *TemplatesConfig) setDir(dir string) {
+	if t.Plan != "" {
+		t.planDir = dir
+	}
+	if t.Default != "" {
+		t.defaultDir = dir
+	}
+}
+
 // ResolvedTemplates contains prompt template overrides after any file:
 // references have been resolved to their file contents.
 type ResolvedTemplates struct {
@@ -63,74 +127,87 @@ type ResolvedTemplates struct {
 }
 
 // AgentConfig holds per-agent settings that can be customised in the config
-// file. At the moment only ExtraArgs is supported, which appends additional
-// flags to the agent subprocess command line.
+// file: extra command-line flags and how the agent subprocess is started.
 type AgentConfig struct {
 	// ExtraArgs is appended verbatim to the agent subprocess command line
 	// after all built-in flags. Useful for passing flags that ralfinho does
 	// not expose directly (e.g. "--model" for claude).
 	ExtraArgs []string `toml:"extra-args"`
+
+	// Binary replaces the agent's default executable, e.g. to pin a
+	// specific build. Relative paths resolve against the working directory.
+	Binary string `toml:"binary"`
+
+	// Env sets extra variables for the agent subprocess, such as API keys,
+	// without exporting them to the shell.
+	Env map[string]string `toml:"env"`
+
+	// EnvPassthrough, when set, limits the inherited environment to these
+	// variables (path.Match patterns such as "ANTHROPIC_*"); Env still
+	// applies on top.
+	EnvPassthrough []string `toml:"env-passthrough"`
+
+	// Cwd is the agent's working directory. Relative paths resolve against
+	// the working directory.
+	Cwd string `toml:"cwd"`
 }
 
-// Load reads the global and local config files, merges them, and returns the
-// result. Local values take precedence over global ones.
+// Load reads the global and local config files and the RALFINHO_*
+// environment variables, merges them, and returns the result. Local values
+// take precedence over global ones and the environment over both.
 //
 // Missing files are silently skipped (not an error). A read or parse failure
 // is always returned as an error.
 func Load() (*FileConfig, error) {
-	globalDir, err := os.UserConfigDir()
-	if err != nil {
-		// Non-fatal: skip global config if we cannot locate the XDG dir.
-		globalDir = ""
-	}
-
-	var global *FileConfig
-	if globalDir != "" {
-		global, err = loadFile(filepath.Join(globalDir, "ralfinho", "config.toml"))
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	local, err := loadFile(filepath.Join(".ralfinho", "config.toml"))
+	layers, err := LoadLayers()
 	if err != nil {
 		return nil, err
 	}
-
-	return merge(global, local), nil
+	return MergeLayers(layers), nil
 }
 
 // loadFile reads and parses a single TOML config file at path.
 // Returns nil, nil when the file does not exist.
 func loadFile(path string) (*FileConfig, error) {
+	cfg, _, err := decodeFile(path)
+	return cfg, err
+}
+
+// decodeFile is loadFile that also returns the keys the file sets that
+// FileConfig does not recognise.
+func decodeFile(path string) (*FileConfig, []string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil
+			return nil, nil, nil
 		}
-		return nil, fmt.Errorf("reading config %s: %w", path, err)
+		return nil, nil, fmt.Errorf("reading config %s: %w", path, err)
 	}
 
 	var cfg FileConfig
-	if err := toml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", path, err)
 	}
 
 	cfg.Dir = filepath.Dir(path)
-	if cfg.Templates.Plan != "" {
-		cfg.Templates.planDir = cfg.Dir
-	}
-	if cfg.Templates.Default != "" {
-		cfg.Templates.defaultDir = cfg.Dir
+	cfg.Templates.setDir(cfg.Dir)
+	for name, profile := range cfg.Profiles {
+		profile.Templates.setDir(cfg.Dir)
+		cfg.Profiles[name] = profile
 	}
 
-	return &cfg, nil
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		unknown = append(unknown, key.String())
+	}
+	return &cfg, unknown, nil
 }
 
 // merge combines base and override into a single FileConfig. For scalar fields,
-// the override replaces the base only when it carries a non-zero value. For
-// per-agent configs, the override replaces the base entry for the same agent
-// name (no deep-merge of individual agent fields). Template fields merge
+// the override replaces the base only when it carries a non-zero value.
+// Per-agent configs merge field by field (see mergeAgent), so one file can set
+// an agent's binary and another its extra args. Template fields merge
 // independently so one file can override only templates.plan or
 // templates.default.
 //
@@ -177,17 +254,19 @@ func merge(base, override *FileConfig) *FileConfig {
 		result.Templates.Default = override.Templates.Default
 		result.Templates.defaultDir = override.Templates.defaultDir
 	}
+	result.Retry = mergeRetry(result.Retry, override.Retry)
+	result.Storage = mergeStorage(result.Storage, override.Storage)
+	result.Profiles = mergeProfiles(result.Profiles, override.Profiles)
 
-	// Merge per-agent configs: override wins per agent name (full replacement,
-	// not field-level merge within an agent). Build a new map to avoid aliasing
-	// the base map.
+	// Merge per-agent configs field by field. Build a new map to avoid
+	// aliasing the base map.
 	if len(override.Agents) > 0 {
 		merged := make(map[string]AgentConfig, len(result.Agents)+len(override.Agents))
 		for k, v := range result.Agents {
 			merged[k] = v
 		}
 		for k, v := range override.Agents {
-			merged[k] = v
+			merged[k] = mergeAgent(merged[k], v)
 		}
 		result.Agents = merged
 	} else if result.Agents != nil {
@@ -202,6 +281,83 @@ func merge(base, override *FileConfig) *FileConfig {
 	return &result
 }
 
+// mergeAgent merges two [agents.<name>] tables. A list set in override
+// replaces the base list rather than appending to it; env merges per
+// variable.
+func mergeAgent(base, override AgentConfig) AgentConfig {
+	result := base
+	if override.ExtraArgs != nil {
+		result.ExtraArgs = override.ExtraArgs
+	}
+	if override.Binary != "" {
+		result.Binary = override.Binary
+	}
+	if len(base.Env) > 0 || len(override.Env) > 0 {
+		env := make(map[string]string, len(base.Env)+len(override.Env))
+		for k, v := range base.Env {
+			env[k] = v
+		}
+		for k, v := range override.Env {
+			env[k] = v
+		}
+		result.Env = env
+	}
+	if override.EnvPassthrough != nil {
+		result.EnvPassthrough = override.EnvPassthrough
+	}
+	if override.Cwd != "" {
+		result.Cwd = override.Cwd
+	}
+	return result
+}
+
+// mergeRetry merges the [retry] tables field by field. Per-class limits merge
+// per key so a local file can tighten a single class.
+func mergeRetry(base, override RetryConfig) RetryConfig {
+	result := base
+	if override.MaxRetries != nil {
+		result.MaxRetries = override.MaxRetries
+	}
+	if override.Backoff != nil {
+		result.Backoff = override.Backoff
+	}
+	if override.MaxBackoff != nil {
+		result.MaxBackoff = override.MaxBackoff
+	}
+	if override.Retryable != nil {
+		result.Retryable = override.Retryable
+	}
+	if len(base.Limits) > 0 || len(override.Limits) > 0 {
+		merged := make(map[string]int, len(base.Limits)+len(override.Limits))
+		for k, v := range base.Limits {
+			merged[k] = v
+		}
+		for k, v := range override.Limits {
+			merged[k] = v
+		}
+		result.Limits = merged
+	}
+	return result
+}
+
+// mergeStorage merges the [storage] tables field by field.
+func mergeStorage(base, override StorageConfig) StorageConfig {
+	result := base
+	if override.Backend != nil {
+		result.Backend = override.Backend
+	}
+	if override.Compress != nil {
+		result.Compress = override.Compress
+	}
+	if override.RawLogMaxSize != nil {
+		result.RawLogMaxSize = override.RawLogMaxSize
+	}
+	if override.RawLogMaxFiles != nil {
+		result.RawLogMaxFiles = override.RawLogMaxFiles
+	}
+	return result
+}
+
 // ResolveTemplateValue resolves a config template value into template text.
 //
 // Values using the file: prefix are read from disk. Relative paths are
@@ -274,3 +430,145 @@ func ParseInactivityTimeout(cfg *FileConfig) (*time.Duration, error) {
 	}
 	return &d, nil
 }
+
+// ParseRetryPolicy parses the [retry] table of a merged FileConfig. Omitted
+// fields stay at their zero value, which the runner treats as "no retries for
+// agent errors, one retry for inactivity timeouts". Returns an error for
+// unparseable durations, negative counts, or unknown class names.
+func ParseRetryPolicy(cfg *FileConfig) (RetryPolicy, error) {
+	var policy RetryPolicy
+	if cfg == nil {
+		return policy, nil
+	}
+	rc := cfg.Retry
+
+	if rc.MaxRetries != nil {
+		if *rc.MaxRetries < 0 {
+			return RetryPolicy{}, fmt.Errorf("retry.max-retries must not be negative, got %d", *rc.MaxRetries)
+		}
+		policy.MaxRetries = *rc.MaxRetries
+	}
+	if rc.Backoff != nil {
+		d, err := time.ParseDuration(*rc.Backoff)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("parsing retry.backoff %q: %w", *rc.Backoff, err)
+		}
+		policy.Backoff = d
+	}
+	if rc.MaxBackoff != nil {
+		d, err := time.ParseDuration(*rc.MaxBackoff)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("parsing retry.max-backoff %q: %w", *rc.MaxBackoff, err)
+		}
+		policy.MaxBackoff = d
+	}
+	if rc.Retryable != nil {
+		policy.Retryable = make([]string, 0, len(rc.Retryable))
+		for _, class := range rc.Retryable {
+			if !isRetryClass(class) {
+				return RetryPolicy{}, fmt.Errorf("unknown retry class %q in retry.retryable (valid: %s)", class, strings.Join(RetryClasses, ", "))
+			}
+			policy.Retryable = append(policy.Retryable, class)
+		}
+	}
+	if len(rc.Limits) > 0 {
+		policy.Limits = make(map[string]int, len(rc.Limits))
+		for class, n := range rc.Limits {
+			if !isRetryClass(class) {
+				return RetryPolicy{}, fmt.Errorf("unknown retry class %q in retry.limits (valid: %s)", class, strings.Join(RetryClasses, ", "))
+			}
+			if n < 0 {
+				return RetryPolicy{}, fmt.Errorf("retry.limits.%s must not be negative, got %d", class, n)
+			}
+			policy.Limits[class] = n
+		}
+	}
+	return policy, nil
+}
+
+func isRetryClass(name string) bool {
+	for _, c := range RetryClasses {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStoragePolicy parses the [storage] table of a merged FileConfig.
+// Omitted fields keep artifacts uncompressed and raw-output.log unrotated.
+// Returns an error for unknown compression formats, unparseable sizes, or
+// negative counts.
+func ParseStoragePolicy(cfg *FileConfig) (StoragePolicy, error) {
+	policy := StoragePolicy{Backend: "fs"}
+	if cfg == nil {
+		return policy, nil
+	}
+	sc := cfg.Storage
+
+	if sc.Backend != nil {
+		switch *sc.Backend {
+		case "fs", "sqlite":
+			policy.Backend = *sc.Backend
+		default:
+			return StoragePolicy{}, fmt.Errorf("unknown storage.backend %q (valid: %s)", *sc.Backend, strings.Join(StorageBackends, ", "))
+		}
+	}
+
+	if sc.Compress != nil {
+		switch *sc.Compress {
+		case "none":
+		case "gzip", "zstd":
+			policy.Compress = *sc.Compress
+		default:
+			return StoragePolicy{}, fmt.Errorf("unknown storage.compress %q (valid: %s)", *sc.Compress, strings.Join(CompressionFormats, ", "))
+		}
+	}
+	if sc.RawLogMaxSize != nil {
+		n, err := ParseSize(*sc.RawLogMaxSize)
+		if err != nil {
+			return StoragePolicy{}, fmt.Errorf("parsing storage.raw-log-max-size: %w", err)
+		}
+		policy.RawLogMaxSize = n
+	}
+	if sc.RawLogMaxFiles != nil {
+		if *sc.RawLogMaxFiles < 0 {
+			return StoragePolicy{}, fmt.Errorf("storage.raw-log-max-files must not be negative, got %d", *sc.RawLogMaxFiles)
+		}
+		policy.RawLogMaxFiles = *sc.RawLogMaxFiles
+	}
+	return policy, nil
+}
+
+// sizeUnits maps ParseSize suffixes to multipliers. Units are binary:
+// "KB" and "KiB" both mean 1024 bytes.
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize parses a byte size such as "512", "64KB", "100MB" or "1GiB".
+// "0" is allowed and means "no limit" to callers.
+func ParseSize(s string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, u := range sizeUnits {
+		if rest, ok := strings.CutSuffix(text, u.suffix); ok {
+			text, factor = strings.TrimSpace(rest), u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. \"100MB\")", s)
+	}
+	if n > math.MaxInt64/factor {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * factor, nil
+}
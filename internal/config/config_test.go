@@ -1004,3 +1004,522 @@ func TestLoad_LocalFileOnly(t *testing.T) {
 		t.Errorf("MaxIterations: expected *3, got %v", cfg.MaxIterations)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// [retry] table
+// ---------------------------------------------------------------------------
+
+func intPtr(n int) *int { return &n }
+
+func TestLoadFile_RetryTable(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+[retry]
+max-retries = 3
+backoff = "10s"
+max-backoff = "2m"
+retryable = ["network", "exit"]
+
+[retry.limits]
+exit = 1
+timeout = 2
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+
+	cfg, err := loadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policy, err := ParseRetryPolicy(cfg)
+	if err != nil {
+		t.Fatalf("ParseRetryPolicy: %v", err)
+	}
+	if policy.MaxRetries != 3 {
+		t.Errorf("MaxRetries = %d, want 3", policy.MaxRetries)
+	}
+	if policy.Backoff != 10*time.Second || policy.MaxBackoff != 2*time.Minute {
+		t.Errorf("Backoff/MaxBackoff = %s/%s, want 10s/2m", policy.Backoff, policy.MaxBackoff)
+	}
+	if strings.Join(policy.Retryable, ",") != "network,exit" {
+		t.Errorf("Retryable = %v, want [network exit]", policy.Retryable)
+	}
+	if policy.Limits["exit"] != 1 || policy.Limits["timeout"] != 2 {
+		t.Errorf("Limits = %v, want exit=1 timeout=2", policy.Limits)
+	}
+}
+
+func TestParseRetryPolicy_Omitted(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []*FileConfig{nil, {}} {
+		policy, err := ParseRetryPolicy(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if policy.MaxRetries != 0 || policy.Backoff != 0 || policy.Retryable != nil || policy.Limits != nil {
+			t.Errorf("policy = %+v, want zero value", policy)
+		}
+	}
+}
+
+func TestParseRetryPolicy_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		retry   RetryConfig
+		wantErr string
+	}{
+		{"bad backoff", RetryConfig{Backoff: strPtr("soon")}, `parsing retry.backoff "soon"`},
+		{"bad max-backoff", RetryConfig{MaxBackoff: strPtr("1 hour")}, `parsing retry.max-backoff "1 hour"`},
+		{"negative max-retries", RetryConfig{MaxRetries: intPtr(-1)}, "retry.max-retries must not be negative"},
+		{"unknown retryable class", RetryConfig{Retryable: []string{"exit", "gremlins"}}, `unknown retry class "gremlins" in retry.retryable`},
+		{"unknown limit class", RetryConfig{Limits: map[string]int{"oom": 1}}, `unknown retry class "oom" in retry.limits`},
+		{"negative limit", RetryConfig{Limits: map[string]int{"crash": -2}}, "retry.limits.crash must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRetryPolicy(&FileConfig{Retry: tt.retry})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerge_RetryPerFieldAndPerClass(t *testing.T) {
+	t.Parallel()
+
+	base := &FileConfig{Retry: RetryConfig{
+		MaxRetries: intPtr(2),
+		Backoff:    strPtr("5s"),
+		Retryable:  []string{"exit"},
+		Limits:     map[string]int{"exit": 1, "timeout": 3},
+	}}
+	override := &FileConfig{Retry: RetryConfig{
+		Backoff: strPtr("30s"),
+		Limits:  map[string]int{"timeout": 0},
+	}}
+
+	got := merge(base, override).Retry
+	if got.MaxRetries == nil || *got.MaxRetries != 2 {
+		t.Errorf("MaxRetries = %v, want base value 2", got.MaxRetries)
+	}
+	if got.Backoff == nil || *got.Backoff != "30s" {
+		t.Errorf("Backoff = %v, want override 30s", got.Backoff)
+	}
+	if strings.Join(got.Retryable, ",") != "exit" {
+		t.Errorf("Retryable = %v, want base [exit]", got.Retryable)
+	}
+	if got.Limits["exit"] != 1 || got.Limits["timeout"] != 0 {
+		t.Errorf("Limits = %v, want exit=1 timeout=0", got.Limits)
+	}
+	if base.Retry.Limits["timeout"] != 3 {
+		t.Error("merge mutated base retry limits")
+	}
+}
+
+func TestLoadFile_StorageTable(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+[storage]
+backend = "sqlite"
+compress = "gzip"
+raw-log-max-size = "100MB"
+raw-log-max-files = 5
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+
+	cfg, err := loadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policy, err := ParseStoragePolicy(cfg)
+	if err != nil {
+		t.Fatalf("ParseStoragePolicy: %v", err)
+	}
+	if policy.Backend != "sqlite" || policy.Compress != "gzip" || policy.RawLogMaxSize != 100<<20 || policy.RawLogMaxFiles != 5 {
+		t.Errorf("policy = %+v, want sqlite, gzip, 100MiB, 5 files", policy)
+	}
+}
+
+func TestParseStoragePolicy_OmittedAndNone(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []*FileConfig{nil, {}, {Storage: StorageConfig{Compress: strPtr("none")}}} {
+		policy, err := ParseStoragePolicy(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if policy != (StoragePolicy{Backend: "fs"}) {
+			t.Errorf("policy = %+v, want fs backend and nothing else", policy)
+		}
+	}
+}
+
+func TestParseStoragePolicy_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		storage StorageConfig
+		wantErr string
+	}{
+		{"unknown backend", StorageConfig{Backend: strPtr("postgres")}, `unknown storage.backend "postgres" (valid: fs, sqlite)`},
+		{"unknown format", StorageConfig{Compress: strPtr("zip")}, `unknown storage.compress "zip" (valid: none, gzip, zstd)`},
+		{"bad size", StorageConfig{RawLogMaxSize: strPtr("big")}, `parsing storage.raw-log-max-size: invalid size "big"`},
+		{"negative files", StorageConfig{RawLogMaxFiles: intPtr(-1)}, "storage.raw-log-max-files must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStoragePolicy(&FileConfig{Storage: tt.storage})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]int64{
+		"0":      0,
+		"512":    512,
+		"512b":   512,
+		"64KB":   64 << 10,
+		"64 KiB": 64 << 10,
+		"100MB":  100 << 20,
+		"2m":     2 << 20,
+		"1GiB":   1 << 30,
+	} {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1MB", "1.5GB", "ten", "99999999999GB"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestMerge_StoragePerField(t *testing.T) {
+	t.Parallel()
+
+	base := &FileConfig{Storage: StorageConfig{Backend: strPtr("sqlite"), Compress: strPtr("gzip"), RawLogMaxSize: strPtr("10MB")}}
+	override := &FileConfig{Storage: StorageConfig{RawLogMaxSize: strPtr("1GB"), RawLogMaxFiles: intPtr(3)}}
+
+	got := merge(base, override).Storage
+	if got.Backend == nil || *got.Backend != "sqlite" || got.Compress == nil || *got.Compress != "gzip" {
+		t.Errorf("Storage = %+v, want base sqlite backend and gzip kept", got)
+	}
+	if got.RawLogMaxSize == nil || *got.RawLogMaxSize != "1GB" || got.RawLogMaxFiles == nil || *got.RawLogMaxFiles != 3 {
+		t.Errorf("Storage = %+v, want override size and file count", got)
+	}
+}
+
+func TestLoadFile_ProfilesTable(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+agent = "pi"
+
+[profiles.nightly]
+agent = "claude"
+max-iterations = 50
+inactivity-timeout = "30m"
+
+[profiles.nightly.templates]
+plan = "file:prompts/careful.md"
+
+[profiles.nightly.agents.claude]
+extra-args = ["--model", "opus"]
+
+[profiles.quick]
+max-iterations = 3
+no-tui = true
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+
+	cfg, err := loadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(ProfileNames(cfg), ","); got != "nightly,quick" {
+		t.Fatalf("ProfileNames = %q, want nightly,quick", got)
+	}
+	nightly := cfg.Profiles["nightly"]
+	if nightly.Agent != "claude" || *nightly.MaxIterations != 50 || *nightly.InactivityTimeout != "30m" {
+		t.Errorf("nightly = %+v", nightly)
+	}
+	if nightly.Templates.planDir != dir {
+		t.Errorf("nightly plan template dir = %q, want %q", nightly.Templates.planDir, dir)
+	}
+	if got := strings.Join(nightly.Agents["claude"].ExtraArgs, " "); got != "--model opus" {
+		t.Errorf("nightly claude extra-args = %q", got)
+	}
+	if quick := cfg.Profiles["quick"]; *quick.MaxIterations != 3 || quick.NoTUI == nil || !*quick.NoTUI {
+		t.Errorf("quick = %+v", quick)
+	}
+}
+
+func TestMerge_ProfilesPerField(t *testing.T) {
+	t.Parallel()
+
+	base := &FileConfig{Profiles: map[string]ProfileConfig{
+		"nightly": {Agent: "claude", MaxIterations: intPtr(50)},
+		"quick":   {MaxIterations: intPtr(3)},
+	}}
+	override := &FileConfig{Profiles: map[string]ProfileConfig{
+		"nightly": {MaxIterations: intPtr(20)},
+		"review":  {Agent: "kiro"},
+	}}
+
+	merged := merge(base, override)
+	if got := strings.Join(ProfileNames(merged), ","); got != "nightly,quick,review" {
+		t.Fatalf("ProfileNames = %q", got)
+	}
+	if nightly := merged.Profiles["nightly"]; nightly.Agent != "claude" || *nightly.MaxIterations != 20 {
+		t.Errorf("nightly = %+v, want the global agent and the local limit", nightly)
+	}
+	if *base.Profiles["nightly"].MaxIterations != 50 {
+		t.Error("merge mutated the base profile")
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	cfg := &FileConfig{
+		Agent:             "pi",
+		MaxIterations:     intPtr(10),
+		InactivityTimeout: strPtr("5m"),
+		RunsDir:           "/runs",
+		Agents:            map[string]AgentConfig{"pi": {ExtraArgs: []string{"--fast"}}},
+		Templates:         TemplatesConfig{Plan: "global plan", Default: "global default"},
+		Profiles: map[string]ProfileConfig{
+			"nightly": {
+				Agent:         "claude",
+				MaxIterations: intPtr(0),
+				Agents:        map[string]AgentConfig{"claude": {ExtraArgs: []string{"--model", "opus"}}},
+				Templates:     TemplatesConfig{Plan: "careful plan"},
+			},
+		},
+	}
+
+	got, err := ApplyProfile(cfg, "nightly")
+	if err != nil {
+		t.Fatalf("ApplyProfile error: %v", err)
+	}
+	if got.Agent != "claude" || *got.MaxIterations != 0 {
+		t.Errorf("agent/limit = %q/%d, want the profile's", got.Agent, *got.MaxIterations)
+	}
+	if *got.InactivityTimeout != "5m" || got.RunsDir != "/runs" {
+		t.Errorf("timeout/runs-dir = %q/%q, want the top-level values the profile leaves unset", *got.InactivityTimeout, got.RunsDir)
+	}
+	if got.Templates.Plan != "careful plan" || got.Templates.Default != "global default" {
+		t.Errorf("templates = %+v, want the profile's plan and the top-level default", got.Templates)
+	}
+	if len(got.Agents["pi"].ExtraArgs) != 1 || len(got.Agents["claude"].ExtraArgs) != 2 {
+		t.Errorf("agents = %+v, want both agents' extra-args", got.Agents)
+	}
+	if cfg.Agent != "pi" {
+		t.Error("ApplyProfile mutated the config")
+	}
+
+	if _, err := ApplyProfile(cfg, "weekly"); err == nil || !strings.Contains(err.Error(), `unknown profile "weekly" (defined: nightly)`) {
+		t.Errorf("unknown profile error = %v", err)
+	}
+	if _, err := ApplyProfile(nil, "nightly"); err == nil || !strings.Contains(err.Error(), "no profiles are defined") {
+		t.Errorf("nil config error = %v", err)
+	}
+}
+
+func TestLoadLayers_ReportsUnknownKeys(t *testing.T) {
+	// Cannot use t.Parallel() alongside t.Setenv.
+
+	tmpDir := t.TempDir()
+	globalCfgDir := filepath.Join(tmpDir, "xdg")
+	if err := os.MkdirAll(filepath.Join(globalCfgDir, "ralfinho"), 0755); err != nil {
+		t.Fatalf("mkdir global config dir: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", globalCfgDir)
+
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(filepath.Join(projectDir, ".ralfinho"), 0755); err != nil {
+		t.Fatalf("mkdir local config dir: %v", err)
+	}
+	localCfg := `
+agent = "claude"
+max-iteration = 3
+
+[retry]
+backof = "1s"
+
+[profiles.nightly]
+no-tiu = true
+`
+	if err := os.WriteFile(filepath.Join(projectDir, ".ralfinho", "config.toml"), []byte(localCfg), 0600); err != nil {
+		t.Fatalf("writing local config: %v", err)
+	}
+	t.Chdir(projectDir)
+
+	layers, err := LoadLayers()
+	if err != nil {
+		t.Fatalf("LoadLayers error: %v", err)
+	}
+	if len(layers) != 3 || layers[0].Source != "global" || layers[1].Source != "local" || layers[2].Source != "env" {
+		t.Fatalf("layers = %+v, want global, local then env", layers)
+	}
+	if layers[0].Config != nil {
+		t.Errorf("global layer config = %+v, want nil for a missing file", layers[0].Config)
+	}
+	local := layers[1]
+	if local.Config == nil || local.Config.Agent != "claude" {
+		t.Fatalf("local layer config = %+v", local.Config)
+	}
+	want := "max-iteration,retry.backof,profiles.nightly.no-tiu"
+	if got := strings.Join(local.Unknown, ","); got != want {
+		t.Errorf("Unknown = %q, want %q", got, want)
+	}
+	if got := MergeLayers(layers); got.Agent != "claude" {
+		t.Errorf("MergeLayers agent = %q, want claude", got.Agent)
+	}
+}
+
+func TestExplain_AttributesEachSetting(t *testing.T) {
+	t.Parallel()
+
+	layers := []Layer{
+		{Source: "global", Config: &FileConfig{
+			Agent:         "pi",
+			MaxIterations: intPtr(10),
+			Agents:        map[string]AgentConfig{"claude": {ExtraArgs: []string{"--model", "opus"}}},
+			Retry:         RetryConfig{Backoff: strPtr("1s"), Limits: map[string]int{"network": 5}},
+		}},
+		{Source: "local", Config: &FileConfig{
+			Agent:     "claude",
+			Templates: TemplatesConfig{Plan: "line one\nline two\n"},
+			Profiles: map[string]ProfileConfig{
+				"nightly": {MaxIterations: intPtr(50), InactivityTimeout: strPtr("30m")},
+			},
+		}},
+	}
+
+	settings, err := Explain(layers, "nightly")
+	if err != nil {
+		t.Fatalf("Explain error: %v", err)
+	}
+	var got []string
+	for _, s := range settings {
+		got = append(got, s.Key+"="+s.Value+"@"+s.Source)
+	}
+	want := []string{
+		`agent="claude"@local`,
+		`agents.claude.extra-args=["--model", "opus"]@global`,
+		`inactivity-timeout="30m"@profile nightly`,
+		`max-iterations=50@profile nightly`,
+		`retry.backoff="1s"@global`,
+		`retry.limits.network=5@global`,
+		`templates.plan=(inline, 2 lines)@local`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Explain =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Explain(layers, "weekly"); err == nil {
+		t.Error("Explain with an unknown profile succeeded, want error")
+	}
+	if settings, err := Explain(nil, ""); err != nil || len(settings) != 0 {
+		t.Errorf("Explain(nil) = %v, %v, want no settings", settings, err)
+	}
+}
+
+func TestLoadFile_AgentProcessSettings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+[agents.claude]
+binary = "/opt/claude/bin/claude"
+cwd = "services/api"
+env-passthrough = ["PATH", "ANTHROPIC_*"]
+
+[agents.claude.env]
+ANTHROPIC_API_KEY = "sk-test"
+ANTHROPIC_MODEL = "opus"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+
+	cfg, err := loadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ac := cfg.Agents["claude"]
+	if ac.Binary != "/opt/claude/bin/claude" || ac.Cwd != "services/api" {
+		t.Errorf("binary/cwd = %q/%q", ac.Binary, ac.Cwd)
+	}
+	if strings.Join(ac.EnvPassthrough, ",") != "PATH,ANTHROPIC_*" {
+		t.Errorf("env-passthrough = %v", ac.EnvPassthrough)
+	}
+	if ac.Env["ANTHROPIC_API_KEY"] != "sk-test" || ac.Env["ANTHROPIC_MODEL"] != "opus" {
+		t.Errorf("env = %v", ac.Env)
+	}
+
+	values := flatten(cfg)
+	if values["agents.claude.env.ANTHROPIC_API_KEY"] != "(hidden)" {
+		t.Errorf("flattened env value = %q, want it hidden", values["agents.claude.env.ANTHROPIC_API_KEY"])
+	}
+}
+
+func TestMerge_AgentConfigMergesPerField(t *testing.T) {
+	t.Parallel()
+
+	base := &FileConfig{Agents: map[string]AgentConfig{
+		"claude": {
+			ExtraArgs: []string{"--base-flag"},
+			Binary:    "/usr/local/bin/claude",
+			Env:       map[string]string{"ANTHROPIC_API_KEY": "global", "ANTHROPIC_MODEL": "sonnet"},
+		},
+	}}
+	override := &FileConfig{Agents: map[string]AgentConfig{
+		"claude": {
+			Cwd:            "app",
+			Env:            map[string]string{"ANTHROPIC_MODEL": "opus"},
+			EnvPassthrough: []string{"PATH"},
+		},
+	}}
+
+	got := merge(base, override).Agents["claude"]
+	if strings.Join(got.ExtraArgs, " ") != "--base-flag" || got.Binary != "/usr/local/bin/claude" {
+		t.Errorf("extra-args/binary = %v/%q, want the base values", got.ExtraArgs, got.Binary)
+	}
+	if got.Cwd != "app" || strings.Join(got.EnvPassthrough, ",") != "PATH" {
+		t.Errorf("cwd/env-passthrough = %q/%v, want the override values", got.Cwd, got.EnvPassthrough)
+	}
+	if got.Env["ANTHROPIC_API_KEY"] != "global" || got.Env["ANTHROPIC_MODEL"] != "opus" {
+		t.Errorf("env = %v, want a per-variable merge", got.Env)
+	}
+	if base.Agents["claude"].Env["ANTHROPIC_MODEL"] != "sonnet" {
+		t.Error("merge mutated the base env map")
+	}
+}
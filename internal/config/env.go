@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// config key. The rest of the name is the dotted key upper-cased with dots
+// and dashes turned into underscores: max-iterations is
+// RALFINHO_MAX_ITERATIONS and agents.claude.extra-args is
+// RALFINHO_AGENTS_CLAUDE_EXTRA_ARGS. Agent environment variables keep their
+// own name after the prefix: agents.claude.env.ANTHROPIC_API_KEY is
+// RALFINHO_AGENTS_CLAUDE_ENV_ANTHROPIC_API_KEY.
+const EnvPrefix = "RALFINHO_"
+
+// envKeys maps the scalar keys that can be set from the environment to
+// their setters. Per-agent settings and per-class retry limits have
+// variable names and are handled in envLayer directly.
+var envKeys = map[string]func(cfg *FileConfig, v string) error{
+	"agent":              func(cfg *FileConfig, v string) error { cfg.Agent = v; return nil },
+	"max-iterations":     func(cfg *FileConfig, v string) error { return setEnvInt(&cfg.MaxIterations, v) },
+	"inactivity-timeout": func(cfg *FileConfig, v string) error { cfg.InactivityTimeout = &v; return nil },
+	"runs-dir":           func(cfg *FileConfig, v string) error { cfg.RunsDir = v; return nil },
+	"no-tui": func(cfg *FileConfig, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		cfg.NoTUI = &b
+		return nil
+	},
+	"templates.plan":    func(cfg *FileConfig, v string) error { cfg.Templates.Plan = v; return nil },
+	"templates.default": func(cfg *FileConfig, v string) error { cfg.Templates.Default = v; return nil },
+	"retry.max-retries": func(cfg *FileConfig, v string) error { return setEnvInt(&cfg.Retry.MaxRetries, v) },
+	"retry.backoff":     func(cfg *FileConfig, v string) error { cfg.Retry.Backoff = &v; return nil },
+	"retry.max-backoff": func(cfg *FileConfig, v string) error { cfg.Retry.MaxBackoff = &v; return nil },
+	"retry.retryable": func(cfg *FileConfig, v string) (err error) {
+		cfg.Retry.Retryable, err = parseEnvList(v, ",")
+		return err
+	},
+	"storage.backend":           func(cfg *FileConfig, v string) error { cfg.Storage.Backend = &v; return nil },
+	"storage.compress":          func(cfg *FileConfig, v string) error { cfg.Storage.Compress = &v; return nil },
+	"storage.raw-log-max-size":  func(cfg *FileConfig, v string) error { cfg.Storage.RawLogMaxSize = &v; return nil },
+	"storage.raw-log-max-files": func(cfg *FileConfig, v string) error { return setEnvInt(&cfg.Storage.RawLogMaxFiles, v) },
+}
+
+// EnvVar returns the environment variable name for a dotted config key.
+func EnvVar(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// envLayer builds a config layer from the RALFINHO_* variables in environ
+// (as returned by os.Environ). It returns a nil config when none are set.
+// Variables that match no key are returned as unknown so config validate
+// can report them. file: template references resolve relative to the
+// working directory.
+func envLayer(environ []string) (*FileConfig, []string, error) {
+	byVar := make(map[string]string, len(envKeys))
+	for key := range envKeys {
+		byVar[EnvVar(key)] = key
+	}
+
+	var (
+		cfg     FileConfig
+		found   bool
+		unknown []string
+	)
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+
+		var err error
+		switch rest := strings.TrimPrefix(name, EnvPrefix); {
+		case byVar[name] != "":
+			err = envKeys[byVar[name]](&cfg, value)
+		case strings.HasPrefix(rest, "AGENTS_"):
+			var ok bool
+			if ok, err = setEnvAgent(&cfg, strings.TrimPrefix(rest, "AGENTS_"), value); !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+		case strings.HasPrefix(rest, "RETRY_LIMITS_") && len(rest) > len("RETRY_LIMITS_"):
+			class := strings.ToLower(strings.TrimPrefix(rest, "RETRY_LIMITS_"))
+			var n *int
+			if err = setEnvInt(&n, value); err == nil {
+				if cfg.Retry.Limits == nil {
+					cfg.Retry.Limits = make(map[string]int)
+				}
+				cfg.Retry.Limits[class] = *n
+			}
+		default:
+			unknown = append(unknown, name)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		found = true
+	}
+	sort.Strings(unknown)
+
+	if !found {
+		return nil, unknown, nil
+	}
+	cfg.Templates.setDir(".")
+	return &cfg, unknown, nil
+}
+
+// setEnvAgent applies a RALFINHO_AGENTS_<NAME>_<FIELD> variable, where rest
+// is "<NAME>_<FIELD>". Agent names contain no underscores, so the first one
+// ends the name; ENV_<VAR> sets a single agent environment variable. ok is
+// false when rest names no agent field.
+func setEnvAgent(cfg *FileConfig, rest, value string) (ok bool, err error) {
+	name, field, _ := strings.Cut(rest, "_")
+	if name == "" || field == "" {
+		return false, nil
+	}
+	agentName := strings.ToLower(name)
+	ac := cfg.Agents[agentName]
+
+	switch {
+	case field == "EXTRA_ARGS":
+		ac.ExtraArgs, err = parseEnvList(value, "")
+	case field == "BINARY":
+		ac.Binary = value
+	case field == "CWD":
+		ac.Cwd = value
+	case field == "ENV_PASSTHROUGH":
+		ac.EnvPassthrough, err = parseEnvList(value, ",")
+	case strings.HasPrefix(field, "ENV_") && len(field) > len("ENV_"):
+		if ac.Env == nil {
+			ac.Env = make(map[string]string)
+		}
+		ac.Env[strings.TrimPrefix(field, "ENV_")] = value
+	default:
+		return false, nil
+	}
+	if cfg.Agents == nil {
+		cfg.Agents = make(map[string]AgentConfig)
+	}
+	cfg.Agents[agentName] = ac
+	return true, err
+}
+
+func setEnvInt(dst **int, v string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = &n
+	return nil
+}
+
+// parseEnvList parses a list-valued variable. A value starting with "[" is
+// read as a TOML array, which allows items containing spaces; anything else
+// is split on sep, or on whitespace when sep is empty.
+func parseEnvList(v, sep string) ([]string, error) {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "[") {
+		var doc struct {
+			List []string `toml:"list"`
+		}
+		if _, err := toml.Decode("list = "+v, &doc); err != nil {
+			return nil, fmt.Errorf("invalid list %q: %w", v, err)
+		}
+		return doc.List, nil
+	}
+	if sep == "" {
+		return append([]string{}, strings.Fields(v)...), nil
+	}
+	items := []string{}
+	for _, item := range strings.Split(v, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvVar(t *testing.T) {
+	t.Parallel()
+
+	for key, want := range map[string]string{
+		"max-iterations":            "RALFINHO_MAX_ITERATIONS",
+		"storage.raw-log-max-size":  "RALFINHO_STORAGE_RAW_LOG_MAX_SIZE",
+		"agents.claude.extra-args":  "RALFINHO_AGENTS_CLAUDE_EXTRA_ARGS",
+		"templates.default":         "RALFINHO_TEMPLATES_DEFAULT",
+		"retry.limits.network":      "RALFINHO_RETRY_LIMITS_NETWORK",
+		"storage.raw-log-max-files": "RALFINHO_STORAGE_RAW_LOG_MAX_FILES",
+	} {
+		if got := EnvVar(key); got != want {
+			t.Errorf("EnvVar(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEnvLayer_CoversEveryKey(t *testing.T) {
+	t.Parallel()
+
+	cfg, unknown, err := envLayer([]string{
+		"HOME=/home/me",
+		"RALFINHO_AGENT=claude",
+		"RALFINHO_MAX_ITERATIONS=12",
+		"RALFINHO_INACTIVITY_TIMEOUT=30m",
+		"RALFINHO_RUNS_DIR=/ci/runs",
+		"RALFINHO_NO_TUI=1",
+		"RALFINHO_TEMPLATES_PLAN=file:plan.tmpl",
+		"RALFINHO_TEMPLATES_DEFAULT=Work on {{.NotesPath}}",
+		"RALFINHO_RETRY_MAX_RETRIES=3",
+		"RALFINHO_RETRY_BACKOFF=10s",
+		"RALFINHO_RETRY_MAX_BACKOFF=2m",
+		"RALFINHO_RETRY_RETRYABLE=network, timeout",
+		"RALFINHO_RETRY_LIMITS_NETWORK=5",
+		"RALFINHO_STORAGE_BACKEND=sqlite",
+		"RALFINHO_STORAGE_COMPRESS=gzip",
+		"RALFINHO_STORAGE_RAW_LOG_MAX_SIZE=100MB",
+		"RALFINHO_STORAGE_RAW_LOG_MAX_FILES=4",
+		"RALFINHO_AGENTS_CLAUDE_EXTRA_ARGS=--model opus",
+		`RALFINHO_AGENTS_PI_EXTRA_ARGS=["--system", "be brief"]`,
+		"RALFINHO_MAX_ITERATION=3",
+	})
+	if err != nil {
+		t.Fatalf("envLayer error: %v", err)
+	}
+	if got := strings.Join(unknown, ","); got != "RALFINHO_MAX_ITERATION" {
+		t.Errorf("unknown = %q, want the typo only", got)
+	}
+
+	values := flatten(cfg)
+	for key, want := range map[string]string{
+		"agent":                     `"claude"`,
+		"max-iterations":            "12",
+		"inactivity-timeout":        `"30m"`,
+		"runs-dir":                  `"/ci/runs"`,
+		"no-tui":                    "true",
+		"templates.plan":            `"file:plan.tmpl"`,
+		"templates.default":         "(inline, 1 line)",
+		"retry.max-retries":         "3",
+		"retry.backoff":             `"10s"`,
+		"retry.max-backoff":         `"2m"`,
+		"retry.retryable":           `["network", "timeout"]`,
+		"retry.limits.network":      "5",
+		"storage.backend":           `"sqlite"`,
+		"storage.compress":          `"gzip"`,
+		"storage.raw-log-max-size":  `"100MB"`,
+		"storage.raw-log-max-files": "4",
+		"agents.claude.extra-args":  `["--model", "opus"]`,
+		"agents.pi.extra-args":      `["--system", "be brief"]`,
+	} {
+		if values[key] != want {
+			t.Errorf("%s = %q, want %q", key, values[key], want)
+		}
+	}
+	if len(values) != 18 {
+		t.Errorf("env layer sets %d keys, want 18: %v", len(values), values)
+	}
+	if cfg.Templates.planDir != "." {
+		t.Errorf("plan template dir = %q, want the working directory", cfg.Templates.planDir)
+	}
+}
+
+func TestEnvLayer_NoVariables(t *testing.T) {
+	t.Parallel()
+
+	cfg, unknown, err := envLayer([]string{"HOME=/home/me", "PATH=/bin"})
+	if err != nil || cfg != nil || unknown != nil {
+		t.Errorf("envLayer = %+v, %v, %v; want nothing", cfg, unknown, err)
+	}
+}
+
+func TestEnvLayer_InvalidValues(t *testing.T) {
+	t.Parallel()
+
+	for _, kv := range []string{
+		"RALFINHO_MAX_ITERATIONS=many",
+		"RALFINHO_NO_TUI=maybe",
+		"RALFINHO_RETRY_LIMITS_NETWORK=x",
+		`RALFINHO_AGENTS_PI_EXTRA_ARGS=["unterminated`,
+	} {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, _, err := envLayer([]string{kv}); err == nil || !strings.HasPrefix(err.Error(), name+": ") {
+			t.Errorf("envLayer(%q) error = %v, want one naming the variable", kv, err)
+		}
+	}
+}
+
+func TestLoad_EnvOverridesLocal(t *testing.T) {
+	// Cannot use t.Parallel() alongside t.Setenv.
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(writeLocalConfig(t, `
+agent = "pi"
+max-iterations = 5
+
+[agents.claude]
+extra-args = ["--model", "sonnet"]
+`))
+	t.Setenv("RALFINHO_MAX_ITERATIONS", "0")
+	t.Setenv("RALFINHO_AGENTS_CLAUDE_EXTRA_ARGS", "--model opus")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if cfg.Agent != "pi" || cfg.MaxIterations == nil || *cfg.MaxIterations != 0 {
+		t.Errorf("agent/max-iterations = %q/%v, want the local agent and the env limit", cfg.Agent, cfg.MaxIterations)
+	}
+	if got := strings.Join(cfg.Agents["claude"].ExtraArgs, " "); got != "--model opus" {
+		t.Errorf("claude extra-args = %q, want the env value", got)
+	}
+
+	t.Setenv("RALFINHO_NO_TUI", "sometimes")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RALFINHO_NO_TUI") {
+		t.Errorf("Load error = %v, want one naming RALFINHO_NO_TUI", err)
+	}
+}
+
+// writeLocalConfig writes content to .ralfinho/config.toml in a new project
+// directory and returns the directory.
+func writeLocalConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".ralfinho"), 0755); err != nil {
+		t.Fatalf("mkdir local config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".ralfinho", "config.toml"), []byte(content), 0600); err != nil {
+		t.Fatalf("writing local config: %v", err)
+	}
+	return dir
+}
+
+func TestEnvLayer_AgentProcessSettings(t *testing.T) {
+	t.Parallel()
+
+	cfg, unknown, err := envLayer([]string{
+		"RALFINHO_AGENTS_CLAUDE_BINARY=/opt/claude/bin/claude",
+		"RALFINHO_AGENTS_CLAUDE_CWD=services/api",
+		"RALFINHO_AGENTS_CLAUDE_ENV_PASSTHROUGH=PATH,ANTHROPIC_*",
+		"RALFINHO_AGENTS_CLAUDE_ENV_ANTHROPIC_API_KEY=sk-test",
+		"RALFINHO_AGENTS_KIRO_BINARI=/typo",
+		"RALFINHO_AGENTS_PI=x",
+	})
+	if err != nil {
+		t.Fatalf("envLayer error: %v", err)
+	}
+	if got := strings.Join(unknown, ","); got != "RALFINHO_AGENTS_KIRO_BINARI,RALFINHO_AGENTS_PI" {
+		t.Errorf("unknown = %q", got)
+	}
+	if _, ok := cfg.Agents["kiro"]; ok {
+		t.Error("an unknown agent field created a kiro entry")
+	}
+	ac := cfg.Agents["claude"]
+	if ac.Binary != "/opt/claude/bin/claude" || ac.Cwd != "services/api" {
+		t.Errorf("binary/cwd = %q/%q", ac.Binary, ac.Cwd)
+	}
+	if strings.Join(ac.EnvPassthrough, ",") != "PATH,ANTHROPIC_*" || ac.Env["ANTHROPIC_API_KEY"] != "sk-test" {
+		t.Errorf("env-passthrough/env = %v/%v", ac.EnvPassthrough, ac.Env)
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Layer is one config source as read by LoadLayers: a config file or the
+// RALFINHO_* environment variables.
+type Layer struct {
+	Source string      // "global", "local" or "env"
+	Path   string      // path of the TOML file, whether or not it exists; "" for env
+	Config *FileConfig // nil when the file does not exist or no variable is set
+	// Unknown lists the dotted keys set in the file that ralfinho does not
+	// recognise, e.g. "max-iteration", or the unrecognised RALFINHO_*
+	// variables. Both are otherwise ignored.
+	Unknown []string
+}
+
+// Origin names where the layer was read from: its file path, or
+// "environment" for the env layer.
+func (l Layer) Origin() string {
+	if l.Path == "" {
+		return "environment"
+	}
+	return l.Path
+}
+
+// Setting is one effective config value and the file layer that set it.
+type Setting struct {
+	Key    string // dotted TOML key, e.g. "retry.backoff"
+	Value  string // the value formatted as TOML
+	Source string // "global", "local", "env" or "profile <name>"
+}
+
+// LoadLayers reads the global and local config files and the RALFINHO_*
+// environment variables without merging them, in precedence order. The
+// global layer is omitted when the user config directory cannot be located.
+// A read or parse failure is returned as an error; missing files are not.
+func LoadLayers() ([]Layer, error) {
+	dirs := configDirs()
+	layers := make([]Layer, 0, len(dirs)+1)
+	for _, d := range dirs {
+		path := filepath.Join(d[1], "config.toml")
+		cfg, unknown, err := decodeFile(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, Layer{Source: d[0], Path: path, Config: cfg, Unknown: unknown})
+	}
+
+	cfg, unknown, err := envLayer(os.Environ())
+	if err != nil {
+		return nil, fmt.Errorf("environment: %w", err)
+	}
+	return append(layers, Layer{Source: "env", Config: cfg, Unknown: unknown}), nil
+}
+
+// configDirs returns the "global" and "local" ralfinho config directories in
+// precedence order. The global one is omitted when the user config directory
+// cannot be located.
+func configDirs() [][2]string {
+	var dirs [][2]string
+	if globalDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, [2]string{"global", filepath.Join(globalDir, "ralfinho")})
+	}
+	return append(dirs, [2]string{"local", ".ralfinho"})
+}
+
+// MergeLayers merges layers in order, later layers taking precedence. It is
+// what Load returns for the layers LoadLayers read.
+func MergeLayers(layers []Layer) *FileConfig {
+	var merged *FileConfig
+	for _, l := range layers {
+		merged = merge(merged, l.Config)
+	}
+	if merged == nil {
+		return &FileConfig{}
+	}
+	return merged
+}
+
+// Explain lists the effective settings of the merged layers with the
+// selected profile (if any) applied, each attributed to the last layer that
+// set it. Settings no layer sets are omitted; callers fill in CLI flags and
+// built-in defaults. Returns an error for an unknown profile.
+func Explain(layers []Layer, profile string) ([]Setting, error) {
+	effective := MergeLayers(layers)
+	sources := make(map[string]string)
+	for _, l := range layers {
+		for key := range flatten(l.Config) {
+			sources[key] = l.Source
+		}
+	}
+	if profile != "" {
+		var err error
+		if effective, err = ApplyProfile(effective, profile); err != nil {
+			return nil, err
+		}
+		p, _ := effective.Profile(profile)
+		for key := range flatten(p) {
+			sources[key] = "profile " + profile
+		}
+	}
+
+	values := flatten(effective)
+	settings := make([]Setting, 0, len(values))
+	for key, value := range values {
+		settings = append(settings, Setting{Key: key, Value: value, Source: sources[key]})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings, nil
+}
+
+// flatten returns the settings cfg sets as dotted keys mapped to their
+// TOML-formatted values. Profiles are not included: they only take effect
+// once applied.
+func flatten(cfg *FileConfig) map[string]string {
+	out := make(map[string]string)
+	if cfg == nil {
+		return out
+	}
+	setString := func(key, v string) {
+		if v != "" {
+			out[key] = strconv.Quote(v)
+		}
+	}
+	setStringPtr := func(key string, v *string) {
+		if v != nil {
+			out[key] = strconv.Quote(*v)
+		}
+	}
+	setInt := func(key string, v *int) {
+		if v != nil {
+			out[key] = strconv.Itoa(*v)
+		}
+	}
+
+	setString("agent", cfg.Agent)
+	setInt("max-iterations", cfg.MaxIterations)
+	setStringPtr("inactivity-timeout", cfg.InactivityTimeout)
+	setString("runs-dir", cfg.RunsDir)
+	if cfg.NoTUI != nil {
+		out["no-tui"] = strconv.FormatBool(*cfg.NoTUI)
+	}
+	for name, a := range cfg.Agents {
+		prefix := "agents." + name + "."
+		if a.ExtraArgs != nil {
+			out[prefix+"extra-args"] = formatList(a.ExtraArgs)
+		}
+		setString(prefix+"binary", a.Binary)
+		// Env values are typically API keys; only show that they are set.
+		for k := range a.Env {
+			out[prefix+"env."+k] = "(hidden)"
+		}
+		if a.EnvPassthrough != nil {
+			out[prefix+"env-passthrough"] = formatList(a.EnvPassthrough)
+		}
+		setString(prefix+"cwd", a.Cwd)
+	}
+	if cfg.Templates.Plan != "" {
+		out["templates.plan"] = formatTemplate(cfg.Templates.Plan)
+	}
+	if cfg.Templates.Default != "" {
+		out["templates.default"] = formatTemplate(cfg.Templates.Default)
+	}
+
+	setInt("retry.max-retries", cfg.Retry.MaxRetries)
+	setStringPtr("retry.backoff", cfg.Retry.Backoff)
+	setStringPtr("retry.max-backoff", cfg.Retry.MaxBackoff)
+	if cfg.Retry.Retryable != nil {
+		out["retry.retryable"] = formatList(cfg.Retry.Retryable)
+	}
+	for class, n := range cfg.Retry.Limits {
+		out["retry.limits."+class] = strconv.Itoa(n)
+	}
+
+	setStringPtr("storage.backend", cfg.Storage.Backend)
+	setStringPtr("storage.compress", cfg.Storage.Compress)
+	setStringPtr("storage.raw-log-max-size", cfg.Storage.RawLogMaxSize)
+	setInt("storage.raw-log-max-files", cfg.Storage.RawLogMaxFiles)
+	return out
+}
+
+func formatList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// formatTemplate shows file: references as-is and summarizes inline
+// template text, which is usually too long for a one-line listing.
+func formatTemplate(value string) string {
+	if strings.HasPrefix(value, "file:") {
+		return strconv.Quote(value)
+	}
+	lines := strings.Count(strings.TrimRight(value, "\n"), "\n") + 1
+	if lines == 1 {
+		return "(inline, 1 line)"
+	}
+	return fmt.Sprintf("(inline, %d lines)", lines)
+}
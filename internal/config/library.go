@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// templateExts are the file extensions LoadTemplateLibrary picks up.
+var templateExts = []string{".md", ".tmpl"}
+
+// LibraryTemplate is a named prompt template found in a templates directory
+// next to a config file: <user config dir>/ralfinho/templates or
+// .ralfinho/templates.
+type LibraryTemplate struct {
+	// Name is the file's path relative to the templates directory without
+	// its extension and with forward slashes, e.g. "rules/commit".
+	Name   string
+	Path   string
+	Source string // "global" or "local"
+	Text   string
+}
+
+// TemplateDirs returns the template library directories in precedence order,
+// whether or not they exist.
+func TemplateDirs() []string {
+	var dirs []string
+	for _, d := range configDirs() {
+		dirs = append(dirs, filepath.Join(d[1], "templates"))
+	}
+	return dirs
+}
+
+// LoadTemplateLibrary reads every .md and .tmpl file under the global and
+// local templates directories, sorted by name. A local template replaces a
+// global one with the same name. Missing directories are skipped; an
+// unreadable file is an error.
+func LoadTemplateLibrary() ([]LibraryTemplate, error) {
+	byName := make(map[string]LibraryTemplate)
+	for _, d := range configDirs() {
+		dir := filepath.Join(d[1], "templates")
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dir && os.IsNotExist(err) {
+					return fs.SkipDir
+				}
+				return err
+			}
+			ext := filepath.Ext(path)
+			if entry.IsDir() || !slices.Contains(templateExts, ext) {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, path)
+			name := filepath.ToSlash(strings.TrimSuffix(rel, ext))
+			byName[name] = LibraryTemplate{Name: name, Path: path, Source: d[0], Text: string(data)}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading templates: %w", err)
+		}
+	}
+
+	library := make([]LibraryTemplate, 0, len(byName))
+	for _, t := range byName {
+		library = append(library, t)
+	}
+	sort.Slice(library, func(i, j int) bool { return library[i].Name < library[j].Name })
+	return library, nil
+}
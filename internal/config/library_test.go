@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplateLibrary(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+	t.Chdir(root)
+
+	global := filepath.Join(root, "xdg", "ralfinho", "templates")
+	local := filepath.Join(".ralfinho", "templates")
+	files := map[string]string{
+		filepath.Join(global, "review.md"):           "global review",
+		filepath.Join(global, "rules", "tests.tmpl"): "global test rules",
+		filepath.Join(local, "review.md"):            "local review",
+		filepath.Join(local, "rules", "commit.md"):   "local commit rules",
+		filepath.Join(local, "README.txt"):           "not a template",
+	}
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	library, err := LoadTemplateLibrary()
+	if err != nil {
+		t.Fatalf("LoadTemplateLibrary: %v", err)
+	}
+	want := []LibraryTemplate{
+		{Name: "review", Path: filepath.Join(local, "review.md"), Source: "local", Text: "local review"},
+		{Name: "rules/commit", Path: filepath.Join(local, "rules", "commit.md"), Source: "local", Text: "local commit rules"},
+		{Name: "rules/tests", Path: filepath.Join(global, "rules", "tests.tmpl"), Source: "global", Text: "global test rules"},
+	}
+	if len(library) != len(want) {
+		t.Fatalf("library = %+v, want %+v", library, want)
+	}
+	for i := range want {
+		if library[i] != want[i] {
+			t.Errorf("library[%d] = %+v, want %+v", i, library[i], want[i])
+		}
+	}
+}
+
+func TestLoadTemplateLibrary_NoDirectories(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+	t.Chdir(root)
+
+	library, err := LoadTemplateLibrary()
+	if err != nil || len(library) != 0 {
+		t.Fatalf("LoadTemplateLibrary = %+v, %v; want an empty library", library, err)
+	}
+	if dirs := TemplateDirs(); len(dirs) != 2 || dirs[1] != filepath.Join(".ralfinho", "templates") {
+		t.Errorf("TemplateDirs = %q, want the global and local directories", dirs)
+	}
+}
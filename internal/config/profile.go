@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileConfig is a named bundle of run defaults defined under
+// [profiles.<name>] and selected with --profile, e.g. a cheap exploratory
+// loop and a careful overnight one. Its fields mean the same as the
+// top-level ones and override them when the profile is selected.
+type ProfileConfig struct {
+	Agent             string                 `toml:"agent"`
+	MaxIterations     *int                   `toml:"max-iterations"`
+	InactivityTimeout *string                `toml:"inactivity-timeout"`
+	NoTUI             *bool                  `toml:"no-tui"`
+	Agents            map[string]AgentConfig `toml:"agents"`
+	Templates         TemplatesConfig        `toml:"templates"`
+	Retry             RetryConfig            `toml:"retry"`
+}
+
+// fileConfig returns the profile as a config file layer for merge.
+func (p ProfileConfig) fileConfig() *FileConfig {
+	return &FileConfig{
+		Agent:             p.Agent,
+		MaxIterations:     p.MaxIterations,
+		InactivityTimeout: p.InactivityTimeout,
+		NoTUI:             p.NoTUI,
+		Agents:            p.Agents,
+		Templates:         p.Templates,
+		Retry:             p.Retry,
+	}
+}
+
+// mergeProfiles merges the profiles of two config files. A profile defined
+// in both merges field by field like the top-level settings, so a local
+// file can adjust one field of a global profile.
+func mergeProfiles(base, override map[string]ProfileConfig) map[string]ProfileConfig {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]ProfileConfig, len(base)+len(override))
+	for name, profile := range base {
+		merged[name] = profile
+	}
+	for name, profile := range override {
+		if prev, ok := merged[name]; ok {
+			m := merge(prev.fileConfig(), profile.fileConfig())
+			profile = ProfileConfig{
+				Agent:             m.Agent,
+				MaxIterations:     m.MaxIterations,
+				InactivityTimeout: m.InactivityTimeout,
+				NoTUI:             m.NoTUI,
+				Agents:            m.Agents,
+				Templates:         m.Templates,
+				Retry:             m.Retry,
+			}
+		}
+		merged[name] = profile
+	}
+	return merged
+}
+
+// ProfileNames returns the names of the profiles cfg defines, sorted.
+func ProfileNames(cfg *FileConfig) []string {
+	if cfg == nil {
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the named profile as a standalone config layer, as
+// ApplyProfile merges it. ok is false when cfg defines no such profile.
+func (c *FileConfig) Profile(name string) (profile *FileConfig, ok bool) {
+	if c == nil {
+		return nil, false
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, false
+	}
+	return p.fileConfig(), true
+}
+
+// ApplyProfile returns cfg with the named profile merged over its top-level
+// settings. CLI flags still take precedence over the result. Returns an
+// error when cfg defines no such profile.
+func ApplyProfile(cfg *FileConfig, name string) (*FileConfig, error) {
+	profile, ok := cfg.Profile(name)
+	if !ok {
+		names := ProfileNames(cfg)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown profile %q (no profiles are defined)", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(names, ", "))
+	}
+	return merge(cfg, profile), nil
+}
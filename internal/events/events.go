@@ -5,7 +5,10 @@
 // the runner package needs the Agent interface. Both import events.
 package events
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // EventType enumerates the agent JSON protocol event types.
 type EventType string
@@ -30,6 +33,11 @@ const (
 	// and the runner retries the iteration.
 	EventInactivityTimeout EventType = "inactivity_timeout"
 
+	// EventIterationRetry is emitted when an iteration failed with a
+	// retryable agent error and the runner is about to redo it. The ID has
+	// the form "retry-<iteration>-<attempt>:<class>".
+	EventIterationRetry EventType = "iteration_retry"
+
 	// EventIterationRestart is emitted when the user requests a restart of
 	// the current iteration. The iteration counter is not incremented; the
 	// iteration is redone with the latest prompt and reminders.
@@ -130,3 +138,18 @@ type ContentBlock struct {
 type ToolArgs struct {
 	Command string `json:"command,omitempty"`
 }
+
+// NormalizeToolName maps tool name variants from different agent backends
+// to a canonical lowercase form. Comparison is case-insensitive so that
+// "Bash", "bash", "BASH" all normalize to "bash"; unknown names pass through
+// unchanged.
+func NormalizeToolName(name string) string {
+	switch lower := strings.ToLower(name); lower {
+	case "bash", "shell", "execute":
+		return "bash"
+	case "read", "edit", "write":
+		return lower
+	default:
+		return name
+	}
+}
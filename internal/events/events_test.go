@@ -190,3 +190,41 @@ func TestMessageEnvelopeAndToolArgsRoundTrip(t *testing.T) {
 		t.Errorf("json.Marshal(ToolArgs) = %s, want command field", data)
 	}
 }
+
+func TestNormalizeToolName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// bash variants
+		{"bash", "bash"},
+		{"Bash", "bash"},
+		{"BASH", "bash"},
+		{"shell", "bash"},
+		{"Shell", "bash"},
+		{"execute", "bash"},
+		{"Execute", "bash"},
+		// read variants
+		{"read", "read"},
+		{"Read", "read"},
+		// edit variants
+		{"edit", "edit"},
+		{"Edit", "edit"},
+		// write variants
+		{"write", "write"},
+		{"Write", "write"},
+		// unknown names pass through unchanged
+		{"list_files", "list_files"},
+		{"unknown_tool", "unknown_tool"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := NormalizeToolName(tt.input)
+			if got != tt.want {
+				t.Errorf("NormalizeToolName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
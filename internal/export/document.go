@@ -0,0 +1,193 @@
+// Package export renders saved runs into shareable, self-contained formats.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/tui"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// Format identifies an export output format.
+type Format string
+
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+)
+
+// Formats lists the supported formats in the order shown to users.
+var Formats = []Format{FormatHTML, FormatMarkdown, FormatJSON}
+
+// Extension returns the file extension (without dot) for the format.
+func (f Format) Extension() string {
+	if f == FormatMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// Document is a run laid out the way the TUI main view shows it: the
+// transcript is split into iteration sections of rendered blocks.
+type Document struct {
+	Meta     runner.RunMeta
+	Prompt   string
+	Notes    string
+	Progress string
+	Sections []Section
+}
+
+// Section groups the blocks of one iteration. Iteration is 0 for events
+// recorded before the first iteration boundary (runs saved before iteration
+// markers were persisted have only this section).
+type Section struct {
+	Iteration int
+	Blocks    []tui.MainBlock
+}
+
+// NewDocument converts a saved run through the TUI's EventConverter and
+// block builder so exports match what the replay viewer shows.
+func NewDocument(run *viewer.SavedRun) *Document {
+	doc := &Document{
+		Meta:     run.Meta,
+		Prompt:   run.Prompt,
+		Notes:    run.Notes,
+		Progress: run.Progress,
+	}
+
+	var current *Section
+	for _, b := range tui.BuildBlocks(tui.ConvertEvents(run.Events)) {
+		if b.Kind == tui.BlockIteration {
+			doc.Sections = append(doc.Sections, Section{Iteration: b.Iteration})
+			current = &doc.Sections[len(doc.Sections)-1]
+			continue
+		}
+		if current == nil {
+			doc.Sections = append(doc.Sections, Section{})
+			current = &doc.Sections[len(doc.Sections)-1]
+		}
+		current.Blocks = append(current.Blocks, b)
+	}
+	return doc
+}
+
+// metaRows lists the non-empty run metadata fields as label/value pairs.
+func (d *Document) metaRows() [][2]string {
+	m := d.Meta
+	rows := [][2]string{
+		{"Run ID", m.RunID},
+		{"Agent", m.Agent},
+		{"Status", m.Status},
+		{"Started", m.StartedAt},
+		{"Ended", m.EndedAt},
+		{"Iterations", fmt.Sprintf("%d", m.IterationsCompleted)},
+		{"Prompt source", m.PromptSource},
+		{"Prompt file", m.PromptFile},
+		{"Plan file", m.PlanFile},
+		{"Template", m.Template},
+		{"Resumed from", m.ResumedFrom},
+		{"Parent run", m.ParentRunID},
+	}
+	if m.MaxIterations > 0 {
+		rows = append(rows, [2]string{"Max iterations", fmt.Sprintf("%d", m.MaxIterations)})
+	}
+	if f := m.Failure; f != nil {
+		rows = append(rows, [2]string{"Failure", fmt.Sprintf("%s: %s", f.Category, f.Message)})
+		if f.ExitCode != nil {
+			rows = append(rows, [2]string{"Exit code", fmt.Sprintf("%d", *f.ExitCode)})
+		}
+	}
+
+	if env := m.Environment; env != nil {
+		rows = append(rows,
+			[2]string{"ralfinho version", env.RalfinhoVersion},
+			[2]string{"Agent binary", env.AgentBinary},
+			[2]string{"Agent version", env.AgentVersion},
+			[2]string{"Agent args", strings.Join(env.AgentExtraArgs, " ")},
+			[2]string{"Inactivity timeout", env.InactivityTimeout},
+			[2]string{"Working directory", env.WorkDir},
+			[2]string{"Host", env.Hostname},
+			[2]string{"Prompt SHA-256", env.PromptSHA256},
+		)
+		if env.GitStart != nil {
+			rows = append(rows, [2]string{"Git at start", viewer.FormatGitState(env.GitStart)})
+		}
+		if env.GitEnd != nil {
+			rows = append(rows, [2]string{"Git at end", viewer.FormatGitState(env.GitEnd)})
+		}
+	}
+
+	out := rows[:0]
+	for _, r := range rows {
+		if strings.TrimSpace(r[1]) != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (s Section) title() string {
+	if s.Iteration == 0 {
+		return "Transcript"
+	}
+	return fmt.Sprintf("Iteration %d", s.Iteration)
+}
+
+func (s Section) anchor() string {
+	if s.Iteration == 0 {
+		return "transcript"
+	}
+	return fmt.Sprintf("iteration-%d", s.Iteration)
+}
+
+// toolStatus is the one-word outcome of a tool call block.
+func toolStatus(b tui.MainBlock) string {
+	switch {
+	case b.ToolError:
+		return "error"
+	case b.ToolDone:
+		return "ok"
+	default:
+		return "unfinished"
+	}
+}
+
+// Write renders the run in the given format.
+func Write(w io.Writer, run *viewer.SavedRun, format Format) error {
+	switch format {
+	case FormatHTML:
+		return WriteHTML(w, NewDocument(run))
+	case FormatMarkdown:
+		return WriteMarkdown(w, NewDocument(run))
+	case FormatJSON:
+		return WriteJSON(w, NewJSONDocument(run))
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ParseFormat validates a user-supplied format name. "md" is accepted as
+// shorthand for markdown.
+func ParseFormat(s string) (Format, error) {
+	if s == "md" {
+		return FormatMarkdown, nil
+	}
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported export format %q (valid: %s)", s, formatList())
+}
+
+func formatList() string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
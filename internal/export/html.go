@@ -0,0 +1,151 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/tui"
+)
+
+// markdown converts assistant text, prompts and memory files to HTML. Raw
+// HTML in the source is escaped (goldmark's default), so agent output cannot
+// inject markup into the report.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// WriteHTML renders doc as a single static HTML page with inline styles and
+// no external assets.
+func WriteHTML(w io.Writer, doc *Document) error {
+	return htmlTemplate.Execute(w, htmlView{Document: doc})
+}
+
+// htmlView adapts a Document for the template.
+type htmlView struct {
+	*Document
+}
+
+func (v htmlView) Title() string {
+	return "ralfinho run " + v.Meta.RunID
+}
+
+func (v htmlView) MetaRows() [][2]string {
+	return v.metaRows()
+}
+
+func (v htmlView) Failure() *runner.Failure {
+	return v.Meta.Failure
+}
+
+var htmlTemplate = template.Must(template.New("run").Funcs(template.FuncMap{
+	"markdown":     renderMarkdownHTML,
+	"sectionTitle": Section.title,
+	"sectionID":    Section.anchor,
+	"toolStatus":   toolStatus,
+	"isText":       func(b tui.MainBlock) bool { return b.Kind == tui.BlockAssistantText },
+	"isThinking":   func(b tui.MainBlock) bool { return b.Kind == tui.BlockThinking },
+	"isTool":       func(b tui.MainBlock) bool { return b.Kind == tui.BlockToolCall },
+	"isInfo":       func(b tui.MainBlock) bool { return b.Kind == tui.BlockInfo },
+}).Parse(htmlSource))
+
+func renderMarkdownHTML(text string) template.HTML {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		return template.HTML("<pre>" + template.HTMLEscapeString(text) + "</pre>")
+	}
+	return template.HTML(buf.String())
+}
+
+const htmlSource = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 960px; margin: 2rem auto; padding: 0 1rem; color: #1f2328; line-height: 1.5; }
+h1 { font-size: 1.6rem; border-bottom: 2px solid #5f87ff; padding-bottom: .3rem; }
+h2 { font-size: 1.3rem; color: #5f87ff; border-bottom: 1px solid #d0d7de; padding-bottom: .2rem; margin-top: 2rem; }
+table.meta { border-collapse: collapse; margin-bottom: 1rem; }
+table.meta th { text-align: left; padding: .2rem 1rem .2rem 0; color: #59636e; font-weight: 600; }
+table.meta td { padding: .2rem 0; }
+nav ul { list-style: none; padding: 0; display: flex; flex-wrap: wrap; gap: .5rem 1rem; }
+pre { background: #f6f8fa; padding: .75rem; overflow-x: auto; border-radius: 6px; font-size: .85rem; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+details { margin: .5rem 0; }
+details.tool { border: 1px solid #ffaf00; border-radius: 6px; padding: .25rem .75rem; }
+details.tool.error { border-color: #d1242f; }
+details.tool.unfinished { border-style: dashed; }
+details.tool summary { cursor: pointer; }
+details.tool .name { font-weight: 600; color: #9a6700; }
+details.tool.error .name, details.tool.error .status { color: #d1242f; }
+details.tool .status { color: #59636e; font-size: .85rem; }
+.thinking { color: #8250df; font-style: italic; margin: .25rem 0; }
+.info { color: #59636e; border-left: 3px solid #d0d7de; padding-left: .5rem; }
+.failure { border: 1px solid #d1242f; border-radius: 6px; padding: .5rem 1rem; }
+.empty { color: #59636e; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table class="meta">
+{{- range .MetaRows}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+{{- with .Failure}}{{if .Stderr}}
+<details class="failure"><summary>Agent stderr</summary><pre>{{.Stderr}}</pre></details>
+{{- end}}{{end}}
+{{- if .Sections}}
+<nav><ul>
+{{- range .Sections}}
+<li><a href="#{{sectionID .}}">{{sectionTitle .}}</a></li>
+{{- end}}
+</ul></nav>
+{{- end}}
+{{- if .Prompt}}
+<h2 id="prompt">Effective prompt</h2>
+<details><summary>Show prompt</summary>
+{{markdown .Prompt}}
+</details>
+{{- end}}
+{{- range .Sections}}
+<section id="{{sectionID .}}">
+<h2>{{sectionTitle .}}</h2>
+{{- range .Blocks}}
+{{- if isText .}}
+<div class="assistant">
+{{markdown .Text}}
+</div>
+{{- else if isThinking .}}
+<p class="thinking">thinking ({{.ThinkingLen}} chars)</p>
+{{- else if isTool .}}
+<details class="tool {{toolStatus .}}"><summary><span class="name">{{.ToolName}}</span>{{if .ToolArgs}} <code>{{.ToolArgs}}</code>{{end}} <span class="status">{{toolStatus .}}</span></summary>
+{{- if .ToolResult}}
+<pre>{{.ToolResult}}</pre>
+{{- else}}
+<p class="empty">No output.</p>
+{{- end}}
+</details>
+{{- else if isInfo .}}
+<p class="info">{{.InfoText}}</p>
+{{- end}}
+{{- end}}
+</section>
+{{- else}}
+<p class="empty">No events recorded.</p>
+{{- end}}
+{{- if .Notes}}
+<h2 id="notes">NOTES.md</h2>
+{{markdown .Notes}}
+{{- end}}
+{{- if .Progress}}
+<h2 id="progress">PROGRESS.md</h2>
+{{markdown .Progress}}
+{{- end}}
+</body>
+</html>
+`
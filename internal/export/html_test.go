@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/tui"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func sampleRun() *viewer.SavedRun {
+	exitCode := 1
+	return &viewer.SavedRun{
+		Meta: runner.RunMeta{
+			RunID:               "run-1234",
+			Agent:               "claude",
+			Status:              string(runner.StatusFailed),
+			StartedAt:           "2026-03-02T10:00:00Z",
+			PromptSource:        "plan",
+			PlanFile:            "PLAN.md",
+			IterationsCompleted: 2,
+			Failure: &runner.Failure{
+				Category: runner.FailureAgentExit,
+				Message:  "exit status 1",
+				ExitCode: &exitCode,
+				Stderr:   "panic: <nil>",
+			},
+		},
+		Prompt:   "Fix the **bug**",
+		Notes:    "# Notes\nremember this",
+		Progress: "- [x] step one",
+		Events: []runner.Event{
+			{Type: runner.EventIteration, ID: "iteration-1"},
+			{Type: runner.EventMessageStart, Message: json.RawMessage(`{"role":"assistant","model":"m"}`)},
+			{Type: runner.EventMessageUpdate, AssistantMessageEvent: json.RawMessage(`{"type":"text_delta","delta":"Looking at <script>alert(1)</script> the ` + "`code`" + `"}`)},
+			{Type: runner.EventMessageEnd},
+			{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: "t1", Args: json.RawMessage(`{"command":"go test ./..."}`)},
+			{Type: runner.EventToolExecutionEnd, ToolName: "bash", ToolCallID: "t1", Result: json.RawMessage(`"FAIL <pkg>"`), IsError: boolPtr(true)},
+			{Type: runner.EventIteration, ID: "iteration-2"},
+			{Type: runner.EventToolExecutionStart, ToolName: "read", ToolCallID: "t2", Args: json.RawMessage(`{"path":"main.go"}`)},
+		},
+	}
+}
+
+func TestNewDocumentSplitsIterations(t *testing.T) {
+	doc := NewDocument(sampleRun())
+
+	if len(doc.Sections) != 2 {
+		t.Fatalf("len(Sections) = %d, want 2", len(doc.Sections))
+	}
+	first := doc.Sections[0]
+	if first.Iteration != 1 || len(first.Blocks) != 2 {
+		t.Fatalf("section 1 = iteration %d with %d blocks, want 1 with 2", first.Iteration, len(first.Blocks))
+	}
+	if first.Blocks[0].Kind != tui.BlockAssistantText || !first.Blocks[0].AssistantFinal {
+		t.Errorf("block 0 = %+v, want final assistant text", first.Blocks[0])
+	}
+	tool := first.Blocks[1]
+	if tool.Kind != tui.BlockToolCall || tool.ToolArgs != "$ go test ./..." || !tool.ToolError || tool.ToolResult != "FAIL <pkg>" {
+		t.Errorf("block 1 = %+v, want failed bash tool call", tool)
+	}
+
+	second := doc.Sections[1]
+	if second.Iteration != 2 || len(second.Blocks) != 1 || second.Blocks[0].ToolDone {
+		t.Errorf("section 2 = %+v, want one unfinished tool call", second)
+	}
+}
+
+func TestNewDocumentWithoutIterationMarkers(t *testing.T) {
+	doc := NewDocument(&viewer.SavedRun{
+		Events: []runner.Event{
+			{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: "t1"},
+			{Type: runner.EventToolExecutionEnd, ToolName: "bash", ToolCallID: "t1"},
+		},
+	})
+	if len(doc.Sections) != 1 || doc.Sections[0].Iteration != 0 || len(doc.Sections[0].Blocks) != 1 {
+		t.Fatalf("Sections = %+v, want one unnumbered section", doc.Sections)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleRun(), FormatHTML); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<title>ralfinho run run-1234</title>",
+		"<tr><th>Agent</th><td>claude</td></tr>",
+		"<tr><th>Failure</th><td>agent_exit: exit status 1</td></tr>",
+		"<tr><th>Exit code</th><td>1</td></tr>",
+		"<pre>panic: &lt;nil&gt;</pre>",
+		`<a href="#iteration-1">Iteration 1</a>`,
+		`<section id="iteration-2">`,
+		"<p>Fix the <strong>bug</strong></p>",
+		"<code>code</code>",
+		`<details class="tool error"><summary><span class="name">bash</span> <code>$ go test ./...</code>`,
+		"<pre>FAIL &lt;pkg&gt;</pre>",
+		`<details class="tool unfinished">`,
+		"<h2 id=\"notes\">NOTES.md</h2>\n<h1>Notes</h1>",
+		`type="checkbox"> step one</li>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	// Agent output must never become live markup.
+	if strings.Contains(out, "<script>") {
+		t.Errorf("HTML contains unescaped <script> from agent output:\n%s", out)
+	}
+	// Empty meta fields are omitted.
+	if strings.Contains(out, "<th>Prompt file</th>") {
+		t.Error("HTML contains empty Prompt file row")
+	}
+}
+
+func TestWriteHTMLEmptyRun(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, NewDocument(&viewer.SavedRun{Meta: runner.RunMeta{RunID: "empty"}})); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "No events recorded.") {
+		t.Error("HTML missing empty transcript notice")
+	}
+	for _, unwanted := range []string{"<nav>", "Effective prompt", "NOTES.md", "PROGRESS.md"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("HTML for empty run contains %q", unwanted)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("html"); err != nil || f != FormatHTML {
+		t.Fatalf("ParseFormat(html) = %q, %v", f, err)
+	}
+	if f, err := ParseFormat("md"); err != nil || f != FormatMarkdown {
+		t.Fatalf("ParseFormat(md) = %q, %v", f, err)
+	}
+	if FormatMarkdown.Extension() != "md" || FormatJSON.Extension() != "json" {
+		t.Errorf("extensions = %q, %q", FormatMarkdown.Extension(), FormatJSON.Extension())
+	}
+	_, err := ParseFormat("pdf")
+	if err == nil || !strings.Contains(err.Error(), "valid: html, markdown, json") {
+		t.Fatalf("ParseFormat(pdf) error = %v, want list of valid formats", err)
+	}
+	if err := Write(&bytes.Buffer{}, &viewer.SavedRun{}, Format("pdf")); err == nil {
+		t.Fatal("Write with unknown format succeeded")
+	}
+}
@@ -0,0 +1,268 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// JSONSchemaVersion is bumped whenever the shape of JSONDocument changes in a
+// way consumers need to know about.
+const JSONSchemaVersion = 1
+
+// JSONDocument merges meta.json, events.jsonl and operator-log.jsonl into one
+// object. Streaming fragments are folded into whole messages and tool calls
+// so consumers do not need to know each backend's event protocol.
+type JSONDocument struct {
+	SchemaVersion   int                    `json:"schema_version"`
+	Meta            runner.RunMeta         `json:"meta"`
+	EffectivePrompt string                 `json:"effective_prompt,omitempty"`
+	Notes           string                 `json:"notes,omitempty"`
+	Progress        string                 `json:"progress,omitempty"`
+	Events          []JSONEvent            `json:"events"`
+	OperatorLog     []runner.OperatorEntry `json:"operator_log"`
+}
+
+// JSONEvent kinds.
+const (
+	JSONEventIteration        = "iteration"
+	JSONEventSession          = "session"
+	JSONEventUserMessage      = "user_message"
+	JSONEventAssistantMessage = "assistant_message"
+	JSONEventThinking         = "thinking"
+	JSONEventToolCall         = "tool_call"
+	JSONEventNotice           = "notice"
+)
+
+// JSONEvent is one normalized transcript entry. Only the fields relevant to
+// Type are set.
+type JSONEvent struct {
+	Seq       int    `json:"seq"`
+	Iteration int    `json:"iteration"`
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp,omitempty"`
+
+	// session
+	SessionID string `json:"session_id,omitempty"`
+	CWD       string `json:"cwd,omitempty"`
+
+	// user_message / assistant_message / thinking / notice
+	Text       string          `json:"text,omitempty"`
+	Model      string          `json:"model,omitempty"`
+	StopReason string          `json:"stop_reason,omitempty"`
+	Usage      json.RawMessage `json:"usage,omitempty"`
+
+	// tool_call
+	Tool *JSONToolCall `json:"tool,omitempty"`
+
+	// notice: the runner event that produced it (e.g. "iteration_retry").
+	Source string `json:"source,omitempty"`
+}
+
+// JSONToolCall is a tool invocation with its final result.
+type JSONToolCall struct {
+	ID          string          `json:"id,omitempty"`
+	Name        string          `json:"name"`
+	Args        json.RawMessage `json:"args,omitempty"`
+	DisplayArgs string          `json:"display_args,omitempty"`
+	Result      string          `json:"result,omitempty"`
+	IsError     bool            `json:"is_error"`
+	Finished    bool            `json:"finished"`
+}
+
+// NewJSONDocument normalizes a saved run.
+func NewJSONDocument(run *viewer.SavedRun) *JSONDocument {
+	doc := &JSONDocument{
+		SchemaVersion:   JSONSchemaVersion,
+		Meta:            run.Meta,
+		EffectivePrompt: run.Prompt,
+		Notes:           run.Notes,
+		Progress:        run.Progress,
+		Events:          normalizeEvents(run.Events),
+		OperatorLog:     run.OperatorLog,
+	}
+	if doc.OperatorLog == nil {
+		doc.OperatorLog = []runner.OperatorEntry{}
+	}
+	return doc
+}
+
+// WriteJSON writes doc as indented JSON.
+func WriteJSON(w io.Writer, doc *JSONDocument) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// eventNormalizer folds the raw event stream into JSONEvents.
+type eventNormalizer struct {
+	out       []JSONEvent
+	iteration int
+
+	assistant int // index in out of the open assistant message, or -1
+	thinking  strings.Builder
+	tools     map[string]int // tool call ID → index in out
+}
+
+func normalizeEvents(events []runner.Event) []JSONEvent {
+	n := &eventNormalizer{
+		out:       []JSONEvent{},
+		assistant: -1,
+		tools:     map[string]int{},
+	}
+	for i := range events {
+		n.add(&events[i])
+	}
+	return n.out
+}
+
+func (n *eventNormalizer) emit(ev *runner.Event, e JSONEvent) int {
+	e.Seq = len(n.out)
+	e.Iteration = n.iteration
+	e.Timestamp = ev.Timestamp
+	n.out = append(n.out, e)
+	return len(n.out) - 1
+}
+
+func (n *eventNormalizer) add(ev *runner.Event) {
+	switch ev.Type {
+	case runner.EventIteration:
+		if _, err := fmt.Sscanf(ev.ID, "iteration-%d", &n.iteration); err != nil {
+			return
+		}
+		n.emit(ev, JSONEvent{Type: JSONEventIteration})
+
+	case runner.EventSession:
+		n.emit(ev, JSONEvent{Type: JSONEventSession, SessionID: ev.ID, CWD: ev.CWD})
+
+	case runner.EventMessageStart:
+		msg := decodeMessage(ev.Message)
+		switch msg.Role {
+		case "user":
+			n.emit(ev, JSONEvent{Type: JSONEventUserMessage, Text: messageText(msg.Content)})
+		case "assistant":
+			n.assistant = n.emit(ev, JSONEvent{Type: JSONEventAssistantMessage, Model: msg.Model})
+		}
+
+	case runner.EventMessageUpdate:
+		var ae runner.AssistantEvent
+		if len(ev.AssistantMessageEvent) == 0 || json.Unmarshal(ev.AssistantMessageEvent, &ae) != nil {
+			return
+		}
+		switch ae.Type {
+		case "text_delta":
+			if n.assistant >= 0 {
+				n.out[n.assistant].Text += ae.Delta
+			}
+		case "thinking_start":
+			n.thinking.Reset()
+		case "thinking_delta":
+			n.thinking.WriteString(ae.Delta)
+		case "thinking_end":
+			if n.thinking.Len() > 0 {
+				n.emit(ev, JSONEvent{Type: JSONEventThinking, Text: n.thinking.String()})
+				n.thinking.Reset()
+			}
+		}
+
+	case runner.EventMessageEnd:
+		if n.assistant < 0 {
+			return
+		}
+		msg := decodeMessage(ev.Message)
+		e := &n.out[n.assistant]
+		if e.Text == "" {
+			e.Text = messageText(msg.Content)
+		}
+		if e.Model == "" {
+			e.Model = msg.Model
+		}
+		e.StopReason = msg.StopReason
+		e.Usage = msg.Usage
+		n.assistant = -1
+
+	case runner.EventToolExecutionStart:
+		idx := n.emit(ev, JSONEvent{Type: JSONEventToolCall, Tool: &JSONToolCall{
+			ID:          ev.ToolCallID,
+			Name:        ev.ToolName,
+			Args:        ev.Args,
+			DisplayArgs: ev.ToolDisplayArgs,
+		}})
+		if ev.ToolCallID != "" {
+			n.tools[ev.ToolCallID] = idx
+		}
+
+	case runner.EventToolExecutionUpdate:
+		if idx, ok := n.tools[ev.ToolCallID]; ok {
+			tool := n.out[idx].Tool
+			if len(ev.Args) > 0 {
+				tool.Args = ev.Args
+			}
+			if ev.ToolDisplayArgs != "" {
+				tool.DisplayArgs = ev.ToolDisplayArgs
+			}
+		}
+
+	case runner.EventToolExecutionEnd:
+		idx, ok := n.tools[ev.ToolCallID]
+		if !ok {
+			idx = n.emit(ev, JSONEvent{Type: JSONEventToolCall, Tool: &JSONToolCall{
+				ID:   ev.ToolCallID,
+				Name: ev.ToolName,
+			}})
+		}
+		tool := n.out[idx].Tool
+		tool.Finished = true
+		tool.IsError = ev.IsError != nil && *ev.IsError
+		if len(ev.Result) > 0 {
+			tool.Result = rawText(ev.Result)
+		}
+		delete(n.tools, ev.ToolCallID)
+
+	case runner.EventInactivityTimeout, runner.EventIterationRetry, runner.EventIterationRestart, runner.EventRateLimit:
+		n.emit(ev, JSONEvent{Type: JSONEventNotice, Source: string(ev.Type), Text: ev.ID})
+	}
+}
+
+func decodeMessage(raw json.RawMessage) runner.MessageEnvelope {
+	var msg runner.MessageEnvelope
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &msg)
+	}
+	return msg
+}
+
+// messageText extracts text from message content, which is either a plain
+// string or an array of content blocks.
+func messageText(content json.RawMessage) string {
+	if len(content) == 0 {
+		return ""
+	}
+	var blocks []runner.ContentBlock
+	if err := json.Unmarshal(content, &blocks); err == nil {
+		var parts []string
+		for _, b := range blocks {
+			if b.Text != "" {
+				parts = append(parts, b.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	var s string
+	_ = json.Unmarshal(content, &s)
+	return s
+}
+
+// rawText unquotes JSON strings and returns other JSON values verbatim.
+func rawText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
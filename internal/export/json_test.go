@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+func TestNewJSONDocumentNormalizesEvents(t *testing.T) {
+	run := &viewer.SavedRun{
+		Meta: runner.RunMeta{RunID: "run-1"},
+		Events: []runner.Event{
+			{Type: runner.EventIteration, ID: "iteration-1", Timestamp: "2026-03-02T10:00:00Z"},
+			{Type: runner.EventSession, ID: "sess-1", CWD: "/repo"},
+			{Type: runner.EventMessageStart, Message: json.RawMessage(`{"role":"user","content":[{"type":"text","text":"do it"}]}`)},
+			{Type: runner.EventMessageStart, Message: json.RawMessage(`{"role":"assistant","model":"m1"}`)},
+			{Type: runner.EventMessageUpdate, AssistantMessageEvent: json.RawMessage(`{"type":"thinking_start"}`)},
+			{Type: runner.EventMessageUpdate, AssistantMessageEvent: json.RawMessage(`{"type":"thinking_delta","delta":"hmm"}`)},
+			{Type: runner.EventMessageUpdate, AssistantMessageEvent: json.RawMessage(`{"type":"thinking_end"}`)},
+			{Type: runner.EventMessageUpdate, AssistantMessageEvent: json.RawMessage(`{"type":"text_delta","delta":"Hel"}`)},
+			{Type: runner.EventMessageUpdate, AssistantMessageEvent: json.RawMessage(`{"type":"text_delta","delta":"lo"}`)},
+			{Type: runner.EventMessageEnd, Message: json.RawMessage(`{"role":"assistant","stopReason":"toolUse","usage":{"input":3}}`)},
+			{Type: runner.EventToolExecutionStart, ToolName: "shell", ToolCallID: "t1"},
+			{Type: runner.EventToolExecutionUpdate, ToolName: "shell", ToolCallID: "t1", Args: json.RawMessage(`{"command":"ls"}`)},
+			{Type: runner.EventToolExecutionEnd, ToolName: "shell", ToolCallID: "t1", Result: json.RawMessage(`"a\nb"`), IsError: boolPtr(false)},
+			{Type: runner.EventIterationRetry, ID: "retry-1-1:crash"},
+			{Type: runner.EventIteration, ID: "iteration-2"},
+			// Assistant message whose text only arrives in message_end.
+			{Type: runner.EventMessageStart, Message: json.RawMessage(`{"role":"assistant"}`)},
+			{Type: runner.EventMessageEnd, Message: json.RawMessage(`{"role":"assistant","model":"m2","content":"done"}`)},
+			{Type: runner.EventTurnEnd},
+		},
+		OperatorLog: []runner.OperatorEntry{{TS: "2026-03-02T10:01:00Z", Action: "reminder_add", ID: "r1", Text: "hurry"}},
+	}
+
+	doc := NewJSONDocument(run)
+	if doc.SchemaVersion != JSONSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", doc.SchemaVersion, JSONSchemaVersion)
+	}
+	if len(doc.OperatorLog) != 1 || doc.OperatorLog[0].Action != "reminder_add" {
+		t.Errorf("OperatorLog = %+v", doc.OperatorLog)
+	}
+
+	wantTypes := []string{
+		JSONEventIteration, JSONEventSession, JSONEventUserMessage, JSONEventAssistantMessage,
+		JSONEventThinking, JSONEventToolCall, JSONEventNotice, JSONEventIteration, JSONEventAssistantMessage,
+	}
+	if len(doc.Events) != len(wantTypes) {
+		t.Fatalf("len(Events) = %d, want %d: %+v", len(doc.Events), len(wantTypes), doc.Events)
+	}
+	for i, want := range wantTypes {
+		if doc.Events[i].Type != want || doc.Events[i].Seq != i {
+			t.Errorf("Events[%d] = %s seq %d, want %s seq %d", i, doc.Events[i].Type, doc.Events[i].Seq, want, i)
+		}
+	}
+
+	ev := doc.Events
+	if ev[0].Iteration != 1 || ev[0].Timestamp != "2026-03-02T10:00:00Z" {
+		t.Errorf("iteration event = %+v", ev[0])
+	}
+	if ev[1].SessionID != "sess-1" || ev[1].CWD != "/repo" {
+		t.Errorf("session event = %+v", ev[1])
+	}
+	if ev[2].Text != "do it" {
+		t.Errorf("user message text = %q", ev[2].Text)
+	}
+	if ev[3].Text != "Hello" || ev[3].Model != "m1" || ev[3].StopReason != "toolUse" || string(ev[3].Usage) != `{"input":3}` {
+		t.Errorf("assistant message = %+v", ev[3])
+	}
+	if ev[4].Text != "hmm" {
+		t.Errorf("thinking text = %q", ev[4].Text)
+	}
+	tool := ev[5].Tool
+	if tool == nil || tool.Name != "shell" || string(tool.Args) != `{"command":"ls"}` || tool.Result != "a\nb" || !tool.Finished || tool.IsError {
+		t.Errorf("tool call = %+v", tool)
+	}
+	if ev[6].Source != "iteration_retry" || ev[6].Text != "retry-1-1:crash" {
+		t.Errorf("notice = %+v", ev[6])
+	}
+	if ev[8].Iteration != 2 || ev[8].Text != "done" || ev[8].Model != "m2" {
+		t.Errorf("second assistant message = %+v", ev[8])
+	}
+}
+
+func TestWriteJSONRoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, &viewer.SavedRun{Meta: runner.RunMeta{RunID: "run-1", Status: "completed"}}, FormatJSON); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	// Empty collections are arrays, never null, so consumers can iterate.
+	for _, key := range []string{"events", "operator_log"} {
+		if string(raw[key]) != "[]" {
+			t.Errorf("%s = %s, want []", key, raw[key])
+		}
+	}
+
+	var doc JSONDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if doc.SchemaVersion != JSONSchemaVersion || doc.Meta.RunID != "run-1" {
+		t.Errorf("doc = %+v", doc)
+	}
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/tui"
+)
+
+// WriteMarkdown renders doc as a Markdown transcript suitable for pasting
+// into a pull request: assistant text verbatim, one summary line per tool
+// call, and the prompt and memory files folded into <details> blocks.
+func WriteMarkdown(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# ralfinho run %s\n\n", doc.Meta.RunID)
+	for _, row := range doc.metaRows() {
+		fmt.Fprintf(bw, "- **%s:** %s\n", row[0], row[1])
+	}
+	if f := doc.Meta.Failure; f != nil && f.Stderr != "" {
+		writeMarkdownDetails(bw, "Agent stderr", fence(f.Stderr))
+	}
+
+	if doc.Prompt != "" {
+		fmt.Fprint(bw, "\n## Effective prompt\n")
+		writeMarkdownDetails(bw, "Show prompt", doc.Prompt)
+	}
+
+	if len(doc.Sections) == 0 {
+		fmt.Fprint(bw, "\n_No events recorded._\n")
+	}
+	for _, s := range doc.Sections {
+		fmt.Fprintf(bw, "\n## %s\n", s.title())
+		for _, b := range s.Blocks {
+			writeMarkdownBlock(bw, b)
+		}
+	}
+
+	if doc.Notes != "" {
+		fmt.Fprint(bw, "\n## NOTES.md\n")
+		writeMarkdownDetails(bw, "Show notes", doc.Notes)
+	}
+	if doc.Progress != "" {
+		fmt.Fprint(bw, "\n## PROGRESS.md\n")
+		writeMarkdownDetails(bw, "Show progress", doc.Progress)
+	}
+
+	return bw.Flush()
+}
+
+func writeMarkdownBlock(w io.Writer, b tui.MainBlock) {
+	switch b.Kind {
+	case tui.BlockAssistantText:
+		if text := strings.TrimSpace(b.Text); text != "" {
+			fmt.Fprintf(w, "\n%s\n", text)
+		}
+	case tui.BlockThinking:
+		fmt.Fprintf(w, "\n_thinking (%d chars)_\n", b.ThinkingLen)
+	case tui.BlockToolCall:
+		line := "**" + b.ToolName + "**"
+		if b.ToolArgs != "" {
+			line += " " + inlineCode(b.ToolArgs)
+		}
+		fmt.Fprintf(w, "\n- %s — %s\n", line, toolStatus(b))
+	case tui.BlockInfo:
+		fmt.Fprintf(w, "\n> %s\n", b.InfoText)
+	}
+}
+
+// writeMarkdownDetails folds body into a GitHub-style collapsible block.
+func writeMarkdownDetails(w io.Writer, summary, body string) {
+	fmt.Fprintf(w, "\n<details><summary>%s</summary>\n\n%s\n\n</details>\n", summary, strings.TrimRight(body, "\n"))
+}
+
+// inlineCode wraps s in a code span, using enough backticks that any
+// backticks inside s cannot close it early.
+func inlineCode(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	ticks := strings.Repeat("`", longestRun(s, '`')+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return ticks + " " + s + " " + ticks
+	}
+	return ticks + s + ticks
+}
+
+// fence wraps s in a fenced code block that s cannot terminate.
+func fence(s string) string {
+	n := longestRun(s, '`') + 1
+	if n < 3 {
+		n = 3
+	}
+	ticks := strings.Repeat("`", n)
+	return ticks + "\n" + strings.TrimRight(s, "\n") + "\n" + ticks
+}
+
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
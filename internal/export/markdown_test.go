@@ -0,0 +1,98 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleRun(), FormatMarkdown); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# ralfinho run run-1234\n",
+		"- **Agent:** claude\n",
+		"- **Failure:** agent_exit: exit status 1\n",
+		"<details><summary>Agent stderr</summary>\n\n```\npanic: <nil>\n```\n\n</details>",
+		"## Effective prompt\n\n<details><summary>Show prompt</summary>\n\nFix the **bug**\n\n</details>",
+		"## Iteration 1\n",
+		"Looking at <script>alert(1)</script> the `code`\n",
+		"- **bash** `$ go test ./...` — error\n",
+		"## Iteration 2\n\n- **read** `main.go` — unfinished\n",
+		"## NOTES.md\n\n<details><summary>Show notes</summary>\n\n# Notes\nremember this\n\n</details>",
+		"## PROGRESS.md\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown missing %q\n---\n%s", want, out)
+		}
+	}
+	// Tool results are summarized, not dumped.
+	if strings.Contains(out, "FAIL <pkg>") {
+		t.Error("Markdown contains raw tool output")
+	}
+}
+
+func TestWriteMarkdownEmptyRun(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, NewDocument(&viewer.SavedRun{Meta: runner.RunMeta{RunID: "empty"}})); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "_No events recorded._") {
+		t.Errorf("Markdown missing empty transcript notice:\n%s", out)
+	}
+	if strings.Contains(out, "<details>") {
+		t.Errorf("Markdown for empty run contains details blocks:\n%s", out)
+	}
+}
+
+func TestWriteMarkdownEnvironment(t *testing.T) {
+	run := &viewer.SavedRun{Meta: runner.RunMeta{RunID: "env", Environment: &runner.RunEnvironment{
+		RalfinhoVersion: "1.4.0",
+		AgentVersion:    "pi 0.9.1",
+		GitStart:        &runner.GitState{Branch: "main", Commit: "0123456789abcdef"},
+	}}}
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, NewDocument(run)); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"- **ralfinho version:** 1.4.0\n",
+		"- **Agent version:** pi 0.9.1\n",
+		"- **Git at start:** main@0123456789ab\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown missing %q\n---\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Git at end") || strings.Contains(out, "Host") {
+		t.Errorf("Markdown lists unrecorded environment fields:\n%s", out)
+	}
+}
+
+func TestInlineCodeAndFence(t *testing.T) {
+	tests := map[string]string{
+		"ls":          "`ls`",
+		"echo `date`": "`` echo `date` ``",
+		"a`b":         "``a`b``",
+		"`x`":         "`` `x` ``",
+		"a\nb":        "`a b`",
+	}
+	for in, want := range tests {
+		if got := inlineCode(in); got != want {
+			t.Errorf("inlineCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := fence("x ``` y\n"); got != "````\nx ``` y\n````" {
+		t.Errorf("fence = %q", got)
+	}
+}
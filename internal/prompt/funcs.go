@@ -0,0 +1,121 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultShellTimeout bounds a shell template call that passes no timeout.
+const DefaultShellTimeout = 30 * time.Second
+
+// funcs returns the functions available to plan and default templates:
+//
+//	readFile PATH            contents of a file
+//	glob PATTERN             sorted paths matching a filepath.Match pattern
+//	env NAME                 value of an environment variable, "" if unset
+//	shell CMD [TIMEOUT]      trimmed stdout of "sh -c CMD"; fails on a
+//	                         non-zero exit or after TIMEOUT (default 30s)
+//	truncate N TEXT          TEXT cut to N characters, marked with "…"
+//
+// Relative paths and commands resolve against ralfinho's working directory.
+// shell commands are killed when ctx, the render's context, is done.
+func funcs(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"readFile": readFile,
+		"glob":     filepath.Glob,
+		"env":      os.Getenv,
+		"shell": func(command string, timeout ...string) (string, error) {
+			return shell(ctx, command, timeout...)
+		},
+		"truncate": truncate,
+	}
+}
+
+// checkFuncs mirrors funcs with side-effect-free stubs for CheckTemplate.
+func checkFuncs() template.FuncMap {
+	return template.FuncMap{
+		"readFile": func(string) (string, error) { return "", nil },
+		"glob":     func(string) ([]string, error) { return nil, nil },
+		"env":      func(string) string { return "" },
+		"shell": func(_ string, timeout ...string) (string, error) {
+			_, err := shellTimeout(timeout)
+			return "", err
+		},
+		"truncate": truncate,
+	}
+}
+
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func shell(parent context.Context, command string, timeout ...string) (string, error) {
+	d, err := shellTimeout(timeout)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(parent, d)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// Don't wait on children that outlive a killed command and keep its
+	// output pipe open.
+	cmd.WaitDelay = 100 * time.Millisecond
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	switch {
+	case parent.Err() != nil:
+		return "", fmt.Errorf("shell %q: %w", command, parent.Err())
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("shell %q: timed out after %s", command, d)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("shell %q: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("shell %q: %w", command, err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// shellTimeout parses the optional timeout argument of shell.
+func shellTimeout(args []string) (time.Duration, error) {
+	switch len(args) {
+	case 0:
+		return DefaultShellTimeout, nil
+	case 1:
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("shell: invalid timeout %q", args[0])
+		}
+		return d, nil
+	}
+	return 0, fmt.Errorf("shell: want a command and at most one timeout, got %d timeouts", len(args))
+}
+
+// truncate shortens s to at most n characters, replacing the tail with "…"
+// when it is cut. The argument order lets it end a pipeline:
+// {{readFile "CHANGELOG.md" | truncate 2000}}.
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	if n == 0 {
+		return ""
+	}
+	return string(runes[:n-1]) + "…"
+}
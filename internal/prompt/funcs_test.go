@@ -0,0 +1,77 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.md": "alpha", "b.md": "beta"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+	t.Setenv("RALFINHO_TEST_VALUE", "from-env")
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "readFile", text: `{{readFile "a.md"}}`, want: "alpha"},
+		{name: "readFile missing", text: `{{readFile "missing.md"}}`, wantErr: "missing.md"},
+		{name: "glob", text: `{{range glob "*.md"}}{{.}} {{end}}`, want: "a.md b.md "},
+		{name: "env", text: `{{env "RALFINHO_TEST_VALUE"}}|{{env "RALFINHO_TEST_UNSET"}}`, want: "from-env|"},
+		{name: "shell", text: `{{shell "echo one; echo two"}}`, want: "one\ntwo"},
+		{name: "shell failure", text: `{{shell "echo broken >&2; exit 3"}}`, wantErr: "exit status 3: broken"},
+		{name: "shell timeout", text: `{{shell "sleep 5" "50ms"}}`, wantErr: "timed out after 50ms"},
+		{name: "truncate", text: `{{readFile "b.md" | truncate 3}}|{{truncate 10 "short"}}|{{truncate 0 "gone"}}`, want: "be…|short|"},
+		{name: "truncate runes", text: `{{truncate 3 "ação!"}}`, want: "aç…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := DefaultTemplate(tt.text, "", "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tmpl.Render(context.Background(), Iteration{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateShellStopsWithRenderContext(t *testing.T) {
+	tmpl, err := DefaultTemplate(`{{shell "sleep 5"}}`, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = tmpl.Render(ctx, Iteration{})
+	if err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Fatalf("Render error = %v, want the cancellation", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Render took %s after its context was cancelled", elapsed)
+	}
+}
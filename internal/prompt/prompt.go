@@ -2,41 +2,172 @@ package prompt
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"sort"
+	"strings"
 	"text/template"
+	"time"
 )
 
-// planData is the data passed into the plan/default templates.
-type planData struct {
+// Data is the data passed into the plan/default templates. The loop fields
+// describe the iteration being rendered; they are zero outside of a run.
+type Data struct {
 	PlanPath     string
 	PlanContent  string
 	NotesPath    string
 	ProgressPath string
+
+	RunID         string
+	Iteration     int // 1-based
+	MaxIterations int // 0 = unlimited
+	Agent         string
+	Date          string // current date, YYYY-MM-DD
+	// PreviousOutcome is how the previous attempt ended: "" before the
+	// first, then "ok", "error", "timeout" or "restarted".
+	PreviousOutcome string
+
+	dir string          // where GitBranch and GitLog run git
+	ctx context.Context // cancels GitBranch and GitLog; nil = never
 }
 
-// BuildFromPlan reads planPath, renders either the built-in plan template or a
-// caller-provided override with the plan content, and returns the final prompt
-// string.
-func BuildFromPlan(planPath, templateOverride, notesPath, progressPath string) (string, error) {
-	data, err := os.ReadFile(planPath)
-	if err != nil {
-		return "", fmt.Errorf("reading plan file %q: %w", planPath, err)
-	}
+// GitBranch returns the current git branch of the working directory, or ""
+// outside a repository. git only runs when a template references it.
+func (d Data) GitBranch() string {
+	return gitOutput(d.ctx, d.dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// GitLog returns the last ten commits of the working directory as
+// "<short hash> <subject>" lines, or "" outside a repository.
+func (d Data) GitLog() string {
+	return gitOutput(d.ctx, d.dir, "log", "--oneline", "-n", "10")
+}
+
+// Iteration describes the loop state a Template is rendered for.
+type Iteration struct {
+	RunID           string
+	Number          int
+	MaxIterations   int
+	Agent           string
+	PreviousOutcome string
+	Dir             string // working directory for git; "" = current
+}
+
+// Library maps partial names to template text. Every partial can be
+// included by the prompt template and by the other partials with
+// {{template "name" .}}.
+type Library map[string]string
+
+// rootName names the prompt template itself in its template set. The
+// parentheses keep it from clashing with a partial's name.
+const rootName = "(prompt)"
+
+// Template is a parsed plan or default template that is rendered again for
+// every iteration, so the plan content, git state and template functions
+// reflect the work done so far.
+type Template struct {
+	tmpl *template.Template
+	data Data
+}
 
+// PlanTemplate parses the built-in plan template, or templateOverride when
+// non-empty, for the plan at planPath, along with the partials in lib. The
+// plan is read on every Render.
+func PlanTemplate(planPath, templateOverride, notesPath, progressPath string, lib Library) (*Template, error) {
 	templateText := defaultTemplate
 	if templateOverride != "" {
 		templateText = templateOverride
 	}
-
-	return renderTemplate(templateText, planData{
+	return newTemplate(templateText, lib, Data{
 		PlanPath:     planPath,
-		PlanContent:  string(data),
 		NotesPath:    notesPath,
 		ProgressPath: progressPath,
 	})
 }
 
+// DefaultTemplate parses the built-in default prompt, or templateOverride
+// when non-empty, along with the partials in lib.
+func DefaultTemplate(templateOverride, notesPath, progressPath string, lib Library) (*Template, error) {
+	templateText := defaultPromptTemplate
+	if templateOverride != "" {
+		templateText = templateOverride
+	}
+	return newTemplate(templateText, lib, Data{
+		NotesPath:    notesPath,
+		ProgressPath: progressPath,
+	})
+}
+
+func newTemplate(templateText string, lib Library, data Data) (*Template, error) {
+	tmpl, err := parse(templateText, lib)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl, data: data}, nil
+}
+
+// parse parses templateText and the partials in lib into one template set.
+// Render binds the template functions to its context on a clone.
+func parse(templateText string, lib Library) (*template.Template, error) {
+	root := template.New(rootName).Funcs(funcs(context.Background()))
+	names := make([]string, 0, len(lib))
+	for name := range lib {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := root.New(name).Parse(lib[name]); err != nil {
+			return nil, fmt.Errorf("parsing template %q: %w", name, err)
+		}
+	}
+	if _, err := root.Parse(templateText); err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	return root, nil
+}
+
+// Render executes the template for one iteration. Cancelling ctx stops
+// the shell commands and git calls the template makes.
+func (t *Template) Render(ctx context.Context, it Iteration) (string, error) {
+	data := t.data
+	if data.PlanPath != "" {
+		content, err := os.ReadFile(data.PlanPath)
+		if err != nil {
+			return "", fmt.Errorf("reading plan file %q: %w", data.PlanPath, err)
+		}
+		data.PlanContent = string(content)
+	}
+	data.RunID = it.RunID
+	data.Iteration = it.Number
+	data.MaxIterations = it.MaxIterations
+	data.Agent = it.Agent
+	data.PreviousOutcome = it.PreviousOutcome
+	data.Date = time.Now().Format(time.DateOnly)
+	data.dir = it.Dir
+	if data.dir == "" {
+		data.dir = "."
+	}
+	data.ctx = ctx
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return execute(tmpl.Funcs(funcs(ctx)), data)
+}
+
+// BuildFromPlan reads planPath, renders either the built-in plan template or a
+// caller-provided override with the plan content, and returns the final prompt
+// string.
+func BuildFromPlan(planPath, templateOverride, notesPath, progressPath string) (string, error) {
+	tmpl, err := PlanTemplate(planPath, templateOverride, notesPath, progressPath, nil)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(context.Background(), Iteration{Number: 1})
+}
+
 // BuildFromPromptFile reads the file at promptPath and returns its contents
 // verbatim as the prompt.
 func BuildFromPromptFile(promptPath string) (string, error) {
@@ -50,25 +181,56 @@ func BuildFromPromptFile(promptPath string) (string, error) {
 // BuildDefault returns the built-in default prompt or a caller-provided
 // template override, rendered with the given memory file paths.
 func BuildDefault(templateOverride, notesPath, progressPath string) (string, error) {
-	templateText := defaultPromptTemplate
-	if templateOverride != "" {
-		templateText = templateOverride
+	tmpl, err := DefaultTemplate(templateOverride, notesPath, progressPath, nil)
+	if err != nil {
+		return "", err
 	}
-	return renderTemplate(templateText, planData{
-		NotesPath:    notesPath,
-		ProgressPath: progressPath,
-	})
+	return tmpl.Render(context.Background(), Iteration{Number: 1})
 }
 
-func renderTemplate(templateText string, data planData) (string, error) {
-	tmpl, err := template.New("prompt").Parse(templateText)
+// CheckTemplate reports whether templateText would render with the partials
+// in lib: it must parse and only reference fields, functions and partials
+// that exist. Template functions are replaced by stubs, so no file is read
+// and no command runs.
+func CheckTemplate(templateText string, lib Library) error {
+	tmpl, err := parse(templateText, lib)
 	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
+		return err
 	}
+	_, err = execute(tmpl.Funcs(checkFuncs()), Data{
+		PlanPath:     "PLAN.md",
+		PlanContent:  "plan",
+		NotesPath:    "NOTES.md",
+		ProgressPath: "PROGRESS.md",
+		RunID:        "run",
+		Iteration:    1,
+		Agent:        "pi",
+		Date:         time.Now().Format(time.DateOnly),
+	})
+	return err
+}
 
+func execute(tmpl *template.Template, data Data) (string, error) {
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("executing template: %w", err)
 	}
 	return buf.String(), nil
 }
+
+// gitOutput runs git in dir and returns its trimmed output, or "" when git
+// fails, e.g. outside a repository.
+func gitOutput(parent context.Context, dir string, args ...string) string {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
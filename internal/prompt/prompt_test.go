@@ -1,10 +1,13 @@
 package prompt
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBuildFromPlan(t *testing.T) {
@@ -238,3 +241,127 @@ func TestBuildDefault_EmptyPaths(t *testing.T) {
 		t.Error("output missing expected content")
 	}
 }
+
+func TestCheckTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{name: "built-in plan", text: defaultTemplate},
+		{name: "built-in default", text: defaultPromptTemplate},
+		{name: "valid override", text: "Plan {{.PlanPath}} with {{.NotesPath}}"},
+		{name: "syntax error", text: "{{.PlanPath", wantErr: "parsing template"},
+		{name: "unknown field", text: "{{.Plan}}", wantErr: "executing template"},
+		{name: "loop data", text: "{{.RunID}} {{.Iteration}}/{{.MaxIterations}} {{.Agent}} {{.Date}} {{.PreviousOutcome}} {{.GitBranch}} {{.GitLog}}"},
+		{name: "functions do not run", text: `{{shell "exit 1"}}{{readFile "missing.md" | truncate 10}}{{range glob "*.go"}}{{.}}{{end}}{{env "HOME"}}`},
+		{name: "unknown function", text: `{{exec "ls"}}`, wantErr: "parsing template"},
+		{name: "invalid shell timeout", text: `{{shell "true" "soon"}}`, wantErr: "invalid timeout"},
+		{name: "missing partial", text: `{{template "rules/commit" .}}`, wantErr: `"rules/commit" not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTemplate(tt.text, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckTemplate error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckTemplate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplate_RenderIterationData(t *testing.T) {
+	dir := t.TempDir()
+	planPath := filepath.Join(dir, "PLAN.md")
+	if err := os.WriteFile(planPath, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := PlanTemplate(planPath, "{{.RunID}} {{.Iteration}}/{{.MaxIterations}} {{.Agent}} [{{.PreviousOutcome}}] {{.Date}} {{.PlanContent}}", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tmpl.Render(context.Background(), Iteration{RunID: "r1", Number: 2, MaxIterations: 5, Agent: "claude", PreviousOutcome: "timeout", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "r1 2/5 claude [timeout] " + time.Now().Format(time.DateOnly) + " first"
+	if got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+
+	// The plan is read again on every render.
+	if err := os.WriteFile(planPath, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tmpl.Render(context.Background(), Iteration{}); !strings.HasSuffix(got, " second") {
+		t.Errorf("Render after plan edit = %q, want the new plan content", got)
+	}
+	os.Remove(planPath)
+	if _, err := tmpl.Render(context.Background(), Iteration{}); err == nil || !strings.Contains(err.Error(), "reading plan file") {
+		t.Errorf("Render without plan error = %v, want a read error", err)
+	}
+}
+
+func TestTemplate_RenderGitData(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	tmpl, err := DefaultTemplate("branch={{.GitBranch}} log={{.GitLog}}", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tmpl.Render(context.Background(), Iteration{Dir: dir}); got != "branch= log=" {
+		t.Errorf("outside a repository = %q, want empty git data", got)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "topic"},
+		{"commit", "-q", "--allow-empty", "-m", "first change"},
+	} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	got, err := tmpl.Render(context.Background(), Iteration{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "branch=topic log=") || !strings.HasSuffix(got, " first change") {
+		t.Errorf("Render = %q, want the branch and commit log", got)
+	}
+}
+
+func TestTemplate_Partials(t *testing.T) {
+	lib := Library{
+		"rules/commit":   `Commit rules for {{.Agent}}. {{template "rules/sign-off"}}`,
+		"rules/sign-off": "No Co-Authored-By.",
+	}
+	tmpl, err := DefaultTemplate(`Work. {{template "rules/commit" .}}`, "", "", lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tmpl.Render(context.Background(), Iteration{Agent: "claude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Work. Commit rules for claude. No Co-Authored-By."; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+	if err := CheckTemplate(`{{template "rules/commit" .}}`, lib); err != nil {
+		t.Errorf("CheckTemplate with the partial: %v", err)
+	}
+
+	_, err = DefaultTemplate("Work.", "", "", Library{"broken": "{{.Agent"})
+	if err == nil || !strings.Contains(err.Error(), `parsing template "broken"`) {
+		t.Errorf("broken partial error = %v, want it named", err)
+	}
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+)
+
+// RunEnvironment records what a run executed with and where, so a good run
+// can be reproduced and a bad one compared against it (see "ralfinho diff").
+type RunEnvironment struct {
+	RalfinhoVersion string `json:"ralfinho_version,omitempty"`
+
+	// AgentBinary is the resolved path of the agent executable, or its bare
+	// name when it was not found on PATH. AgentVersion is the first line of
+	// its --version output.
+	AgentBinary    string   `json:"agent_binary,omitempty"`
+	AgentVersion   string   `json:"agent_version,omitempty"`
+	AgentExtraArgs []string `json:"agent_extra_args,omitempty"`
+
+	// InactivityTimeout is the watchdog duration the run started with after
+	// defaults were applied; "0s" means the watchdog was disabled.
+	InactivityTimeout string `json:"inactivity_timeout,omitempty"`
+
+	// WorkDir is where the agent ran: its configured working directory, or
+	// ralfinho's.
+	WorkDir  string `json:"work_dir,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+
+	// PromptSHA256 is the hex SHA-256 of effective-prompt.md, the prompt as
+	// rendered for the first iteration. A plan or default template is
+	// rendered again for every iteration, so later iterations may have been
+	// sent different text than this hash covers.
+	PromptSHA256 string `json:"prompt_sha256,omitempty"`
+
+	// GitStart and GitEnd describe the working directory's repository when
+	// the run started and ended. Nil outside a git repository.
+	GitStart *GitState `json:"git_start,omitempty"`
+	GitEnd   *GitState `json:"git_end,omitempty"`
+}
+
+// GitState is a snapshot of a git working tree.
+type GitState struct {
+	Branch string `json:"branch,omitempty"` // "HEAD" when detached
+	Commit string `json:"commit"`
+	Dirty  bool   `json:"dirty,omitempty"` // uncommitted changes present
+}
+
+// environmentProbeTimeout bounds each external command run to describe the
+// environment (agent --version, git), so a hung binary cannot stall startup.
+const environmentProbeTimeout = 2 * time.Second
+
+// captureEnvironment describes the environment the run is starting in.
+func (r *Runner) captureEnvironment() *RunEnvironment {
+	env := &RunEnvironment{
+		RalfinhoVersion: r.cfg.Version,
+		AgentExtraArgs:  r.cfg.AgentExtraArgs,
+		PromptSHA256:    promptSHA256(r.cfg.Prompt),
+	}
+	if disabled, timeout := r.control.watchdogState(); disabled {
+		env.InactivityTimeout = "0s"
+	} else {
+		env.InactivityTimeout = timeout.String()
+	}
+	env.WorkDir, _ = os.Getwd()
+	if dir := r.cfg.AgentProcess.Dir; dir != "" {
+		env.WorkDir, _ = filepath.Abs(dir)
+	}
+	env.Hostname, _ = os.Hostname()
+
+	bin := agent.Executable(r.cfg.Agent)
+	if bin != "" && r.cfg.AgentProcess.Binary != "" {
+		bin = r.cfg.AgentProcess.Binary
+	}
+	if bin != "" {
+		env.AgentBinary = bin
+		if path, err := exec.LookPath(bin); err == nil {
+			env.AgentBinary = path
+			// Probe the binary the way the agent will be started, with its
+			// configured environment and working directory.
+			ctx, cancel := context.WithTimeout(context.Background(), environmentProbeTimeout)
+			env.AgentVersion, _ = r.cfg.AgentProcess.Version(ctx, agent.Executable(r.cfg.Agent))
+			cancel()
+		}
+	}
+
+	env.GitStart = readGitState(env.WorkDir)
+	return env
+}
+
+func promptSHA256(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// readGitState returns the branch, commit and dirtiness of the repository
+// containing dir, or nil when dir is not in one or git is unavailable.
+func readGitState(dir string) *GitState {
+	if dir == "" {
+		return nil
+	}
+	commit, err := probeCommand(dir, "git", "rev-parse", "HEAD")
+	if err != nil {
+		return nil
+	}
+	state := &GitState{Commit: commit}
+	state.Branch, _ = probeCommand(dir, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	if status, err := probeCommand(dir, "git", "status", "--porcelain"); err == nil {
+		state.Dirty = status != ""
+	}
+	return state
+}
+
+// probeCommand runs a short informational command in dir and returns its
+// trimmed standard output.
+func probeCommand(dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), environmentProbeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	// Don't wait on children that outlive a killed command and keep its
+	// output pipe open.
+	cmd.WaitDelay = 100 * time.Millisecond
+	out, err := cmd.Output()
+	return string(bytes.TrimSpace(out)), err
+}
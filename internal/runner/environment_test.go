@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+)
+
+func TestRun_RecordsEnvironment(t *testing.T) {
+	// A fake "pi" on an otherwise empty PATH stands in for the agent binary.
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho 'pi 0.9.1'\necho 'build abc'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pi"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	disabled := time.Duration(0)
+	fa := &fakeAgent{responses: []fakeResponse{{text: completionMarker}}}
+	r := newTestRunnerWithAgent(t, fa, RunConfig{
+		Agent:             "pi",
+		Prompt:            "reproduce me",
+		Version:           "1.4.0",
+		AgentExtraArgs:    []string{"--model", "fast"},
+		InactivityTimeout: &disabled,
+	})
+	r.Run(context.Background())
+
+	meta, err := r.store().ReadMeta(r.runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := meta.Environment
+	if env == nil {
+		t.Fatal("meta.json has no environment")
+	}
+	wd, _ := os.Getwd()
+	host, _ := os.Hostname()
+	checks := []struct{ field, got, want string }{
+		{"ralfinho_version", env.RalfinhoVersion, "1.4.0"},
+		{"agent_binary", env.AgentBinary, filepath.Join(binDir, "pi")},
+		{"agent_version", env.AgentVersion, "pi 0.9.1"},
+		{"agent_extra_args", strings.Join(env.AgentExtraArgs, " "), "--model fast"},
+		{"inactivity_timeout", env.InactivityTimeout, "0s"},
+		{"work_dir", env.WorkDir, wd},
+		{"hostname", env.Hostname, host},
+		{"prompt_sha256", env.PromptSHA256, promptSHA256("reproduce me")},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.field, c.got, c.want)
+		}
+	}
+	if len(env.PromptSHA256) != 64 {
+		t.Errorf("prompt_sha256 = %q, want a hex SHA-256", env.PromptSHA256)
+	}
+}
+
+func TestCaptureEnvironment_DefaultTimeoutAndUnknownAgent(t *testing.T) {
+	r, _ := newTestRunner(t)
+	r.control = newControlState(nil)
+	env := r.captureEnvironment()
+	if env.InactivityTimeout != defaultInactivityTimeout.String() {
+		t.Errorf("inactivity_timeout = %q, want the default", env.InactivityTimeout)
+	}
+	if env.AgentBinary != "" || env.AgentVersion != "" {
+		t.Errorf("agent %q recorded binary %q %q", r.cfg.Agent, env.AgentBinary, env.AgentVersion)
+	}
+}
+
+func TestCaptureEnvironment_AgentProcess(t *testing.T) {
+	binDir := t.TempDir()
+	binary := filepath.Join(binDir, "pi-pinned")
+	// The version probe starts the binary like the agent: with its
+	// environment and in its working directory.
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho \"pi 1.0.0-$PI_CHANNEL in $(pwd)\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workDir := t.TempDir()
+
+	r, _ := newTestRunner(t)
+	r.control = newControlState(nil)
+	r.cfg.Agent = "pi"
+	r.cfg.AgentProcess = agent.Process{Binary: binary, Dir: workDir, Env: map[string]string{"PI_CHANNEL": "pinned"}}
+	env := r.captureEnvironment()
+	if env.AgentBinary != binary || env.AgentVersion != "pi 1.0.0-pinned in "+workDir {
+		t.Errorf("agent binary/version = %q/%q, want the configured binary", env.AgentBinary, env.AgentVersion)
+	}
+	if env.WorkDir != workDir {
+		t.Errorf("work_dir = %q, want the agent's directory %q", env.WorkDir, workDir)
+	}
+}
+
+func TestReadGitState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	if state := readGitState(dir); state != nil {
+		t.Fatalf("readGitState outside a repository = %+v, want nil", state)
+	}
+
+	git("init", "-q", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "a.txt")
+	git("commit", "-q", "-m", "first")
+
+	state := readGitState(dir)
+	if state == nil || state.Branch != "feature" || len(state.Commit) != 40 || state.Dirty {
+		t.Fatalf("clean state = %+v", state)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if state := readGitState(dir); state == nil || !state.Dirty {
+		t.Errorf("dirty state = %+v, want Dirty", state)
+	}
+}
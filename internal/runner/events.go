@@ -25,6 +25,7 @@ const (
 	EventAgentEnd            = events.EventAgentEnd
 	EventIteration           = events.EventIteration
 	EventInactivityTimeout   = events.EventInactivityTimeout
+	EventIterationRetry      = events.EventIterationRetry
 	EventIterationRestart    = events.EventIterationRestart
 	EventReminderState       = events.EventReminderState
 	EventRateLimit           = events.EventRateLimit
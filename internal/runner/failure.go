@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+)
+
+// FailureCategory classifies why a run did not complete.
+type FailureCategory string
+
+const (
+	FailureAgentExit  FailureCategory = "agent_exit"  // agent exited non-zero, crashed, or its output broke
+	FailureAgentStart FailureCategory = "agent_start" // agent could not be constructed or started
+	FailureTimeout    FailureCategory = "timeout"     // inactivity watchdog exhausted its retries
+	FailureBudget     FailureCategory = "budget"      // max iterations reached without completion
+	FailureUser       FailureCategory = "user"        // interrupted by the operator
+	FailurePrompt     FailureCategory = "prompt"      // the prompt template failed to render
+)
+
+// Failure is the structured record of why a run ended without completing.
+// It is persisted under "failure" in meta.json.
+type Failure struct {
+	Category  FailureCategory `json:"category"`
+	Message   string          `json:"message"`
+	ExitCode  *int            `json:"exit_code,omitempty"`
+	Stderr    string          `json:"stderr,omitempty"` // last 4 KB of agent stderr
+	Iteration int             `json:"iteration"`
+	Timestamp string          `json:"timestamp"`
+}
+
+// newFailure builds a Failure stamped with the current time.
+func newFailure(category FailureCategory, message string, iteration int) *Failure {
+	return &Failure{
+		Category:  category,
+		Message:   message,
+		Iteration: iteration,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// failureFromError builds a Failure for an iteration error, pulling the exit
+// code and stderr tail out of an *agent.Error when available. The message
+// keeps only the first line of the error because the stderr tail is stored
+// separately.
+func failureFromError(err error, iteration int) *Failure {
+	message, _, _ := strings.Cut(err.Error(), "\n")
+	f := newFailure(FailureAgentExit, message, iteration)
+	if errors.Is(err, errRenderPrompt) {
+		f.Category = FailurePrompt
+		return f
+	}
+
+	var ae *agent.Error
+	if errors.As(err, &ae) {
+		if ae.Kind == agent.ErrorKindStart {
+			f.Category = FailureAgentStart
+		}
+		if ae.ExitCode >= 0 {
+			code := ae.ExitCode
+			f.ExitCode = &code
+		}
+		f.Stderr = ae.Stderr
+	}
+	return f
+}
@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
+
+func TestFailureFromError(t *testing.T) {
+	t.Run("agent exit carries code and stderr", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", &agent.Error{
+			Kind:     agent.ErrorKindExit,
+			ExitCode: 2,
+			Stderr:   "missing API key",
+			Err:      errors.New("agent exited with error: exit status 2\nstderr: missing API key"),
+		})
+		f := failureFromError(err, 4)
+		if f.Category != FailureAgentExit {
+			t.Errorf("category = %q, want %q", f.Category, FailureAgentExit)
+		}
+		if f.ExitCode == nil || *f.ExitCode != 2 {
+			t.Errorf("exit code = %v, want 2", f.ExitCode)
+		}
+		if f.Stderr != "missing API key" {
+			t.Errorf("stderr = %q", f.Stderr)
+		}
+		if f.Message != "wrapped: agent exited with error: exit status 2" {
+			t.Errorf("message = %q, want first line only", f.Message)
+		}
+		if f.Iteration != 4 {
+			t.Errorf("iteration = %d, want 4", f.Iteration)
+		}
+		if _, perr := time.Parse(time.RFC3339, f.Timestamp); perr != nil {
+			t.Errorf("timestamp %q is not RFC3339: %v", f.Timestamp, perr)
+		}
+	})
+
+	t.Run("start error", func(t *testing.T) {
+		f := failureFromError(&agent.Error{Kind: agent.ErrorKindStart, ExitCode: -1, Err: errors.New("starting agent: not found")}, 1)
+		if f.Category != FailureAgentStart {
+			t.Errorf("category = %q, want %q", f.Category, FailureAgentStart)
+		}
+		if f.ExitCode != nil {
+			t.Errorf("exit code = %d, want nil", *f.ExitCode)
+		}
+	})
+
+	t.Run("plain error", func(t *testing.T) {
+		f := failureFromError(errors.New("boom"), 2)
+		if f.Category != FailureAgentExit || f.ExitCode != nil || f.Stderr != "" {
+			t.Errorf("failure = %+v, want bare agent_exit", f)
+		}
+	})
+}
+
+func readMetaFailure(t *testing.T, dir string) *Failure {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		t.Fatalf("reading meta.json: %v", err)
+	}
+	var meta RunMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("parsing meta.json: %v", err)
+	}
+	return meta.Failure
+}
+
+func TestRun_FailureRecordedInResultAndMeta(t *testing.T) {
+	tests := []struct {
+		name      string
+		behaviors []agentBehavior
+		maxIters  int
+		want      FailureCategory
+		wantIter  int
+	}{
+		{
+			name:      "agent exit",
+			behaviors: []agentBehavior{exitFailure("segfault")},
+			want:      FailureAgentExit,
+			wantIter:  1,
+		},
+		{
+			name: "budget",
+			behaviors: []agentBehavior{
+				func(context.Context, func(events.Event)) (string, error) { return "more", nil },
+			},
+			maxIters: 1,
+			want:     FailureBudget,
+			wantIter: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fa := &flexAgent{behaviors: tt.behaviors}
+			runsDir := t.TempDir()
+			r := New(RunConfig{Agent: "test", Prompt: "p", RunsDir: runsDir, MaxIterations: tt.maxIters})
+			r.iterAgent = fa
+			r.stderr = io.Discard
+
+			result := r.Run(context.Background())
+			if result.Failure == nil {
+				t.Fatal("expected failure record on result")
+			}
+			if result.Failure.Category != tt.want {
+				t.Errorf("category = %q, want %q", result.Failure.Category, tt.want)
+			}
+			if result.Failure.Iteration != tt.wantIter {
+				t.Errorf("iteration = %d, want %d", result.Failure.Iteration, tt.wantIter)
+			}
+
+			persisted := readMetaFailure(t, filepath.Join(runsDir, result.RunID))
+			if persisted == nil || persisted.Category != tt.want {
+				t.Errorf("meta.json failure = %+v, want category %q", persisted, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_AgentExitFailureKeepsStderr(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure("Error: quota exceeded")}}
+	runsDir := t.TempDir()
+	r := New(RunConfig{Agent: "test", Prompt: "p", RunsDir: runsDir})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+
+	result := r.Run(context.Background())
+	f := readMetaFailure(t, filepath.Join(runsDir, result.RunID))
+	if f == nil {
+		t.Fatal("meta.json has no failure record")
+	}
+	if f.Stderr != "Error: quota exceeded" {
+		t.Errorf("stderr = %q", f.Stderr)
+	}
+	if f.ExitCode == nil || *f.ExitCode != 1 {
+		t.Errorf("exit code = %v, want 1", f.ExitCode)
+	}
+}
+
+func TestRun_TimeoutFailure(t *testing.T) {
+	hang := func(ctx context.Context, _ func(events.Event)) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	timeout := 20 * time.Millisecond
+	r := New(RunConfig{Agent: "test", Prompt: "p", RunsDir: t.TempDir(), InactivityTimeout: &timeout})
+	r.iterAgent = &flexAgent{behaviors: []agentBehavior{hang, hang}}
+	r.stderr = io.Discard
+
+	result := r.Run(context.Background())
+	if result.Failure == nil || result.Failure.Category != FailureTimeout {
+		t.Fatalf("failure = %+v, want timeout", result.Failure)
+	}
+	if result.Failure.Message != result.Error {
+		t.Errorf("message = %q, want %q", result.Failure.Message, result.Error)
+	}
+}
+
+func TestRun_CompletedRunHasNoFailure(t *testing.T) {
+	runsDir := t.TempDir()
+	r := New(RunConfig{Agent: "test", Prompt: "p", RunsDir: runsDir})
+	r.iterAgent = &flexAgent{behaviors: []agentBehavior{completes}}
+	r.stderr = io.Discard
+
+	result := r.Run(context.Background())
+	if result.Failure != nil {
+		t.Errorf("failure = %+v, want nil", result.Failure)
+	}
+	if f := readMetaFailure(t, filepath.Join(runsDir, result.RunID)); f != nil {
+		t.Errorf("meta.json failure = %+v, want absent", f)
+	}
+}
@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
+
+// StatusImported marks a run rebuilt from an external log that never
+// emitted the completion marker, so its real outcome is unknown.
+const StatusImported Status = "imported"
+
+// ImportConfig describes an external agent log to turn into a run directory.
+type ImportConfig struct {
+	Agent   string    // backend whose raw output format Log is in
+	Log     io.Reader // captured agent stdout, as in raw-output.log
+	Source  string    // where Log came from; recorded in session.log
+	RunsDir string
+	RunID   string // generated when empty
+}
+
+// Import replays an external agent log through the backend's event mapper
+// and writes a run directory the viewer, browser and exporters can read:
+// events.jsonl with iteration markers, a copy of the log as raw-output.log,
+// empty memory files and meta.json. The run is marked completed when the
+// last iteration contains the completion marker, and imported otherwise.
+func Import(cfg ImportConfig) (RunMeta, error) {
+	runID := cfg.RunID
+	if runID == "" {
+		runID = newUUID()
+	}
+	dir := filepath.Join(cfg.RunsDir, runID)
+	if _, err := os.Stat(dir); err == nil {
+		return RunMeta{}, fmt.Errorf("run %s already exists", runID)
+	}
+
+	raw, err := io.ReadAll(cfg.Log)
+	if err != nil {
+		return RunMeta{}, fmt.Errorf("reading agent log: %w", err)
+	}
+
+	eventsData, texts, err := replayEvents(cfg.Agent, raw)
+	if err != nil {
+		return RunMeta{}, err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	meta := RunMeta{
+		RunID:               runID,
+		StartedAt:           now,
+		EndedAt:             now,
+		Status:              string(StatusImported),
+		Agent:               cfg.Agent,
+		PromptSource:        "import",
+		IterationsCompleted: len(texts),
+	}
+	if len(texts) > 0 && strings.Contains(texts[len(texts)-1], completionMarker) {
+		meta.Status = string(StatusCompleted)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return RunMeta{}, fmt.Errorf("creating run dir: %w", err)
+	}
+	session := fmt.Sprintf("imported %d iteration(s) from %s (%s)\n", len(texts), cfg.Source, cfg.Agent)
+	for name, data := range map[string][]byte{
+		"events.jsonl":   eventsData,
+		"raw-output.log": raw,
+		"session.log":    []byte(session),
+		"NOTES.md":       nil,
+		"PROGRESS.md":    nil,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return RunMeta{}, fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	if err := writeMetaJSON(filepath.Join(dir, "meta.json"), meta); err != nil {
+		return RunMeta{}, err
+	}
+	return meta, nil
+}
+
+// replayEvents runs a raw agent log through agent.Replay and encodes the
+// result as events.jsonl content. It also returns each iteration's
+// assistant text.
+func replayEvents(agentName string, raw []byte) ([]byte, []string, error) {
+	var buf bytes.Buffer
+	var encErr error
+	texts, err := agent.Replay(agentName, bytes.NewReader(raw), func(ev events.Event) {
+		if encErr != nil {
+			return
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			encErr = fmt.Errorf("encoding event: %w", err)
+			return
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	})
+	if err == nil {
+		err = encErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), texts, nil
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const importPiLog = `{"type":"session","id":"s1"}
+{"type":"agent_start"}
+{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"working"}}
+{"type":"agent_end"}
+{"type":"session","id":"s2"}
+{"type":"agent_start"}
+{"type":"message_update","assistantMessageEvent":{"type":"text_delta","delta":"<promise>COMPLETE</promise>"}}
+{"type":"agent_end"}
+`
+
+func TestImport_WritesRunDirectory(t *testing.T) {
+	runsDir := t.TempDir()
+	meta, err := Import(ImportConfig{
+		Agent:   "pi",
+		Log:     strings.NewReader(importPiLog),
+		Source:  "pi.jsonl",
+		RunsDir: runsDir,
+		RunID:   "imported-run",
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if meta.Status != string(StatusCompleted) || meta.IterationsCompleted != 2 || meta.PromptSource != "import" {
+		t.Errorf("meta = %+v, want completed import with 2 iterations", meta)
+	}
+
+	dir := filepath.Join(runsDir, "imported-run")
+	raw, err := os.ReadFile(filepath.Join(dir, "raw-output.log"))
+	if err != nil || string(raw) != importPiLog {
+		t.Errorf("raw-output.log = %q, %v; want copy of the log", raw, err)
+	}
+	for _, name := range []string{"meta.json", "NOTES.md", "PROGRESS.md", "session.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing: %v", name, err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, "events.jsonl"))
+	if err != nil {
+		t.Fatalf("open events.jsonl: %v", err)
+	}
+	defer f.Close()
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("bad events.jsonl line %q: %v", scanner.Text(), err)
+		}
+		if ev.Type == EventIteration {
+			ids = append(ids, ev.ID)
+		}
+	}
+	if strings.Join(ids, ",") != "iteration-1,iteration-2" {
+		t.Errorf("iteration markers = %v", ids)
+	}
+}
+
+func TestImport_UnfinishedLogIsMarkedImported(t *testing.T) {
+	meta, err := Import(ImportConfig{
+		Agent:   "pi",
+		Log:     strings.NewReader(`{"type":"agent_start"}` + "\n"),
+		RunsDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if meta.Status != string(StatusImported) || meta.RunID == "" {
+		t.Errorf("meta = %+v, want imported status and a generated run ID", meta)
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	runsDir := t.TempDir()
+	if _, err := Import(ImportConfig{Agent: "codex", Log: strings.NewReader(""), RunsDir: runsDir}); err == nil {
+		t.Error("Import with unknown agent succeeded")
+	}
+	if entries, _ := os.ReadDir(runsDir); len(entries) != 0 {
+		t.Errorf("failed import left %d entries in runs dir", len(entries))
+	}
+
+	if err := os.Mkdir(filepath.Join(runsDir, "taken"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Import(ImportConfig{Agent: "pi", Log: strings.NewReader(""), RunsDir: runsDir, RunID: "taken"})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("err = %v, want already exists", err)
+	}
+}
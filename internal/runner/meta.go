@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"unicode"
 )
 
 // RunMeta is the structure written to meta.json at the end of a run.
@@ -18,6 +20,51 @@ type RunMeta struct {
 	PlanFile            string `json:"plan_file"`
 	MaxIterations       int    `json:"max_iterations"`
 	IterationsCompleted int    `json:"iterations_completed"`
+
+	// Template names the library template the prompt was rendered from
+	// (--template). Empty when the configured or built-in template was used.
+	Template string `json:"template,omitempty"`
+
+	// Name, Tags and Annotation are user labels. Name and Tags come from
+	// --name and --tag and can be edited later from the session browser,
+	// along with the free-form Annotation. Tags are also used for bulk
+	// cleanup, e.g. "nightly".
+	Name       string   `json:"name,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Annotation string   `json:"annotation,omitempty"`
+
+	// ResumedFrom is the run this one continues: it was resumed from the
+	// session browser with that run's NOTES.md and PROGRESS.md. A chain of
+	// resumed runs forms one job.
+	ResumedFrom string `json:"resumed_from,omitempty"`
+
+	// ParentRunID is the run this one re-ran with the same settings
+	// ("ralfinho rerun"). Empty for runs started from scratch.
+	ParentRunID string `json:"parent_run_id,omitempty"`
+
+	// Environment records what the run executed with and where. Absent
+	// for runs written before it was recorded and for imported runs.
+	Environment *RunEnvironment `json:"environment,omitempty"`
+
+	// RawLogPruned counts the oldest raw-output.log segments deleted under
+	// storage.raw-log-max-files. When non-zero the raw log no longer holds
+	// the whole run, so reprocessing it yields an incomplete event log.
+	RawLogPruned int `json:"raw_log_pruned,omitempty"`
+
+	// Failure records why the run ended without completing. Absent for
+	// completed and still-running runs.
+	Failure *Failure `json:"failure,omitempty"`
+}
+
+// ValidTag reports whether tag can label a run: it must be non-empty, must
+// not start with "-" or "@", and must not contain whitespace, "," or "#".
+func ValidTag(tag string) bool {
+	if tag == "" || strings.HasPrefix(tag, "-") || strings.HasPrefix(tag, "@") {
+		return false
+	}
+	return !strings.ContainsFunc(tag, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == '#'
+	})
 }
 
 // writeMetaJSON writes meta.json to the given path.
@@ -32,3 +79,16 @@ func writeMetaJSON(path string, meta RunMeta) error {
 	}
 	return nil
 }
+
+// readMetaJSON reads meta.json from the given path.
+func readMetaJSON(path string) (RunMeta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RunMeta{}, fmt.Errorf("reading meta.json: %w", err)
+	}
+	var meta RunMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return RunMeta{}, fmt.Errorf("parsing meta.json: %w", err)
+	}
+	return meta, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"time"
 )
 
@@ -27,7 +28,8 @@ type syncer interface {
 	Sync() error
 }
 
-type operatorEntry struct {
+// OperatorEntry is one line of operator-log.jsonl.
+type OperatorEntry struct {
 	TS           string   `json:"ts"`
 	Action       string   `json:"action"`
 	Value        string   `json:"value,omitempty"`
@@ -64,7 +66,7 @@ func timeoutString(d *time.Duration) string {
 	return d.String()
 }
 
-func (l *operatorLogger) write(e operatorEntry) {
+func (l *operatorLogger) write(e OperatorEntry) {
 	if l == nil {
 		return
 	}
@@ -90,7 +92,7 @@ func (l *operatorLogger) write(e operatorEntry) {
 }
 
 func (l *operatorLogger) logTimeoutSet(prev, next *time.Duration) {
-	l.write(operatorEntry{
+	l.write(OperatorEntry{
 		Action:   "timeout_set",
 		Value:    timeoutString(next),
 		Previous: timeoutString(prev),
@@ -102,7 +104,7 @@ func (l *operatorLogger) logReminderAdd(r Reminder) {
 	if r.Kind == ReminderPersistent {
 		kind = "persistent"
 	}
-	l.write(operatorEntry{
+	l.write(OperatorEntry{
 		Action: "reminder_add",
 		Kind:   kind,
 		ID:     r.ID,
@@ -111,7 +113,7 @@ func (l *operatorLogger) logReminderAdd(r Reminder) {
 }
 
 func (l *operatorLogger) logReminderRemove(id string) {
-	l.write(operatorEntry{
+	l.write(OperatorEntry{
 		Action: "reminder_remove",
 		ID:     id,
 	})
@@ -120,7 +122,7 @@ func (l *operatorLogger) logReminderRemove(id string) {
 // logRestartRequested records a restart request. restartCount is the
 // upcoming attempt number (e.g. 1 for the first restart of an iteration).
 func (l *operatorLogger) logRestartRequested(iteration, restartCount int, reminderIDs []string) {
-	l.write(operatorEntry{
+	l.write(OperatorEntry{
 		Action:       "restart_requested",
 		Iteration:    iteration,
 		RestartCount: restartCount,
@@ -132,9 +134,27 @@ func (l *operatorLogger) logOneOffConsumed(ids []string, iteration int) {
 	if len(ids) == 0 {
 		return
 	}
-	l.write(operatorEntry{
+	l.write(OperatorEntry{
 		Action:    "oneoff_consumed",
 		IDs:       ids,
 		Iteration: iteration,
 	})
 }
+
+// PersistentReminders replays the reminder entries of an operator log and
+// returns the persistent reminders still active at its end, in the order
+// they were added.
+func PersistentReminders(entries []OperatorEntry) []Reminder {
+	var active []Reminder
+	for _, e := range entries {
+		switch e.Action {
+		case "reminder_add":
+			if e.Kind == "persistent" {
+				active = append(active, Reminder{ID: e.ID, Text: e.Text, Kind: ReminderPersistent})
+			}
+		case "reminder_remove":
+			active = slices.DeleteFunc(active, func(r Reminder) bool { return r.ID == e.ID })
+		}
+	}
+	return active
+}
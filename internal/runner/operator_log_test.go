@@ -19,20 +19,20 @@ import (
 
 // readOperatorLog returns the parsed entries from operator-log.jsonl in the
 // given run directory. Empty lines are skipped.
-func readOperatorLog(t *testing.T, runDir string) []operatorEntry {
+func readOperatorLog(t *testing.T, runDir string) []OperatorEntry {
 	t.Helper()
 	data, err := os.ReadFile(filepath.Join(runDir, "operator-log.jsonl"))
 	if err != nil {
 		t.Fatalf("read operator-log.jsonl: %v", err)
 	}
-	var entries []operatorEntry
+	var entries []OperatorEntry
 	sc := bufio.NewScanner(bytes.NewReader(data))
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
 		if line == "" {
 			continue
 		}
-		var e operatorEntry
+		var e OperatorEntry
 		if err := json.Unmarshal([]byte(line), &e); err != nil {
 			t.Fatalf("parse line %q: %v", line, err)
 		}
@@ -85,14 +85,14 @@ func TestOperatorLogger_WritesEachActionType(t *testing.T) {
 	l.logRestartRequested(3, 1, []string{"rmd-bbbb"})
 	l.logOneOffConsumed([]string{"rmd-bbbb"}, 3)
 
-	var entries []operatorEntry
+	var entries []OperatorEntry
 	sc := bufio.NewScanner(&buf)
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
 		if line == "" {
 			continue
 		}
-		var e operatorEntry
+		var e OperatorEntry
 		if err := json.Unmarshal([]byte(line), &e); err != nil {
 			t.Fatalf("parse %q: %v", line, err)
 		}
@@ -253,7 +253,7 @@ func TestRun_OperatorLog_RecordsTimeoutAddRemoveRestart(t *testing.T) {
 	}
 
 	// Find the oneoff_consumed entry (it follows the completing iteration).
-	var consumed *operatorEntry
+	var consumed *OperatorEntry
 	for i := 5; i < len(entries); i++ {
 		if entries[i].Action == "oneoff_consumed" {
 			c := entries[i]
@@ -328,3 +328,71 @@ func waitFor(cond func() bool, timeout time.Duration) bool {
 	}
 	return false
 }
+
+func TestPersistentReminders(t *testing.T) {
+	entries := []OperatorEntry{
+		{Action: "reminder_add", Kind: "persistent", ID: "r1", Text: "lint always"},
+		{Action: "reminder_add", Kind: "oneoff", ID: "r2", Text: "fix auth"},
+		{Action: "timeout_set", Value: "10m0s"},
+		{Action: "reminder_add", Kind: "persistent", ID: "r3", Text: "no new deps"},
+		{Action: "reminder_add", Kind: "persistent", ID: "r4", Text: "keep tests green"},
+		{Action: "reminder_remove", ID: "r3"},
+		{Action: "oneoff_consumed", IDs: []string{"r2"}, Iteration: 1},
+	}
+	got := PersistentReminders(entries)
+	if len(got) != 2 || got[0].Text != "lint always" || got[1].Text != "keep tests green" {
+		t.Fatalf("PersistentReminders = %+v, want lint always and keep tests green", got)
+	}
+	for _, rem := range got {
+		if rem.Kind != ReminderPersistent {
+			t.Errorf("reminder %+v is not persistent", rem)
+		}
+	}
+	if got := PersistentReminders(nil); got != nil {
+		t.Errorf("PersistentReminders(nil) = %+v, want nil", got)
+	}
+}
+
+func TestRun_SeedsInitialReminders(t *testing.T) {
+	complete := func(_ context.Context, _ func(events.Event)) (string, error) {
+		return completionMarker, nil
+	}
+	fa := &flexAgent{behaviors: []agentBehavior{complete}}
+
+	runsDir := t.TempDir()
+	r := New(RunConfig{
+		Agent:       "test",
+		Prompt:      "base prompt",
+		RunsDir:     runsDir,
+		ParentRunID: "parent-run",
+		Reminders:   []Reminder{{Text: "lint always"}},
+	})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+
+	result := r.Run(context.Background())
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, want %s", result.Status, StatusCompleted)
+	}
+	if len(fa.prompts) != 1 || !strings.Contains(fa.prompts[0], "lint always") {
+		t.Errorf("prompts = %q, want the seeded reminder appended", fa.prompts)
+	}
+
+	runDir := filepath.Join(runsDir, result.RunID)
+	entries := readOperatorLog(t, runDir)
+	if len(entries) == 0 || entries[0].Action != "reminder_add" || entries[0].Kind != "persistent" || entries[0].Text != "lint always" {
+		t.Fatalf("entries = %+v, want the seeded reminder logged as persistent", entries)
+	}
+	// The seeded reminder survives into a re-run of this run.
+	if got := PersistentReminders(entries); len(got) != 1 || got[0].Text != "lint always" {
+		t.Errorf("PersistentReminders = %+v, want the seeded reminder", got)
+	}
+
+	meta, err := r.store().ReadMeta(result.RunID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ParentRunID != "parent-run" {
+		t.Errorf("ParentRunID = %q, want parent-run", meta.ParentRunID)
+	}
+}
@@ -1,6 +1,13 @@
 package runner
 
-import "strings"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
+)
 
 // buildIterationPrompt returns the prompt that should be passed to the agent
 // for one iteration. When there are no reminders, the base prompt is returned
@@ -21,3 +28,37 @@ func buildIterationPrompt(base string, reminders []Reminder) string {
 	}
 	return b.String()
 }
+
+// errRenderPrompt wraps PromptTemplate errors so the run's failure is
+// categorized as a prompt problem rather than an agent one.
+var errRenderPrompt = errors.New("rendering prompt")
+
+// basePrompt returns the prompt for the current iteration before reminders
+// are appended: PromptTemplate rendered for this attempt, or Prompt as-is.
+// The first attempt of iteration 1 reuses Prompt, the template's first
+// render, so the agent gets exactly what effective-prompt.md records.
+// ctx bounds the commands the template runs.
+func (r *Runner) basePrompt(ctx context.Context) (string, error) {
+	if r.cfg.PromptTemplate == nil {
+		return r.cfg.Prompt, nil
+	}
+	if r.iteration == 1 && r.lastOutcome == "" && r.cfg.Prompt != "" {
+		return r.cfg.Prompt, nil
+	}
+	var dir string
+	if r.env != nil {
+		dir = r.env.WorkDir
+	}
+	text, err := r.cfg.PromptTemplate.Render(ctx, prompt.Iteration{
+		RunID:           r.runID,
+		Number:          r.iteration,
+		MaxIterations:   r.cfg.MaxIterations,
+		Agent:           r.cfg.Agent,
+		PreviousOutcome: r.lastOutcome,
+		Dir:             dir,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errRenderPrompt, err)
+	}
+	return text, nil
+}
@@ -1,8 +1,16 @@
 package runner
 
 import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/events"
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
 )
 
 func TestBuildIterationPrompt_NoReminders_ReturnsBase(t *testing.T) {
@@ -73,3 +81,105 @@ func TestBuildIterationPrompt_BaseWithTrailingNewline(t *testing.T) {
 		t.Errorf("section header missing: %q", got)
 	}
 }
+
+func TestRun_RendersPromptTemplateEachIteration(t *testing.T) {
+	planPath := filepath.Join(t.TempDir(), "PLAN.md")
+	if err := os.WriteFile(planPath, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := prompt.PlanTemplate(planPath,
+		"{{.RunID}} {{.Iteration}}/{{.MaxIterations}} {{.Agent}} prev={{.PreviousOutcome}} plan={{.PlanContent}}", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first iteration edits the plan; the second must see the edit.
+	fa := &flexAgent{behaviors: []agentBehavior{
+		func(context.Context, func(events.Event)) (string, error) {
+			return "", os.WriteFile(planPath, []byte("v2"), 0644)
+		},
+		func(context.Context, func(events.Event)) (string, error) {
+			return completionMarker, nil
+		},
+	}}
+	r := New(RunConfig{
+		Agent:          "test",
+		Prompt:         "first render",
+		PromptTemplate: tmpl,
+		MaxIterations:  3,
+		RunsDir:        t.TempDir(),
+		RunID:          "run-1",
+	})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+
+	if result := r.Run(context.Background()); result.Status != StatusCompleted {
+		t.Fatalf("status = %s, want completed", result.Status)
+	}
+	// Iteration 1 gets the caller's first render rather than a second one.
+	want := []string{
+		"first render",
+		"run-1 2/3 test prev=ok plan=v2",
+	}
+	if strings.Join(fa.prompts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("prompts = %q, want %q", fa.prompts, want)
+	}
+}
+
+func TestRun_RetryRerendersFirstIterationPrompt(t *testing.T) {
+	tmpl, err := prompt.DefaultTemplate("{{.Iteration}} prev={{.PreviousOutcome}}", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fa := &flexAgent{behaviors: []agentBehavior{
+		func(context.Context, func(events.Event)) (string, error) {
+			return "", &agent.Error{Kind: agent.ErrorKindExit, ExitCode: 1}
+		},
+		func(context.Context, func(events.Event)) (string, error) {
+			return completionMarker, nil
+		},
+	}}
+	r := New(RunConfig{
+		Agent:          "test",
+		Prompt:         "first render",
+		PromptTemplate: tmpl,
+		RunsDir:        t.TempDir(),
+		Retry:          RetryPolicy{MaxRetries: 1},
+	})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+
+	if result := r.Run(context.Background()); result.Status != StatusCompleted {
+		t.Fatalf("status = %s, want completed", result.Status)
+	}
+	want := []string{"first render", "1 prev=error"}
+	if strings.Join(fa.prompts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("prompts = %q, want %q", fa.prompts, want)
+	}
+}
+
+func TestRun_PromptTemplateErrorFailsRun(t *testing.T) {
+	tmpl, err := prompt.DefaultTemplate(`{{readFile "does-not-exist.md"}}`, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fa := &flexAgent{}
+	r := New(RunConfig{
+		Agent:          "test",
+		PromptTemplate: tmpl,
+		RunsDir:        t.TempDir(),
+	})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+
+	result := r.Run(context.Background())
+	if result.Status != StatusFailed {
+		t.Fatalf("status = %s, want failed", result.Status)
+	}
+	if fa.callCount != 0 {
+		t.Errorf("agent called %d times, want 0", fa.callCount)
+	}
+	if f := result.Failure; f == nil || f.Category != FailurePrompt || !strings.Contains(f.Message, "rendering prompt") {
+		t.Errorf("failure = %+v, want a prompt failure", f)
+	}
+}
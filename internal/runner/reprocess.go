@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
+)
+
+// ReprocessResult describes a rewritten events.jsonl.
+type ReprocessResult struct {
+	Events     int    // events written
+	Iterations int    // iterations found in the raw log
+	Backup     string // path the previous events.jsonl was moved to; "" if there was none
+	Inferred   bool   // raw log had no iteration markers, so boundaries were guessed
+	Pruned     int    // raw log segments deleted during the run; events before them are lost
+}
+
+// Reprocess re-derives a run's events.jsonl from its raw-output.log using
+// the current event mapper for the run's agent. Iteration events come from
+// the markers the runner writes into the raw log; runs recorded before
+// those markers existed get heuristic boundaries instead (see
+// agent.Replay). Rotated and compressed raw logs are read whole; segments
+// deleted under storage.raw-log-max-files are gone, which the result
+// reports in Pruned. The previous events.jsonl is kept next to the new one
+// as events.jsonl.<timestamp>.bak (.bak.gz or .bak.zst if the run was
+// compressed, in which case the new file is compressed the same way).
+// meta.json is left untouched.
+func Reprocess(runsDir, runID string) (ReprocessResult, error) {
+	dir := filepath.Join(runsDir, runID)
+	meta, err := readMetaJSON(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		return ReprocessResult{}, err
+	}
+	if meta.Status == string(StatusRunning) {
+		return ReprocessResult{}, fmt.Errorf("run %s is still running", runID)
+	}
+
+	raw, err := artifact.ReadFile(filepath.Join(dir, "raw-output.log"))
+	if err != nil {
+		return ReprocessResult{}, fmt.Errorf("reading raw-output.log: %w", err)
+	}
+	data, texts, err := replayEvents(meta.Agent, raw)
+	if err != nil {
+		return ReprocessResult{}, err
+	}
+
+	result := ReprocessResult{
+		Events:     bytes.Count(data, []byte{'\n'}),
+		Iterations: len(texts),
+		Inferred:   !bytes.Contains(raw, []byte(agent.MarkerType)),
+		Pruned:     meta.RawLogPruned,
+	}
+
+	// Write the new log beside the old one first so a failure leaves the
+	// original in place.
+	eventsPath := filepath.Join(dir, "events.jsonl")
+	tmpPath := eventsPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return ReprocessResult{}, fmt.Errorf("writing events.jsonl: %w", err)
+	}
+	var (
+		format     artifact.Format
+		compressed bool
+	)
+	if current, err := artifact.Resolve(eventsPath); err == nil {
+		format, compressed = artifact.FormatOf(current)
+		result.Backup = fmt.Sprintf("%s.%s.bak", eventsPath, time.Now().Format("20060102T150405"))
+		if compressed {
+			result.Backup += format.Ext()
+		}
+		if err := os.Rename(current, result.Backup); err != nil {
+			os.Remove(tmpPath)
+			return ReprocessResult{}, fmt.Errorf("backing up events.jsonl: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, eventsPath); err != nil {
+		return ReprocessResult{}, fmt.Errorf("replacing events.jsonl: %w", err)
+	}
+	// Keep a compressed run compressed.
+	if compressed {
+		if _, _, err := artifact.CompressFile(eventsPath, format); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,189 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+)
+
+func TestRun_WritesIterationMarkersToRawLog(t *testing.T) {
+	fa := &fakeAgent{
+		responses: []fakeResponse{
+			{text: "not yet"},
+			{text: completionMarker},
+		},
+	}
+	r := newTestRunnerWithAgent(t, fa, RunConfig{Agent: "test", Prompt: "markers"})
+	r.Run(context.Background())
+
+	data, err := os.ReadFile(filepath.Join(r.cfg.RunsDir, r.runID, "raw-output.log"))
+	if err != nil {
+		t.Fatalf("reading raw-output.log: %v", err)
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var m agent.Marker
+		if err := json.Unmarshal([]byte(line), &m); err != nil || m.Type != agent.MarkerType {
+			t.Fatalf("unexpected raw-output.log line %q", line)
+		}
+		if m.Event.Timestamp == "" {
+			t.Errorf("marker %q has no timestamp", line)
+		}
+		ids = append(ids, m.Event.ID)
+	}
+	if strings.Join(ids, ",") != "iteration-1,iteration-2" {
+		t.Errorf("marker IDs = %v, want iteration-1,iteration-2", ids)
+	}
+}
+
+func TestRun_RecordsRetryMarkers(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 1}, nil)
+	r.Run(context.Background())
+
+	dir := filepath.Join(r.cfg.RunsDir, r.runID)
+	data, err := os.ReadFile(filepath.Join(dir, "raw-output.log"))
+	if err != nil {
+		t.Fatalf("reading raw-output.log: %v", err)
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var m agent.Marker
+		if err := json.Unmarshal([]byte(line), &m); err == nil && m.Type == agent.MarkerType {
+			ids = append(ids, m.Event.ID)
+		}
+	}
+	want := "iteration-1,retry-1-1:exit,iteration-1"
+	if strings.Join(ids, ",") != want {
+		t.Errorf("marker IDs = %v, want %s", ids, want)
+	}
+
+	got := readEventTypes(t, filepath.Join(dir, "events.jsonl"))
+	if len(got) != 3 || got[1] != string(EventIterationRetry) {
+		t.Errorf("events.jsonl = %v, want the retry between both attempts", got)
+	}
+}
+
+// writeReprocessRun creates a finished pi run with the given raw log and a
+// stale events.jsonl.
+func writeReprocessRun(t *testing.T, status Status, raw string) (runsDir, dir string) {
+	t.Helper()
+	runsDir = t.TempDir()
+	dir = filepath.Join(runsDir, "run-1")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeMetaJSON(filepath.Join(dir, "meta.json"), RunMeta{RunID: "run-1", Agent: "pi", Status: string(status)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "raw-output.log"), []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return runsDir, dir
+}
+
+func readEventTypes(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("bad event line %q: %v", line, err)
+		}
+		if ev.Type == EventIteration {
+			got = append(got, ev.ID+"@"+ev.Timestamp)
+			continue
+		}
+		got = append(got, string(ev.Type))
+	}
+	return got
+}
+
+func TestReprocess_RewritesEventsWithBackup(t *testing.T) {
+	marker := func(id, ts string) string {
+		line, err := agent.EncodeMarker(Event{Type: EventIteration, ID: id, Timestamp: ts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(line)
+	}
+	raw := marker("iteration-1", "2026-03-02T10:00:00Z") +
+		`{"type":"session","id":"s1"}` + "\n" +
+		`{"type":"agent_end"}` + "\n" +
+		marker("iteration-2", "2026-03-02T10:05:00Z") +
+		`{"type":"session","id":"s2"}` + "\n"
+	runsDir, dir := writeReprocessRun(t, StatusCompleted, raw)
+
+	result, err := Reprocess(runsDir, "run-1")
+	if err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if result.Events != 5 || result.Iterations != 2 || result.Inferred {
+		t.Errorf("result = %+v, want 5 events in 2 marked iterations", result)
+	}
+
+	got := readEventTypes(t, filepath.Join(dir, "events.jsonl"))
+	want := []string{"iteration-1@2026-03-02T10:00:00Z", "session", "agent_end", "iteration-2@2026-03-02T10:05:00Z", "session"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events.jsonl = %v, want %v", got, want)
+	}
+
+	backup, err := os.ReadFile(result.Backup)
+	if err != nil || string(backup) != "stale\n" {
+		t.Errorf("backup %q = %q, %v; want the previous events.jsonl", result.Backup, backup, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events.jsonl.tmp")); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestReprocess_InfersBoundariesWithoutMarkers(t *testing.T) {
+	runsDir, dir := writeReprocessRun(t, StatusFailed,
+		`{"type":"session","id":"s1"}`+"\n"+`{"type":"session","id":"s2"}`+"\n")
+
+	result, err := Reprocess(runsDir, "run-1")
+	if err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if !result.Inferred || result.Iterations != 2 {
+		t.Errorf("result = %+v, want 2 inferred iterations", result)
+	}
+	got := readEventTypes(t, filepath.Join(dir, "events.jsonl"))
+	if strings.Join(got, ",") != "iteration-1@,session,iteration-2@,session" {
+		t.Errorf("events.jsonl = %v", got)
+	}
+}
+
+func TestReprocess_Errors(t *testing.T) {
+	runsDir, dir := writeReprocessRun(t, StatusRunning, "")
+	if _, err := Reprocess(runsDir, "run-1"); err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Errorf("err = %v, want still running", err)
+	}
+
+	runsDir, dir = writeReprocessRun(t, StatusCompleted, "")
+	if err := os.Remove(filepath.Join(dir, "raw-output.log")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reprocess(runsDir, "run-1"); err == nil {
+		t.Error("Reprocess without raw-output.log succeeded")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "events.jsonl")); string(data) != "stale\n" {
+		t.Errorf("failed reprocess modified events.jsonl: %q", data)
+	}
+
+	if _, err := Reprocess(t.TempDir(), "missing"); err == nil {
+		t.Error("Reprocess of missing run succeeded")
+	}
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+)
+
+// RetryClass groups iteration failures that share a retry budget.
+type RetryClass string
+
+const (
+	RetryCrash   RetryClass = "crash"   // agent process killed or vanished mid-iteration
+	RetryExit    RetryClass = "exit"    // agent process exited with a non-zero status
+	RetryScanner RetryClass = "scanner" // reading the agent's output stream failed
+	RetryNetwork RetryClass = "network" // stderr looks like a transient network/API problem
+	RetryTimeout RetryClass = "timeout" // inactivity watchdog fired
+)
+
+// defaultTimeoutRetries is how many consecutive inactivity timeouts are
+// retried before the run is declared stuck when the policy does not say
+// otherwise.
+const defaultTimeoutRetries = 1
+
+// RetryPolicy controls how the runner reacts to failed iterations.
+//
+// The zero value preserves the historical behaviour: agent errors fail the
+// run immediately and inactivity timeouts are retried once.
+type RetryPolicy struct {
+	// MaxRetries is the number of consecutive retries allowed for any
+	// retryable agent-error class without an entry in Limits.
+	MaxRetries int
+	// Backoff is the delay before the first retry. Each further consecutive
+	// retry of the same class doubles it, up to MaxBackoff. 0 retries
+	// immediately.
+	Backoff time.Duration
+	// MaxBackoff caps the exponential backoff. 0 means uncapped.
+	MaxBackoff time.Duration
+	// Retryable restricts which agent-error classes may be retried. nil
+	// means every classified agent error (crash, exit, scanner, network).
+	// Timeouts are governed solely by Limits[RetryTimeout].
+	Retryable []RetryClass
+	// Limits overrides MaxRetries per class. Limits[RetryTimeout] defaults
+	// to 1 when absent.
+	Limits map[RetryClass]int
+}
+
+// limit returns how many consecutive retries the policy allows for class.
+func (p RetryPolicy) limit(class RetryClass) int {
+	if n, ok := p.Limits[class]; ok {
+		return n
+	}
+	if class == RetryTimeout {
+		return defaultTimeoutRetries
+	}
+	if !p.retryable(class) {
+		return 0
+	}
+	return p.MaxRetries
+}
+
+// retryable reports whether the policy allows class to be retried at all.
+func (p RetryPolicy) retryable(class RetryClass) bool {
+	if class == "" {
+		return false
+	}
+	if class == RetryTimeout || p.Retryable == nil {
+		return true
+	}
+	for _, c := range p.Retryable {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the backoff before retry number attempt (1-based).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.Backoff <= 0 || attempt < 1 {
+		return 0
+	}
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// transientNetworkPatterns are lower-cased stderr fragments that indicate a
+// failure outside the agent's control, typically worth retrying after a
+// short wait.
+var transientNetworkPatterns = []string{
+	"connection reset",
+	"connection refused",
+	"econnreset",
+	"econnrefused",
+	"etimedout",
+	"eai_again",
+	"socket hang up",
+	"network is unreachable",
+	"temporary failure in name resolution",
+	"no such host",
+	"i/o timeout",
+	"tls handshake timeout",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+	"overloaded",
+}
+
+// classifyError maps an iteration error to its retry class. Errors that are
+// never worth retrying (the agent binary is missing, protocol violations,
+// unknown errors) return "".
+func classifyError(err error) RetryClass {
+	var ae *agent.Error
+	if !errors.As(err, &ae) {
+		return ""
+	}
+	if ae.Kind != agent.ErrorKindStart && isTransientNetwork(ae.Stderr) {
+		return RetryNetwork
+	}
+	switch ae.Kind {
+	case agent.ErrorKindCrash:
+		return RetryCrash
+	case agent.ErrorKindExit:
+		return RetryExit
+	case agent.ErrorKindOutput:
+		return RetryScanner
+	}
+	return ""
+}
+
+// isTransientNetwork reports whether stderr matches a known transient
+// network failure.
+func isTransientNetwork(stderr string) bool {
+	lower := strings.ToLower(stderr)
+	for _, p := range transientNetworkPatterns {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepCtx waits for d or until ctx is cancelled. It reports whether the
+// full duration elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
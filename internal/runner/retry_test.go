@@ -0,0 +1,261 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
+
+// ---------------------------------------------------------------------------
+// classifyError
+// ---------------------------------------------------------------------------
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want RetryClass
+	}{
+		{"plain error", errors.New("boom"), ""},
+		{"start failure", &agent.Error{Kind: agent.ErrorKindStart, Err: errors.New("no such file")}, ""},
+		{"protocol error", &agent.Error{Kind: agent.ErrorKindProtocol, Err: errors.New("bad frame")}, ""},
+		{"non-zero exit", &agent.Error{Kind: agent.ErrorKindExit, ExitCode: 1, Err: errors.New("exit status 1")}, RetryExit},
+		{"crash", &agent.Error{Kind: agent.ErrorKindCrash, Err: errors.New("signal: killed")}, RetryCrash},
+		{"scanner", &agent.Error{Kind: agent.ErrorKindOutput, Err: errors.New("token too long")}, RetryScanner},
+		{"network stderr on exit", &agent.Error{Kind: agent.ErrorKindExit, Stderr: "Error: read ECONNRESET", Err: errors.New("exit status 1")}, RetryNetwork},
+		{"network stderr on crash", &agent.Error{Kind: agent.ErrorKindCrash, Stderr: "API overloaded, try later", Err: errors.New("killed")}, RetryNetwork},
+		{"network stderr on start ignored", &agent.Error{Kind: agent.ErrorKindStart, Stderr: "connection refused", Err: errors.New("start")}, ""},
+		{"wrapped", fmt.Errorf("kiro: %w", &agent.Error{Kind: agent.ErrorKindExit, Err: errors.New("x")}), RetryExit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// RetryPolicy
+// ---------------------------------------------------------------------------
+
+func TestRetryPolicy_ZeroValueDefaults(t *testing.T) {
+	var p RetryPolicy
+	if got := p.limit(RetryTimeout); got != 1 {
+		t.Errorf("timeout limit = %d, want 1", got)
+	}
+	for _, c := range []RetryClass{RetryCrash, RetryExit, RetryScanner, RetryNetwork} {
+		if got := p.limit(c); got != 0 {
+			t.Errorf("%s limit = %d, want 0", c, got)
+		}
+	}
+	if p.retryable("") {
+		t.Error("unclassified errors must never be retryable")
+	}
+}
+
+func TestRetryPolicy_RetryableAndLimits(t *testing.T) {
+	p := RetryPolicy{
+		MaxRetries: 3,
+		Retryable:  []RetryClass{RetryNetwork, RetryExit},
+		Limits:     map[RetryClass]int{RetryExit: 1, RetryTimeout: 0},
+	}
+	if got := p.limit(RetryNetwork); got != 3 {
+		t.Errorf("network limit = %d, want 3 (MaxRetries)", got)
+	}
+	if got := p.limit(RetryExit); got != 1 {
+		t.Errorf("exit limit = %d, want 1 (per-class)", got)
+	}
+	if got := p.limit(RetryCrash); got != 0 {
+		t.Errorf("crash limit = %d, want 0 (not retryable)", got)
+	}
+	if got := p.limit(RetryTimeout); got != 0 {
+		t.Errorf("timeout limit = %d, want 0 (explicit)", got)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.delay(i + 1); got != w {
+			t.Errorf("delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+	if got := (RetryPolicy{}).delay(3); got != 0 {
+		t.Errorf("zero backoff delay = %s, want 0", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Run loop integration
+// ---------------------------------------------------------------------------
+
+func exitFailure(stderr string) agentBehavior {
+	return func(context.Context, func(events.Event)) (string, error) {
+		return "", &agent.Error{Kind: agent.ErrorKindExit, ExitCode: 1, Stderr: stderr, Err: errors.New("agent exited with error: exit status 1")}
+	}
+}
+
+func completes(context.Context, func(events.Event)) (string, error) {
+	return completionMarker, nil
+}
+
+func newRetryTestRunner(t *testing.T, fa *flexAgent, policy RetryPolicy, ch chan Event) *Runner {
+	t.Helper()
+	r := New(RunConfig{
+		Agent:     "test",
+		Prompt:    "test",
+		RunsDir:   t.TempDir(),
+		EventChan: ch,
+		Retry:     policy,
+	})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+	return r
+}
+
+func TestRun_AgentError_NotRetriedByDefault(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{}, nil)
+
+	result := r.Run(context.Background())
+	if result.Status != StatusFailed {
+		t.Errorf("status = %s, want %s", result.Status, StatusFailed)
+	}
+	if fa.callCount != 1 {
+		t.Errorf("agent called %d times, want 1", fa.callCount)
+	}
+}
+
+func TestRun_AgentError_RetriedWithinLimit(t *testing.T) {
+	ch := make(chan Event, 100)
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 2}, ch)
+
+	result := r.Run(context.Background())
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, want %s (error %q)", result.Status, StatusCompleted, result.Error)
+	}
+	if result.Iterations != 1 {
+		t.Errorf("iterations = %d, want 1 (retries are not counted)", result.Iterations)
+	}
+	if fa.callCount != 3 {
+		t.Errorf("agent called %d times, want 3", fa.callCount)
+	}
+
+	var retryIDs []string
+	for len(ch) > 0 {
+		if ev := <-ch; ev.Type == EventIterationRetry {
+			retryIDs = append(retryIDs, ev.ID)
+		}
+	}
+	want := []string{"retry-1-1:exit", "retry-1-2:exit"}
+	if strings.Join(retryIDs, ",") != strings.Join(want, ",") {
+		t.Errorf("retry events = %v, want %v", retryIDs, want)
+	}
+}
+
+func TestRun_AgentError_ExhaustsLimit(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 5, Limits: map[RetryClass]int{RetryExit: 1}}, nil)
+
+	result := r.Run(context.Background())
+	if result.Status != StatusFailed {
+		t.Errorf("status = %s, want %s", result.Status, StatusFailed)
+	}
+	if fa.callCount != 2 {
+		t.Errorf("agent called %d times, want 2", fa.callCount)
+	}
+}
+
+func TestRun_AgentError_ClassNotRetryable(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 3, Retryable: []RetryClass{RetryNetwork}}, nil)
+
+	result := r.Run(context.Background())
+	if result.Status != StatusFailed {
+		t.Errorf("status = %s, want %s", result.Status, StatusFailed)
+	}
+}
+
+func TestRun_AgentError_NetworkRetriedWhenExitIsNot(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure("fetch failed: ETIMEDOUT"), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 3, Retryable: []RetryClass{RetryNetwork}}, nil)
+
+	result := r.Run(context.Background())
+	if result.Status != StatusCompleted {
+		t.Errorf("status = %s, want %s", result.Status, StatusCompleted)
+	}
+}
+
+func TestRun_RetryCounterResetsAfterSuccess(t *testing.T) {
+	continues := func(context.Context, func(events.Event)) (string, error) { return "not yet", nil }
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), continues, exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 1}, nil)
+
+	result := r.Run(context.Background())
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %s, want %s (error %q)", result.Status, StatusCompleted, result.Error)
+	}
+	if result.Iterations != 2 {
+		t.Errorf("iterations = %d, want 2", result.Iterations)
+	}
+}
+
+func TestRun_RetryBackoffInterruptedByContext(t *testing.T) {
+	fa := &flexAgent{behaviors: []agentBehavior{exitFailure(""), completes}}
+	r := newRetryTestRunner(t, fa, RetryPolicy{MaxRetries: 1, Backoff: time.Hour}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := r.Run(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Run took %s, backoff should stop on cancellation", elapsed)
+	}
+	if result.Status != StatusInterrupted {
+		t.Errorf("status = %s, want %s", result.Status, StatusInterrupted)
+	}
+	if fa.callCount != 1 {
+		t.Errorf("agent called %d times, want 1", fa.callCount)
+	}
+}
+
+func TestRun_InactivityTimeout_ConfigurableLimit(t *testing.T) {
+	hang := func(ctx context.Context, _ func(events.Event)) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	fa := &flexAgent{behaviors: []agentBehavior{hang, hang, hang, hang}}
+	timeout := 30 * time.Millisecond
+	r := New(RunConfig{
+		Agent:             "test",
+		Prompt:            "test",
+		InactivityTimeout: &timeout,
+		RunsDir:           t.TempDir(),
+		Retry:             RetryPolicy{Limits: map[RetryClass]int{RetryTimeout: 2}},
+	})
+	r.iterAgent = fa
+	r.stderr = io.Discard
+
+	result := r.Run(context.Background())
+	if result.Status != StatusStuck {
+		t.Errorf("status = %s, want %s", result.Status, StatusStuck)
+	}
+	if fa.callCount != 3 {
+		t.Errorf("agent called %d times, want 3", fa.callCount)
+	}
+	if !strings.Contains(result.Error, "3 consecutive timeouts") {
+		t.Errorf("error = %q, want it to mention 3 consecutive timeouts", result.Error)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/fsmiamoto/ralfinho/internal/agent"
+	"github.com/fsmiamoto/ralfinho/internal/prompt"
 )
 
 // Status describes the final outcome of a run.
@@ -42,13 +43,47 @@ type RunConfig struct {
 	PromptSource      string            // "prompt", "plan", or "default"
 	PromptFile        string            // path when PromptSource is "prompt"
 	PlanFile          string            // path when PromptSource is "plan"
+	Template          string            // optional: library template name (--template), recorded in meta.json
 	EventChan         chan<- Event      // optional: send events to TUI
 	ControlChan       <-chan ControlMsg // optional: TUI → runner control messages
 	RunID             string            // optional: pre-generated run ID; if empty, a UUID is generated
+	Name              string            // optional: human-readable run name (--name)
+	Tags              []string          // optional: run labels (--tag)
+	Version           string            // ralfinho version recorded in meta.json
+	ParentRunID       string            // optional: run this one re-runs, recorded in meta.json
+	ResumedFrom       string            // optional: run this one continues, recorded in meta.json
+
+	// PromptTemplate, when set, is rendered at the start of every iteration
+	// after the first and for every retry, and sent instead of Prompt, so
+	// plan content, git state and template functions are current. Prompt
+	// should hold its first-iteration render: it is sent for the first
+	// attempt of iteration 1 and is what effective-prompt.md records.
+	PromptTemplate *prompt.Template
+
+	// Reminders are persistent reminders active from the first iteration,
+	// e.g. those a re-run inherits from its parent. They are logged to
+	// operator-log.jsonl like reminders added from the TUI.
+	Reminders []Reminder
 
 	// AgentExtraArgs holds extra arguments to append to the agent subprocess
 	// command line. Sourced from per-agent config file settings.
 	AgentExtraArgs []string
+
+	// AgentProcess overrides the agent binary and sets its environment and
+	// working directory. Sourced from per-agent config file settings.
+	AgentProcess agent.Process
+
+	// Retry decides which failed iterations are retried and how long to wait
+	// between attempts. The zero value retries only inactivity timeouts, once.
+	Retry RetryPolicy
+
+	// Storage controls compression and rotation of the run's artifacts.
+	Storage StoragePolicy
+
+	// Store persists the run's metadata and locates its directory. Nil
+	// means a filesystem store rooted at RunsDir. The caller owns the
+	// store and closes it.
+	Store RunStore
 }
 
 // RunResult is the summary returned after the loop finishes.
@@ -57,28 +92,32 @@ type RunResult struct {
 	Iterations int
 	Status     Status
 	Agent      string
-	Error      string // non-empty when Status == StatusFailed
+	Error      string   // non-empty when Status == StatusFailed
+	Failure    *Failure // structured reason; nil when Status == StatusCompleted
 }
 
 // Runner drives the agent iteration loop.
 type Runner struct {
-	cfg                 RunConfig
-	runID               string
-	stdin               io.Reader // user input (for interactive prompts)
-	stderr              io.Writer // progress output goes here
-	events              []Event   // all parsed events across all iterations
-	eventsFile          *os.File  // events.jsonl
-	rawFile             *os.File  // raw-output.log
-	sessionFile         *os.File  // session.log
-	startedAt           time.Time
-	iteration           int             // current iteration number
-	sessionText         strings.Builder // accumulates assistant text for session.log
-	iterAgent           agent.Agent     // agent implementation for running iterations
-	consecutiveTimeouts int             // reset to 0 on any successful iteration
-	control             *controlState   // live, mutex-guarded mutable parameters
-	restartCount        map[int]int     // attempts logged for each iteration that was restarted
-	operatorLog         *operatorLogger // operator-log.jsonl; nil if file failed to open
-	operatorLogFile     *os.File        // backing file for operatorLog (closed in closeRunFiles)
+	cfg             RunConfig
+	runID           string
+	stdin           io.Reader      // user input (for interactive prompts)
+	stderr          io.Writer      // progress output goes here
+	events          []Event        // all parsed events across all iterations
+	eventsFile      *os.File       // events.jsonl
+	rawFile         io.WriteCloser // raw-output.log
+	sessionFile     *os.File       // session.log
+	startedAt       time.Time
+	iteration       int                // current iteration number
+	sessionText     strings.Builder    // accumulates assistant text for session.log
+	iterAgent       agent.Agent        // agent implementation for running iterations
+	retries         map[RetryClass]int // consecutive retries per class; reset on any successful iteration
+	control         *controlState      // live, mutex-guarded mutable parameters
+	restartCount    map[int]int        // attempts logged for each iteration that was restarted
+	operatorLog     *operatorLogger    // operator-log.jsonl; nil if file failed to open
+	operatorLogFile *os.File           // backing file for operatorLog (closed in closeRunFiles)
+	failure         *Failure           // set once the run ends without completing; written to meta.json
+	env             *RunEnvironment    // captured at start; GitEnd is filled in when the run ends
+	lastOutcome     string             // how the previous attempt ended, for PromptTemplate
 }
 
 // NewRunID generates a new UUID suitable for use as a run ID.
@@ -98,6 +137,7 @@ func New(cfg RunConfig) *Runner {
 		stderr:       os.Stderr,
 		control:      newControlState(cfg.InactivityTimeout),
 		restartCount: make(map[int]int),
+		retries:      make(map[RetryClass]int),
 	}
 }
 
@@ -114,6 +154,7 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 	}
 
 	r.logf("run %s started (agent=%s, max_iterations=%d)\n", r.runID, r.cfg.Agent, r.cfg.MaxIterations)
+	r.env = r.captureEnvironment()
 
 	// Write effective prompt for auditability.
 	if err := r.writeEffectivePrompt(); err != nil {
@@ -126,6 +167,8 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 	// Create empty memory files so the TUI always has something to read.
 	r.initMemoryFiles()
 
+	r.seedReminders()
+
 	// Write initial meta.json so external tools can see the run immediately.
 	r.writeMeta(StatusRunning, 0)
 
@@ -139,11 +182,15 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 		if len(r.cfg.AgentExtraArgs) > 0 {
 			agentOpts = append(agentOpts, agent.WithExtraArgs(r.cfg.AgentExtraArgs))
 		}
+		agentOpts = append(agentOpts, agent.WithProcess(r.cfg.AgentProcess))
 		resolved, err := agent.Resolve(r.cfg.Agent, agentOpts...)
 		if err != nil {
 			r.logf("error: %v\n", err)
 			result.Status = StatusFailed
 			result.Error = err.Error()
+			r.failure = newFailure(FailureAgentStart, err.Error(), 0)
+			result.Failure = r.failure
+			r.env.GitEnd = readGitState(r.env.WorkDir)
 			r.writeMeta(result.Status, result.Iterations)
 			r.closeRunFiles()
 			return result
@@ -158,6 +205,7 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 		if r.cfg.MaxIterations > 0 && result.Iterations > r.cfg.MaxIterations {
 			result.Iterations--
 			result.Status = StatusMaxIterationsReached
+			r.failure = newFailure(FailureBudget, fmt.Sprintf("max iterations (%d) reached", r.cfg.MaxIterations), result.Iterations)
 			r.logf("max iterations (%d) reached\n", r.cfg.MaxIterations)
 			break
 		}
@@ -166,8 +214,9 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 		r.sessionLogf("\n=== Iteration %d ===\n", r.iteration)
 		r.logf("--- iteration %d ---\n", result.Iterations)
 
-		// Send synthetic iteration event to TUI.
-		r.sendEvent(Event{
+		// Record the iteration boundary so replays and exports can split
+		// the event log by iteration.
+		r.recordRunnerEvent(Event{
 			Type:      EventIteration,
 			ID:        fmt.Sprintf("iteration-%d", r.iteration),
 			Timestamp: time.Now().Format(time.RFC3339),
@@ -177,27 +226,41 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 		if err != nil {
 			r.logf("error: %v\n", err)
 			r.sessionLogf("[%s] error: %v\n", r.timestamp(), err)
+			if class := classifyError(err); r.retryAllowed(class) {
+				if !r.waitForRetry(ctx, class) {
+					result.Status = StatusInterrupted
+					r.failure = newFailure(FailureUser, "interrupted while waiting to retry", r.iteration)
+					break
+				}
+				// Don't count the failed iteration.
+				result.Iterations--
+				r.lastOutcome = "error"
+				continue
+			}
 			result.Status = StatusFailed
 			result.Error = err.Error()
+			r.failure = failureFromError(err, r.iteration)
 			r.consumeOneOffsAndEmit()
 			break
 		}
 
 		switch status {
 		case iterComplete:
-			r.consecutiveTimeouts = 0
+			clear(r.retries)
 			result.Status = StatusCompleted
 			r.logf("agent signalled COMPLETE\n")
 			r.consumeOneOffsAndEmit()
 			done = true
 		case iterContinue:
-			r.consecutiveTimeouts = 0
+			clear(r.retries)
+			r.lastOutcome = "ok"
 			r.consumeOneOffsAndEmit()
 		case iterRestart:
-			r.consecutiveTimeouts = 0
+			clear(r.retries)
 			result.Iterations--
+			r.lastOutcome = "restarted"
 			r.restartCount[r.iteration]++
-			r.sendEvent(Event{
+			r.recordRunnerEvent(Event{
 				Type:      EventIterationRestart,
 				ID:        fmt.Sprintf("restart-%d-%d", r.iteration, r.restartCount[r.iteration]),
 				Timestamp: time.Now().Format(time.RFC3339),
@@ -205,24 +268,32 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 			r.logf("restart requested — redoing iteration %d (attempt %d)\n", r.iteration, r.restartCount[r.iteration]+1)
 		case iterInterrupted:
 			result.Status = StatusInterrupted
+			r.failure = newFailure(FailureUser, "interrupted by user", r.iteration)
 			r.consumeOneOffsAndEmit()
 			done = true
 		case iterTimedOut:
 			_, timeout := r.control.watchdogState()
-			r.consecutiveTimeouts++
-			if r.consecutiveTimeouts < 2 {
+			if r.retryAllowed(RetryTimeout) {
 				r.logf("inactivity timeout — retrying iteration\n")
 				r.sessionLogf("[%s] inactivity timeout — retrying iteration\n", r.timestamp())
-				r.sendEvent(Event{
+				r.recordRunnerEvent(Event{
 					Type:      EventInactivityTimeout,
 					ID:        fmt.Sprintf("timeout-%d", r.iteration),
 					Timestamp: time.Now().Format(time.RFC3339),
 				})
+				if !r.waitForRetry(ctx, RetryTimeout) {
+					result.Status = StatusInterrupted
+					r.failure = newFailure(FailureUser, "interrupted while waiting to retry", r.iteration)
+					done = true
+					break
+				}
 				// Don't count the timed-out iteration.
 				result.Iterations--
+				r.lastOutcome = "timeout"
 			} else {
 				result.Status = StatusStuck
-				result.Error = fmt.Sprintf("agent unresponsive for %s (2 consecutive timeouts)", timeout)
+				result.Error = fmt.Sprintf("agent unresponsive for %s (%d consecutive timeouts)", timeout, r.retries[RetryTimeout]+1)
+				r.failure = newFailure(FailureTimeout, result.Error, r.iteration)
 				r.logf("%s\n", result.Error)
 				r.sessionLogf("[%s] %s\n", r.timestamp(), result.Error)
 				done = true
@@ -231,12 +302,43 @@ func (r *Runner) Run(ctx context.Context) RunResult {
 	}
 
 	// Write final meta.json and close persistence files.
+	result.Failure = r.failure
+	r.env.GitEnd = readGitState(r.env.WorkDir)
 	r.writeMeta(result.Status, result.Iterations)
 	r.closeRunFiles()
+	r.compressArtifacts()
 
 	return result
 }
 
+// retryAllowed reports whether another consecutive retry of class fits in
+// the configured policy.
+func (r *Runner) retryAllowed(class RetryClass) bool {
+	if !r.cfg.Retry.retryable(class) {
+		return false
+	}
+	return r.retries[class] < r.cfg.Retry.limit(class)
+}
+
+// waitForRetry records a retry of class, announces it, and sleeps for the
+// policy's backoff. It returns false if ctx was cancelled while waiting.
+func (r *Runner) waitForRetry(ctx context.Context, class RetryClass) bool {
+	r.retries[class]++
+	attempt := r.retries[class]
+	delay := r.cfg.Retry.delay(attempt)
+	if class != RetryTimeout {
+		r.logf("retrying iteration %d after %s error (retry %d/%d, backoff %s)\n",
+			r.iteration, class, attempt, r.cfg.Retry.limit(class), delay)
+		r.sessionLogf("[%s] retrying iteration after %s error (retry %d)\n", r.timestamp(), class, attempt)
+		r.recordRunnerEvent(Event{
+			Type:      EventIterationRetry,
+			ID:        fmt.Sprintf("retry-%d-%d:%s", r.iteration, attempt, class),
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+	return sleepCtx(ctx, delay)
+}
+
 type iterStatus int
 
 const (
@@ -262,6 +364,13 @@ func resolveInactivityTimeout(cfg *time.Duration) time.Duration {
 
 // runIteration runs one invocation of the agent and processes its output.
 func (r *Runner) runIteration(ctx context.Context) (iterStatus, error) {
+	// Render the prompt template before the watchdog starts: its shell
+	// calls may take a while and are not agent inactivity.
+	base, err := r.basePrompt(ctx)
+	if err != nil {
+		return iterContinue, err
+	}
+
 	iterCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -358,7 +467,7 @@ func (r *Runner) runIteration(ctx context.Context) (iterStatus, error) {
 	// Build the prompt for this iteration, appending any reminders. Persistent
 	// reminders survive across iterations; one-offs are consumed by the outer
 	// loop after a non-restart, non-timeout outcome (see Run).
-	prompt := buildIterationPrompt(r.cfg.Prompt, r.control.snapshotReminders())
+	prompt := buildIterationPrompt(base, r.control.snapshotReminders())
 
 	// Delegate to the agent. The onEvent callback persists, stores, and
 	// processes each event as it arrives.
@@ -373,13 +482,7 @@ func (r *Runner) runIteration(ctx context.Context) (iterStatus, error) {
 		}
 
 		// Persist to events.jsonl.
-		if r.eventsFile != nil {
-			if data, merr := json.Marshal(ev); merr == nil {
-				if _, werr := fmt.Fprintln(r.eventsFile, string(data)); werr != nil {
-					r.logf("warning: writing to events.jsonl: %v\n", werr)
-				}
-			}
-		}
+		r.persistEvent(ev)
 
 		// Store in memory.
 		r.events = append(r.events, ev)
@@ -460,6 +563,19 @@ func (r *Runner) handleControlMsg(msg ControlMsg) {
 	}
 }
 
+// seedReminders installs the configured initial reminders as persistent
+// ones, once the operator log is open so their addition is recorded.
+func (r *Runner) seedReminders() {
+	if len(r.cfg.Reminders) == 0 {
+		return
+	}
+	for _, rem := range r.cfg.Reminders {
+		rem.Kind = ReminderPersistent
+		r.operatorLog.logReminderAdd(r.control.addReminder(rem))
+	}
+	r.emitReminderState()
+}
+
 // emitReminderState sends the current reminder snapshot to the TUI so its
 // pending-list mirror stays in sync. It is a synthetic event — not written to
 // events.jsonl, just delivered via the EventChan.
@@ -494,6 +610,44 @@ func reminderIDs(rs []Reminder) []string {
 	return ids
 }
 
+// persistEvent appends ev to events.jsonl, if open.
+func (r *Runner) persistEvent(ev Event) {
+	if r.eventsFile == nil {
+		return
+	}
+	if data, err := json.Marshal(ev); err == nil {
+		if _, werr := fmt.Fprintln(r.eventsFile, string(data)); werr != nil {
+			r.logf("warning: writing to events.jsonl: %v\n", werr)
+		}
+	}
+}
+
+// recordRunnerEvent persists an event the runner synthesized itself to
+// events.jsonl, writes it to raw-output.log as a marker so reprocessing can
+// restore it, and forwards it to the TUI. Like writeRawMarker it must only be
+// called between iterations.
+func (r *Runner) recordRunnerEvent(ev Event) {
+	r.persistEvent(ev)
+	r.writeRawMarker(ev)
+	r.sendEvent(ev)
+}
+
+// writeRawMarker appends a runner-synthesized event to raw-output.log, if
+// open. It must only be called between iterations, while no agent is
+// writing to the file.
+func (r *Runner) writeRawMarker(ev Event) {
+	if r.rawFile == nil {
+		return
+	}
+	line, err := agent.EncodeMarker(ev)
+	if err != nil {
+		return
+	}
+	if _, err := r.rawFile.Write(line); err != nil {
+		r.logf("warning: writing to raw-output.log: %v\n", err)
+	}
+}
+
 // sendEvent sends an event to the TUI channel if configured (non-blocking).
 func (r *Runner) sendEvent(ev Event) {
 	if r.cfg.EventChan != nil {
@@ -632,28 +786,31 @@ func (r *Runner) logToolArgs(args json.RawMessage) {
 	}
 }
 
+// store returns the configured RunStore, defaulting to the filesystem.
+func (r *Runner) store() RunStore {
+	if r.cfg.Store != nil {
+		return r.cfg.Store
+	}
+	return NewFSStore(r.cfg.RunsDir)
+}
+
 func (r *Runner) logf(format string, args ...any) {
 	fmt.Fprintf(r.stderr, format, args...)
 }
 
-// writeEffectivePrompt creates the run directory and writes the prompt text
-// to <runs-dir>/<run-id>/effective-prompt.md for auditability.
+// writeEffectivePrompt creates the run in the store, which writes the
+// prompt text to <run-dir>/effective-prompt.md for auditability.
 func (r *Runner) writeEffectivePrompt() error {
-	dir := filepath.Join(r.cfg.RunsDir, r.runID)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("creating run dir: %w", err)
+	if err := r.store().CreateRun(r.runID, r.cfg.Prompt); err != nil {
+		return err
 	}
-	path := filepath.Join(dir, "effective-prompt.md")
-	if err := os.WriteFile(path, []byte(r.cfg.Prompt), 0644); err != nil {
-		return fmt.Errorf("writing effective prompt: %w", err)
-	}
-	r.logf("effective prompt written to %s\n", path)
+	r.logf("effective prompt written to %s\n", filepath.Join(r.store().RunDir(r.runID), "effective-prompt.md"))
 	return nil
 }
 
 // openRunFiles opens the persistence files for the run.
 func (r *Runner) openRunFiles() {
-	dir := filepath.Join(r.cfg.RunsDir, r.runID)
+	dir := r.store().RunDir(r.runID)
 	// Directory should already exist from writeEffectivePrompt.
 
 	var err error
@@ -664,7 +821,7 @@ func (r *Runner) openRunFiles() {
 		r.eventsFile = nil
 	}
 
-	r.rawFile, err = os.Create(filepath.Join(dir, "raw-output.log"))
+	r.rawFile, err = r.cfg.Storage.createRawLog(filepath.Join(dir, "raw-output.log"))
 	if err != nil {
 		r.logf("warning: could not create raw-output.log: %v\n", err)
 		r.rawFile = nil
@@ -692,7 +849,7 @@ func (r *Runner) openRunFiles() {
 // so the TUI overlay always has something to read, even before the agent writes.
 // Files that already exist (e.g. copied from a resumed session) are left untouched.
 func (r *Runner) initMemoryFiles() {
-	dir := filepath.Join(r.cfg.RunsDir, r.runID)
+	dir := r.store().RunDir(r.runID)
 	for _, name := range []string{"NOTES.md", "PROGRESS.md"} {
 		path := filepath.Join(dir, name)
 		if _, err := os.Stat(path); err == nil {
@@ -742,16 +899,21 @@ func (r *Runner) timestamp() string {
 	return time.Now().Format(time.RFC3339)
 }
 
-// writeMeta writes meta.json to the run directory. For terminal statuses
+// writeMeta records the run's metadata in the store. For terminal statuses
 // (anything other than StatusRunning), EndedAt is populated with the current
 // time. For StatusRunning, EndedAt is left empty to signal the run is still
 // in progress.
 func (r *Runner) writeMeta(status Status, iterations int) {
-	dir := filepath.Join(r.cfg.RunsDir, r.runID)
 	var endedAt string
 	if status != StatusRunning {
 		endedAt = time.Now().Format(time.RFC3339)
 	}
+	// Labels can be edited from the session browser while the run is in
+	// progress, so once meta.json exists its labels win over the config.
+	name, tags, annotation := r.cfg.Name, r.cfg.Tags, ""
+	if prev, err := r.store().ReadMeta(r.runID); err == nil {
+		name, tags, annotation = prev.Name, prev.Tags, prev.Annotation
+	}
 	meta := RunMeta{
 		RunID:               r.runID,
 		StartedAt:           r.startedAt.Format(time.RFC3339),
@@ -763,8 +925,17 @@ func (r *Runner) writeMeta(status Status, iterations int) {
 		PlanFile:            r.cfg.PlanFile,
 		MaxIterations:       r.cfg.MaxIterations,
 		IterationsCompleted: iterations,
-	}
-	if err := writeMetaJSON(filepath.Join(dir, "meta.json"), meta); err != nil {
+		Template:            r.cfg.Template,
+		Name:                name,
+		Tags:                tags,
+		Annotation:          annotation,
+		ResumedFrom:         r.cfg.ResumedFrom,
+		ParentRunID:         r.cfg.ParentRunID,
+		Environment:         r.env,
+		RawLogPruned:        r.rawLogPruned(),
+		Failure:             r.failure,
+	}
+	if err := r.store().WriteMeta(meta); err != nil {
 		r.logf("warning: could not write meta.json: %v\n", err)
 	}
 }
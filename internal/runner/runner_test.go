@@ -676,6 +676,58 @@ func TestRunner_WriteMeta_Terminal_HasEndedAt(t *testing.T) {
 	}
 }
 
+func TestRunner_WriteMeta_KeepsEditedLabels(t *testing.T) {
+	r, _ := newTestRunner(t)
+	r.startedAt = time.Now()
+	r.cfg.Name = "refactor"
+	r.cfg.Tags = []string{"nightly"}
+
+	r.writeMeta(StatusRunning, 0)
+	meta, err := r.store().ReadMeta(r.runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "refactor" || strings.Join(meta.Tags, ",") != "nightly" {
+		t.Fatalf("initial labels = %q %q, want the configured ones", meta.Name, meta.Tags)
+	}
+
+	// Simulate an edit from the session browser mid-run.
+	meta.Tags = []string{"nightly", "flaky"}
+	meta.Annotation = "retry after the API outage"
+	if err := r.store().WriteMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+
+	r.writeMeta(StatusCompleted, 3)
+	meta, err = r.store().ReadMeta(r.runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(meta.Tags, ",") != "nightly,flaky" || meta.Annotation != "retry after the API outage" {
+		t.Errorf("final labels = %q %q, want the edited ones", meta.Tags, meta.Annotation)
+	}
+	if meta.Status != "completed" || meta.IterationsCompleted != 3 {
+		t.Errorf("final meta = %+v", meta)
+	}
+}
+
+func TestValidTag(t *testing.T) {
+	for tag, want := range map[string]bool{
+		"nightly":    true,
+		"team/infra": true,
+		"":           false,
+		"-nightly":   false,
+		"@nightly":   false,
+		"two words":  false,
+		"a,b":        false,
+		"a#b":        false,
+	} {
+		if got := ValidTag(tag); got != want {
+			t.Errorf("ValidTag(%q) = %v, want %v", tag, got, want)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Effective prompt writing
 // ---------------------------------------------------------------------------
@@ -902,6 +954,7 @@ func TestRun_WritesMetaJSON(t *testing.T) {
 		Prompt:       "check meta",
 		PromptSource: "prompt",
 		PromptFile:   "/tmp/test.md",
+		Template:     "review",
 	})
 
 	result := r.Run(context.Background())
@@ -932,6 +985,9 @@ func TestRun_WritesMetaJSON(t *testing.T) {
 	if meta.PromptFile != "/tmp/test.md" {
 		t.Errorf("meta.prompt_file = %q, want %q", meta.PromptFile, "/tmp/test.md")
 	}
+	if meta.Template != "review" {
+		t.Errorf("meta.template = %q, want %q", meta.Template, "review")
+	}
 	if meta.IterationsCompleted != 1 {
 		t.Errorf("meta.iterations_completed = %d, want 1", meta.IterationsCompleted)
 	}
@@ -987,14 +1043,23 @@ func TestRun_PersistsEventsToJSONL(t *testing.T) {
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
-	// 3 agent events emitted
-	if len(lines) != 3 {
-		t.Fatalf("expected 3 lines in events.jsonl, got %d", len(lines))
+	// iteration marker + 3 agent events emitted
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines in events.jsonl, got %d", len(lines))
+	}
+
+	// The iteration boundary is recorded ahead of the agent's events.
+	var marker events.Event
+	if err := json.Unmarshal([]byte(lines[0]), &marker); err != nil {
+		t.Fatalf("parsing iteration event: %v", err)
+	}
+	if marker.Type != events.EventIteration || marker.ID != "iteration-1" {
+		t.Errorf("first event = %s %q, want %s %q", marker.Type, marker.ID, events.EventIteration, "iteration-1")
 	}
 
-	// Verify first event type.
+	// Verify first agent event type.
 	var first events.Event
-	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+	if err := json.Unmarshal([]byte(lines[1]), &first); err != nil {
 		t.Fatalf("parsing first event: %v", err)
 	}
 	if first.Type != events.EventMessageStart {
@@ -0,0 +1,413 @@
+// Package sqlitestore implements runner.RunStore on top of a SQLite index of
+// run metadata, so listing and filtering thousands of runs does not read
+// every meta.json.
+//
+// The index lives in <runs-dir>/index.db next to the run directories. Run
+// artifacts, meta.json included, are still written to the run directories
+// exactly as runner.FSStore writes them: the index is a cache that can be
+// deleted at any time and is rebuilt from the directories on the next
+// listing. Runs created, finished or deleted by other processes are picked
+// up the same way: the index remembers the runs directory's modification
+// time and only rescans it when that changes.
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// IndexFile is the name of the index database inside the runs directory.
+const IndexFile = "index.db"
+
+// schemaVersion is stored in PRAGMA user_version. An index with another
+// version is dropped and rebuilt from the run directories.
+const schemaVersion = 2
+
+const schema = `
+CREATE TABLE runs (
+	run_id        TEXT PRIMARY KEY,
+	meta          TEXT,                       -- meta.json content; NULL when unreadable
+	meta_error    TEXT NOT NULL DEFAULT '',
+	status        TEXT NOT NULL DEFAULT '',
+	agent         TEXT NOT NULL DEFAULT '',
+	prompt_source TEXT NOT NULL DEFAULT '',
+	sort_time     INTEGER NOT NULL            -- unix nanoseconds
+);
+CREATE INDEX runs_by_time ON runs (sort_time DESC, run_id DESC);
+CREATE INDEX runs_by_status ON runs (status, sort_time DESC);
+CREATE INDEX runs_by_agent ON runs (agent, sort_time DESC);
+CREATE TABLE sync_state (
+	id           INTEGER PRIMARY KEY CHECK (id = 1),
+	runs_dir_mod INTEGER NOT NULL             -- runs directory modtime at the last full scan, unix nanoseconds
+);
+`
+
+// settleTime is how old the runs directory's modtime must be before a scan
+// trusts it. Entries added within the filesystem's timestamp granularity of
+// a scan could otherwise leave the modtime unchanged and go unnoticed.
+const settleTime = 2 * time.Second
+
+// Store is a runner.RunStore that keeps run metadata in a SQLite index.
+type Store struct {
+	fs *runner.FSStore
+	db *sql.DB
+}
+
+var _ runner.RunStore = (*Store)(nil)
+
+// Open opens the index in runsDir, creating the directory and the index as
+// needed.
+func Open(runsDir string) (*Store, error) {
+	if err := os.MkdirAll(runsDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating runs directory: %w", err)
+	}
+	// A persistent rollback journal keeps the index's own writes from
+	// adding or removing files in the runs directory, which would change
+	// the modtime sync relies on.
+	dsn := "file:" + filepath.Join(runsDir, IndexFile) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(TRUNCATE)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening run index: %w", err)
+	}
+	s := &Store{fs: runner.NewFSStore(runsDir), db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening run index: %w", err)
+	}
+	return s, nil
+}
+
+// migrate creates the schema, replacing an index built by another version.
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version == schemaVersion {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		"DROP TABLE IF EXISTS runs",
+		"DROP TABLE IF EXISTS sync_state",
+		schema,
+		fmt.Sprintf("PRAGMA user_version = %d", schemaVersion),
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) RunDir(runID string) string { return s.fs.RunDir(runID) }
+
+func (s *Store) CreateRun(runID, prompt string) error {
+	return s.fs.CreateRun(runID, prompt)
+}
+
+// WriteMeta writes meta.json and updates the index.
+func (s *Store) WriteMeta(meta runner.RunMeta) error {
+	if err := s.fs.WriteMeta(meta); err != nil {
+		return err
+	}
+	run := runner.StoredRun{RunID: meta.RunID, Meta: meta, HasMeta: true}
+	if startedAt, ok := runner.ParseMetaTime(meta.StartedAt); ok {
+		run.SortTime = startedAt
+	} else if info, err := os.Stat(s.RunDir(meta.RunID)); err == nil {
+		run.SortTime = info.ModTime()
+	}
+	if err := upsert(s.db, run); err != nil {
+		return fmt.Errorf("indexing run %s: %w", meta.RunID, err)
+	}
+	return nil
+}
+
+// ReadMeta returns the indexed metadata, falling back to meta.json for runs
+// that have not been indexed yet.
+func (s *Store) ReadMeta(runID string) (runner.RunMeta, error) {
+	var data sql.NullString
+	err := s.db.QueryRow("SELECT meta FROM runs WHERE run_id = ?", runID).Scan(&data)
+	if err == nil && data.Valid {
+		var meta runner.RunMeta
+		if err := json.Unmarshal([]byte(data.String), &meta); err == nil {
+			return meta, nil
+		}
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return runner.RunMeta{}, fmt.Errorf("reading run index: %w", err)
+	}
+	return s.fs.ReadMeta(runID)
+}
+
+// ListRuns brings the index up to date with the runs directory (see sync)
+// and answers q from it.
+func (s *Store) ListRuns(q runner.RunQuery) ([]runner.StoredRun, error) {
+	if err := s.sync(); err != nil {
+		return nil, err
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	for _, f := range []struct{ column, value string }{
+		{"agent", q.Agent},
+		{"status", q.Status},
+		{"prompt_source", q.PromptSource},
+	} {
+		if f.value != "" {
+			where = append(where, "meta IS NOT NULL AND "+f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "sort_time >= ?")
+		args = append(args, q.Since.UnixNano())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "sort_time < ?")
+		args = append(args, q.Until.UnixNano())
+	}
+
+	query := "SELECT run_id, meta, meta_error, sort_time FROM runs"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY sort_time DESC, run_id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying run index: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []runner.StoredRun
+	for rows.Next() {
+		var (
+			run      runner.StoredRun
+			meta     sql.NullString
+			sortTime int64
+		)
+		if err := rows.Scan(&run.RunID, &meta, &run.MetaError, &sortTime); err != nil {
+			return nil, fmt.Errorf("querying run index: %w", err)
+		}
+		run.Dir = s.RunDir(run.RunID)
+		run.SortTime = time.Unix(0, sortTime)
+		if meta.Valid {
+			if err := json.Unmarshal([]byte(meta.String), &run.Meta); err != nil {
+				run.MetaError = fmt.Sprintf("parsing indexed meta: %v", err)
+			} else {
+				run.HasMeta = true
+			}
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying run index: %w", err)
+	}
+	return runs, nil
+}
+
+// sync brings the index up to date with the runs directory. Runs whose
+// metadata may have changed since they were indexed (still running, or
+// unreadable) are always re-read. The directory itself is only rescanned —
+// indexing new runs and dropping deleted ones — when its modtime differs
+// from the one recorded at the last scan.
+func (s *Store) sync() error {
+	var dirMod int64
+	info, err := os.Stat(s.fs.RunsDir())
+	if err == nil {
+		dirMod = info.ModTime().UnixNano()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("reading runs directory: %w", err)
+	}
+
+	var scanned int64
+	err = s.db.QueryRow("SELECT runs_dir_mod FROM sync_state").Scan(&scanned)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("reading run index: %w", err)
+	}
+	if dirMod != 0 && dirMod == scanned {
+		return s.refreshStale()
+	}
+	return s.scan(dirMod)
+}
+
+// refreshStale re-reads the runs that are still running or whose metadata
+// could not be read, dropping those whose directory is gone.
+func (s *Store) refreshStale() error {
+	rows, err := s.db.Query("SELECT run_id FROM runs WHERE status = ? OR meta_error != ''", string(runner.StatusRunning))
+	if err != nil {
+		return fmt.Errorf("reading run index: %w", err)
+	}
+	var stale []string
+	for rows.Next() {
+		var runID string
+		if err := rows.Scan(&runID); err != nil {
+			rows.Close()
+			return fmt.Errorf("reading run index: %w", err)
+		}
+		stale = append(stale, runID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading run index: %w", err)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("updating run index: %w", err)
+	}
+	defer tx.Rollback()
+	for _, runID := range stale {
+		info, err := os.Stat(s.RunDir(runID))
+		if err != nil {
+			if _, err := tx.Exec("DELETE FROM runs WHERE run_id = ?", runID); err != nil {
+				return fmt.Errorf("updating run index: %w", err)
+			}
+			continue
+		}
+		if err := upsert(tx, s.fs.StatRun(fs.FileInfoToDirEntry(info))); err != nil {
+			return fmt.Errorf("indexing run %s: %w", runID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("updating run index: %w", err)
+	}
+	return nil
+}
+
+// scan indexes run directories the index does not know yet, re-reads stale
+// runs (see refreshStale), and drops runs whose directory is gone. Only
+// those runs' meta.json files are opened. dirMod is the runs directory's
+// modtime, recorded for the next sync unless it is too recent to trust.
+func (s *Store) scan(dirMod int64) error {
+	entries, err := os.ReadDir(s.fs.RunsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading runs directory: %w", err)
+	}
+
+	indexed := make(map[string]bool) // run ID -> needs refresh
+	rows, err := s.db.Query("SELECT run_id, status, meta_error FROM runs")
+	if err != nil {
+		return fmt.Errorf("reading run index: %w", err)
+	}
+	for rows.Next() {
+		var runID, status, metaError string
+		if err := rows.Scan(&runID, &status, &metaError); err != nil {
+			rows.Close()
+			return fmt.Errorf("reading run index: %w", err)
+		}
+		indexed[runID] = status == string(runner.StatusRunning) || metaError != ""
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading run index: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("updating run index: %w", err)
+	}
+	defer tx.Rollback()
+
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		seen[entry.Name()] = true
+		if refresh, ok := indexed[entry.Name()]; ok && !refresh {
+			continue
+		}
+		if err := upsert(tx, s.fs.StatRun(entry)); err != nil {
+			return fmt.Errorf("indexing run %s: %w", entry.Name(), err)
+		}
+	}
+	for runID := range indexed {
+		if seen[runID] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM runs WHERE run_id = ?", runID); err != nil {
+			return fmt.Errorf("updating run index: %w", err)
+		}
+	}
+
+	if time.Since(time.Unix(0, dirMod)) < settleTime {
+		dirMod = 0
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO sync_state (id, runs_dir_mod) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET runs_dir_mod = excluded.runs_dir_mod`, dirMod); err != nil {
+		return fmt.Errorf("updating run index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("updating run index: %w", err)
+	}
+	return nil
+}
+
+// DeleteRun removes the run directory and its index entry.
+func (s *Store) DeleteRun(runID string) error {
+	if err := s.fs.DeleteRun(runID); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("DELETE FROM runs WHERE run_id = ?", runID); err != nil {
+		return fmt.Errorf("updating run index: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// execer is the part of *sql.DB and *sql.Tx that upsert needs.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func upsert(db execer, run runner.StoredRun) error {
+	var meta sql.NullString
+	if run.HasMeta {
+		data, err := json.Marshal(run.Meta)
+		if err != nil {
+			return err
+		}
+		meta = sql.NullString{String: string(data), Valid: true}
+	}
+	_, err := db.Exec(`
+		INSERT INTO runs (run_id, meta, meta_error, status, agent, prompt_source, sort_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (run_id) DO UPDATE SET
+			meta = excluded.meta,
+			meta_error = excluded.meta_error,
+			status = excluded.status,
+			agent = excluded.agent,
+			prompt_source = excluded.prompt_source,
+			sort_time = excluded.sort_time`,
+		run.RunID, meta, run.MetaError, run.Meta.Status, run.Meta.Agent, run.Meta.PromptSource, run.SortTime.UnixNano())
+	return err
+}
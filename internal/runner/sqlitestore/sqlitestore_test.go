@@ -0,0 +1,214 @@
+package sqlitestore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func openStore(t *testing.T, runsDir string) *Store {
+	t.Helper()
+	s, err := Open(runsDir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func writeRun(t *testing.T, store runner.RunStore, meta runner.RunMeta) {
+	t.Helper()
+	if err := store.CreateRun(meta.RunID, "prompt"); err != nil {
+		t.Fatalf("CreateRun: %v", err)
+	}
+	if err := store.WriteMeta(meta); err != nil {
+		t.Fatalf("WriteMeta: %v", err)
+	}
+}
+
+func listIDs(t *testing.T, store runner.RunStore, q runner.RunQuery) string {
+	t.Helper()
+	runs, err := store.ListRuns(q)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	ids := make([]string, len(runs))
+	for i, r := range runs {
+		ids[i] = r.RunID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestStore_QueriesIndex(t *testing.T) {
+	runsDir := t.TempDir()
+	store := openStore(t, runsDir)
+	writeRun(t, store, runner.RunMeta{RunID: "run-a", Agent: "pi", Status: "completed", PromptSource: "plan", StartedAt: "2026-03-01T10:00:00Z"})
+	writeRun(t, store, runner.RunMeta{RunID: "run-b", Agent: "claude", Status: "failed", PromptSource: "prompt", StartedAt: "2026-03-02T10:00:00Z"})
+	writeRun(t, store, runner.RunMeta{RunID: "run-c", Agent: "pi", Status: "failed", PromptSource: "plan", StartedAt: "2026-03-03T10:00:00Z"})
+
+	// meta.json stays the source of truth next to the index.
+	if _, err := os.Stat(filepath.Join(runsDir, "run-a", "meta.json")); err != nil {
+		t.Errorf("meta.json not written: %v", err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		q    runner.RunQuery
+		want string
+	}{
+		{"all newest first", runner.RunQuery{}, "run-c,run-b,run-a"},
+		{"agent", runner.RunQuery{Agent: "pi"}, "run-c,run-a"},
+		{"status", runner.RunQuery{Status: "failed"}, "run-c,run-b"},
+		{"prompt source", runner.RunQuery{PromptSource: "prompt"}, "run-b"},
+		{"time range", runner.RunQuery{Since: day(2), Until: day(3)}, "run-b"},
+		{"limit", runner.RunQuery{Agent: "pi", Limit: 1}, "run-c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listIDs(t, store, tt.q); got != tt.want {
+				t.Errorf("ListRuns(%+v) = %s, want %s", tt.q, got, tt.want)
+			}
+		})
+	}
+
+	meta, err := store.ReadMeta("run-b")
+	if err != nil || meta.Agent != "claude" || meta.Status != "failed" {
+		t.Errorf("ReadMeta = %+v, %v", meta, err)
+	}
+}
+
+func TestStore_MatchesFSStore(t *testing.T) {
+	runsDir := t.TempDir()
+	fs := runner.NewFSStore(runsDir)
+	writeRun(t, fs, runner.RunMeta{RunID: "run-a", Agent: "pi", Status: "completed", StartedAt: "2026-03-01T10:00:00Z"})
+	writeRun(t, fs, runner.RunMeta{RunID: "run-b", Agent: "kiro", Status: "stuck", StartedAt: "2026-03-01T10:00:00Z"})
+	if err := os.Mkdir(filepath.Join(runsDir, "no-meta"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := openStore(t, runsDir)
+	want, err := fs.ListRuns(runner.RunQuery{})
+	if err != nil {
+		t.Fatalf("FSStore.ListRuns: %v", err)
+	}
+	got, err := store.ListRuns(runner.RunQuery{})
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ListRuns returned %d runs, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.RunID != w.RunID || g.Dir != w.Dir || g.HasMeta != w.HasMeta || g.MetaError != w.MetaError ||
+			!reflect.DeepEqual(g.Meta, w.Meta) || !g.SortTime.Equal(w.SortTime) {
+			t.Errorf("run %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestStore_SyncsWithRunsDirectory(t *testing.T) {
+	runsDir := t.TempDir()
+	store := openStore(t, runsDir)
+	writeRun(t, store, runner.RunMeta{RunID: "run-a", Status: "running", StartedAt: "2026-03-01T10:00:00Z"})
+	writeRun(t, store, runner.RunMeta{RunID: "run-b", Status: "completed", StartedAt: "2026-03-02T10:00:00Z"})
+	if got := listIDs(t, store, runner.RunQuery{}); got != "run-b,run-a" {
+		t.Fatalf("ListRuns = %s", got)
+	}
+
+	// Another process finishes run-a, creates run-c and deletes run-b
+	// without going through the index.
+	fs := runner.NewFSStore(runsDir)
+	writeRun(t, fs, runner.RunMeta{RunID: "run-a", Status: "completed", StartedAt: "2026-03-01T10:00:00Z"})
+	writeRun(t, fs, runner.RunMeta{RunID: "run-c", Status: "failed", StartedAt: "2026-03-03T10:00:00Z"})
+	if err := os.RemoveAll(filepath.Join(runsDir, "run-b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := listIDs(t, store, runner.RunQuery{}); got != "run-c,run-a" {
+		t.Errorf("ListRuns = %s, want run-c,run-a", got)
+	}
+	if got := listIDs(t, store, runner.RunQuery{Status: "completed"}); got != "run-a" {
+		t.Errorf("ListRuns(completed) = %s, want finished run-a", got)
+	}
+
+	if err := store.DeleteRun("run-c"); err != nil {
+		t.Fatalf("DeleteRun: %v", err)
+	}
+	if got := listIDs(t, store, runner.RunQuery{}); got != "run-a" {
+		t.Errorf("ListRuns after delete = %s", got)
+	}
+}
+
+func TestStore_RescansOnlyWhenRunsDirectoryChanges(t *testing.T) {
+	runsDir := t.TempDir()
+	store := openStore(t, runsDir)
+	writeRun(t, store, runner.RunMeta{RunID: "run-a", Status: "running", StartedAt: "2026-03-01T10:00:00Z"})
+	writeRun(t, store, runner.RunMeta{RunID: "run-b", Status: "completed", StartedAt: "2026-03-02T10:00:00Z"})
+
+	// Age the directory so the scan trusts its modtime.
+	settle := func() {
+		t.Helper()
+		old := time.Now().Add(-time.Minute)
+		if err := os.Chtimes(runsDir, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	settle()
+	if got := listIDs(t, store, runner.RunQuery{}); got != "run-b,run-a" {
+		t.Fatalf("ListRuns = %s", got)
+	}
+
+	// Rewriting meta.json in place leaves the directory untouched: the
+	// running run is refreshed, the finished one is served from the index.
+	fs := runner.NewFSStore(runsDir)
+	if err := fs.WriteMeta(runner.RunMeta{RunID: "run-a", Status: "completed", StartedAt: "2026-03-01T10:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteMeta(runner.RunMeta{RunID: "run-b", Status: "failed", StartedAt: "2026-03-02T10:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	settle()
+	if got := listIDs(t, store, runner.RunQuery{Status: "completed"}); got != "run-b,run-a" {
+		t.Errorf("ListRuns(completed) = %s, want run-a refreshed and run-b cached", got)
+	}
+
+	// A new run directory changes the modtime and triggers a rescan.
+	writeRun(t, fs, runner.RunMeta{RunID: "run-c", Status: "failed", StartedAt: "2026-03-03T10:00:00Z"})
+	if got := listIDs(t, store, runner.RunQuery{}); got != "run-c,run-b,run-a" {
+		t.Errorf("ListRuns after new run = %s", got)
+	}
+}
+
+func TestStore_RebuildsIndexFromAnotherVersion(t *testing.T) {
+	runsDir := t.TempDir()
+	writeRun(t, runner.NewFSStore(runsDir), runner.RunMeta{RunID: "run-a", Status: "completed"})
+
+	store := openStore(t, runsDir)
+	if _, err := store.db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store = openStore(t, runsDir)
+	if got := listIDs(t, store, runner.RunQuery{}); got != "run-a" {
+		t.Errorf("ListRuns after rebuild = %s", got)
+	}
+}
+
+func TestStore_MissingRunsDirectoryIsCreated(t *testing.T) {
+	runsDir := filepath.Join(t.TempDir(), "runs")
+	store := openStore(t, runsDir)
+	if got := listIDs(t, store, runner.RunQuery{}); got != "" {
+		t.Errorf("ListRuns = %s, want none", got)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, IndexFile)); err != nil {
+		t.Errorf("index not created: %v", err)
+	}
+}
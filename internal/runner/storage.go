@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"io"
+	"os"
+
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
+)
+
+// StoragePolicy controls how a run's growing artifacts are kept on disk.
+//
+// The zero value keeps the historical behaviour: one uncompressed
+// raw-output.log and events.jsonl per run.
+type StoragePolicy struct {
+	// Compress compresses events.jsonl and raw-output.log with this format
+	// once the run ends; "" keeps them plain. Readers in internal/artifact
+	// decompress them transparently.
+	Compress artifact.Format
+	// RawLogMaxSize rotates raw-output.log to numbered segments every this
+	// many bytes. 0 disables rotation.
+	RawLogMaxSize int64
+	// RawLogMaxFiles caps how many rotated segments are kept, deleting the
+	// oldest. 0 keeps them all.
+	RawLogMaxFiles int
+}
+
+// createRawLog opens raw-output.log, rotating it if the policy asks to.
+func (p StoragePolicy) createRawLog(path string) (io.WriteCloser, error) {
+	if p.RawLogMaxSize > 0 {
+		return artifact.CreateRotating(path, p.RawLogMaxSize, p.RawLogMaxFiles)
+	}
+	return os.Create(path)
+}
+
+// compressArtifacts compresses the finished run's artifacts if the policy
+// asks to. Failures are logged; the run's outcome is unaffected.
+func (r *Runner) compressArtifacts() {
+	if r.cfg.Storage.Compress == "" {
+		return
+	}
+	if _, _, err := artifact.CompressRun(r.store().RunDir(r.runID), r.cfg.Storage.Compress); err != nil {
+		r.logf("warning: compressing run artifacts: %v\n", err)
+	}
+}
+
+// rawLogPruned returns how many rotated raw-output.log segments have been
+// deleted so far under RawLogMaxFiles.
+func (r *Runner) rawLogPruned() int {
+	if rw, ok := r.rawFile.(*artifact.RotatingWriter); ok {
+		return rw.Pruned()
+	}
+	return 0
+}
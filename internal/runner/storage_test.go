@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
+)
+
+func TestRun_StoragePolicyRotatesAndCompresses(t *testing.T) {
+	fa := &fakeAgent{
+		responses: []fakeResponse{
+			{text: "one"},
+			{text: "two"},
+			{text: completionMarker},
+		},
+	}
+	r := newTestRunnerWithAgent(t, fa, RunConfig{
+		Agent:  "pi",
+		Prompt: "storage",
+		// Each iteration marker is larger than this, so every one lands in
+		// its own segment.
+		Storage: StoragePolicy{Compress: artifact.Gzip, RawLogMaxSize: 50},
+	})
+	r.Run(context.Background())
+
+	dir := filepath.Join(r.cfg.RunsDir, r.runID)
+	for _, name := range []string{"events.jsonl.gz", "raw-output.log.gz", "raw-output.log.1.gz", "raw-output.log.2.gz", "meta.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing: %v", name, err)
+		}
+	}
+	for _, name := range []string{"events.jsonl", "raw-output.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("plain %s left behind: %v", name, err)
+		}
+	}
+
+	raw, err := artifact.ReadFile(filepath.Join(dir, "raw-output.log"))
+	if err != nil {
+		t.Fatalf("reading raw log: %v", err)
+	}
+	if n := strings.Count(string(raw), "iteration-"); n != 3 {
+		t.Errorf("raw log holds %d iteration markers across segments, want 3:\n%s", n, raw)
+	}
+
+	// Reprocessing a compressed run keeps it compressed.
+	result, err := Reprocess(r.cfg.RunsDir, r.runID)
+	if err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if result.Iterations != 3 || !strings.HasSuffix(result.Backup, ".bak.gz") {
+		t.Errorf("result = %+v, want 3 iterations and a compressed backup", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events.jsonl.gz")); err != nil {
+		t.Errorf("reprocessed events.jsonl not compressed: %v", err)
+	}
+}
+
+func TestRun_RecordsPrunedRawLogSegments(t *testing.T) {
+	fa := &fakeAgent{
+		responses: []fakeResponse{
+			{text: "one"},
+			{text: "two"},
+			{text: completionMarker},
+		},
+	}
+	r := newTestRunnerWithAgent(t, fa, RunConfig{
+		Agent:   "pi",
+		Prompt:  "storage",
+		Storage: StoragePolicy{Compress: artifact.Zstd, RawLogMaxSize: 50, RawLogMaxFiles: 1},
+	})
+	r.Run(context.Background())
+
+	dir := filepath.Join(r.cfg.RunsDir, r.runID)
+	meta, err := readMetaJSON(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.RawLogPruned != 1 {
+		t.Errorf("RawLogPruned = %d, want 1", meta.RawLogPruned)
+	}
+
+	result, err := Reprocess(r.cfg.RunsDir, r.runID)
+	if err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if result.Pruned != 1 || !strings.HasSuffix(result.Backup, ".bak.zst") {
+		t.Errorf("result = %+v, want 1 pruned segment and a zstd backup", result)
+	}
+}
+
+func TestRun_DefaultStorageLeavesArtifactsPlain(t *testing.T) {
+	fa := &fakeAgent{responses: []fakeResponse{{text: completionMarker}}}
+	r := newTestRunnerWithAgent(t, fa, RunConfig{Agent: "pi", Prompt: "plain"})
+	r.Run(context.Background())
+
+	dir := filepath.Join(r.cfg.RunsDir, r.runID)
+	for _, name := range []string{"events.jsonl", "raw-output.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "raw-output.log.1")); !os.IsNotExist(err) {
+		t.Errorf("raw-output.log rotated without a size cap: %v", err)
+	}
+}
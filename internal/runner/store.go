@@ -0,0 +1,213 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RunStore persists run metadata and locates each run's artifact files.
+//
+// Artifacts that are streamed while a run is in progress (events.jsonl,
+// raw-output.log, session.log, …) are always plain files in RunDir; a store
+// decides how metadata is kept and how runs are listed and queried.
+type RunStore interface {
+	// RunDir returns the directory that holds runID's artifact files.
+	RunDir(runID string) string
+	// CreateRun creates the run's directory and records the effective prompt
+	// it was started with.
+	CreateRun(runID, prompt string) error
+	// WriteMeta records meta for meta.RunID, replacing any previous value.
+	WriteMeta(meta RunMeta) error
+	// ReadMeta returns the metadata last written for runID.
+	ReadMeta(runID string) (RunMeta, error)
+	// ListRuns returns the stored runs matching q, newest first.
+	ListRuns(q RunQuery) ([]StoredRun, error)
+	// DeleteRun removes the run and all its artifacts.
+	DeleteRun(runID string) error
+	// Close releases resources held by the store.
+	Close() error
+}
+
+// RunQuery filters ListRuns. Zero-valued fields match every run.
+type RunQuery struct {
+	Agent        string
+	Status       string
+	PromptSource string
+	Since        time.Time // only runs that started at or after Since
+	Until        time.Time // only runs that started before Until
+	Limit        int       // at most this many runs; 0 = no limit
+}
+
+// Match reports whether run passes q's filters; Limit is not considered.
+// Runs without readable metadata only match queries that filter on nothing
+// but time.
+func (q RunQuery) Match(run StoredRun) bool {
+	if q.Agent != "" || q.Status != "" || q.PromptSource != "" {
+		if !run.HasMeta {
+			return false
+		}
+		if q.Agent != "" && run.Meta.Agent != q.Agent {
+			return false
+		}
+		if q.Status != "" && run.Meta.Status != q.Status {
+			return false
+		}
+		if q.PromptSource != "" && run.Meta.PromptSource != q.PromptSource {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && run.SortTime.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !run.SortTime.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// StoredRun is one entry returned by RunStore.ListRuns. Runs whose metadata
+// is missing or unreadable are still listed, with MetaError set, so callers
+// can show and clean them up.
+type StoredRun struct {
+	RunID     string
+	Dir       string
+	Meta      RunMeta
+	HasMeta   bool
+	MetaError string    // e.g. "meta.json missing"; empty when HasMeta
+	SortTime  time.Time // parsed started_at, falling back to the directory modtime
+}
+
+// SortStoredRuns orders runs newest first, breaking ties by run ID.
+func SortStoredRuns(runs []StoredRun) {
+	sort.SliceStable(runs, func(i, j int) bool {
+		if !runs[i].SortTime.Equal(runs[j].SortTime) {
+			return runs[i].SortTime.After(runs[j].SortTime)
+		}
+		return runs[i].RunID > runs[j].RunID
+	})
+}
+
+// FSStore is the default RunStore: one directory per run under RunsDir with
+// metadata in meta.json. Listing reads every run's meta.json.
+type FSStore struct {
+	runsDir string
+}
+
+// NewFSStore returns a filesystem store rooted at runsDir.
+func NewFSStore(runsDir string) *FSStore {
+	return &FSStore{runsDir: runsDir}
+}
+
+// RunsDir returns the directory the store keeps runs in.
+func (s *FSStore) RunsDir() string { return s.runsDir }
+
+func (s *FSStore) RunDir(runID string) string {
+	return filepath.Join(s.runsDir, runID)
+}
+
+func (s *FSStore) CreateRun(runID, prompt string) error {
+	dir := s.RunDir(runID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating run dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "effective-prompt.md"), []byte(prompt), 0644); err != nil {
+		return fmt.Errorf("writing effective prompt: %w", err)
+	}
+	return nil
+}
+
+func (s *FSStore) WriteMeta(meta RunMeta) error {
+	return writeMetaJSON(filepath.Join(s.RunDir(meta.RunID), "meta.json"), meta)
+}
+
+func (s *FSStore) ReadMeta(runID string) (RunMeta, error) {
+	return readMetaJSON(filepath.Join(s.RunDir(runID), "meta.json"))
+}
+
+// ListRuns reads the metadata of every run directory and filters it in
+// memory. A missing runs directory lists no runs.
+func (s *FSStore) ListRuns(q RunQuery) ([]StoredRun, error) {
+	entries, err := os.ReadDir(s.runsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading runs directory: %w", err)
+	}
+
+	runs := make([]StoredRun, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		run := s.StatRun(entry)
+		if q.Match(run) {
+			runs = append(runs, run)
+		}
+	}
+	SortStoredRuns(runs)
+	if q.Limit > 0 && len(runs) > q.Limit {
+		runs = runs[:q.Limit]
+	}
+	return runs, nil
+}
+
+// StatRun builds the StoredRun for a run directory entry of the runs
+// directory by reading its meta.json.
+func (s *FSStore) StatRun(entry os.DirEntry) StoredRun {
+	run := StoredRun{
+		RunID: entry.Name(),
+		Dir:   s.RunDir(entry.Name()),
+	}
+	if info, err := entry.Info(); err == nil {
+		run.SortTime = info.ModTime()
+	}
+
+	data, err := os.ReadFile(filepath.Join(run.Dir, "meta.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			run.MetaError = "meta.json missing"
+		} else {
+			run.MetaError = fmt.Sprintf("reading meta.json: %v", err)
+		}
+		return run
+	}
+	if err := json.Unmarshal(data, &run.Meta); err != nil {
+		run.MetaError = fmt.Sprintf("parsing meta.json: %v", err)
+		return run
+	}
+	run.HasMeta = true
+	if startedAt, ok := ParseMetaTime(run.Meta.StartedAt); ok {
+		run.SortTime = startedAt
+	}
+	return run
+}
+
+func (s *FSStore) DeleteRun(runID string) error {
+	if runID == "" || filepath.Base(runID) != runID {
+		return errors.New("invalid run ID")
+	}
+	return os.RemoveAll(s.RunDir(runID))
+}
+
+func (s *FSStore) Close() error { return nil }
+
+// ParseMetaTime parses a meta.json timestamp as written by the runner.
+func ParseMetaTime(raw string) (time.Time, bool) {
+	if raw == "" {
+		return time.Time{}, false
+	}
+
+	// RFC3339Nano's reference format uses ".999999999" which means optional
+	// fractional seconds — it parses both "...T10:00:00Z" and
+	// "...T10:00:00.123456789Z" correctly.
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
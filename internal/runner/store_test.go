@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func storedRunIDs(runs []StoredRun) string {
+	ids := make([]string, len(runs))
+	for i, r := range runs {
+		ids[i] = r.RunID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestFSStore_WriteReadAndList(t *testing.T) {
+	store := NewFSStore(t.TempDir())
+	for _, meta := range []RunMeta{
+		{RunID: "run-a", Agent: "pi", Status: "completed", PromptSource: "plan", StartedAt: "2026-03-01T10:00:00Z"},
+		{RunID: "run-b", Agent: "claude", Status: "failed", PromptSource: "prompt", StartedAt: "2026-03-02T10:00:00Z"},
+		{RunID: "run-c", Agent: "pi", Status: "failed", PromptSource: "plan", StartedAt: "2026-03-03T10:00:00Z"},
+	} {
+		if err := store.CreateRun(meta.RunID, "prompt for "+meta.RunID); err != nil {
+			t.Fatalf("CreateRun: %v", err)
+		}
+		if err := store.WriteMeta(meta); err != nil {
+			t.Fatalf("WriteMeta: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(store.RunDir("run-b"), "effective-prompt.md"))
+	if err != nil || string(data) != "prompt for run-b" {
+		t.Errorf("effective-prompt.md = %q, %v", data, err)
+	}
+	meta, err := store.ReadMeta("run-b")
+	if err != nil || meta.Agent != "claude" {
+		t.Errorf("ReadMeta = %+v, %v", meta, err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		q    RunQuery
+		want string
+	}{
+		{"all newest first", RunQuery{}, "run-c,run-b,run-a"},
+		{"agent", RunQuery{Agent: "pi"}, "run-c,run-a"},
+		{"status", RunQuery{Status: "failed"}, "run-c,run-b"},
+		{"prompt source", RunQuery{PromptSource: "prompt"}, "run-b"},
+		{"since", RunQuery{Since: day(2)}, "run-c,run-b"},
+		{"until", RunQuery{Until: day(2)}, "run-a"},
+		{"limit", RunQuery{Status: "failed", Limit: 1}, "run-c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := store.ListRuns(tt.q)
+			if err != nil {
+				t.Fatalf("ListRuns: %v", err)
+			}
+			if got := storedRunIDs(runs); got != tt.want {
+				t.Errorf("ListRuns(%+v) = %s, want %s", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSStore_ListKeepsRunsWithoutMeta(t *testing.T) {
+	runsDir := t.TempDir()
+	store := NewFSStore(runsDir)
+	if err := os.Mkdir(filepath.Join(runsDir, "no-meta"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(runsDir, "bad-meta"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(runsDir, "bad-meta", "meta.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(runsDir, "stray-file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := store.ListRuns(RunQuery{})
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("ListRuns = %s, want the two run directories", storedRunIDs(runs))
+	}
+	for _, run := range runs {
+		if run.HasMeta || run.MetaError == "" || run.SortTime.IsZero() {
+			t.Errorf("run %+v, want meta error and modtime sort key", run)
+		}
+	}
+
+	// Metadata filters never match runs without metadata.
+	if runs, _ := store.ListRuns(RunQuery{Status: "unknown"}); len(runs) != 0 {
+		t.Errorf("ListRuns(status) = %s, want none", storedRunIDs(runs))
+	}
+}
+
+func TestFSStore_MissingRunsDirAndDelete(t *testing.T) {
+	runsDir := filepath.Join(t.TempDir(), "runs")
+	store := NewFSStore(runsDir)
+	if runs, err := store.ListRuns(RunQuery{}); err != nil || runs != nil {
+		t.Errorf("ListRuns on missing dir = %v, %v; want nil, nil", runs, err)
+	}
+
+	if err := store.CreateRun("gone", ""); err != nil {
+		t.Fatalf("CreateRun: %v", err)
+	}
+	if err := store.DeleteRun("gone"); err != nil {
+		t.Fatalf("DeleteRun: %v", err)
+	}
+	if _, err := os.Stat(store.RunDir("gone")); !os.IsNotExist(err) {
+		t.Errorf("run dir still exists: %v", err)
+	}
+	for _, bad := range []string{"", "../escape", "a/b"} {
+		if err := store.DeleteRun(bad); err == nil {
+			t.Errorf("DeleteRun(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRun_UsesConfiguredStore(t *testing.T) {
+	store := &recordingStore{FSStore: NewFSStore(t.TempDir())}
+	r := New(RunConfig{Agent: "no-such-agent", Prompt: "p", Store: store, RunsDir: "/nonexistent"})
+	r.stderr = &strings.Builder{}
+	r.Run(t.Context())
+
+	if len(store.statuses) == 0 || store.statuses[len(store.statuses)-1] != string(StatusFailed) {
+		t.Errorf("meta written through store = %v, want final failed", store.statuses)
+	}
+	if _, err := os.Stat(filepath.Join(store.RunDir(r.runID), "effective-prompt.md")); err != nil {
+		t.Errorf("effective prompt not written through store: %v", err)
+	}
+}
+
+// recordingStore records the statuses written through it.
+type recordingStore struct {
+	*FSStore
+	statuses []string
+}
+
+func (s *recordingStore) WriteMeta(meta RunMeta) error {
+	s.statuses = append(s.statuses, meta.Status)
+	return s.FSStore.WriteMeta(meta)
+}
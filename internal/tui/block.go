@@ -4,27 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
-)
-// normalizeToolName maps tool name variants from different agent backends
-// to a canonical lowercase form. Comparison is case-insensitive so that
-// "Bash", "bash", "BASH" all normalize to "bash".
-func normalizeToolName(name string) string {
-	switch strings.ToLower(name) {
-	case "bash", "shell", "execute":
-		return "bash"
-	case "read":
-		return "read"
-	case "edit":
-		return "edit"
-	case "write":
-		return "write"
-	default:
-		return name
-	}
-}
 
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
 
 // BlockKind identifies the type of content block rendered in the main view.
 type BlockKind int
@@ -52,6 +37,11 @@ type MainBlock struct {
 	ThinkingLen    int    // char count for thinking summary
 	InfoText       string // for BlockInfo
 
+	// Iteration navigation (see main_iterations.go).
+	StartedAt time.Time // for BlockIteration: when the iteration started
+	Outcome   string    // for BlockIteration: early end reported by the runner
+	Folded    bool      // BlockIteration hides its content; BlockToolCall its result
+
 	// Layout cache: rendered screen lines for a given width.
 	// Nil layoutLines means the cache is stale and must be recomputed.
 	layoutWidth int      // width the layout was last computed for
@@ -103,8 +93,11 @@ func (b *MainBlock) InvalidateLayout() {
 
 func (b *MainBlock) renderIteration(width int) string {
 	label := fmt.Sprintf("iteration %d", b.Iteration)
+	if b.Folded {
+		label = fmt.Sprintf("▸ iteration %d (folded)", b.Iteration)
+	}
 	// Fill remaining width with ─ characters.
-	labelW := 3 + len(label) + 1 // "── " prefix + label + " " trailing
+	labelW := 3 + lipgloss.Width(label) + 1 // "── " prefix + label + " " trailing
 	remaining := width - labelW
 	if remaining < 3 {
 		remaining = 3
@@ -155,10 +148,12 @@ func (b *MainBlock) renderToolCall(width int) string {
 		inner = append(inner, b.ToolArgs)
 	}
 
-	if b.ToolDone && b.ToolResult != "" {
+	if b.Folded && b.ToolDone && b.ToolResult != "" {
+		inner = append(inner, toolResultStyle.Render("▸ result folded"))
+	} else if b.ToolDone && b.ToolResult != "" {
 		// For read/write/edit, don't dump file contents — just show a
 		// line count summary.  Full output is in the Detail pane.
-		normalized := normalizeToolName(b.ToolName)
+		normalized := events.NormalizeToolName(b.ToolName)
 		if normalized == "read" || normalized == "write" || normalized == "edit" {
 			lines := strings.Count(b.ToolResult, "\n") + 1
 			summary := fmt.Sprintf("(%d lines)", lines)
@@ -211,7 +206,7 @@ func formatToolArgs(toolName string, rawArgs json.RawMessage) string {
 		return ""
 	}
 
-	switch normalizeToolName(toolName) {
+	switch events.NormalizeToolName(toolName) {
 	case "bash":
 		var args struct {
 			Command string `json:"command"`
@@ -133,48 +133,6 @@ func TestMainBlockLayoutCache_InvalidateLayoutClearsCache(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// normalizeToolName
-// ---------------------------------------------------------------------------
-
-func TestNormalizeToolName(t *testing.T) {
-	tests := []struct {
-		input string
-		want  string
-	}{
-		// bash variants
-		{"bash", "bash"},
-		{"Bash", "bash"},
-		{"BASH", "bash"},
-		{"shell", "bash"},
-		{"Shell", "bash"},
-		{"execute", "bash"},
-		{"Execute", "bash"},
-		// read variants
-		{"read", "read"},
-		{"Read", "read"},
-		// edit variants
-		{"edit", "edit"},
-		{"Edit", "edit"},
-		// write variants
-		{"write", "write"},
-		{"Write", "write"},
-		// unknown names pass through unchanged
-		{"list_files", "list_files"},
-		{"unknown_tool", "unknown_tool"},
-		{"", ""},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got := normalizeToolName(tt.input)
-			if got != tt.want {
-				t.Errorf("normalizeToolName(%q) = %q, want %q", tt.input, got, tt.want)
-			}
-		})
-	}
-}
-
 // ---------------------------------------------------------------------------
 // formatToolArgs — name-based detection
 // ---------------------------------------------------------------------------
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	runewidth "github.com/mattn/go-runewidth"
 
+	"github.com/fsmiamoto/ralfinho/internal/runner"
 	"github.com/fsmiamoto/ralfinho/internal/viewer"
 )
 
@@ -20,7 +21,18 @@ const (
 	BrowserActionNone   BrowserAction = ""
 	BrowserActionOpen   BrowserAction = "open"
 	BrowserActionResume BrowserAction = "resume"
+	BrowserActionRerun  BrowserAction = "rerun"
 	BrowserActionDelete BrowserAction = "delete"
+	BrowserActionDiff   BrowserAction = "diff"
+
+	// Bulk actions carry their runs in BrowserResult.RunIDs.
+	BrowserActionBulkDelete BrowserAction = "bulk-delete"
+	BrowserActionExport     BrowserAction = "export"
+	BrowserActionTag        BrowserAction = "tag"
+
+	// Label edits of RunID, written to its meta.json.
+	BrowserActionEditTags BrowserAction = "edit-tags"
+	BrowserActionAnnotate BrowserAction = "annotate"
 )
 
 // BrowserResult is returned by the browser TUI so main can dispatch actions.
@@ -28,14 +40,38 @@ type BrowserResult struct {
 	Action BrowserAction
 	RunID  string
 
+	// SearchHit is the content search hit to jump to when opening the run
+	// (set only for BrowserActionOpen, nil when the run was not found by
+	// its content).
+	SearchHit *ContentHit
+
 	// Resume metadata (set only for BrowserActionResume).
 	ResumeAgent  string
 	ResumeSource viewer.ResumeSource
 	ResumePath   string
 
-	// Delete metadata (set only for BrowserActionDelete).
+	// Delete metadata (set only for BrowserActionDelete, and
+	// BrowserActionBulkDelete with DeleteDirs).
 	DeleteDir       string
+	DeleteDirs      []string
 	DeleteNextRunID string
+
+	// RunIDs are the runs of a bulk action; RunID is then the session to
+	// select when the browser reopens.
+	RunIDs []string
+	// Tag is added to RunIDs by BrowserActionTag, or removed when it starts
+	// with "-".
+	Tag string
+
+	// Tags replace RunID's tags (set only for BrowserActionEditTags) and
+	// Annotation its annotation (set only for BrowserActionAnnotate); empty
+	// values clear them.
+	Tags       []string
+	Annotation string
+
+	// DiffRunID is the run compared against RunID (set only for
+	// BrowserActionDiff).
+	DiffRunID string
 }
 
 type browserSortMode string
@@ -81,8 +117,41 @@ type BrowserModel struct {
 	searchQuery   string
 	searching     bool
 
+	// Content search: hits for searchQuery by run ID, and the hit selected
+	// with n/N for hitRunID.
+	content     *ContentIndex
+	indexing    bool // a background content index sync is running
+	contentHits map[string][]ContentHit
+	hitRunID    string
+	hitCursor   int
+
 	helpOverlay bool
 
+	// Runs marked with m for diffing, oldest first; at most two.
+	marked []string
+
+	// Bulk selection (space, V) and the run V extends a range from.
+	bulkSelected map[string]bool
+	bulkAnchor   string
+
+	confirmingBulkDelete bool
+	bulkDelete           bulkDeletePlan
+	bulkHint             string // why the last bulk key did nothing; cleared by the next key
+
+	// Pending bulk export, written as <run-id>.html into exportDir ("" is
+	// the working directory).
+	confirmingBulkExport bool
+	bulkExport           bulkExportPlan
+	exportDir            string
+
+	tagging  bool
+	tagInput string
+
+	// Label editor for one session (e, A).
+	editingLabel browserLabelField
+	labelRunID   string
+	labelInput   string
+
 	confirmingDelete   bool
 	confirmDeleteRunID string
 	confirmDeleteDir   string
@@ -98,6 +167,21 @@ type BrowserModel struct {
 
 	dateFilter  string
 	dateOptions []string
+
+	tagFilter  string
+	tagOptions []string
+
+	// Optional store query for the agent, status, prompt and date filters.
+	// Its result for the active filters is cached in queried; tag and
+	// search filters are applied to it in memory.
+	query      func(runner.RunQuery) ([]viewer.RunSummary, error)
+	queried    []viewer.RunSummary
+	queriedFor *runner.RunQuery
+
+	// Job tree (L): chains of resumed runs are listed together under the
+	// run they started from.
+	treeView bool
+	jobs     map[string]*viewer.RunJob
 }
 
 // NewBrowserModel creates a browser over a preloaded in-memory session list.
@@ -116,6 +200,8 @@ func NewBrowserModel(summaries []viewer.RunSummary) BrowserModel {
 			return summary.PromptSource
 		}),
 		dateOptions: browserDateOptions(all),
+		tagOptions:  browserTagOptions(all),
+		jobs:        viewer.GroupRunJobs(all),
 	}
 	m.applyBrowserView()
 	return m
@@ -132,6 +218,33 @@ func (m BrowserModel) WithSelectedRunID(runID string) BrowserModel {
 	return m
 }
 
+// WithContentIndex returns a copy of the browser whose search also matches
+// run content (assistant text, tool names, arguments and results) through
+// idx. The index is brought up to date in the background each time search
+// is started.
+func (m BrowserModel) WithContentIndex(idx *ContentIndex) BrowserModel {
+	m.content = idx
+	return m
+}
+
+// WithExportDir returns a copy of the browser that names dir as the
+// destination when confirming a bulk export.
+func (m BrowserModel) WithExportDir(dir string) BrowserModel {
+	m.exportDir = dir
+	return m
+}
+
+// WithRunQuery returns a copy of the browser that answers the agent,
+// status, prompt and date filters by running query against the run store,
+// so an indexed store filters without the browser scanning every run. If a
+// query fails the browser filters its preloaded list instead.
+func (m BrowserModel) WithRunQuery(query func(runner.RunQuery) ([]viewer.RunSummary, error)) BrowserModel {
+	m.query = query
+	m.queriedFor = nil
+	m.applyBrowserView()
+	return m
+}
+
 // Result returns the action requested by the browser, if any.
 func (m BrowserModel) Result() BrowserResult {
 	return m.result
@@ -152,12 +265,20 @@ func (m BrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		return m.handleKey(msg)
+
+	case ContentIndexedMsg:
+		if m.content != nil && m.content.Apply(msg) {
+			m.indexing = false
+			m.applyBrowserView()
+		}
+		return m, nil
 	}
 
 	return m, nil
 }
 
 func (m BrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.bulkHint = ""
 	if m.helpOverlay {
 		switch msg.String() {
 		case "?", "q", "esc":
@@ -170,15 +291,31 @@ func (m BrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleConfirmDeleteKey(msg)
 	}
 
+	if m.confirmingBulkDelete {
+		return m.handleConfirmBulkDeleteKey(msg)
+	}
+
+	if m.confirmingBulkExport {
+		return m.handleConfirmBulkExportKey(msg)
+	}
+
 	if m.searching {
 		return m.handleSearchKey(msg)
 	}
 
+	if m.tagging {
+		return m.handleTagKey(msg)
+	}
+
+	if m.editingLabel != browserLabelNone {
+		return m.handleLabelKey(msg)
+	}
+
 	switch msg.String() {
 	case "enter", "o":
 		if m.focusedPane == 0 {
 			if summary := m.currentSummary(); summary != nil && summary.Actions.Open.Available {
-				m.result = BrowserResult{Action: BrowserActionOpen, RunID: summary.RunID}
+				m.result = BrowserResult{Action: BrowserActionOpen, RunID: summary.RunID, SearchHit: m.currentHit()}
 				return m, tea.Quit
 			}
 		}
@@ -197,6 +334,18 @@ func (m BrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case "R":
+		if m.focusedPane == 0 {
+			if summary := m.currentSummary(); summary != nil && summary.Actions.Rerun.Available {
+				m.result = BrowserResult{Action: BrowserActionRerun, RunID: summary.RunID}
+				return m, tea.Quit
+			}
+		}
+
+	case "L":
+		m.treeView = !m.treeView
+		m.applyBrowserView()
+
 	case "x":
 		if m.focusedPane == 0 {
 			if summary := m.currentSummary(); summary != nil && summary.Actions.Delete.Available {
@@ -206,13 +355,84 @@ func (m BrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case "m":
+		if m.focusedPane == 0 {
+			if summary := m.currentSummary(); summary != nil && summary.Actions.Open.Available {
+				m.toggleMark(summary.RunID)
+			}
+		}
+
+	case " ":
+		if m.focusedPane == 0 {
+			m.toggleBulkSelect()
+		}
+
+	case "V":
+		if m.focusedPane == 0 {
+			m.selectBulkRange()
+		}
+
+	case "X":
+		if len(m.bulkSelected) == 0 && !m.browserFiltered() {
+			m.bulkHint = "Select sessions or filter the list to bulk delete"
+		} else if plan := m.planBulkDelete(); len(plan.RunIDs) > 0 {
+			m.confirmingBulkDelete = true
+			m.bulkDelete = plan
+		}
+
+	case "E":
+		if plan := m.planBulkExport(); len(plan.RunIDs) > 0 {
+			m.confirmingBulkExport = true
+			m.bulkExport = plan
+		}
+
+	case "#":
+		if targets, _ := m.bulkTargets(); len(targets) > 0 {
+			m.tagging = true
+			m.tagInput = ""
+		}
+
+	case "e":
+		if m.focusedPane == 0 {
+			m.startLabelEdit(browserLabelTags)
+		}
+
+	case "A":
+		if m.focusedPane == 0 {
+			m.startLabelEdit(browserLabelAnnotation)
+		}
+
+	case "D":
+		if a, b, ok := m.diffPair(); ok {
+			m.result = BrowserResult{Action: BrowserActionDiff, RunID: a, DiffRunID: b}
+			return m, tea.Quit
+		}
+
 	case "/":
 		m.searching = true
+		if m.content != nil && !m.indexing {
+			// Indexing reads every new or changed run's events, so it runs
+			// in the background; the query is applied again once it is done.
+			m.indexing = true
+			return m, m.content.SyncCmd(m.allSummaries)
+		}
+
+	case "n", "N":
+		if m.focusedPane == 0 {
+			m.moveHit(msg.String() == "n")
+		}
 
 	case "?":
 		m.helpOverlay = true
 
-	case "q", "esc", "ctrl+c":
+	case "esc":
+		if len(m.bulkSelected) > 0 {
+			m.clearBulkSelection()
+			return m, nil
+		}
+		return m, tea.Quit
+
+	case "q", "ctrl+c":
 		return m, tea.Quit
 
 	case "tab":
@@ -297,6 +517,10 @@ func (m BrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.dateFilter = cycleBrowserOption(m.dateFilter, m.dateOptions)
 		m.applyBrowserView()
 
+	case "T":
+		m.tagFilter = cycleBrowserOption(m.tagFilter, m.tagOptions)
+		m.applyBrowserView()
+
 	case "c":
 		m.clearBrowserFilters()
 	}
@@ -304,6 +528,46 @@ func (m BrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// toggleMark marks runID for diffing, or unmarks it. Marking a third run
+// drops the oldest mark.
+func (m *BrowserModel) toggleMark(runID string) {
+	for i, id := range m.marked {
+		if id == runID {
+			m.marked = append(m.marked[:i:i], m.marked[i+1:]...)
+			return
+		}
+	}
+	m.marked = append(m.marked, runID)
+	if len(m.marked) > 2 {
+		m.marked = m.marked[len(m.marked)-2:]
+	}
+}
+
+// diffPair returns the runs D compares: the two marked runs, or the marked
+// run and the selected one.
+func (m BrowserModel) diffPair() (string, string, bool) {
+	switch len(m.marked) {
+	case 2:
+		return m.marked[0], m.marked[1], true
+	case 1:
+		if summary := m.currentSummary(); summary != nil && summary.RunID != m.marked[0] && summary.Actions.Open.Available {
+			return m.marked[0], summary.RunID, true
+		}
+	}
+	return "", "", false
+}
+
+// markLabel returns "A" or "B" for a run marked for diffing, matching the
+// side it will be shown on, and "" for unmarked runs.
+func (m BrowserModel) markLabel(runID string) string {
+	for i, id := range m.marked {
+		if id == runID {
+			return string(rune('A' + i))
+		}
+	}
+	return ""
+}
+
 func (m BrowserModel) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
 	case tea.KeyCtrlC:
@@ -435,6 +699,46 @@ func (m *BrowserModel) ensureCursorVisible() {
 	}
 }
 
+// currentHit returns the selected content search hit of the selected run,
+// or nil when the run did not match by content.
+func (m BrowserModel) currentHit() *ContentHit {
+	summary := m.currentSummary()
+	if summary == nil {
+		return nil
+	}
+	hits := m.contentHits[summary.RunID]
+	if len(hits) == 0 {
+		return nil
+	}
+	hit := hits[0]
+	if m.hitRunID == summary.RunID && m.hitCursor < len(hits) {
+		hit = hits[m.hitCursor]
+	}
+	return &hit
+}
+
+// moveHit selects the next (or previous) content search hit of the selected
+// run, wrapping around.
+func (m *BrowserModel) moveHit(forward bool) {
+	summary := m.currentSummary()
+	if summary == nil {
+		return
+	}
+	hits := m.contentHits[summary.RunID]
+	if len(hits) == 0 {
+		return
+	}
+	if m.hitRunID != summary.RunID || m.hitCursor >= len(hits) {
+		m.hitRunID = summary.RunID
+		m.hitCursor = 0
+	}
+	if forward {
+		m.hitCursor = (m.hitCursor + 1) % len(hits)
+	} else {
+		m.hitCursor = (m.hitCursor - 1 + len(hits)) % len(hits)
+	}
+}
+
 func (m *BrowserModel) clampPreviewScroll() {
 	maxScroll := m.previewLineCount() - m.visiblePreviewLines()
 	if maxScroll < 0 {
@@ -455,6 +759,7 @@ func (m *BrowserModel) clearBrowserFilters() {
 	m.statusFilter = ""
 	m.promptFilter = ""
 	m.dateFilter = ""
+	m.tagFilter = ""
 	m.applyBrowserView()
 }
 
@@ -478,8 +783,16 @@ func (m *BrowserModel) applyBrowserView() {
 		}
 	}
 
-	filtered := make([]viewer.RunSummary, 0, len(m.allSummaries))
-	for _, summary := range m.allSummaries {
+	m.contentHits = nil
+	m.hitRunID = ""
+	m.hitCursor = 0
+	if m.content != nil {
+		m.contentHits = m.content.Search(m.searchQuery)
+	}
+
+	candidates := m.filterCandidates()
+	filtered := make([]viewer.RunSummary, 0, len(candidates))
+	for _, summary := range candidates {
 		if !m.matchesBrowserFilters(summary) {
 			continue
 		}
@@ -489,6 +802,9 @@ func (m *BrowserModel) applyBrowserView() {
 	sort.SliceStable(filtered, func(i, j int) bool {
 		return browserSummaryLess(filtered[i], filtered[j], m.sortMode)
 	})
+	if m.treeView {
+		filtered = groupBrowserJobs(filtered, m.jobs)
+	}
 
 	m.summaries = filtered
 	if len(m.summaries) == 0 {
@@ -522,6 +838,47 @@ func (m *BrowserModel) applyBrowserView() {
 	m.clampPreviewScroll()
 }
 
+// filterCandidates returns the runs the active filters are applied to: the
+// store's answer for the filters it can evaluate, or every preloaded run.
+func (m *BrowserModel) filterCandidates() []viewer.RunSummary {
+	q, ok := m.storeQuery()
+	if !ok || m.query == nil {
+		return m.allSummaries
+	}
+	if m.queriedFor == nil || *m.queriedFor != q {
+		runs, err := m.query(q)
+		if err != nil {
+			m.queriedFor = nil
+			return m.allSummaries
+		}
+		m.queried, m.queriedFor = runs, &q
+	}
+	return m.queried
+}
+
+// storeQuery translates the agent, status, prompt and date filters into a
+// run store query. ok is false when none of them is active. "unknown"
+// values describe runs without metadata, which only the in-memory filters
+// can match.
+func (m BrowserModel) storeQuery() (runner.RunQuery, bool) {
+	var q runner.RunQuery
+	known := func(v string) string {
+		if v == "unknown" {
+			return ""
+		}
+		return v
+	}
+	q.Agent = known(m.agentFilter)
+	q.Status = known(m.statusFilter)
+	q.PromptSource = known(m.promptFilter)
+	// Dates are shown in each run's own UTC offset, so the store is asked
+	// for a day either side and matchesBrowserFilters narrows it down.
+	if day, err := time.ParseInLocation("2006-01-02", m.dateFilter, time.Local); err == nil {
+		q.Since, q.Until = day.AddDate(0, 0, -1), day.AddDate(0, 0, 2)
+	}
+	return q, q != runner.RunQuery{}
+}
+
 func (m BrowserModel) matchesBrowserFilters(summary viewer.RunSummary) bool {
 	if m.agentFilter != "" && !strings.EqualFold(summary.Agent, m.agentFilter) {
 		return false
@@ -535,7 +892,10 @@ func (m BrowserModel) matchesBrowserFilters(summary viewer.RunSummary) bool {
 	if m.dateFilter != "" && browserSummaryDate(summary) != m.dateFilter {
 		return false
 	}
-	if strings.TrimSpace(m.searchQuery) != "" && !summary.Matches(m.searchQuery) {
+	if m.tagFilter != "" && !browserSummaryHasTag(summary, m.tagFilter) {
+		return false
+	}
+	if strings.TrimSpace(m.searchQuery) != "" && !summary.Matches(m.searchQuery) && len(m.contentHits[summary.RunID]) == 0 {
 		return false
 	}
 	return true
@@ -554,10 +914,24 @@ func (m BrowserModel) renderBrowserHelpOverlay() string {
 		"Sessions\n" +
 		"  Enter/o       Open session\n" +
 		"  r             Resume session\n" +
+		"  R             Rerun with the same settings\n" +
 		"  x             Delete session\n" +
-		"  /             Search\n" +
+		"  m             Mark session for diff\n" +
+		"  D             Diff marked sessions\n" +
+		"  e / A         Edit tags / annotation\n" +
+		"  /             Search metadata and content\n" +
+		"  n / N         Next / previous content match\n" +
 		"  s             Cycle sort mode\n" +
-		"  a/t/d/p       Cycle filters\n" +
+		"  a/t/d/p/T     Cycle filters (T: tag)\n" +
+		"  L             Toggle job tree of resumed runs\n" +
+		"\n" +
+		"Bulk (selection, or all shown when none)\n" +
+		"  Space         Select session\n" +
+		"  V             Select range from last selected\n" +
+		"  X             Delete sessions\n" +
+		"  E             Export sessions to HTML\n" +
+		"  #             Tag sessions (-tag removes)\n" +
+		"  Esc           Clear selection\n" +
 		"\n" +
 		"Other\n" +
 		"  q/Esc         Quit\n" +
@@ -661,8 +1035,24 @@ func (m BrowserModel) renderSessionsPane() string {
 		var lines []string
 		for i := m.scroll; i < len(m.summaries) && i < m.scroll+visibleRows; i++ {
 			summary := m.summaries[i]
-			primary := padToWidth(browserPrimaryRow(summary, lineWidth), lineWidth)
-			secondary := padToWidth(browserSecondaryRow(summary, lineWidth), lineWidth)
+			primary := browserPrimaryRow(summary, lineWidth)
+			secondary := browserSecondaryRow(summary, lineWidth)
+			if job, head := m.browserJobRow(i); job != nil {
+				if head {
+					secondary = browserJobSummaryRow(job, lineWidth)
+				} else {
+					primary = "└ " + primary
+				}
+			}
+			if label := m.markLabel(summary.RunID); label != "" {
+				primary = "[" + label + "] " + primary
+			}
+			if m.bulkSelected[summary.RunID] {
+				primary = "✓ " + primary
+			}
+			primary = truncateToWidth(primary, lineWidth)
+			primary = padToWidth(primary, lineWidth)
+			secondary = padToWidth(secondary, lineWidth)
 
 			if i == m.cursor {
 				lines = append(lines,
@@ -716,7 +1106,11 @@ func (m BrowserModel) renderPreviewPane() string {
 	content := ""
 
 	summary := m.currentSummary()
-	if summary == nil {
+	if m.confirmingBulkDelete {
+		content = m.renderBulkDeleteCard(contentWidth, visibleLines)
+	} else if m.confirmingBulkExport {
+		content = m.renderBulkExportCard(contentWidth, visibleLines)
+	} else if summary == nil {
 		content = m.renderBrowserPreviewEmpty(contentWidth, visibleLines)
 	} else {
 		raw := m.browserPreviewText()
@@ -822,17 +1216,42 @@ func (m BrowserModel) browserStatusLeft() string {
 	if m.confirmingDelete {
 		return fmt.Sprintf("Delete run %s? This cannot be undone.", shortID(m.confirmDeleteRunID))
 	}
+	if m.confirmingBulkDelete || m.confirmingBulkExport || m.tagging {
+		return m.bulkStatusLeft()
+	}
+	if m.editingLabel != browserLabelNone {
+		return m.labelStatusLeft()
+	}
+	if m.bulkHint != "" {
+		return m.bulkHint
+	}
 	if len(m.allSummaries) == 0 {
 		return "No saved runs │ run ralfinho to create a session"
 	}
 	if len(m.summaries) == 0 {
+		if m.indexing {
+			return fmt.Sprintf("0/%d runs │ indexing run content…", len(m.allSummaries))
+		}
 		return fmt.Sprintf("0/%d runs │ search/filter hid all matches", len(m.allSummaries))
 	}
 
 	left := fmt.Sprintf("%d/%d runs │ %s", len(m.summaries), len(m.allSummaries), shortID(m.summaries[m.cursor].RunID))
+	if hits := m.contentHits[m.summaries[m.cursor].RunID]; len(hits) > 0 {
+		n := 1
+		if m.hitRunID == m.summaries[m.cursor].RunID {
+			n = m.hitCursor + 1
+		}
+		left += fmt.Sprintf(" │ match %d/%d", n, len(hits))
+	}
 	if browserHasArtifactIssues(m.summaries[m.cursor]) {
 		left += " │ artifact warnings"
 	}
+	if m.indexing {
+		left += " │ indexing run content…"
+	}
+	if bulk := m.bulkStatusLeft(); bulk != "" {
+		left += " │ " + bulk
+	}
 	if m.useStackedBrowserLayout() {
 		left += " │ stacked"
 	}
@@ -848,7 +1267,7 @@ func (m BrowserModel) browserStatusRightVariants() []string {
 		return strings.Join(parts, statusSepStyle.Render(" │ "))
 	}
 
-	if m.confirmingDelete {
+	if m.confirmingDelete || m.confirmingBulkDelete {
 		return []string{
 			render(
 				browserHint{Key: "y/Enter", Label: "confirm delete"},
@@ -861,6 +1280,19 @@ func (m BrowserModel) browserStatusRightVariants() []string {
 		}
 	}
 
+	if m.confirmingBulkExport {
+		return []string{
+			render(
+				browserHint{Key: "y/Enter", Label: "confirm export"},
+				browserHint{Key: "n/Esc", Label: "cancel"},
+			),
+			render(
+				browserHint{Key: "y", Label: "confirm"},
+				browserHint{Key: "Esc", Label: "cancel"},
+			),
+		}
+	}
+
 	if m.searching {
 		return []string{
 			render(
@@ -885,10 +1317,42 @@ func (m BrowserModel) browserStatusRightVariants() []string {
 		}
 	}
 
+	if m.tagging || m.editingLabel != browserLabelNone {
+		return []string{
+			render(
+				browserHint{Key: "Enter", Label: "apply"},
+				browserHint{Key: "Esc", Label: "cancel"},
+				browserHint{Key: "Ctrl+u", Label: "clear"},
+			),
+			render(browserHint{Key: "Esc", Label: "cancel"}),
+		}
+	}
+
 	if len(m.allSummaries) == 0 {
 		return []string{render(browserHint{Key: "q", Label: "quit"})}
 	}
 
+	if len(m.bulkSelected) > 0 && m.focusedPane == 0 {
+		return []string{
+			render(
+				browserHint{Key: "Space", Label: "select"},
+				browserHint{Key: "V", Label: "range"},
+				browserHint{Key: "X", Label: "delete"},
+				browserHint{Key: "E", Label: "export"},
+				browserHint{Key: "#", Label: "tag"},
+				browserHint{Key: "Esc", Label: "clear"},
+				browserHint{Key: "q", Label: "quit"},
+			),
+			render(
+				browserHint{Key: "X", Label: "delete"},
+				browserHint{Key: "E", Label: "export"},
+				browserHint{Key: "#", Label: "tag"},
+				browserHint{Key: "Esc", Label: "clear"},
+			),
+			render(browserHint{Key: "Esc", Label: "clear"}),
+		}
+	}
+
 	if len(m.summaries) == 0 {
 		return []string{
 			render(
@@ -945,6 +1409,12 @@ func (m BrowserModel) browserStatusRightVariants() []string {
 		if summary.Actions.Delete.Available {
 			actions = append(actions, browserHint{Key: "x", Label: "delete"})
 		}
+		if len(m.contentHits[summary.RunID]) > 1 {
+			actions = append(actions, browserHint{Key: "n/N", Label: "match"})
+		}
+	}
+	if _, _, ok := m.diffPair(); ok {
+		actions = append(actions, browserHint{Key: "D", Label: "diff"})
 	}
 
 	q := browserHint{Key: "q", Label: "quit"}
@@ -1011,6 +1481,9 @@ func (m BrowserModel) browserStatusRightVariants() []string {
 
 func (m BrowserModel) browserStateTokens() []string {
 	tokens := []string{fmt.Sprintf("sort:%s", m.sortMode)}
+	if m.treeView {
+		tokens = append(tokens, "tree")
+	}
 	if m.agentFilter != "" {
 		tokens = append(tokens, "agent:"+m.agentFilter)
 	}
@@ -1023,6 +1496,15 @@ func (m BrowserModel) browserStateTokens() []string {
 	if m.dateFilter != "" {
 		tokens = append(tokens, "date:"+m.dateFilter)
 	}
+	if m.tagFilter != "" {
+		tokens = append(tokens, "tag:"+m.tagFilter)
+	}
+	if len(m.marked) > 0 {
+		tokens = append(tokens, fmt.Sprintf("marked:%d", len(m.marked)))
+	}
+	if len(m.bulkSelected) > 0 {
+		tokens = append(tokens, fmt.Sprintf("selected:%d", len(m.bulkSelected)))
+	}
 	query := strings.TrimSpace(m.searchQuery)
 	if query != "" || m.searching {
 		if query == "" {
@@ -1039,7 +1521,14 @@ func (m BrowserModel) browserStateTokens() []string {
 func (m BrowserModel) browserPreviewText() string {
 	summary := m.currentSummary()
 	if summary != nil {
-		return browserPreviewText(summary)
+		text := browserPreviewText(summary)
+		if job := m.jobs[summary.RunID]; job != nil && len(job.RunIDs) > 1 {
+			text = m.browserJobText(job, summary.RunID) + "\n\n" + text
+		}
+		if hits := m.contentHits[summary.RunID]; len(hits) > 0 {
+			return m.browserHitsText(hits) + "\n\n" + text
+		}
+		return text
 	}
 	if len(m.allSummaries) == 0 {
 		return "No saved runs found.\n\nRun ralfinho to create a session, then open `ralfinho view` again."
@@ -1055,6 +1544,7 @@ func (m BrowserModel) browserPreviewText() string {
 		fmt.Sprintf("Status filter: %s", browserFilterLabel(m.statusFilter)),
 		fmt.Sprintf("Prompt filter: %s", browserFilterLabel(m.promptFilter)),
 		fmt.Sprintf("Date filter: %s", browserFilterLabel(m.dateFilter)),
+		fmt.Sprintf("Tag filter: %s", browserFilterLabel(m.tagFilter)),
 		fmt.Sprintf("Search: %s", browserSearchLabel(m.searchQuery, m.searching)),
 		"",
 		"Press c to clear filters, or / to refine the search.",
@@ -1062,6 +1552,42 @@ func (m BrowserModel) browserPreviewText() string {
 	return strings.Join(lines, "\n")
 }
 
+// browserMaxPreviewHits caps the content search hits listed in the preview.
+const browserMaxPreviewHits = 20
+
+// browserHitsText lists the selected run's content search hits, marking the
+// one Enter jumps to.
+func (m BrowserModel) browserHitsText(hits []ContentHit) string {
+	selected := 0
+	if current := m.currentHit(); current != nil {
+		for i, hit := range hits {
+			if hit.Block == current.Block {
+				selected = i
+				break
+			}
+		}
+	}
+
+	start := 0
+	if selected >= browserMaxPreviewHits {
+		start = selected - browserMaxPreviewHits + 1
+	}
+	end := min(start+browserMaxPreviewHits, len(hits))
+
+	lines := []string{fmt.Sprintf("Content matches (%d)", len(hits))}
+	for i := start; i < end; i++ {
+		marker := "  "
+		if i == selected {
+			marker = "▶ "
+		}
+		lines = append(lines, marker+hits[i].Label()+": "+hits[i].Snippet)
+	}
+	if more := len(hits) - end; more > 0 {
+		lines = append(lines, fmt.Sprintf("  … %d more", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (m BrowserModel) renderBrowserSessionsEmpty(contentWidth, visibleLines int) string {
 	if len(m.allSummaries) == 0 {
 		return renderBrowserStateCard(contentWidth, visibleLines, "NO SAVED RUNS", []string{
@@ -1074,7 +1600,7 @@ func (m BrowserModel) renderBrowserSessionsEmpty(contentWidth, visibleLines int)
 	return renderBrowserStateCard(contentWidth, visibleLines, "NO MATCHES", []string{
 		fmt.Sprintf("Visible: %d/%d", len(m.summaries), len(m.allSummaries)),
 		fmt.Sprintf("Search: %s", browserSearchLabel(m.searchQuery, m.searching)),
-		fmt.Sprintf("Filters: agent=%s status=%s prompt=%s date=%s", browserFilterLabel(m.agentFilter), browserFilterLabel(m.statusFilter), browserFilterLabel(m.promptFilter), browserFilterLabel(m.dateFilter)),
+		fmt.Sprintf("Filters: agent=%s status=%s prompt=%s date=%s tag=%s", browserFilterLabel(m.agentFilter), browserFilterLabel(m.statusFilter), browserFilterLabel(m.promptFilter), browserFilterLabel(m.dateFilter), browserFilterLabel(m.tagFilter)),
 		"Press c to clear filters or / to refine the search.",
 	}, false)
 }
@@ -1090,8 +1616,8 @@ func (m BrowserModel) renderBrowserPreviewEmpty(contentWidth, visibleLines int)
 	return renderBrowserStateCard(contentWidth, visibleLines, "NO MATCHES", []string{
 		fmt.Sprintf("Sort: %s", m.sortMode),
 		fmt.Sprintf("Search: %s", browserSearchLabel(m.searchQuery, m.searching)),
-		fmt.Sprintf("Filters: agent=%s status=%s prompt=%s date=%s", browserFilterLabel(m.agentFilter), browserFilterLabel(m.statusFilter), browserFilterLabel(m.promptFilter), browserFilterLabel(m.dateFilter)),
-		"Press c to clear filters, or change /, a, t, p, or d.",
+		fmt.Sprintf("Filters: agent=%s status=%s prompt=%s date=%s tag=%s", browserFilterLabel(m.agentFilter), browserFilterLabel(m.statusFilter), browserFilterLabel(m.promptFilter), browserFilterLabel(m.dateFilter), browserFilterLabel(m.tagFilter)),
+		"Press c to clear filters, or change /, a, t, p, d, or T.",
 	}, false)
 }
 
@@ -1391,12 +1917,18 @@ func browserPrimaryRow(summary viewer.RunSummary, width int) string {
 		prefix += " ⚠"
 	}
 	row := fmt.Sprintf("%s  %s", prefix, date)
+	if name := strings.TrimSpace(summary.Meta.Name); name != "" {
+		row += "  " + name
+	}
 	return truncateToWidth(row, width)
 }
 
 func browserSecondaryRow(summary viewer.RunSummary, width int) string {
 	prompt := browserPromptDescriptor(summary)
 	row := fmt.Sprintf("%s • %s • %s", summary.Agent, summary.Status, prompt)
+	for _, tag := range summary.Meta.Tags {
+		row += " #" + tag
+	}
 	return truncateToWidth(row, width)
 }
 
@@ -1406,8 +1938,17 @@ func browserPreviewText(summary *viewer.RunSummary) string {
 	}
 
 	var lines []string
+	lines = append(lines, fmt.Sprintf("Run: %s", summary.RunID))
+	if summary.Meta.Name != "" {
+		lines = append(lines, fmt.Sprintf("Name: %s", summary.Meta.Name))
+	}
+	if summary.Meta.ResumedFrom != "" {
+		lines = append(lines, fmt.Sprintf("Resumed from: %s", summary.Meta.ResumedFrom))
+	}
+	if summary.Meta.ParentRunID != "" {
+		lines = append(lines, fmt.Sprintf("Rerun of: %s", summary.Meta.ParentRunID))
+	}
 	lines = append(lines,
-		fmt.Sprintf("Run: %s", summary.RunID),
 		fmt.Sprintf("Started: %s", browserLongDate(*summary)),
 		fmt.Sprintf("Agent: %s", summary.Agent),
 		fmt.Sprintf("Status: %s", summary.Status),
@@ -1419,10 +1960,32 @@ func browserPreviewText(summary *viewer.RunSummary) string {
 	if summary.PromptPath != "" {
 		lines = append(lines, fmt.Sprintf("Prompt path: %s", summary.PromptPath))
 	}
+	if len(summary.Meta.Tags) > 0 {
+		lines = append(lines, fmt.Sprintf("Tags: %s", strings.Join(summary.Meta.Tags, ", ")))
+	}
+	if summary.Meta.Annotation != "" {
+		lines = append(lines, fmt.Sprintf("Annotation: %s", summary.Meta.Annotation))
+	}
 	if summary.StartedAtText != "" && summary.StartedAt.IsZero() {
 		lines = append(lines, fmt.Sprintf("Started raw: %s", summary.StartedAtText))
 	}
 
+	if failure := summary.Meta.Failure; failure != nil {
+		lines = append(lines, "", "Failure")
+		for _, l := range failureLines(failure, browserFailureStderrLines) {
+			if l == "" {
+				lines = append(lines, "")
+				continue
+			}
+			lines = append(lines, "  "+l)
+		}
+	}
+
+	if env := summary.Meta.Environment; env != nil {
+		lines = append(lines, "", "Environment")
+		lines = append(lines, browserEnvironmentLines(env)...)
+	}
+
 	lines = append(lines,
 		"",
 		"Artifacts",
@@ -1433,6 +1996,7 @@ func browserPreviewText(summary *viewer.RunSummary) string {
 		"Actions",
 		fmt.Sprintf("  open: %s", browserOpenState(summary.Actions.Open)),
 		fmt.Sprintf("  resume: %s", browserResumeState(summary.Actions.Resume)),
+		fmt.Sprintf("  rerun: %s", browserOpenState(summary.Actions.Rerun)),
 		fmt.Sprintf("  delete: %s", browserDeleteState(summary.Actions.Delete)),
 	)
 	if summary.Actions.Resume.Available && summary.Actions.Resume.Path != "" {
@@ -1456,6 +2020,39 @@ func browserPreviewText(summary *viewer.RunSummary) string {
 }
 
 
+// browserEnvironmentLines describes where and with what a run executed,
+// skipping fields that were not recorded.
+func browserEnvironmentLines(env *runner.RunEnvironment) []string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("  %s: %s", label, value))
+		}
+	}
+	add("ralfinho", env.RalfinhoVersion)
+	add("agent binary", env.AgentBinary)
+	add("agent version", env.AgentVersion)
+	add("agent args", strings.Join(env.AgentExtraArgs, " "))
+	add("inactivity timeout", env.InactivityTimeout)
+	add("directory", env.WorkDir)
+	add("host", env.Hostname)
+	if env.GitStart != nil {
+		git := viewer.FormatGitState(env.GitStart)
+		if env.GitEnd != nil && *env.GitEnd != *env.GitStart {
+			git += " → " + viewer.FormatGitState(env.GitEnd)
+		}
+		add("git", git)
+	}
+	if len(env.PromptSHA256) >= 12 {
+		add("prompt sha256", env.PromptSHA256[:12])
+	}
+	return lines
+}
+
+// browserFailureStderrLines caps the stderr tail shown in the preview pane;
+// the full 4 KB tail stays in meta.json.
+const browserFailureStderrLines = 8
+
 func browserPromptDescriptor(summary viewer.RunSummary) string {
 	label := strings.TrimSpace(summary.PromptLabel)
 	if label == "" || label == "unknown" {
@@ -0,0 +1,321 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// Bulk operations: space and V select sessions, then X deletes, E exports
+// and # tags all of them at once. With nothing selected they apply to every
+// session the current search and filters show; delete then needs an active
+// search or filter, so it never covers the whole list by accident. Delete
+// and export ask for confirmation first.
+
+// bulkDeletePlan describes a confirmed-pending bulk delete.
+type bulkDeletePlan struct {
+	RunIDs   []string
+	Dirs     []string
+	Bytes    int64 // disk space the runs use
+	Running  int   // targeted sessions kept because they are still running
+	Matching bool  // nothing was selected; the plan covers the filtered list
+}
+
+// bulkExportPlan describes a confirmed-pending bulk export.
+type bulkExportPlan struct {
+	RunIDs   []string
+	Matching bool // nothing was selected; the plan covers the filtered list
+}
+
+// toggleBulkSelect selects or deselects the session under the cursor and
+// makes it the anchor for V.
+func (m *BrowserModel) toggleBulkSelect() {
+	summary := m.currentSummary()
+	if summary == nil {
+		return
+	}
+	if m.bulkSelected[summary.RunID] {
+		delete(m.bulkSelected, summary.RunID)
+	} else {
+		if m.bulkSelected == nil {
+			m.bulkSelected = make(map[string]bool)
+		}
+		m.bulkSelected[summary.RunID] = true
+	}
+	m.bulkAnchor = summary.RunID
+}
+
+// selectBulkRange selects every visible session between the anchor and the
+// cursor. Without an anchor on screen it selects the cursor's session.
+func (m *BrowserModel) selectBulkRange() {
+	if len(m.summaries) == 0 {
+		return
+	}
+	anchor := m.cursor
+	for i, s := range m.summaries {
+		if s.RunID == m.bulkAnchor {
+			anchor = i
+		}
+	}
+	if m.bulkSelected == nil {
+		m.bulkSelected = make(map[string]bool)
+	}
+	for i := min(anchor, m.cursor); i <= max(anchor, m.cursor); i++ {
+		m.bulkSelected[m.summaries[i].RunID] = true
+	}
+	m.bulkAnchor = m.summaries[m.cursor].RunID
+}
+
+// currentRunID returns the run under the cursor, or "".
+func (m BrowserModel) currentRunID() string {
+	if summary := m.currentSummary(); summary != nil {
+		return summary.RunID
+	}
+	return ""
+}
+
+func (m *BrowserModel) clearBulkSelection() {
+	m.bulkSelected = nil
+	m.bulkAnchor = ""
+}
+
+// browserFiltered reports whether a search or filter narrows the list.
+func (m BrowserModel) browserFiltered() bool {
+	return strings.TrimSpace(m.searchQuery) != "" || m.agentFilter != "" || m.statusFilter != "" ||
+		m.promptFilter != "" || m.dateFilter != "" || m.tagFilter != ""
+}
+
+// bulkTargets returns the sessions a bulk action applies to: the selected
+// ones, or every visible session when nothing is selected.
+func (m BrowserModel) bulkTargets() (targets []viewer.RunSummary, matching bool) {
+	if len(m.bulkSelected) == 0 {
+		return m.summaries, true
+	}
+	for _, s := range m.allSummaries {
+		if m.bulkSelected[s.RunID] {
+			targets = append(targets, s)
+		}
+	}
+	return targets, false
+}
+
+// planBulkDelete collects the targeted sessions that can be deleted and
+// the disk space they use. Running sessions are never deleted.
+func (m BrowserModel) planBulkDelete() bulkDeletePlan {
+	targets, matching := m.bulkTargets()
+	plan := bulkDeletePlan{Matching: matching}
+	if matching && !m.browserFiltered() {
+		return plan
+	}
+	for _, s := range targets {
+		if !s.Actions.Delete.Available {
+			continue
+		}
+		if s.Status == string(runner.StatusRunning) {
+			plan.Running++
+			continue
+		}
+		plan.RunIDs = append(plan.RunIDs, s.RunID)
+		plan.Dirs = append(plan.Dirs, s.Dir)
+		plan.Bytes += viewer.DirSize(s.Dir)
+	}
+	return plan
+}
+
+func (m BrowserModel) handleConfirmBulkDeleteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		m.confirmingBulkDelete = false
+		m.result = BrowserResult{
+			Action:          BrowserActionBulkDelete,
+			RunIDs:          m.bulkDelete.RunIDs,
+			DeleteDirs:      m.bulkDelete.Dirs,
+			DeleteNextRunID: m.nextRunIDAfterBulkDelete(),
+		}
+		return m, tea.Quit
+	case "n", "esc":
+		m.confirmingBulkDelete = false
+		m.bulkDelete = bulkDeletePlan{}
+	case "ctrl+c":
+		m.confirmingBulkDelete = false
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// nextRunIDAfterBulkDelete returns the session to select once the planned
+// runs are gone: the first survivor at or after the cursor, else before it.
+func (m BrowserModel) nextRunIDAfterBulkDelete() string {
+	deleted := make(map[string]bool, len(m.bulkDelete.RunIDs))
+	for _, id := range m.bulkDelete.RunIDs {
+		deleted[id] = true
+	}
+	for i := m.cursor; i < len(m.summaries); i++ {
+		if !deleted[m.summaries[i].RunID] {
+			return m.summaries[i].RunID
+		}
+	}
+	for i := min(m.cursor, len(m.summaries)) - 1; i >= 0; i-- {
+		if !deleted[m.summaries[i].RunID] {
+			return m.summaries[i].RunID
+		}
+	}
+	return ""
+}
+
+// planBulkExport collects the targeted sessions that have events to
+// export.
+func (m BrowserModel) planBulkExport() bulkExportPlan {
+	targets, matching := m.bulkTargets()
+	plan := bulkExportPlan{Matching: matching}
+	for _, s := range targets {
+		if s.Actions.Open.Available {
+			plan.RunIDs = append(plan.RunIDs, s.RunID)
+		}
+	}
+	return plan
+}
+
+func (m BrowserModel) handleConfirmBulkExportKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		m.confirmingBulkExport = false
+		m.result = BrowserResult{Action: BrowserActionExport, RunID: m.currentRunID(), RunIDs: m.bulkExport.RunIDs}
+		return m, tea.Quit
+	case "n", "esc":
+		m.confirmingBulkExport = false
+		m.bulkExport = bulkExportPlan{}
+	case "ctrl+c":
+		m.confirmingBulkExport = false
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// exportDestination names the directory bulk export writes into.
+func (m BrowserModel) exportDestination() string {
+	if m.exportDir == "" {
+		return "the current directory"
+	}
+	return m.exportDir
+}
+
+func (m BrowserModel) handleTagKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.tagging = false
+		m.tagInput = ""
+	case tea.KeyEnter:
+		tag, ok := parseBulkTag(m.tagInput)
+		if !ok {
+			return m, nil
+		}
+		targets, _ := m.bulkTargets()
+		var ids []string
+		for _, s := range targets {
+			if s.HasMeta {
+				ids = append(ids, s.RunID)
+			}
+		}
+		m.tagging = false
+		m.tagInput = ""
+		if len(ids) == 0 {
+			return m, nil
+		}
+		m.result = BrowserResult{Action: BrowserActionTag, RunID: m.currentRunID(), RunIDs: ids, Tag: tag}
+		return m, tea.Quit
+	case tea.KeyBackspace:
+		if r := []rune(m.tagInput); len(r) > 0 {
+			m.tagInput = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		m.tagInput = ""
+	case tea.KeyRunes:
+		m.tagInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// parseBulkTag validates the tag typed after #. A leading "-" asks for the
+// tag to be removed and is kept; a leading "#" is dropped.
+func parseBulkTag(input string) (string, bool) {
+	tag := strings.TrimPrefix(strings.TrimSpace(input), "#")
+	name := strings.TrimPrefix(tag, "-")
+	if !runner.ValidTag(name) {
+		return "", false
+	}
+	return tag, true
+}
+
+// bulkStatusLeft describes the selection, or the prompt of a pending bulk
+// action, for the status bar. It returns "" when neither applies.
+func (m BrowserModel) bulkStatusLeft() string {
+	targets, matching := m.bulkTargets()
+	scope := fmt.Sprintf("%d selected", len(targets))
+	if matching {
+		scope = fmt.Sprintf("%d matching", len(targets))
+	}
+	switch {
+	case m.confirmingBulkDelete:
+		return fmt.Sprintf("Delete %d sessions (%s)? This cannot be undone.", len(m.bulkDelete.RunIDs), viewer.FormatBytes(m.bulkDelete.Bytes))
+	case m.confirmingBulkExport:
+		return fmt.Sprintf("Export %d sessions as HTML into %s?", len(m.bulkExport.RunIDs), m.exportDestination())
+	case m.tagging:
+		return fmt.Sprintf("Tag %s (-tag removes): %s_", scope, m.tagInput)
+	case len(m.bulkSelected) > 0:
+		return scope
+	}
+	return ""
+}
+
+// renderBulkDeleteCard summarizes a pending bulk delete in the preview pane.
+func (m BrowserModel) renderBulkDeleteCard(contentWidth, visibleLines int) string {
+	plan := m.bulkDelete
+	scope := "selected"
+	if plan.Matching {
+		scope = "matching the current search and filters"
+	}
+	body := []string{
+		fmt.Sprintf("Sessions: %d %s", len(plan.RunIDs), scope),
+		fmt.Sprintf("Disk space: %s", viewer.FormatBytes(plan.Bytes)),
+	}
+	if plan.Running > 0 {
+		body = append(body, fmt.Sprintf("Kept: %d still running", plan.Running))
+	}
+	const shown = 5
+	var ids []string
+	for i, id := range plan.RunIDs {
+		if i == shown {
+			ids = append(ids, fmt.Sprintf("… and %d more", len(plan.RunIDs)-shown))
+			break
+		}
+		ids = append(ids, shortID(id))
+	}
+	body = append(body, "Runs: "+strings.Join(ids, ", "), "", "y/Enter deletes them, n/Esc cancels.")
+	title := fmt.Sprintf("DELETE %d SESSIONS", len(plan.RunIDs))
+	return renderBrowserStateCard(contentWidth, visibleLines, title, body, true)
+}
+
+// renderBulkExportCard summarizes a pending bulk export in the preview pane.
+func (m BrowserModel) renderBulkExportCard(contentWidth, visibleLines int) string {
+	plan := m.bulkExport
+	scope := "selected"
+	if plan.Matching {
+		scope = "matching the current search and filters"
+	}
+	body := []string{
+		fmt.Sprintf("Sessions: %d %s", len(plan.RunIDs), scope),
+		"Destination: " + m.exportDestination(),
+		"Files: <run-id>.html, replacing existing ones",
+		"",
+		"y/Enter exports them, n/Esc cancels.",
+	}
+	title := fmt.Sprintf("EXPORT %d SESSIONS", len(plan.RunIDs))
+	return renderBrowserStateCard(contentWidth, visibleLines, title, body, false)
+}
@@ -0,0 +1,227 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// bulkTestBrowser is a sized browser over five sessions, newest first:
+// run-1 (running), run-2 … run-5 (pi or claude, completed or failed).
+func bulkTestBrowser(t *testing.T) BrowserModel {
+	t.Helper()
+	now := time.Now()
+	summaries := []viewer.RunSummary{
+		browserTestSummaryWithActions("run-1", now, "pi", "running", "default", true),
+		browserTestSummaryWithActions("run-2", now.Add(-1*time.Hour), "pi", "failed", "default", true),
+		browserTestSummaryWithActions("run-3", now.Add(-2*time.Hour), "claude", "completed", "default", true),
+		browserTestSummaryWithActions("run-4", now.Add(-3*time.Hour), "pi", "failed", "default", false),
+		browserTestSummaryWithActions("run-5", now.Add(-4*time.Hour), "claude", "failed", "default", true),
+	}
+	m := NewBrowserModel(summaries)
+	m.width = 120
+	m.height = 40
+	return m
+}
+
+func bulkTargetIDs(m BrowserModel) string {
+	targets, _ := m.bulkTargets()
+	return strings.Join(browserRunIDs(targets), ",")
+}
+
+func TestBrowserBulkSelection(t *testing.T) {
+	m := bulkTestBrowser(t)
+
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, " ")
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, "V")
+	if got := bulkTargetIDs(m); got != "run-2,run-3,run-4" {
+		t.Fatalf("targets after space and V = %q", got)
+	}
+	if !strings.Contains(m.View(), "✓ run-3") || !strings.Contains(strings.Join(m.browserStateTokens(), " "), "selected:3") {
+		t.Errorf("selection not shown:\n%s", m.View())
+	}
+
+	// Space on a selected session deselects it.
+	m = pressKey(t, m, "k")
+	m = pressKey(t, m, " ")
+	if got := bulkTargetIDs(m); got != "run-2,run-4" {
+		t.Fatalf("targets after deselect = %q", got)
+	}
+
+	// Esc clears the selection before it quits.
+	m, cmd := updateBrowserModelWithCmd(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEsc}))
+	if cmd != nil || len(m.bulkSelected) != 0 {
+		t.Fatalf("esc with a selection: cmd %v, %d selected", cmd, len(m.bulkSelected))
+	}
+	if _, cmd := updateBrowserModelWithCmd(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEsc})); cmd == nil {
+		t.Error("esc without a selection did not quit")
+	}
+}
+
+func TestBrowserBulkDeleteSelection(t *testing.T) {
+	m := bulkTestBrowser(t)
+	dir := t.TempDir()
+	for i := range m.allSummaries {
+		m.allSummaries[i].Dir = filepath.Join(dir, m.allSummaries[i].RunID)
+		if err := os.MkdirAll(m.allSummaries[i].Dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(m.allSummaries[i].Dir, "events.jsonl"), make([]byte, 1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.applyBrowserView()
+
+	m = pressKey(t, m, " ")
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, "V")
+	m = pressKey(t, m, "X")
+	if !m.confirmingBulkDelete {
+		t.Fatal("X did not ask for confirmation")
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"DELETE 2 SESSIONS", "Sessions: 2 selected", "Disk space: 2.0 KiB", "Kept: 1 still running", "Runs: run-2, run-3"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("confirmation missing %q:\n%s", want, view)
+		}
+	}
+
+	// n cancels without a result.
+	cancelled := pressKey(t, m, "n")
+	if cancelled.confirmingBulkDelete || cancelled.Result().Action != BrowserActionNone {
+		t.Fatal("n did not cancel the bulk delete")
+	}
+
+	m = pressKey(t, m, "y")
+	result := m.Result()
+	if result.Action != BrowserActionBulkDelete || strings.Join(result.RunIDs, ",") != "run-2,run-3" || len(result.DeleteDirs) != 2 {
+		t.Fatalf("Result() = %+v, want bulk delete of run-2 and run-3", result)
+	}
+	if result.DeleteNextRunID != "run-4" {
+		t.Errorf("DeleteNextRunID = %q, want run-4", result.DeleteNextRunID)
+	}
+}
+
+func TestBrowserBulkDeleteNeedsSelectionOrFilter(t *testing.T) {
+	m := bulkTestBrowser(t)
+	m = pressKey(t, m, "X")
+	if m.confirmingBulkDelete {
+		t.Fatal("X without a selection or filter offered to delete every session")
+	}
+	if left := m.browserStatusLeft(); !strings.Contains(left, "Select sessions or filter the list") {
+		t.Errorf("status left = %q, want a hint", left)
+	}
+	// The hint goes away with the next key.
+	m = pressKey(t, m, "j")
+	if strings.Contains(m.browserStatusLeft(), "Select sessions") {
+		t.Error("hint kept after the next key")
+	}
+}
+
+func TestBrowserBulkDeleteMatchingFilter(t *testing.T) {
+	m := bulkTestBrowser(t)
+	m = pressKey(t, m, "t") // status filter: completed
+	m = pressKey(t, m, "t") // failed
+	if m.statusFilter != "failed" {
+		t.Fatalf("status filter = %q", m.statusFilter)
+	}
+
+	m = pressKey(t, m, "X")
+	if !m.bulkDelete.Matching || strings.Join(m.bulkDelete.RunIDs, ",") != "run-2,run-4,run-5" {
+		t.Fatalf("plan = %+v, want every failed run", m.bulkDelete)
+	}
+	if !strings.Contains(ansi.Strip(m.View()), "matching the current search and filters") {
+		t.Errorf("confirmation does not say it covers the filter:\n%s", ansi.Strip(m.View()))
+	}
+}
+
+func TestBrowserBulkExport(t *testing.T) {
+	m := bulkTestBrowser(t)
+	m = pressKey(t, m, "V")
+	m = pressKey(t, m, "G")
+	m = pressKey(t, m, "V")
+
+	m = m.WithExportDir("/tmp/exports")
+	m = pressKey(t, m, "E")
+	if !m.confirmingBulkExport || m.Result().Action != BrowserActionNone {
+		t.Fatal("E exported without asking for confirmation")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Export 4 sessions as HTML into /tmp/exports?") {
+		t.Errorf("confirmation does not show the count and destination:\n%s", view)
+	}
+
+	// n cancels without a result.
+	cancelled := pressKey(t, m, "n")
+	if cancelled.confirmingBulkExport || cancelled.Result().Action != BrowserActionNone {
+		t.Fatal("n did not cancel the bulk export")
+	}
+
+	m, cmd := updateBrowserModelWithCmd(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune("y")}))
+	result := m.Result()
+	if cmd == nil || result.Action != BrowserActionExport {
+		t.Fatalf("Result() = %+v, want export", result)
+	}
+	// run-4 has no events to export.
+	if got := strings.Join(result.RunIDs, ","); got != "run-1,run-2,run-3,run-5" || result.RunID != "run-5" {
+		t.Errorf("export of %q from %q", got, result.RunID)
+	}
+}
+
+func TestBrowserBulkTag(t *testing.T) {
+	m := bulkTestBrowser(t)
+	m = pressKey(t, m, " ")
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, " ")
+	m = pressKey(t, m, "#")
+	if !m.tagging {
+		t.Fatal("# did not start tag input")
+	}
+	for _, r := range "bad tag" {
+		m = pressKey(t, m, string(r))
+	}
+	if !strings.Contains(ansi.Strip(m.View()), "Tag 2 selected (-tag removes): bad tag_") {
+		t.Errorf("tag prompt not shown:\n%s", ansi.Strip(m.View()))
+	}
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	if !m.tagging || m.Result().Action != BrowserActionNone {
+		t.Fatal("an invalid tag was accepted")
+	}
+
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyCtrlU}))
+	for _, r := range "#nightly" {
+		m = pressKey(t, m, string(r))
+	}
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	result := m.Result()
+	if result.Action != BrowserActionTag || result.Tag != "nightly" || strings.Join(result.RunIDs, ",") != "run-1,run-2" {
+		t.Fatalf("Result() = %+v, want nightly tag on run-1 and run-2", result)
+	}
+}
+
+func TestParseBulkTag(t *testing.T) {
+	for input, want := range map[string]string{
+		"nightly":    "nightly",
+		" #nightly ": "nightly",
+		"-nightly":   "-nightly",
+		"":           "",
+		"-":          "",
+		"two words":  "",
+		"a,b":        "",
+	} {
+		got, ok := parseBulkTag(input)
+		if got != want || ok != (want != "") {
+			t.Errorf("parseBulkTag(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+}
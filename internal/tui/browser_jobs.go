@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// Job tree: L lists each chain of resumed runs together, in resume order,
+// under the run it started from. The first listed run of a job shows the
+// job's totals in place of its own details, which stay in the preview.
+
+// groupBrowserJobs reorders sorted summaries so each job's runs follow the
+// first of them in job order. Jobs keep the position of their best-sorted
+// run; runs hidden by filters stay hidden.
+func groupBrowserJobs(summaries []viewer.RunSummary, jobs map[string]*viewer.RunJob) []viewer.RunSummary {
+	byID := make(map[string]viewer.RunSummary, len(summaries))
+	for _, summary := range summaries {
+		byID[summary.RunID] = summary
+	}
+	grouped := make([]viewer.RunSummary, 0, len(summaries))
+	seen := make(map[*viewer.RunJob]bool)
+	for _, summary := range summaries {
+		job := jobs[summary.RunID]
+		if job == nil {
+			grouped = append(grouped, summary)
+			continue
+		}
+		if seen[job] {
+			continue
+		}
+		seen[job] = true
+		for _, runID := range job.RunIDs {
+			if member, ok := byID[runID]; ok {
+				grouped = append(grouped, member)
+			}
+		}
+	}
+	return grouped
+}
+
+// browserJobRow returns the multi-run job the i-th listed session belongs
+// to in the job tree, and whether it is the first listed run of that job.
+// It returns nil outside the job tree and for runs that were never resumed.
+func (m BrowserModel) browserJobRow(i int) (*viewer.RunJob, bool) {
+	if !m.treeView {
+		return nil, false
+	}
+	job := m.jobs[m.summaries[i].RunID]
+	if job == nil || len(job.RunIDs) < 2 {
+		return nil, false
+	}
+	return job, i == 0 || m.jobs[m.summaries[i-1].RunID] != job
+}
+
+func browserJobSummaryRow(job *viewer.RunJob, width int) string {
+	row := fmt.Sprintf("job • %d runs • %d iterations • %s • %s",
+		len(job.RunIDs), job.Iterations, formatElapsed(job.Duration), job.Status)
+	return truncateToWidth(row, width)
+}
+
+// browserJobText describes the selected session's job in the preview,
+// listing its runs and marking the selected one.
+func (m BrowserModel) browserJobText(job *viewer.RunJob, selectedRunID string) string {
+	byID := make(map[string]viewer.RunSummary, len(job.RunIDs))
+	for _, summary := range m.allSummaries {
+		if m.jobs[summary.RunID] == job {
+			byID[summary.RunID] = summary
+		}
+	}
+	lines := []string{
+		fmt.Sprintf("Job (%d runs)", len(job.RunIDs)),
+		fmt.Sprintf("  iterations: %d • duration: %s • status: %s",
+			job.Iterations, formatElapsed(job.Duration), job.Status),
+	}
+	for _, runID := range job.RunIDs {
+		summary := byID[runID]
+		marker := " "
+		if runID == selectedRunID {
+			marker = "▸"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s  %s  %s • %d it",
+			marker, shortID(runID), browserCompactDate(summary), summary.Status, summary.IterationsCompleted))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// jobTestBrowser is a sized browser over a job of three resumed runs
+// (job-1 → job-2 → job-3) interleaved with an unrelated run, newest first.
+func jobTestBrowser(t *testing.T) BrowserModel {
+	t.Helper()
+	now := time.Now()
+	summaries := []viewer.RunSummary{
+		browserTestSummaryWithActions("job-3", now, "pi", "completed", "prompt", true),
+		browserTestSummaryWithActions("solo-1", now.Add(-1*time.Hour), "claude", "failed", "default", true),
+		browserTestSummaryWithActions("job-2", now.Add(-2*time.Hour), "pi", "interrupted", "prompt", true),
+		browserTestSummaryWithActions("job-1", now.Add(-3*time.Hour), "pi", "max_iterations_reached", "plan", true),
+	}
+	summaries[0].Meta.ResumedFrom = "job-2"
+	summaries[2].Meta.ResumedFrom = "job-1"
+	for i := range summaries {
+		summaries[i].IterationsCompleted = i + 1
+	}
+	m := NewBrowserModel(summaries)
+	m.width = 120
+	m.height = 40
+	return m
+}
+
+func TestBrowserJobTree(t *testing.T) {
+	m := jobTestBrowser(t)
+	if got := strings.Join(browserRunIDs(m.summaries), ","); got != "job-3,solo-1,job-2,job-1" {
+		t.Fatalf("flat order = %q", got)
+	}
+
+	m = pressKey(t, m, "L")
+	if got := strings.Join(browserRunIDs(m.summaries), ","); got != "job-1,job-2,job-3,solo-1" {
+		t.Fatalf("tree order = %q, want the job grouped in resume order", got)
+	}
+	if m.selectedRunID != "job-3" {
+		t.Errorf("selection = %q, want it kept on job-3", m.selectedRunID)
+	}
+	if !strings.Contains(strings.Join(m.browserStateTokens(), " "), "tree") {
+		t.Errorf("state tokens = %q, want tree", m.browserStateTokens())
+	}
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"job • 3 runs • 8 iterations", "└ job-2", "└ job-3"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "└ solo-1") {
+		t.Errorf("unrelated run drawn as part of the job:\n%s", view)
+	}
+
+	// Filters still apply per run; the rest of the job stays together.
+	m = pressKey(t, m, "a")
+	if got := strings.Join(browserRunIDs(m.summaries), ","); m.agentFilter != "claude" || got != "solo-1" {
+		t.Fatalf("agent:%s shows %q, want solo-1", m.agentFilter, got)
+	}
+	m = pressKey(t, m, "a")
+	if got := strings.Join(browserRunIDs(m.summaries), ","); got != "job-1,job-2,job-3" {
+		t.Fatalf("agent:%s shows %q, want the job", m.agentFilter, got)
+	}
+
+	m = pressKey(t, m, "L")
+	if got := strings.Join(browserRunIDs(m.summaries), ","); got != "job-3,job-2,job-1" {
+		t.Errorf("flat order after toggling back = %q", got)
+	}
+}
+
+func TestBrowserJobPreview(t *testing.T) {
+	m := jobTestBrowser(t)
+	preview := m.browserPreviewText()
+	for _, want := range []string{"Job (3 runs)", "iterations: 8", "status: completed", "▸ job-3", "  job-1", "Resumed from: job-2"} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("preview missing %q:\n%s", want, preview)
+		}
+	}
+
+	m = pressKey(t, m, "j")
+	if preview := m.browserPreviewText(); strings.Contains(preview, "Job (") {
+		t.Errorf("run outside any job shows a job section:\n%s", preview)
+	}
+}
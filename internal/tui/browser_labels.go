@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// Session labels: e edits the selected session's tags and A its free-form
+// annotation; T cycles the tag filter. Edits are written to meta.json by
+// main, like the other browser actions.
+
+// browserLabelField names the label being edited in the status bar.
+type browserLabelField string
+
+const (
+	browserLabelNone       browserLabelField = ""
+	browserLabelTags       browserLabelField = "tags"
+	browserLabelAnnotation browserLabelField = "annotation"
+)
+
+// startLabelEdit opens the editor for field, prefilled with the selected
+// session's current value. Sessions without meta.json cannot be labeled.
+func (m *BrowserModel) startLabelEdit(field browserLabelField) {
+	summary := m.currentSummary()
+	if summary == nil || !summary.HasMeta {
+		return
+	}
+	m.editingLabel = field
+	m.labelRunID = summary.RunID
+	switch field {
+	case browserLabelTags:
+		m.labelInput = strings.Join(summary.Meta.Tags, " ")
+	case browserLabelAnnotation:
+		m.labelInput = summary.Meta.Annotation
+	}
+}
+
+func (m BrowserModel) handleLabelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.editingLabel = browserLabelNone
+		m.labelInput = ""
+	case tea.KeyEnter:
+		switch m.editingLabel {
+		case browserLabelTags:
+			tags, ok := parseTagList(m.labelInput)
+			if !ok {
+				return m, nil
+			}
+			m.result = BrowserResult{Action: BrowserActionEditTags, RunID: m.labelRunID, Tags: tags}
+		case browserLabelAnnotation:
+			m.result = BrowserResult{Action: BrowserActionAnnotate, RunID: m.labelRunID, Annotation: strings.TrimSpace(m.labelInput)}
+		}
+		m.editingLabel = browserLabelNone
+		m.labelInput = ""
+		return m, tea.Quit
+	case tea.KeyBackspace:
+		if r := []rune(m.labelInput); len(r) > 0 {
+			m.labelInput = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		m.labelInput = ""
+	case tea.KeySpace:
+		m.labelInput += " "
+	case tea.KeyRunes:
+		m.labelInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// parseTagList splits the tags typed after e on spaces and commas. Each
+// must be a valid tag; duplicates are dropped and an empty list clears the
+// session's tags.
+func parseTagList(input string) ([]string, bool) {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(input, func(r rune) bool { return r == ' ' || r == ',' }) {
+		tag = strings.TrimPrefix(tag, "#")
+		if !runner.ValidTag(tag) {
+			return nil, false
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, true
+}
+
+// labelStatusLeft is the status bar prompt while a label is edited.
+func (m BrowserModel) labelStatusLeft() string {
+	switch m.editingLabel {
+	case browserLabelTags:
+		return fmt.Sprintf("Tags for %s (space-separated): %s_", shortID(m.labelRunID), m.labelInput)
+	case browserLabelAnnotation:
+		return fmt.Sprintf("Annotation for %s: %s_", shortID(m.labelRunID), m.labelInput)
+	}
+	return ""
+}
+
+// browserTagOptions returns every tag used by summaries, for the T filter.
+func browserTagOptions(summaries []viewer.RunSummary) []string {
+	seen := make(map[string]bool)
+	var options []string
+	for _, summary := range summaries {
+		for _, tag := range summary.Meta.Tags {
+			if key := strings.ToLower(tag); !seen[key] {
+				seen[key] = true
+				options = append(options, tag)
+			}
+		}
+	}
+	sort.Slice(options, func(i, j int) bool {
+		return browserFacetLess(options[i], options[j])
+	})
+	return options
+}
+
+func browserSummaryHasTag(summary viewer.RunSummary, tag string) bool {
+	return slices.ContainsFunc(summary.Meta.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// labelTestBrowser is a sized browser over three sessions, newest first:
+// run-1 (named, tagged nightly), run-2 (tagged nightly and flaky) and run-3
+// (no labels).
+func labelTestBrowser(t *testing.T) BrowserModel {
+	t.Helper()
+	now := time.Now()
+	summaries := []viewer.RunSummary{
+		browserTestSummaryWithActions("run-1", now, "pi", "completed", "default", true),
+		browserTestSummaryWithActions("run-2", now.Add(-1*time.Hour), "pi", "failed", "default", true),
+		browserTestSummaryWithActions("run-3", now.Add(-2*time.Hour), "claude", "completed", "default", true),
+	}
+	summaries[0].Meta.Name = "auth-refactor"
+	summaries[0].Meta.Tags = []string{"nightly"}
+	summaries[1].Meta.Tags = []string{"Nightly", "flaky"}
+	summaries[1].Meta.Annotation = "hit the rate limit"
+	m := NewBrowserModel(summaries)
+	m.width = 120
+	m.height = 40
+	return m
+}
+
+func TestBrowserTagFilter(t *testing.T) {
+	m := labelTestBrowser(t)
+	if got := strings.Join(m.tagOptions, ","); got != "flaky,nightly" {
+		t.Fatalf("tag options = %q, want flaky,nightly", got)
+	}
+
+	m = pressKey(t, m, "T")
+	if got := strings.Join(browserRunIDs(m.summaries), ","); got != "run-2" || m.tagFilter != "flaky" {
+		t.Fatalf("tag:%s shows %q, want run-2", m.tagFilter, got)
+	}
+	m = pressKey(t, m, "T")
+	if got := strings.Join(browserRunIDs(m.summaries), ","); got != "run-1,run-2" {
+		t.Fatalf("tag:nightly shows %q, want run-1,run-2 (case-insensitive)", got)
+	}
+	if !strings.Contains(strings.Join(m.browserStateTokens(), " "), "tag:nightly") {
+		t.Errorf("state tokens = %q", m.browserStateTokens())
+	}
+
+	m = pressKey(t, m, "c")
+	if m.tagFilter != "" || len(m.summaries) != 3 {
+		t.Errorf("c left tag filter %q with %d sessions", m.tagFilter, len(m.summaries))
+	}
+}
+
+func TestBrowserShowsLabels(t *testing.T) {
+	m := labelTestBrowser(t)
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"auth-refactor", "#nightly", "Name: auth-refactor", "Tags: nightly"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m = pressKey(t, m, "j")
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Annotation: hit the rate limit") {
+		t.Errorf("annotation not previewed:\n%s", view)
+	}
+}
+
+func TestBrowserEditTags(t *testing.T) {
+	m := labelTestBrowser(t)
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, "e")
+	if m.editingLabel != browserLabelTags || m.labelInput != "Nightly flaky" {
+		t.Fatalf("editor = %q with %q, want the current tags", m.editingLabel, m.labelInput)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Tags for run-2 (space-separated): Nightly flaky_") {
+		t.Errorf("tag editor not shown:\n%s", view)
+	}
+
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeySpace}))
+	for _, r := range "#infra,flaky" {
+		m = pressKey(t, m, string(r))
+	}
+	m, cmd := updateBrowserModelWithCmd(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	result := m.Result()
+	if cmd == nil || result.Action != BrowserActionEditTags || result.RunID != "run-2" {
+		t.Fatalf("Result() = %+v, want tag edit of run-2", result)
+	}
+	if got := strings.Join(result.Tags, ","); got != "Nightly,flaky,infra" {
+		t.Errorf("Tags = %q, want Nightly,flaky,infra", got)
+	}
+}
+
+func TestBrowserEditTagsRejectsInvalidAndEscCancels(t *testing.T) {
+	m := labelTestBrowser(t)
+	m = pressKey(t, m, "e")
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyCtrlU}))
+	m = pressKey(t, m, "-bad")
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	if m.editingLabel != browserLabelTags || m.Result().Action != BrowserActionNone {
+		t.Fatal("an invalid tag was accepted")
+	}
+
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEsc}))
+	if m.editingLabel != browserLabelNone || m.Result().Action != BrowserActionNone {
+		t.Fatal("esc did not cancel the edit")
+	}
+}
+
+func TestBrowserAnnotate(t *testing.T) {
+	m := labelTestBrowser(t)
+	m = pressKey(t, m, "A")
+	if m.editingLabel != browserLabelAnnotation || m.labelInput != "" {
+		t.Fatalf("editor = %q with %q", m.editingLabel, m.labelInput)
+	}
+	for _, word := range []string{"good", "baseline"} {
+		m = pressKey(t, m, word)
+		m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeySpace}))
+	}
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	result := m.Result()
+	if result.Action != BrowserActionAnnotate || result.RunID != "run-1" || result.Annotation != "good baseline" {
+		t.Fatalf("Result() = %+v, want annotation of run-1", result)
+	}
+}
+
+func TestParseTagList(t *testing.T) {
+	for input, want := range map[string]string{
+		"nightly flaky":   "nightly,flaky",
+		" #a, b  a ":      "a,b",
+		"":                "",
+		"ok -bad":         "!",
+		"ok @nightly-old": "!",
+	} {
+		tags, ok := parseTagList(input)
+		got := strings.Join(tags, ",")
+		if !ok {
+			got = "!"
+		}
+		if got != want {
+			t.Errorf("parseTagList(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/fsmiamoto/ralfinho/internal/runner"
 	"github.com/fsmiamoto/ralfinho/internal/viewer"
 )
 
@@ -72,6 +73,70 @@ func TestBrowserModelAppliesSearchAndFieldFilters(t *testing.T) {
 	}
 }
 
+func TestBrowserModelRunsStoreQueryForFieldFilters(t *testing.T) {
+	summaries := []viewer.RunSummary{
+		browserTestSummary("run-beta", time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC), "pi", "completed", "plan"),
+		browserTestSummary("run-gamma", time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC), "kiro", "failed", "default"),
+		browserTestSummary("run-alpha", time.Date(2026, 3, 8, 11, 30, 0, 0, time.UTC), "kiro", "interrupted", "prompt"),
+	}
+
+	var queries []runner.RunQuery
+	m := NewBrowserModel(summaries).WithRunQuery(func(q runner.RunQuery) ([]viewer.RunSummary, error) {
+		queries = append(queries, q)
+		var out []viewer.RunSummary
+		for _, s := range summaries {
+			if q.Agent == "" || s.Agent == q.Agent {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	})
+	if len(queries) != 0 {
+		t.Fatalf("queries without filters = %+v, want none", queries)
+	}
+
+	m.agentFilter = "kiro"
+	m.applyBrowserView()
+	if got, want := browserRunIDs(m.summaries), []string{"run-alpha", "run-gamma"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("agent filter results = %v, want %v", got, want)
+	}
+	// Search and sort changes reuse the cached answer.
+	m.searchQuery = "gamma"
+	m.applyBrowserView()
+	if len(queries) != 1 || queries[0] != (runner.RunQuery{Agent: "kiro"}) {
+		t.Fatalf("queries = %+v, want one agent query", queries)
+	}
+	if got, want := browserRunIDs(m.summaries), []string{"run-gamma"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("search within query results = %v, want %v", got, want)
+	}
+
+	m.searchQuery = ""
+	m.dateFilter = "2026-03-06"
+	m.applyBrowserView()
+	last := queries[len(queries)-1]
+	if last.Agent != "kiro" || last.Since.IsZero() || last.Until.IsZero() {
+		t.Fatalf("date query = %+v, want agent and a time range", last)
+	}
+	if got, want := browserRunIDs(m.summaries), []string{"run-gamma"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("agent+date results = %v, want %v", got, want)
+	}
+}
+
+func TestBrowserModelFallsBackWhenStoreQueryFails(t *testing.T) {
+	summaries := []viewer.RunSummary{
+		browserTestSummary("run-beta", time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC), "pi", "completed", "plan"),
+		browserTestSummary("run-gamma", time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC), "kiro", "failed", "default"),
+	}
+	m := NewBrowserModel(summaries).WithRunQuery(func(runner.RunQuery) ([]viewer.RunSummary, error) {
+		return nil, fmt.Errorf("index unavailable")
+	})
+	m.statusFilter = "failed"
+	m.applyBrowserView()
+	if got, want := browserRunIDs(m.summaries), []string{"run-gamma"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("status filter results = %v, want %v", got, want)
+	}
+}
+
 func TestBrowserModelSearchEditingAndSortCyclePreserveSelection(t *testing.T) {
 	summaries := []viewer.RunSummary{
 		browserTestSummary("run-beta", time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC), "pi", "completed", "plan"),
@@ -612,6 +677,26 @@ func TestBrowserResumeActionOnR(t *testing.T) {
 	}
 }
 
+func TestBrowserRerunActionOnShiftR(t *testing.T) {
+	rerunnable := browserTestSummary("rerun-run", time.Now(), "pi", "completed", "prompt")
+	rerunnable.Actions.Rerun.Available = true
+	blocked := browserTestSummary("blocked-run", time.Now().Add(-time.Hour), "pi", "completed", "prompt")
+	m := NewBrowserModel([]viewer.RunSummary{rerunnable, blocked})
+	m.width = 100
+	m.height = 30
+
+	next := updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'R'}}))
+	if result := next.Result(); result.Action != BrowserActionRerun || result.RunID != "rerun-run" {
+		t.Fatalf("Result() = %+v, want rerun of rerun-run", result)
+	}
+
+	m = m.WithSelectedRunID("blocked-run")
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'R'}}))
+	if result := m.Result(); result.Action != BrowserActionNone {
+		t.Fatalf("Result().Action = %q, want none when rerun is unavailable", result.Action)
+	}
+}
+
 func TestBrowserResumeActionBlockedWhenUnavailable(t *testing.T) {
 	summaries := []viewer.RunSummary{
 		browserTestSummaryWithActions("no-resume-run", time.Now(), "pi", "unknown", "default", false),
@@ -2334,6 +2419,29 @@ func TestBrowserPreviewTextStandalone(t *testing.T) {
 		}
 	})
 
+	t.Run("recorded environment", func(t *testing.T) {
+		s := &viewer.RunSummary{RunID: "env-run", Meta: runner.RunMeta{Environment: &runner.RunEnvironment{
+			RalfinhoVersion: "1.4.0",
+			AgentBinary:     "/usr/local/bin/pi",
+			WorkDir:         "/src/app",
+			PromptSHA256:    "0123456789abcdef0123",
+			GitStart:        &runner.GitState{Branch: "main", Commit: "aaaaaaaaaaaaaaaa"},
+			GitEnd:          &runner.GitState{Branch: "main", Commit: "bbbbbbbbbbbbbbbb", Dirty: true},
+		}}}
+		got := browserPreviewText(s)
+		for _, want := range []string{
+			"Environment", "ralfinho: 1.4.0", "agent binary: /usr/local/bin/pi", "directory: /src/app",
+			"git: main@aaaaaaaaaaaa → main@bbbbbbbbbbbb (dirty)", "prompt sha256: 0123456789ab",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("browserPreviewText missing %q in:\n%s", want, got)
+			}
+		}
+		if strings.Contains(got, "agent version:") {
+			t.Errorf("unrecorded fields should be skipped:\n%s", got)
+		}
+	})
+
 	t.Run("summary with errors shows notes", func(t *testing.T) {
 		s := &viewer.RunSummary{
 			RunID:         "err-run",
@@ -2355,6 +2463,37 @@ func TestBrowserPreviewTextStandalone(t *testing.T) {
 		}
 	})
 
+	t.Run("failed run shows failure section", func(t *testing.T) {
+		code := 1
+		stderr := strings.Repeat("noise\n", 20) + "fatal: quota exceeded"
+		s := &viewer.RunSummary{
+			RunID:  "fail-run",
+			Agent:  "claude",
+			Status: "failed",
+			Meta: runner.RunMeta{Failure: &runner.Failure{
+				Category:  runner.FailureAgentExit,
+				Message:   "claude: agent exited with error: exit status 1",
+				ExitCode:  &code,
+				Stderr:    stderr,
+				Iteration: 2,
+				Timestamp: "2026-03-08T14:31:00Z",
+			}},
+		}
+		got := browserPreviewText(s)
+		for _, want := range []string{
+			"Failure", "Category: agent_exit", "Iteration: 2", "Exit code: 1",
+			"Reason: claude: agent exited with error: exit status 1",
+			"Stderr (last 8 lines):", "fatal: quota exceeded",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("browserPreviewText missing %q in:\n%s", want, got)
+			}
+		}
+		if strings.Count(got, "noise") != browserFailureStderrLines-1 {
+			t.Errorf("stderr tail not capped to %d lines:\n%s", browserFailureStderrLines, got)
+		}
+	})
+
 	t.Run("zero time with text shows raw date", func(t *testing.T) {
 		s := &viewer.RunSummary{
 			RunID:         "raw-date",
@@ -3065,3 +3204,149 @@ func pressKeyMsg(t *testing.T, m BrowserModel, msg tea.KeyMsg) BrowserModel {
 	t.Helper()
 	return updateBrowserModel(t, m, msg)
 }
+
+func TestBrowserSearchMatchesRunContent(t *testing.T) {
+	summaries, load, _ := contentTestRuns(t, map[string][]runner.Event{
+		"run-a": contentTestEvents("Editing the migration.", "db/migrations.go"),
+		"run-b": contentTestEvents("Nothing to see here.", "README.md"),
+	})
+	m := NewBrowserModel(summaries).WithContentIndex(NewContentIndex(load))
+	m.width = 120
+	m.height = 40
+
+	// The index is built in the background; typing goes on meanwhile.
+	m, sync := updateBrowserModelWithCmd(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'/'}}))
+	if sync == nil || !m.indexing {
+		t.Fatal("/ did not start indexing in the background")
+	}
+	for _, r := range "migr" {
+		m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{r}}))
+	}
+	if left := m.browserStatusLeft(); !strings.Contains(left, "indexing run content…") {
+		t.Errorf("status left = %q, want the indexing state", left)
+	}
+	if len(m.summaries) != 0 {
+		t.Errorf("content matches shown before indexing finished: %v", browserRunIDs(m.summaries))
+	}
+	updated, _ := m.Update(sync())
+	m = updated.(BrowserModel)
+	if m.indexing {
+		t.Error("indexing state kept after the index was built")
+	}
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+
+	if got, want := browserRunIDs(m.summaries), []string{"run-a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("content search results = %v, want %v", got, want)
+	}
+	preview := m.browserPreviewText()
+	for _, want := range []string{"Content matches (2)", "▶ iter 2 · assistant: Editing the migration.", "custom_edit args"} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("preview missing %q:\n%s", want, preview)
+		}
+	}
+	if left := m.browserStatusLeft(); !strings.Contains(left, "match 1/2") {
+		t.Errorf("status left = %q, want match position", left)
+	}
+
+	// n selects the next hit, N wraps back around.
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'n'}}))
+	if hit := m.currentHit(); hit == nil || hit.Field != "args" {
+		t.Fatalf("currentHit after n = %+v, want the args hit", hit)
+	}
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'N'}}))
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'N'}}))
+	if hit := m.currentHit(); hit == nil || hit.Field != "args" {
+		t.Fatalf("currentHit after N N = %+v, want wrap to the args hit", hit)
+	}
+
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	result := m.Result()
+	if result.Action != BrowserActionOpen || result.RunID != "run-a" {
+		t.Fatalf("Result() = %+v, want open run-a", result)
+	}
+	if result.SearchHit == nil || result.SearchHit.Block != 3 {
+		t.Fatalf("Result().SearchHit = %+v, want the custom_edit block", result.SearchHit)
+	}
+}
+
+func TestBrowserOpenWithoutContentMatchHasNoSearchHit(t *testing.T) {
+	summaries, load, _ := contentTestRuns(t, map[string][]runner.Event{
+		"run-a": contentTestEvents("alpha", "a.go"),
+	})
+	m := NewBrowserModel(summaries).WithContentIndex(NewContentIndex(load))
+	m.searchQuery = "run-a"
+	m.applyBrowserView()
+
+	m = updateBrowserModel(t, m, tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	if result := m.Result(); result.Action != BrowserActionOpen || result.SearchHit != nil {
+		t.Fatalf("Result() = %+v, want metadata match without a search hit", result)
+	}
+}
+
+// ===== Diff marks =====
+
+func TestBrowserMarkTwoRunsAndDiff(t *testing.T) {
+	now := time.Now()
+	summaries := []viewer.RunSummary{
+		browserTestSummaryWithActions("run-1", now, "pi", "completed", "default", true),
+		browserTestSummaryWithActions("run-2", now.Add(-time.Hour), "pi", "failed", "default", true),
+		browserTestSummaryWithActions("run-3", now.Add(-2*time.Hour), "pi", "completed", "default", true),
+	}
+	m := NewBrowserModel(summaries)
+	m.width = 120
+	m.height = 30
+
+	m = pressKey(t, m, "m")
+	if got := strings.Join(m.browserStateTokens(), " "); !strings.Contains(got, "marked:1") {
+		t.Errorf("state tokens = %q, want marked:1", got)
+	}
+	if !strings.Contains(m.View(), "[A] run-1") {
+		t.Errorf("marked run not labelled:\n%s", m.View())
+	}
+
+	// With one mark, D diffs it against the selected run.
+	m = pressKey(t, m, "j")
+	if !strings.Contains(strings.Join(m.browserStatusRightVariants(), " "), "diff") {
+		t.Error("status hints missing D:diff")
+	}
+
+	// Marking a third run drops the oldest mark.
+	m = pressKey(t, m, "m")
+	m = pressKey(t, m, "j")
+	m = pressKey(t, m, "m")
+	if got := strings.Join(m.marked, ","); got != "run-2,run-3" {
+		t.Fatalf("marked = %q, want run-2,run-3", got)
+	}
+
+	// m on a marked run unmarks it.
+	m = pressKey(t, m, "m")
+	if got := strings.Join(m.marked, ","); got != "run-2" {
+		t.Fatalf("marked after unmark = %q, want run-2", got)
+	}
+	m = pressKey(t, m, "m")
+
+	m = pressKey(t, m, "D")
+	result := m.Result()
+	if result.Action != BrowserActionDiff || result.RunID != "run-2" || result.DiffRunID != "run-3" {
+		t.Fatalf("Result() = %+v, want diff of run-2 and run-3", result)
+	}
+}
+
+func TestBrowserDiffNeedsTwoRuns(t *testing.T) {
+	summaries := []viewer.RunSummary{
+		browserTestSummaryWithActions("run-1", time.Now(), "pi", "completed", "default", true),
+	}
+	m := NewBrowserModel(summaries)
+	m.width = 120
+	m.height = 30
+
+	m = pressKey(t, m, "D")
+	m = pressKey(t, m, "m")
+	m = pressKey(t, m, "D")
+	if result := m.Result(); result.Action != BrowserActionNone {
+		t.Fatalf("Result() = %+v, want no action with a single run", result)
+	}
+	if strings.Contains(strings.Join(m.browserStatusRightVariants(), " "), "diff") {
+		t.Error("status hints offer diff without a second run")
+	}
+}
@@ -0,0 +1,435 @@
+package tui
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// ContentHit is one main-view block of a saved run that matches a content
+// search.
+type ContentHit struct {
+	Block     int    // index into the replay viewer's main-view blocks
+	Iteration int    // iteration the block belongs to
+	Field     string // "assistant", "tool", "args" or "result"
+	ToolName  string // set for tool call blocks
+	Snippet   string // single-line excerpt around the first match
+}
+
+// Label describes where the hit is, e.g. "iter 2 · bash args".
+func (h ContentHit) Label() string {
+	where := h.Field
+	if h.ToolName != "" && h.Field != "tool" {
+		where = h.ToolName + " " + h.Field
+	}
+	if h.Iteration > 0 {
+		return fmt.Sprintf("iter %d · %s", h.Iteration, where)
+	}
+	return where
+}
+
+// contentSnippetBefore and contentSnippetAfter bound the excerpt shown for a
+// hit, in runes around the start of the match.
+const (
+	contentSnippetBefore = 30
+	contentSnippetAfter  = 70
+)
+
+// contentField is one searchable piece of a block.
+type contentField struct {
+	name string
+	text string
+}
+
+// contentDoc is the searchable content of one main-view block.
+type contentDoc struct {
+	block     int
+	iteration int
+	toolName  string
+	fields    []contentField
+}
+
+// contentRun is the inverted index of one run's blocks.
+type contentRun struct {
+	stamp    string // events.jsonl path, size and modtime it was built from
+	docs     []contentDoc
+	terms    []string // sorted distinct lower-cased terms
+	postings [][]int  // postings[i] lists the docs containing terms[i]
+}
+
+// ContentIndex is a full-text index over the assistant text, tool names,
+// tool arguments and tool results of saved runs.
+//
+// Each run is indexed from the same blocks the replay viewer renders, so a
+// hit's Block can be handed to Model.WithFocusBlock. Runs are only
+// re-indexed when their events.jsonl changes, which makes it cheap to keep
+// one index for the whole browser session. With a cache directory (see
+// UseCache) each run's index is also saved to disk, so later sessions only
+// read the events of runs that are new or changed.
+type ContentIndex struct {
+	load     func(dir string) ([]runner.Event, error)
+	runs     map[string]*contentRun // by run ID
+	cacheDir string                 // "" keeps the index in memory only
+}
+
+// NewContentIndex creates an empty index that reads a run's events with
+// load, typically viewer.LoadEvents.
+func NewContentIndex(load func(dir string) ([]runner.Event, error)) *ContentIndex {
+	return &ContentIndex{load: load, runs: make(map[string]*contentRun)}
+}
+
+// UseCache makes the index save each run it builds in dir and reuse saved
+// runs whose events have not changed. dir should be specific to one runs
+// directory; files for runs that are no longer listed are removed by Sync.
+func (x *ContentIndex) UseCache(dir string) {
+	x.cacheDir = dir
+}
+
+// Sync brings the index up to date with summaries: runs whose events changed
+// since they were indexed are re-read, and runs no longer listed are
+// dropped. Runs whose events cannot be read are indexed as empty.
+func (x *ContentIndex) Sync(summaries []viewer.RunSummary) {
+	x.runs = x.update(x.runs, summaries)
+}
+
+// SyncCmd runs Sync in the background, since reading every run's events
+// can take a while on a large runs directory. The returned command's
+// ContentIndexedMsg must be passed to Apply on the event loop; until then
+// the index keeps answering searches from what it had indexed before.
+func (x *ContentIndex) SyncCmd(summaries []viewer.RunSummary) tea.Cmd {
+	prev := x.runs
+	return func() tea.Msg {
+		return ContentIndexedMsg{index: x, runs: x.update(prev, summaries)}
+	}
+}
+
+// ContentIndexedMsg carries the result of a ContentIndex.SyncCmd.
+type ContentIndexedMsg struct {
+	index *ContentIndex
+	runs  map[string]*contentRun
+}
+
+// Apply installs the result of a SyncCmd of this index. It reports false
+// for a message from another index.
+func (x *ContentIndex) Apply(msg ContentIndexedMsg) bool {
+	if msg.index != x {
+		return false
+	}
+	x.runs = msg.runs
+	return true
+}
+
+// update returns the index of summaries, reusing the runs of prev whose
+// events did not change. It leaves x.runs alone, so searches can go on
+// meanwhile.
+func (x *ContentIndex) update(prev map[string]*contentRun, summaries []viewer.RunSummary) map[string]*contentRun {
+	runs := make(map[string]*contentRun, len(summaries))
+	listed := make(map[string]bool, len(summaries))
+	for _, s := range summaries {
+		listed[s.RunID] = true
+		if !s.HasEvents {
+			continue
+		}
+		stamp := contentStamp(s.EventsPath)
+		if run, ok := prev[s.RunID]; ok && run.stamp == stamp {
+			runs[s.RunID] = run
+			continue
+		}
+		run, ok := x.readCache(s.RunID, stamp)
+		if !ok {
+			run = &contentRun{stamp: stamp}
+			if events, err := x.load(s.Dir); err == nil {
+				run.docs = contentDocs(ConvertEvents(events))
+			}
+			x.writeCache(s.RunID, run)
+		}
+		run.buildTerms()
+		runs[s.RunID] = run
+	}
+	x.pruneCache(listed)
+	return runs
+}
+
+// contentCacheVersion is bumped whenever the cached form or the way blocks
+// are built changes, so stale files are rebuilt instead of misread.
+const contentCacheVersion = 1
+
+// contentCacheFile is a run's index as saved in the cache directory.
+// Terms are rebuilt on load; only reading events is expensive.
+type contentCacheFile struct {
+	Version int
+	Stamp   string
+	Docs    []contentCacheDoc
+}
+
+type contentCacheDoc struct {
+	Block     int
+	Iteration int
+	ToolName  string
+	Fields    [][2]string // name, text
+}
+
+func (x *ContentIndex) cachePath(runID string) string {
+	return filepath.Join(x.cacheDir, runID+".gob")
+}
+
+// readCache returns the saved index of runID if it was built from the
+// events identified by stamp.
+func (x *ContentIndex) readCache(runID, stamp string) (*contentRun, bool) {
+	if x.cacheDir == "" || stamp == "" {
+		return nil, false
+	}
+	f, err := os.Open(x.cachePath(runID))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	var cached contentCacheFile
+	if err := gob.NewDecoder(f).Decode(&cached); err != nil || cached.Version != contentCacheVersion || cached.Stamp != stamp {
+		return nil, false
+	}
+	run := &contentRun{stamp: stamp, docs: make([]contentDoc, len(cached.Docs))}
+	for i, d := range cached.Docs {
+		doc := contentDoc{block: d.Block, iteration: d.Iteration, toolName: d.ToolName}
+		for _, f := range d.Fields {
+			doc.fields = append(doc.fields, contentField{name: f[0], text: f[1]})
+		}
+		run.docs[i] = doc
+	}
+	return run, true
+}
+
+// writeCache saves run for later sessions. Failures are ignored: the cache
+// only saves time.
+func (x *ContentIndex) writeCache(runID string, run *contentRun) {
+	if x.cacheDir == "" || run.stamp == "" {
+		return
+	}
+	cached := contentCacheFile{Version: contentCacheVersion, Stamp: run.stamp, Docs: make([]contentCacheDoc, len(run.docs))}
+	for i, doc := range run.docs {
+		d := contentCacheDoc{Block: doc.block, Iteration: doc.iteration, ToolName: doc.toolName}
+		for _, f := range doc.fields {
+			d.Fields = append(d.Fields, [2]string{f.name, f.text})
+		}
+		cached.Docs[i] = d
+	}
+
+	if err := os.MkdirAll(x.cacheDir, 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(x.cacheDir, runID+".*.tmp")
+	if err != nil {
+		return
+	}
+	err = gob.NewEncoder(tmp).Encode(cached)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), x.cachePath(runID))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// pruneCache removes the saved index of runs that are no longer listed.
+func (x *ContentIndex) pruneCache(listed map[string]bool) {
+	if x.cacheDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(x.cacheDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if runID, ok := strings.CutSuffix(e.Name(), ".gob"); ok && !listed[runID] {
+			os.Remove(filepath.Join(x.cacheDir, e.Name()))
+		}
+	}
+}
+
+// Search returns the hits for query by run ID, in block order. A block
+// matches when every word of the query is a prefix of a word in the block,
+// so "migr" finds "migrations.go".
+func (x *ContentIndex) Search(query string) map[string][]ContentHit {
+	words := contentTerms(query)
+	if len(words) == 0 {
+		return nil
+	}
+	hits := make(map[string][]ContentHit)
+	for runID, run := range x.runs {
+		for _, doc := range run.match(words) {
+			hits[runID] = append(hits[runID], run.docs[doc].hit(words[0]))
+		}
+	}
+	return hits
+}
+
+// contentStamp identifies the version of a run's events artifact.
+func contentStamp(path string) string {
+	resolved, err := artifact.Resolve(path)
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", resolved, info.Size(), info.ModTime().UnixNano())
+}
+
+// contentDocs builds one document per searchable main-view block. Tool
+// blocks index the raw arguments as well as the display form, which drops
+// everything but the command or path for well-known tools.
+func contentDocs(events []DisplayEvent) []contentDoc {
+	m := Model{activeToolIdx: -1}
+	rawArgs := make(map[string]string)
+	for _, de := range events {
+		m.buildBlock(de)
+		if (de.Type == DisplayToolStart || de.Type == DisplayToolUpdate) && len(de.RawArgs) > 0 {
+			rawArgs[de.ToolCallID] = string(de.RawArgs)
+		}
+	}
+
+	var docs []contentDoc
+	for i, b := range m.blocks {
+		doc := contentDoc{block: i, iteration: b.Iteration}
+		switch b.Kind {
+		case BlockAssistantText:
+			doc.fields = []contentField{{"assistant", b.Text}}
+		case BlockToolCall:
+			args := b.ToolArgs
+			if raw := rawArgs[b.ToolCallID]; raw != args {
+				args = strings.TrimSpace(args + "\n" + raw)
+			}
+			doc.toolName = b.ToolName
+			doc.fields = []contentField{
+				{"tool", b.ToolName},
+				{"args", args},
+				{"result", b.ToolResult},
+			}
+		default:
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func (r *contentRun) buildTerms() {
+	byTerm := make(map[string][]int)
+	for i, doc := range r.docs {
+		seen := make(map[string]bool)
+		for _, f := range doc.fields {
+			for _, term := range contentTerms(f.text) {
+				if !seen[term] {
+					seen[term] = true
+					byTerm[term] = append(byTerm[term], i)
+				}
+			}
+		}
+	}
+	r.terms = make([]string, 0, len(byTerm))
+	for term := range byTerm {
+		r.terms = append(r.terms, term)
+	}
+	sort.Strings(r.terms)
+	r.postings = make([][]int, len(r.terms))
+	for i, term := range r.terms {
+		r.postings[i] = byTerm[term]
+	}
+}
+
+// match returns the docs that contain a term starting with each of words,
+// in order.
+func (r *contentRun) match(words []string) []int {
+	var result map[int]bool
+	for _, word := range words {
+		docs := make(map[int]bool)
+		for i := sort.SearchStrings(r.terms, word); i < len(r.terms) && strings.HasPrefix(r.terms[i], word); i++ {
+			for _, doc := range r.postings[i] {
+				if result == nil || result[doc] {
+					docs[doc] = true
+				}
+			}
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		result = docs
+	}
+
+	out := make([]int, 0, len(result))
+	for doc := range result {
+		out = append(out, doc)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// hit describes doc as a search result, with a snippet from the first field
+// that contains word.
+func (d contentDoc) hit(word string) ContentHit {
+	h := ContentHit{Block: d.block, Iteration: d.iteration, ToolName: d.toolName}
+	for _, f := range d.fields {
+		if snippet, ok := contentSnippet(f.text, word); ok {
+			h.Field = f.name
+			h.Snippet = snippet
+			return h
+		}
+	}
+	// Every doc in a result contains word; this is only reached if the
+	// tokenizer and the snippet search disagree.
+	h.Field = d.fields[0].name
+	h.Snippet = contentSnippetAround([]rune(d.fields[0].text), 0)
+	return h
+}
+
+// contentSnippet returns an excerpt of text around the first occurrence of
+// word, ignoring case.
+func contentSnippet(text, word string) (string, bool) {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	needle := []rune(word)
+	for i := 0; i+len(needle) <= len(lower); i++ {
+		if slices.Equal(lower[i:i+len(needle)], needle) {
+			return contentSnippetAround(runes, i), true
+		}
+	}
+	return "", false
+}
+
+func contentSnippetAround(runes []rune, at int) string {
+	start := max(at-contentSnippetBefore, 0)
+	end := min(at+contentSnippetAfter, len(runes))
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// contentTerms splits text into lower-cased words of letters and digits.
+func contentTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
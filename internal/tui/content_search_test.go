@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// contentTestEvents is a run with one assistant message and an edit tool
+// call whose file only appears in the raw arguments.
+func contentTestEvents(text, path string) []runner.Event {
+	return []runner.Event{
+		{Type: runner.EventIteration, ID: "iteration-2"},
+		{Type: runner.EventMessageStart, Message: []byte(`{"role":"assistant","model":"m"}`)},
+		{Type: runner.EventMessageUpdate, AssistantMessageEvent: []byte(`{"type":"text_delta","delta":"` + text + `"}`)},
+		{Type: runner.EventMessageEnd},
+		{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: "t1", Args: []byte(`{"command":"go test ./..."}`)},
+		{Type: runner.EventToolExecutionEnd, ToolName: "bash", ToolCallID: "t1", Result: []byte(`"FAIL TestMigrate"`)},
+		{Type: runner.EventToolExecutionStart, ToolName: "custom_edit", ToolCallID: "t2", Args: []byte(`{"target":"` + path + `","old":"a"}`)},
+		{Type: runner.EventToolExecutionEnd, ToolName: "custom_edit", ToolCallID: "t2", Result: []byte(`"ok"`)},
+	}
+}
+
+// contentTestRuns writes an events.jsonl per run so Sync can stamp them and
+// returns matching summaries and a loader that counts its calls.
+func contentTestRuns(t *testing.T, runs map[string][]runner.Event) ([]viewer.RunSummary, func(string) ([]runner.Event, error), map[string]int) {
+	t.Helper()
+	root := t.TempDir()
+	loads := make(map[string]int)
+	var summaries []viewer.RunSummary
+	for runID := range runs {
+		dir := filepath.Join(root, runID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		s := browserTestSummaryWithActions(runID, time.Now(), "pi", "completed", "default", true)
+		s.Dir = dir
+		s.EventsPath = filepath.Join(dir, "events.jsonl")
+		summaries = append(summaries, s)
+	}
+	load := func(dir string) ([]runner.Event, error) {
+		runID := filepath.Base(dir)
+		loads[runID]++
+		return runs[runID], nil
+	}
+	return summaries, load, loads
+}
+
+func TestContentIndexSearch(t *testing.T) {
+	summaries, load, _ := contentTestRuns(t, map[string][]runner.Event{
+		"run-a": contentTestEvents("I will fix the migration first.", "db/migrations.go"),
+		"run-b": contentTestEvents("Nothing to see here.", "README.md"),
+	})
+	idx := NewContentIndex(load)
+	idx.Sync(summaries)
+
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]string // run ID -> comma-separated hit labels
+	}{
+		{"assistant text", "migration first", map[string]string{"run-a": "iter 2 · assistant"}},
+		{"prefix", "migr", map[string]string{"run-a": "iter 2 · assistant,iter 2 · custom_edit args"}},
+		{"tool result", "testmig", map[string]string{"run-a": "iter 2 · bash result", "run-b": "iter 2 · bash result"}},
+		{"raw tool args", "migrations.go", map[string]string{"run-a": "iter 2 · custom_edit args"}},
+		{"tool name", "custom_edit", map[string]string{"run-a": "iter 2 · tool", "run-b": "iter 2 · tool"}},
+		{"case insensitive", "README", map[string]string{"run-b": "iter 2 · custom_edit args"}},
+		{"no match", "kubernetes", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := idx.Search(tt.query)
+			if len(hits) != len(tt.want) {
+				t.Fatalf("Search(%q) matched %d runs, want %d: %+v", tt.query, len(hits), len(tt.want), hits)
+			}
+			for runID, want := range tt.want {
+				var labels []string
+				for _, h := range hits[runID] {
+					labels = append(labels, h.Label())
+				}
+				if got := strings.Join(labels, ","); got != want {
+					t.Errorf("Search(%q)[%s] = %s, want %s", tt.query, runID, got, want)
+				}
+			}
+		})
+	}
+
+	if hits := idx.Search("  "); hits != nil {
+		t.Errorf("Search(blank) = %+v, want nil", hits)
+	}
+}
+
+func TestContentIndexHitsPointAtViewerBlocks(t *testing.T) {
+	events := contentTestEvents("Touching migrations.go now.", "db/migrations.go")
+	summaries, load, _ := contentTestRuns(t, map[string][]runner.Event{"run-a": events})
+	idx := NewContentIndex(load)
+	idx.Sync(summaries)
+
+	blocks := BuildBlocks(ConvertEvents(events))
+	for _, h := range idx.Search("migrations")["run-a"] {
+		if h.Block < 0 || h.Block >= len(blocks) {
+			t.Fatalf("hit %+v out of range of %d blocks", h, len(blocks))
+		}
+		if b := blocks[h.Block]; h.ToolName != b.ToolName || h.Iteration != b.Iteration {
+			t.Errorf("hit %+v does not match block %+v", h, b)
+		}
+		if !strings.Contains(strings.ToLower(h.Snippet), "migrations") {
+			t.Errorf("snippet %q does not show the match", h.Snippet)
+		}
+	}
+}
+
+func TestContentIndexSyncReindexesOnlyChangedRuns(t *testing.T) {
+	summaries, load, loads := contentTestRuns(t, map[string][]runner.Event{
+		"run-a": contentTestEvents("alpha", "a.go"),
+		"run-b": contentTestEvents("beta", "b.go"),
+	})
+	idx := NewContentIndex(load)
+	idx.Sync(summaries)
+	idx.Sync(summaries)
+	if loads["run-a"] != 1 || loads["run-b"] != 1 {
+		t.Fatalf("loads = %v, want each run read once", loads)
+	}
+
+	var changed viewer.RunSummary
+	for _, s := range summaries {
+		if s.RunID == "run-a" {
+			changed = s
+		}
+	}
+	if err := os.WriteFile(changed.EventsPath, []byte("{}\n{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx.Sync(summaries)
+	if loads["run-a"] != 2 || loads["run-b"] != 1 {
+		t.Errorf("loads = %v, want only the changed run re-read", loads)
+	}
+
+	idx.Sync([]viewer.RunSummary{changed})
+	if hits := idx.Search("beta"); len(hits) != 0 {
+		t.Errorf("Search after run-b was removed = %+v, want none", hits)
+	}
+}
+
+func TestContentIndexCacheSurvivesSessions(t *testing.T) {
+	summaries, load, loads := contentTestRuns(t, map[string][]runner.Event{
+		"run-a": contentTestEvents("alpha", "a.go"),
+		"run-b": contentTestEvents("beta", "b.go"),
+	})
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	first := NewContentIndex(load)
+	first.UseCache(cacheDir)
+	first.Sync(summaries)
+	want := first.Search("alpha")
+
+	// A new session reads the saved index instead of the events.
+	second := NewContentIndex(load)
+	second.UseCache(cacheDir)
+	second.Sync(summaries)
+	if loads["run-a"] != 1 || loads["run-b"] != 1 {
+		t.Fatalf("loads = %v, want each run read once across sessions", loads)
+	}
+	if got := second.Search("alpha"); len(want["run-a"]) == 0 || len(got) != len(want) || len(got["run-a"]) != len(want["run-a"]) || got["run-a"][0] != want["run-a"][0] {
+		t.Errorf("cached Search = %+v, want %+v", got, want)
+	}
+
+	// Runs that are no longer listed lose their saved index.
+	second.Sync(summaries[:1])
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != summaries[0].RunID+".gob" {
+		t.Errorf("cache entries = %v, %v; want only %s.gob", entries, err, summaries[0].RunID)
+	}
+}
+
+func TestContentSnippet(t *testing.T) {
+	long := strings.Repeat("x ", 40) + "Needle\n\tin the   haystack" + strings.Repeat(" y", 60)
+	got, ok := contentSnippet(long, "needle")
+	if !ok {
+		t.Fatal("contentSnippet did not find the word")
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "Needle in the haystack") {
+		t.Errorf("snippet = %q, want trimmed single-line excerpt around the match", got)
+	}
+	if _, ok := contentSnippet("nothing", "needle"); ok {
+		t.Error("contentSnippet matched a missing word")
+	}
+}
@@ -0,0 +1,299 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// DiffModel shows two saved runs side by side: each section of a
+// viewer.RunDiff as a title row followed by its aligned lines, the first
+// run on the left and the second on the right.
+type DiffModel struct {
+	diff        viewer.RunDiff
+	changesOnly bool
+
+	rows     []diffViewRow
+	sections []int // index in rows of each section title
+
+	scroll        int
+	width, height int
+	helpOverlay   bool
+}
+
+// diffViewRow is one line of the diff view: a section title or a row of
+// the section.
+type diffViewRow struct {
+	title   string // set for section title rows
+	section int
+	row     viewer.DiffRow
+	note    string // set for placeholder rows, e.g. "(no differences)"
+}
+
+// NewDiffModel creates the side-by-side view of diff.
+func NewDiffModel(diff viewer.RunDiff) DiffModel {
+	m := DiffModel{diff: diff}
+	m.buildRows()
+	return m
+}
+
+// buildRows flattens the sections into view rows, keeping only differing
+// rows in changes-only mode.
+func (m *DiffModel) buildRows() {
+	m.rows = m.rows[:0]
+	m.sections = m.sections[:0]
+	for k, s := range m.diff.Sections {
+		m.sections = append(m.sections, len(m.rows))
+		title := fmt.Sprintf("%s (%s)", s.Title, differenceCount(s.Changes()))
+		m.rows = append(m.rows, diffViewRow{title: title, section: k})
+
+		n := 0
+		for _, row := range s.Rows {
+			if m.changesOnly && row.Op == viewer.DiffSame {
+				continue
+			}
+			m.rows = append(m.rows, diffViewRow{section: k, row: row})
+			n++
+		}
+		switch {
+		case len(s.Rows) == 0:
+			m.rows = append(m.rows, diffViewRow{section: k, note: "(empty in both runs)"})
+		case n == 0:
+			m.rows = append(m.rows, diffViewRow{section: k, note: "(no differences)"})
+		}
+	}
+	m.scroll = max(min(m.scroll, m.maxScroll()), 0)
+}
+
+// Init implements tea.Model.
+func (m DiffModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m DiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.scroll = max(min(m.scroll, m.maxScroll()), 0)
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m DiffModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.helpOverlay {
+		m.helpOverlay = false
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "j", "down":
+		m.scrollBy(1)
+	case "k", "up":
+		m.scrollBy(-1)
+	case "ctrl+d", "pgdown":
+		m.scrollBy(m.bodyHeight() / 2)
+	case "ctrl+u", "pgup":
+		m.scrollBy(-m.bodyHeight() / 2)
+	case "g", "home":
+		m.scroll = 0
+	case "G", "end":
+		m.scroll = m.maxScroll()
+	case "]":
+		m.jumpSection(1)
+	case "[":
+		m.jumpSection(-1)
+	case "c":
+		m.changesOnly = !m.changesOnly
+		section := m.currentSection()
+		m.buildRows()
+		if section >= 0 && section < len(m.sections) {
+			m.scroll = min(m.sections[section], m.maxScroll())
+		}
+	case "?":
+		m.helpOverlay = true
+	}
+	return m, nil
+}
+
+// bodyHeight is the number of rows shown between the header and the status
+// bar: the pane minus its borders and column titles.
+func (m DiffModel) bodyHeight() int {
+	return max(m.height-2-2-1, 1)
+}
+
+func (m DiffModel) maxScroll() int {
+	return max(len(m.rows)-m.bodyHeight(), 0)
+}
+
+func (m *DiffModel) scrollBy(delta int) {
+	m.scroll = max(min(m.scroll+delta, m.maxScroll()), 0)
+}
+
+// currentSection returns the section of the row at the top of the view.
+func (m DiffModel) currentSection() int {
+	if m.scroll < len(m.rows) {
+		return m.rows[m.scroll].section
+	}
+	return -1
+}
+
+// jumpSection scrolls to the next (delta 1) or previous (delta -1) section
+// title.
+func (m *DiffModel) jumpSection(delta int) {
+	target := -1
+	for _, start := range m.sections {
+		if delta > 0 && start > m.scroll {
+			target = start
+			break
+		}
+		if delta < 0 && start < m.scroll {
+			target = start
+		}
+	}
+	if target >= 0 {
+		m.scroll = min(target, m.maxScroll())
+	}
+}
+
+// View implements tea.Model.
+func (m DiffModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return "Initializing..."
+	}
+	if m.helpOverlay {
+		return m.renderHelpOverlay()
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.renderHeader(), m.renderBody(), m.renderStatus())
+}
+
+func (m DiffModel) renderHeader() string {
+	bar := fmt.Sprintf("ralfinho diff │ %s ↔ %s │ %s",
+		shortID(m.diff.A.RunID), shortID(m.diff.B.RunID),
+		differenceCount(m.diff.Changes()))
+	return headerStyle.Width(m.width).Render(truncateToWidth(bar, m.width-2))
+}
+
+// diffColumnWidths returns the widths of the left and right columns, which
+// share the pane's content width around a " │ " separator.
+func (m DiffModel) diffColumnWidths() (int, int) {
+	content := m.width - 2
+	left := max((content-3)/2, 1)
+	return left, max(content-3-left, 1)
+}
+
+func (m DiffModel) renderBody() string {
+	contentWidth := m.width - 2
+	leftW, rightW := m.diffColumnWidths()
+	sep := toolSepStyle.Render(" │ ")
+
+	lines := []string{titleStyle.Render(diffCell(runColumnTitle("A", m.diff.A), leftW)) + sep +
+		titleStyle.Render(diffCell(runColumnTitle("B", m.diff.B), rightW))}
+	end := min(m.scroll+m.bodyHeight(), len(m.rows))
+	for _, r := range m.rows[m.scroll:end] {
+		switch {
+		case r.title != "":
+			rule := "── " + r.title + " "
+			rule += strings.Repeat("─", max(contentWidth-lipgloss.Width(rule), 0))
+			lines = append(lines, iterationRuleStyle.Render(ansi.Truncate(rule, contentWidth, "")))
+		case r.note != "":
+			lines = append(lines, browserSubtleStyle.Render(diffCell(r.note, contentWidth)))
+		default:
+			left, right := diffCell(r.row.Left, leftW), diffCell(r.row.Right, rightW)
+			switch r.row.Op {
+			case viewer.DiffChanged:
+				left, right = diffChangedStyle.Render(left), diffChangedStyle.Render(right)
+			case viewer.DiffRemoved:
+				left = diffRemovedStyle.Render(left)
+			case viewer.DiffAdded:
+				right = diffAddedStyle.Render(right)
+			}
+			lines = append(lines, left+sep+right)
+		}
+	}
+	for len(lines) < m.bodyHeight()+1 {
+		lines = append(lines, "")
+	}
+	return focusedBorder.Width(m.width - 2).Height(m.bodyHeight() + 1).Render(strings.Join(lines, "\n"))
+}
+
+// runColumnTitle labels a column with its run, e.g. "A: 1a2b3c4d pi completed".
+func runColumnTitle(label string, meta runner.RunMeta) string {
+	parts := []string{label + ": " + meta.RunID}
+	if meta.Agent != "" {
+		parts = append(parts, meta.Agent)
+	}
+	if meta.Status != "" {
+		parts = append(parts, meta.Status)
+	}
+	return strings.Join(parts, " ")
+}
+
+// differenceCount renders n as "1 difference" or "n differences".
+func differenceCount(n int) string {
+	if n == 1 {
+		return "1 difference"
+	}
+	return fmt.Sprintf("%d differences", n)
+}
+
+// diffCell fits one side of a row into width columns.
+func diffCell(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return padRight(ansi.Truncate(s, width, "…"), width)
+}
+
+func (m DiffModel) renderStatus() string {
+	maxWidth := m.width - 2
+	left := ""
+	if k := m.currentSection(); k >= 0 {
+		left = fmt.Sprintf("%s (%d/%d)", m.diff.Sections[k].Title, k+1, len(m.diff.Sections))
+	}
+	if m.changesOnly {
+		left += " │ changes only"
+	}
+
+	sep := statusSepStyle.Render(" │ ")
+	right := statusKeyStyle.Render("↑↓") + ":scroll" +
+		sep + statusKeyStyle.Render("]/[") + ":section" +
+		sep + statusKeyStyle.Render("c") + ":changes only" +
+		sep + statusKeyStyle.Render("?") + ":help" +
+		sep + statusKeyStyle.Render("q") + ":back"
+	if lipgloss.Width(left)+1+lipgloss.Width(right) > maxWidth {
+		right = statusKeyStyle.Render("q") + ":back"
+	}
+	left = truncateToWidth(left, max(maxWidth-lipgloss.Width(right)-1, 4))
+	gap := max(maxWidth-lipgloss.Width(left)-lipgloss.Width(right), 1)
+	return statusBarStyle.Width(m.width).Render(left + strings.Repeat(" ", gap) + right)
+}
+
+func (m DiffModel) renderHelpOverlay() string {
+	lines := []string{
+		titleStyle.Render("Run diff"),
+		"",
+		"  j/k, ↑/↓      scroll",
+		"  Ctrl+d/u      half page down/up",
+		"  g/G           top/bottom",
+		"  ]/[           next/previous section",
+		"  c             show only differing lines",
+		"  q, Esc        back",
+		"",
+		browserSubtleStyle.Render("  left: " + m.diff.A.RunID + "   right: " + m.diff.B.RunID),
+		diffRemovedStyle.Render("  only in left") + "   " + diffAddedStyle.Render("only in right") + "   " + diffChangedStyle.Render("changed"),
+		"",
+		dismissHintStyle.Render("Press any key to close"),
+	}
+	box := browserCardBorder.Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,155 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+	"github.com/fsmiamoto/ralfinho/internal/viewer"
+)
+
+// diffTestModel is a sized diff view with a short Meta section, a long
+// Prompt section with one changed line, an identical NOTES.md and an
+// empty PROGRESS.md.
+func diffTestModel(t *testing.T) DiffModel {
+	t.Helper()
+	var prompt []viewer.DiffRow
+	for i := 0; i < 40; i++ {
+		line := fmt.Sprintf("line %d", i)
+		prompt = append(prompt, viewer.DiffRow{Op: viewer.DiffSame, Left: line, Right: line})
+	}
+	prompt[20] = viewer.DiffRow{Op: viewer.DiffChanged, Left: "old step", Right: "new step"}
+
+	diff := viewer.RunDiff{
+		A: runner.RunMeta{RunID: "aaaaaaaa-1111", Agent: "pi", Status: "completed"},
+		B: runner.RunMeta{RunID: "bbbbbbbb-2222", Agent: "claude", Status: "failed"},
+		Sections: []viewer.DiffSection{
+			{Title: "Meta", Rows: []viewer.DiffRow{
+				{Op: viewer.DiffChanged, Left: "agent: pi", Right: "agent: claude"},
+				{Op: viewer.DiffRemoved, Left: "only in a"},
+				{Op: viewer.DiffAdded, Right: "only in b"},
+			}},
+			{Title: "Prompt", Rows: prompt},
+			{Title: "NOTES.md", Rows: []viewer.DiffRow{{Op: viewer.DiffSame, Left: "same", Right: "same"}}},
+			{Title: "PROGRESS.md"},
+		},
+	}
+	m := NewDiffModel(diff)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	return updated.(DiffModel)
+}
+
+func pressDiffKey(t *testing.T, m DiffModel, key string) DiffModel {
+	t.Helper()
+	updated, _ := m.Update(tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune(key)}))
+	return updated.(DiffModel)
+}
+
+func TestDiffModelView(t *testing.T) {
+	m := diffTestModel(t)
+	view := m.View()
+	plain := ansi.Strip(view)
+	for _, want := range []string{
+		"aaaaaaaa ↔ bbbbbbbb │ 4 differences",
+		"A: aaaaaaaa-1111 pi completed",
+		"B: bbbbbbbb-2222 claude failed",
+		"── Meta (3 differences)",
+		"agent: pi",
+		"agent: claude",
+		"only in b",
+		"Meta (1/4)",
+	} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("view missing %q:\n%s", want, plain)
+		}
+	}
+	if !strings.Contains(view, diffChangedStyle.Render(diffCell("agent: pi", 47))) {
+		t.Error("changed row not highlighted")
+	}
+	lines := strings.Split(view, "\n")
+	if len(lines) != 20 {
+		t.Errorf("view is %d lines, want 20", len(lines))
+	}
+	for i, line := range lines {
+		if w := ansi.StringWidth(line); w > 100 {
+			t.Errorf("line %d is %d columns wide", i, w)
+		}
+	}
+}
+
+func TestDiffModelSectionJumps(t *testing.T) {
+	m := diffTestModel(t)
+
+	m = pressDiffKey(t, m, "]")
+	if m.scroll != m.sections[1] || m.currentSection() != 1 {
+		t.Fatalf("] scrolled to %d, want Prompt at %d", m.scroll, m.sections[1])
+	}
+	m = pressDiffKey(t, m, "]")
+	if m.scroll != m.maxScroll() {
+		t.Errorf("] to a section near the end scrolled to %d, want clamped %d", m.scroll, m.maxScroll())
+	}
+	m = pressDiffKey(t, m, "g")
+	m = pressDiffKey(t, m, "[")
+	if m.scroll != 0 {
+		t.Errorf("[ at the top moved to %d", m.scroll)
+	}
+}
+
+func TestDiffModelChangesOnly(t *testing.T) {
+	m := diffTestModel(t)
+	m = pressDiffKey(t, m, "c")
+
+	var got []string
+	for _, r := range m.rows {
+		switch {
+		case r.title != "":
+			got = append(got, "# "+r.title)
+		case r.note != "":
+			got = append(got, r.note)
+		default:
+			got = append(got, r.row.Left+"|"+r.row.Right)
+		}
+	}
+	want := []string{
+		"# Meta (3 differences)",
+		"agent: pi|agent: claude",
+		"only in a|",
+		"|only in b",
+		"# Prompt (1 difference)",
+		"old step|new step",
+		"# NOTES.md (0 differences)",
+		"(no differences)",
+		"# PROGRESS.md (0 differences)",
+		"(empty in both runs)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes-only rows:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(ansi.Strip(m.View()), "changes only") {
+		t.Error("status does not show changes-only mode")
+	}
+
+	m = pressDiffKey(t, m, "c")
+	if len(m.rows) != 4+3+40+1+1 {
+		t.Errorf("second c left %d rows", len(m.rows))
+	}
+}
+
+func TestDiffModelQuit(t *testing.T) {
+	m := diffTestModel(t)
+	m = pressDiffKey(t, m, "?")
+	if !strings.Contains(m.View(), "next/previous section") {
+		t.Fatal("? did not open the help overlay")
+	}
+	m = pressDiffKey(t, m, "q")
+	if m.helpOverlay {
+		t.Fatal("key did not close the help overlay")
+	}
+	if _, cmd := m.Update(tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune("q")})); cmd == nil {
+		t.Error("q did not quit")
+	}
+}
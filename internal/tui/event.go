@@ -55,6 +55,11 @@ type DisplayEvent struct {
 	// DisplayRestart events. Used by the model to bump its restart counter.
 	RestartIter int
 
+	// Outcome is how the current iteration ended ("restart", "timeout" or
+	// "retry") on the events that end an iteration early. Shown in the
+	// iteration sidebar.
+	Outcome string
+
 	// Reminders is the current reminder snapshot; populated only on
 	// DisplayReminderState events. The TUI overwrites its mirror with this.
 	Reminders []runner.Reminder
@@ -308,7 +313,13 @@ func (c *EventConverter) Convert(ev *runner.Event) []DisplayEvent {
 		if _, err := fmt.Sscanf(ev.ID, "iteration-%d", &n); err == nil {
 			c.iteration = n
 		}
-		return []DisplayEvent{MakeIterationEvent(c.iteration)}
+		de := MakeIterationEvent(c.iteration)
+		// Saved runs carry the real start time, which iteration durations
+		// are computed from on replay.
+		if t, err := time.Parse(time.RFC3339, ev.Timestamp); err == nil {
+			de.Timestamp = t
+		}
+		return []DisplayEvent{de}
 
 	case runner.EventInactivityTimeout:
 		return []DisplayEvent{{
@@ -317,6 +328,21 @@ func (c *EventConverter) Convert(ev *runner.Event) []DisplayEvent {
 			Detail:    "Inactivity timeout — retrying iteration",
 			Timestamp: now,
 			Iteration: c.iteration,
+			Outcome:   "timeout",
+		}}
+
+	case runner.EventIterationRetry:
+		// ID format is "retry-<iteration>-<attempt>:<class>".
+		iter, attempt, class := 0, 0, ""
+		_, _ = fmt.Sscanf(ev.ID, "retry-%d-%d:%s", &iter, &attempt, &class)
+		text := fmt.Sprintf("Agent error (%s) — retrying iteration %d (retry %d)", class, iter, attempt)
+		return []DisplayEvent{{
+			Type:      DisplayInfo,
+			Summary:   text,
+			Detail:    text,
+			Timestamp: now,
+			Iteration: c.iteration,
+			Outcome:   "retry",
 		}}
 
 	case runner.EventIterationRestart:
@@ -331,6 +357,7 @@ func (c *EventConverter) Convert(ev *runner.Event) []DisplayEvent {
 			Timestamp:   now,
 			Iteration:   c.iteration,
 			RestartIter: iter,
+			Outcome:     "restart",
 		}}
 
 	case runner.EventReminderState:
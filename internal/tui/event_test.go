@@ -663,6 +663,27 @@ func TestEventConverter_InactivityTimeout(t *testing.T) {
 	}
 }
 
+func TestEventConverter_IterationRetry(t *testing.T) {
+	c := NewEventConverter()
+	c.Convert(&runner.Event{Type: runner.EventIteration, ID: "iteration-3"})
+
+	result := c.Convert(&runner.Event{Type: runner.EventIterationRetry, ID: "retry-3-2:network"})
+	if len(result) != 1 {
+		t.Fatalf("expected 1 display event, got %d", len(result))
+	}
+	de := result[0]
+	if de.Type != DisplayInfo {
+		t.Errorf("type = %q, want %q", de.Type, DisplayInfo)
+	}
+	want := "Agent error (network) — retrying iteration 3 (retry 2)"
+	if de.Summary != want {
+		t.Errorf("summary = %q, want %q", de.Summary, want)
+	}
+	if de.Iteration != 3 {
+		t.Errorf("iteration = %d, want 3", de.Iteration)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Iteration restart event conversion
 // ---------------------------------------------------------------------------
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// failureLines renders a structured failure record as labelled lines.
+// stderrLines caps how many trailing stderr lines are included; 0 means all.
+func failureLines(f *runner.Failure, stderrLines int) []string {
+	if f == nil {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("Category: %s", f.Category)}
+	if f.Iteration > 0 {
+		lines = append(lines, fmt.Sprintf("Iteration: %d", f.Iteration))
+	}
+	if f.ExitCode != nil {
+		lines = append(lines, fmt.Sprintf("Exit code: %d", *f.ExitCode))
+	}
+	if f.Timestamp != "" {
+		lines = append(lines, fmt.Sprintf("At: %s", f.Timestamp))
+	}
+	if f.Message != "" {
+		lines = append(lines, fmt.Sprintf("Reason: %s", f.Message))
+	}
+
+	stderr := strings.TrimSpace(f.Stderr)
+	if stderr == "" {
+		return lines
+	}
+	tail := strings.Split(stderr, "\n")
+	header := "Stderr:"
+	if stderrLines > 0 && len(tail) > stderrLines {
+		tail = tail[len(tail)-stderrLines:]
+		header = fmt.Sprintf("Stderr (last %d lines):", stderrLines)
+	}
+	lines = append(lines, "", header)
+	for _, l := range tail {
+		lines = append(lines, "  "+l)
+	}
+	return lines
+}
+
+// failureOverlayText is the error overlay body for a finished run: the
+// structured failure when available, otherwise the plain error string.
+func failureOverlayText(result runner.RunResult) string {
+	if result.Failure == nil {
+		return result.Error
+	}
+	return strings.Join(failureLines(result.Failure, 0), "\n")
+}
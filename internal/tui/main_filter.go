@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fsmiamoto/ralfinho/internal/events"
+)
+
+// Main-view filters hide blocks without dropping them: a filtered-out block
+// lays out to zero lines in ensureMainLayout, so scrolling, search and the
+// line index work unchanged on what is left.
+
+// mainFilterKind selects which kind of blocks the main view shows.
+type mainFilterKind string
+
+const (
+	mainFilterAll       mainFilterKind = ""
+	mainFilterTools     mainFilterKind = "tools"
+	mainFilterErrors    mainFilterKind = "errors"
+	mainFilterAssistant mainFilterKind = "assistant"
+)
+
+var mainFilterKinds = []mainFilterKind{
+	mainFilterAll,
+	mainFilterTools,
+	mainFilterErrors,
+	mainFilterAssistant,
+}
+
+// mainFilter is the main view's filter state. The zero value shows
+// everything.
+type mainFilter struct {
+	kind     mainFilterKind
+	tool     string // normalized tool name; "" = any
+	iterFrom int    // first iteration shown; 0 = no lower bound
+	iterTo   int    // last iteration shown; 0 = no upper bound
+}
+
+// active reports whether any filter is set.
+func (f mainFilter) active() bool {
+	return f != mainFilter{}
+}
+
+// byContent reports whether the kind or tool filter is set. Iteration rules
+// are only shown for iterations with visible content then.
+func (f mainFilter) byContent() bool {
+	return f.kind != mainFilterAll || f.tool != ""
+}
+
+// inRange reports whether iteration iter passes the iteration range.
+func (f mainFilter) inRange(iter int) bool {
+	if f.iterFrom > 0 && iter < f.iterFrom {
+		return false
+	}
+	if f.iterTo > 0 && iter > f.iterTo {
+		return false
+	}
+	return true
+}
+
+// passes reports whether a content block passes the kind and tool filters.
+func (f mainFilter) passes(b *MainBlock) bool {
+	if f.tool != "" && (b.Kind != BlockToolCall || events.NormalizeToolName(b.ToolName) != f.tool) {
+		return false
+	}
+	switch f.kind {
+	case mainFilterTools:
+		return b.Kind == BlockToolCall
+	case mainFilterErrors:
+		return b.Kind == BlockToolCall && b.ToolError
+	case mainFilterAssistant:
+		return b.Kind == BlockAssistantText
+	}
+	return true
+}
+
+// String describes the filter for the status bar, e.g.
+// "errors · tool:bash · iter 3-7".
+func (f mainFilter) String() string {
+	var parts []string
+	if f.kind != mainFilterAll {
+		parts = append(parts, string(f.kind))
+	}
+	if f.tool != "" {
+		parts = append(parts, "tool:"+f.tool)
+	}
+	if f.iterFrom > 0 || f.iterTo > 0 {
+		parts = append(parts, "iter "+formatIterationRange(f.iterFrom, f.iterTo))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// blockVisible reports whether block i passes the main-view filter. iter is
+// the iteration block i belongs to (the last iteration rule at or before
+// it), which info blocks do not record themselves.
+func (m Model) blockVisible(i, iter int) bool {
+	f := m.mainFilter
+	if !f.active() {
+		return true
+	}
+	if !f.inRange(iter) {
+		return false
+	}
+	b := &m.blocks[i]
+	if b.Kind != BlockIteration {
+		return f.passes(b)
+	}
+	if !f.byContent() {
+		return true
+	}
+	for j := i + 1; j < len(m.blocks) && m.blocks[j].Kind != BlockIteration; j++ {
+		if f.passes(&m.blocks[j]) {
+			return true
+		}
+	}
+	return false
+}
+
+// iterationAt returns the start of the iteration block i belongs to (the
+// index of its iteration rule, or 0) and that iteration's number.
+func (m Model) iterationAt(i int) (start, iter int) {
+	for j := min(i, len(m.blocks)-1); j >= 0; j-- {
+		if m.blocks[j].Kind == BlockIteration {
+			return j, m.blocks[j].Iteration
+		}
+	}
+	return 0, 0
+}
+
+// setMainFilter applies f and re-lays out the main view.
+func (m *Model) setMainFilter(f mainFilter) {
+	m.mainFilter = f
+	m.mainIndexDirtyFrom = 0
+	m.mainAutoScroll = false
+	if m.searchQuery != "" {
+		m.refreshMainSearch()
+	}
+}
+
+// cycleMainFilterKind switches to the next kind filter.
+func (m *Model) cycleMainFilterKind() {
+	f := m.mainFilter
+	idx := 0
+	for i, kind := range mainFilterKinds {
+		if kind == f.kind {
+			idx = i
+			break
+		}
+	}
+	f.kind = mainFilterKinds[(idx+1)%len(mainFilterKinds)]
+	m.setMainFilter(f)
+}
+
+// cycleMainFilterTool switches to the next tool name seen in the run.
+func (m *Model) cycleMainFilterTool() {
+	options := m.mainToolOptions()
+	f := m.mainFilter
+	next := ""
+	if f.tool == "" && len(options) > 0 {
+		next = options[0]
+	}
+	for i, tool := range options {
+		if tool == f.tool && i+1 < len(options) {
+			next = options[i+1]
+		}
+	}
+	f.tool = next
+	m.setMainFilter(f)
+}
+
+// mainToolOptions returns the sorted normalized names of the tools called
+// so far.
+func (m Model) mainToolOptions() []string {
+	seen := make(map[string]bool)
+	var tools []string
+	for i := range m.blocks {
+		if m.blocks[i].Kind != BlockToolCall {
+			continue
+		}
+		name := events.NormalizeToolName(m.blocks[i].ToolName)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			tools = append(tools, name)
+		}
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// handleIterFilterKey handles raw key input while the iteration range
+// prompt is open. Mirrors handleTimeoutKey for the input pattern.
+func (m Model) handleIterFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.iterFilterInput = false
+		m.iterFilterText = ""
+		m.iterFilterError = ""
+		return m, nil
+	case tea.KeyEnter:
+		from, to, err := parseIterationRange(m.iterFilterText)
+		if err != nil {
+			m.iterFilterError = err.Error()
+			return m, nil
+		}
+		f := m.mainFilter
+		f.iterFrom, f.iterTo = from, to
+		m.setMainFilter(f)
+		m.iterFilterInput = false
+		m.iterFilterText = ""
+		m.iterFilterError = ""
+		return m, nil
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		runes := []rune(m.iterFilterText)
+		if len(runes) > 0 {
+			m.iterFilterText = string(runes[:len(runes)-1])
+		}
+		m.iterFilterError = ""
+		return m, nil
+	case tea.KeyCtrlU:
+		m.iterFilterText = ""
+		m.iterFilterError = ""
+		return m, nil
+	case tea.KeyRunes:
+		m.iterFilterText += string(msg.Runes)
+		m.iterFilterError = ""
+		return m, nil
+	}
+	return m, nil
+}
+
+// parseIterationRange parses "5", "3-7", "3-" or "-7". An empty range
+// clears the filter.
+func parseIterationRange(s string) (from, to int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	parse := func(v string) (int, error) {
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid iteration %q", v)
+		}
+		return n, nil
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	if from, err = parse(lo); err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	if to, err = parse(hi); err != nil {
+		return 0, 0, err
+	}
+	if from == 0 && to == 0 {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	if to > 0 && from > to {
+		return 0, 0, fmt.Errorf("range %q ends before it starts", s)
+	}
+	return from, to, nil
+}
+
+// formatIterationRange renders a range the way parseIterationRange reads it.
+func formatIterationRange(from, to int) string {
+	switch {
+	case from == to:
+		return strconv.Itoa(from)
+	case to == 0:
+		return fmt.Sprintf("%d-", from)
+	case from == 0:
+		return fmt.Sprintf("-%d", to)
+	}
+	return fmt.Sprintf("%d-%d", from, to)
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// filterTestModel is a sized replay viewer with three iterations:
+//
+//	iteration 1: assistant text, bash "make build" (ok), read main.go
+//	iteration 2: assistant text, bash "make test" (error)
+//	iteration 3: assistant text, shell "make lint" (error), edit main.go
+func filterTestModel(t *testing.T) Model {
+	t.Helper()
+	var events []DisplayEvent
+	tool := func(id, name, args, result string, isErr bool) {
+		events = append(events,
+			DisplayEvent{Type: DisplayToolStart, ToolCallID: id, ToolName: name, ToolDisplayArgs: args},
+			DisplayEvent{Type: DisplayToolEnd, ToolCallID: id, ToolName: name, ToolResultText: result, ToolIsError: isErr},
+		)
+	}
+	text := func(iter int, s string) {
+		events = append(events, DisplayEvent{Type: DisplayAssistantText, Iteration: iter, Detail: s})
+	}
+
+	events = append(events, MakeIterationEvent(1))
+	text(1, "building first")
+	tool("t1", "bash", "$ make build", "ok", false)
+	tool("t2", "read", "main.go", "package main", false)
+	events = append(events, MakeIterationEvent(2))
+	text(2, "now the tests")
+	tool("t3", "bash", "$ make test", "FAIL", true)
+	events = append(events, MakeIterationEvent(3))
+	text(3, "linting")
+	tool("t4", "shell", "$ make lint", "lint failed", true)
+	tool("t5", "edit", "main.go", "edited", false)
+
+	m := NewViewerModel(events, runner.RunMeta{}, "", "", "")
+	return updateModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 60})
+}
+
+// visibleBlocks lists the blocks the main view lays out.
+func visibleBlocks(m Model) string {
+	m.ensureMainLayout(m.mainContentWidth())
+	var out []string
+	for i, b := range m.blocks {
+		if m.mainBlockLineCounts[i] == 0 {
+			continue
+		}
+		switch b.Kind {
+		case BlockIteration:
+			out = append(out, fmt.Sprintf("iter%d", b.Iteration))
+		case BlockToolCall:
+			out = append(out, b.ToolName)
+		case BlockAssistantText:
+			out = append(out, "text")
+		default:
+			out = append(out, "info")
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func pressMainKey(t *testing.T, m Model, key string) Model {
+	t.Helper()
+	return updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+}
+
+func TestMainFilterKinds(t *testing.T) {
+	m := filterTestModel(t)
+	all := visibleBlocks(m)
+
+	want := []struct {
+		status  string
+		visible string
+	}{
+		{"tools", "iter1 bash read iter2 bash iter3 shell edit"},
+		{"errors", "iter2 bash iter3 shell"},
+		{"assistant", "iter1 text iter2 text iter3 text"},
+		{"", all},
+	}
+	for _, w := range want {
+		m = pressMainKey(t, m, "f")
+		if got := m.mainFilter.String(); got != w.status {
+			t.Errorf("filter = %q, want %q", got, w.status)
+		}
+		if got := visibleBlocks(m); got != w.visible {
+			t.Errorf("filter %q shows %q, want %q", w.status, got, w.visible)
+		}
+	}
+}
+
+func TestMainFilterByToolName(t *testing.T) {
+	m := filterTestModel(t)
+
+	if got := strings.Join(m.mainToolOptions(), " "); got != "bash edit read" {
+		t.Fatalf("tool options = %q", got)
+	}
+
+	// shell is normalized to bash.
+	m = pressMainKey(t, m, "T")
+	if got := visibleBlocks(m); got != "iter1 bash iter2 bash iter3 shell" {
+		t.Errorf("tool:bash shows %q", got)
+	}
+
+	// Combined with the errors filter: only the bash commands that failed.
+	m = pressMainKey(t, m, "f")
+	m = pressMainKey(t, m, "f")
+	if got := visibleBlocks(m); got != "iter2 bash iter3 shell" {
+		t.Errorf("errors · tool:bash shows %q", got)
+	}
+	if got := m.mainFilter.String(); got != "errors · tool:bash" {
+		t.Errorf("filter = %q", got)
+	}
+
+	m = pressMainKey(t, m, "T")
+	m = pressMainKey(t, m, "T")
+	if m.mainFilter.tool != "read" {
+		t.Fatalf("tool = %q, want read", m.mainFilter.tool)
+	}
+	m = pressMainKey(t, m, "T")
+	if m.mainFilter.tool != "" {
+		t.Errorf("tool cycle did not wrap to any, got %q", m.mainFilter.tool)
+	}
+}
+
+func TestMainFilterIterationRange(t *testing.T) {
+	m := filterTestModel(t)
+
+	m = pressMainKey(t, m, "i")
+	if !m.iterFilterInput {
+		t.Fatal("i did not open the iteration prompt")
+	}
+	m = pressMainKey(t, m, "3-1")
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.iterFilterInput || m.iterFilterError == "" {
+		t.Fatalf("invalid range accepted: open %v, error %q", m.iterFilterInput, m.iterFilterError)
+	}
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyCtrlU})
+	m = pressMainKey(t, m, "2-")
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.iterFilterInput {
+		t.Fatal("Enter did not close the iteration prompt")
+	}
+	if got := visibleBlocks(m); got != "iter2 text bash iter3 text shell edit" {
+		t.Errorf("iter 2- shows %q", got)
+	}
+
+	m = pressMainKey(t, m, "f")
+	if status := ansiStripStatus(m); !strings.Contains(status, "filter: tools · iter 2-") {
+		t.Errorf("status bar missing filter: %q", status)
+	}
+
+	m = pressMainKey(t, m, "F")
+	if m.mainFilter.active() || strings.Contains(ansiStripStatus(m), "filter:") {
+		t.Errorf("F left filter %q", m.mainFilter)
+	}
+}
+
+func TestMainFilterSearchSkipsHiddenBlocks(t *testing.T) {
+	m := filterTestModel(t)
+
+	m = typeSearch(t, m, "make")
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.searchMatches) != 3 {
+		t.Fatalf("unfiltered matches = %v", m.searchMatches)
+	}
+
+	m = pressMainKey(t, m, "f")
+	m = pressMainKey(t, m, "f")
+	if len(m.searchMatches) != 2 {
+		t.Errorf("errors filter matches = %v, want the two failed commands", m.searchMatches)
+	}
+	for _, block := range m.searchMatches {
+		if !m.blocks[block].ToolError {
+			t.Errorf("search matched hidden block %d", block)
+		}
+	}
+}
+
+func TestParseIterationRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to int
+		wantErr  bool
+	}{
+		{"", 0, 0, false},
+		{"5", 5, 5, false},
+		{" 3-7 ", 3, 7, false},
+		{"3-", 3, 0, false},
+		{"-7", 0, 7, false},
+		{"-", 0, 0, true},
+		{"0", 0, 0, true},
+		{"7-3", 0, 0, true},
+		{"a-b", 0, 0, true},
+	}
+	for _, tt := range tests {
+		from, to, err := parseIterationRange(tt.in)
+		if (err != nil) != tt.wantErr || from != tt.from || to != tt.to {
+			t.Errorf("parseIterationRange(%q) = %d, %d, %v", tt.in, from, to, err)
+			continue
+		}
+		if err == nil && tt.in != "" {
+			if got := formatIterationRange(from, to); got != strings.TrimSpace(tt.in) {
+				t.Errorf("formatIterationRange(%d, %d) = %q, want %q", from, to, got, tt.in)
+			}
+		}
+	}
+}
+
+// ansiStripStatus returns the rendered status bar without styling.
+func ansiStripStatus(m Model) string {
+	return ansi.Strip(m.renderStatus())
+}
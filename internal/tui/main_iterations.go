@@ -0,0 +1,301 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Iteration navigation: [ and ] jump between iteration rules, I shows an
+// index of the iterations next to the main view, and z/Z/o fold whole
+// iterations or single tool results so long runs stay readable.
+
+// iterationSidebarWidth is the outer width of the iteration index.
+const iterationSidebarWidth = 36
+
+// iterationSummary describes one iteration for the sidebar.
+type iterationSummary struct {
+	Block     int // index of the iteration rule in m.blocks
+	Iteration int
+	Duration  time.Duration // 0 when unknown
+	Tools     int
+	Errors    int
+	Outcome   string // continue, restart, timeout, retry, complete, running, ...
+}
+
+// iterationSummaries summarizes every iteration in the main view, in order.
+// An iteration followed by another run of the same number was redone, so it
+// reads as a restart unless the runner said why it ended.
+func (m Model) iterationSummaries() []iterationSummary {
+	var out []iterationSummary
+	var starts []time.Time
+	for i := range m.blocks {
+		b := &m.blocks[i]
+		switch {
+		case b.Kind == BlockIteration:
+			out = append(out, iterationSummary{Block: i, Iteration: b.Iteration, Outcome: b.Outcome})
+			starts = append(starts, b.StartedAt)
+		case b.Kind == BlockToolCall && len(out) > 0:
+			out[len(out)-1].Tools++
+			if b.ToolError {
+				out[len(out)-1].Errors++
+			}
+		}
+	}
+
+	for k := range out {
+		last := k == len(out)-1
+		end := m.runEndedAt
+		switch {
+		case !last:
+			end = starts[k+1]
+		case m.running:
+			end = time.Now()
+		}
+		if !starts[k].IsZero() && !end.IsZero() && end.After(starts[k]) {
+			out[k].Duration = end.Sub(starts[k])
+		}
+
+		if out[k].Outcome != "" {
+			continue
+		}
+		switch {
+		case !last && out[k+1].Iteration == out[k].Iteration:
+			out[k].Outcome = "restart"
+		case !last:
+			out[k].Outcome = "continue"
+		default:
+			out[k].Outcome = m.finalIterationOutcome()
+		}
+	}
+	return out
+}
+
+// finalIterationOutcome is the outcome of the last iteration, taken from
+// the run's status.
+func (m Model) finalIterationOutcome() string {
+	if m.running {
+		return "running"
+	}
+	switch m.runStatus {
+	case "completed":
+		return "complete"
+	case "stuck":
+		return "timeout"
+	}
+	return m.runStatus
+}
+
+// mainViewTop returns the first document line on screen, with mainScroll
+// clamped the way renderMain clamps it. ensureMainLayout must be current.
+func (m Model) mainViewTop() int {
+	maxScroll := max(m.mainTotalLines-(m.mainHeight()-1), 0)
+	return max(min(m.mainScroll, maxScroll), 0)
+}
+
+// currentIterationBlock returns the index of the iteration rule of the
+// iteration at the top of the main view, or -1 before the first one.
+func (m Model) currentIterationBlock() int {
+	if m.width == 0 || len(m.blocks) == 0 {
+		return -1
+	}
+	m.ensureMainLayout(m.mainContentWidth())
+	start, _ := m.iterationAt(m.blockAtLine(m.mainViewTop()))
+	if m.blocks[start].Kind != BlockIteration {
+		return -1
+	}
+	return start
+}
+
+// jumpIteration scrolls the main view to the next (delta 1) or previous
+// (delta -1) visible iteration rule.
+func (m *Model) jumpIteration(delta int) {
+	if m.width == 0 {
+		return
+	}
+	m.ensureMainLayout(m.mainContentWidth())
+	top := m.mainViewTop()
+	target := -1
+	for i := range m.blocks {
+		if m.blocks[i].Kind != BlockIteration || m.mainBlockLineCounts[i] == 0 {
+			continue
+		}
+		start := m.mainBlockStarts[i]
+		if delta > 0 && start > top {
+			target = start
+			break
+		}
+		if delta < 0 && start < top {
+			target = start
+		}
+	}
+	if target < 0 {
+		return
+	}
+	m.mainScroll = target
+	m.mainAutoScroll = false
+	m.focusedPane = 0
+}
+
+// toggleIterationFold folds or unfolds the iteration at the top of the main
+// view and scrolls to its rule.
+func (m *Model) toggleIterationFold() {
+	i := m.currentIterationBlock()
+	if i < 0 {
+		return
+	}
+	m.setFolded(i, !m.blocks[i].Folded)
+	m.ensureMainLayout(m.mainContentWidth())
+	m.mainScroll = m.mainBlockStarts[i]
+	m.mainAutoScroll = false
+	m.focusedPane = 0
+}
+
+// toggleAllIterationFolds folds every iteration, or unfolds them all when
+// they are already folded.
+func (m *Model) toggleAllIterationFolds() {
+	fold := false
+	for i := range m.blocks {
+		if m.blocks[i].Kind == BlockIteration && !m.blocks[i].Folded {
+			fold = true
+			break
+		}
+	}
+	for i := range m.blocks {
+		if m.blocks[i].Kind == BlockIteration {
+			m.setFolded(i, fold)
+		}
+	}
+	m.mainAutoScroll = false
+	if i := m.currentIterationBlock(); i >= 0 {
+		m.mainScroll = m.mainBlockStarts[i]
+	}
+}
+
+// toggleToolFold folds or unfolds the result of the first tool call on
+// screen.
+func (m *Model) toggleToolFold() {
+	if m.width == 0 {
+		return
+	}
+	m.ensureMainLayout(m.mainContentWidth())
+	top := m.mainViewTop()
+	bottom := top + m.mainHeight() - 1
+	for i := m.blockAtLine(top); i < len(m.blocks); i++ {
+		if m.mainBlockLineCounts[i] == 0 {
+			continue
+		}
+		if m.mainBlockStarts[i] >= bottom {
+			return
+		}
+		if m.blocks[i].Kind == BlockToolCall {
+			m.setFolded(i, !m.blocks[i].Folded)
+			m.mainAutoScroll = false
+			return
+		}
+	}
+}
+
+// setFolded updates a block's fold state and re-lays out the main view
+// from it.
+func (m *Model) setFolded(i int, folded bool) {
+	if m.blocks[i].Folded == folded {
+		return
+	}
+	m.blocks[i].Folded = folded
+	m.blocks[i].InvalidateLayout()
+	m.invalidateMainLayoutFrom(i)
+}
+
+// unfoldIterationOf unfolds the iteration containing block i, so a search
+// match inside a folded iteration can be shown.
+func (m *Model) unfoldIterationOf(i int) {
+	if start, _ := m.iterationAt(i); start != i && m.blocks[start].Kind == BlockIteration {
+		m.setFolded(start, false)
+	}
+}
+
+// mainPaneWidth is the outer width of the main view, which shares the row
+// with the iteration sidebar when it is shown.
+func (m Model) mainPaneWidth() int {
+	if m.showIterations {
+		return m.width - iterationSidebarWidth
+	}
+	return m.width
+}
+
+// renderIterationSidebar renders the iteration index shown next to the main
+// view: one row per iteration with its duration, tool calls and outcome.
+// The iteration at the top of the main view is highlighted.
+func (m Model) renderIterationSidebar() string {
+	ph := m.mainHeight()
+	contentWidth := iterationSidebarWidth - 2
+	visibleLines := ph - 1 // minus title line
+
+	summaries := m.iterationSummaries()
+	current := m.currentIterationBlock()
+	selected := -1
+	for k, s := range summaries {
+		if s.Block == current {
+			selected = k
+		}
+	}
+
+	// Keep the highlighted row on screen.
+	offset := 0
+	if selected >= visibleLines {
+		offset = selected - visibleLines/2
+	}
+	offset = max(min(offset, len(summaries)-visibleLines), 0)
+
+	var lines []string
+	for k := offset; k < len(summaries) && len(lines) < visibleLines; k++ {
+		line := formatIterationSummary(summaries[k], contentWidth-1)
+		if k == selected {
+			lines = append(lines, selectedIndicator.Render("▌")+selectedStyle.Render(padRight(line, contentWidth-1)))
+		} else {
+			lines = append(lines, " "+line)
+		}
+	}
+	if len(summaries) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorDim).Render(" No iterations yet"))
+	}
+	for len(lines) < visibleLines {
+		lines = append(lines, "")
+	}
+
+	title := fmt.Sprintf(" ITERATIONS (%d) ", len(summaries))
+	return unfocusedBorder.
+		Width(iterationSidebarWidth - 2).
+		Height(ph).
+		Render(titleStyle.Render(title) + "\n" + strings.Join(lines, "\n"))
+}
+
+// formatIterationSummary renders one sidebar row, e.g.
+// "#12  1m 20s   8 tools continue".
+func formatIterationSummary(s iterationSummary, width int) string {
+	duration := "-"
+	if s.Duration > 0 {
+		duration = formatElapsed(s.Duration)
+	}
+	tools := fmt.Sprintf("%d tools", s.Tools)
+	if s.Tools == 1 {
+		tools = "1 tool"
+	}
+	if s.Errors > 0 {
+		tools += "!"
+	}
+	line := fmt.Sprintf("#%-3d %7s %9s %s", s.Iteration, duration, tools, s.Outcome)
+	return ansi.Truncate(line, width, "…")
+}
+
+// padRight pads s with spaces to width display columns.
+func padRight(s string, width int) string {
+	if w := lipgloss.Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
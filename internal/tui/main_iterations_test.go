@@ -0,0 +1,211 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// iterationTestModel is a sized replay of a completed run: iteration 1
+// (90s, two tools), iteration 2 redone after 30s, iteration 2 again (80s,
+// one failed tool) and iteration 3 (60s, no tools). Every tool call
+// prints ten lines.
+func iterationTestModel(t *testing.T) Model {
+	t.Helper()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	iteration := func(n, offset int) runner.Event {
+		return runner.Event{
+			Type:      runner.EventIteration,
+			ID:        fmt.Sprintf("iteration-%d", n),
+			Timestamp: start.Add(time.Duration(offset) * time.Second).Format(time.RFC3339),
+		}
+	}
+	isErr := true
+	output := `"` + strings.Repeat(`line\n`, 10) + `"`
+	tool := func(id string, failed bool) []runner.Event {
+		end := runner.Event{Type: runner.EventToolExecutionEnd, ToolName: "bash", ToolCallID: id, Result: []byte(output)}
+		if failed {
+			end.IsError = &isErr
+		}
+		return []runner.Event{
+			{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: id, Args: []byte(`{"command":"make ` + id + `"}`)},
+			end,
+		}
+	}
+
+	var events []runner.Event
+	events = append(events, iteration(1, 0))
+	events = append(events, tool("t1", false)...)
+	events = append(events, tool("t2", false)...)
+	events = append(events, iteration(2, 90))
+	events = append(events, iteration(2, 120))
+	events = append(events, tool("t3", true)...)
+	events = append(events, iteration(3, 200))
+
+	meta := runner.RunMeta{Status: "completed", EndedAt: start.Add(260 * time.Second).Format(time.RFC3339)}
+	m := NewViewerModel(ConvertEvents(events), meta, "", "", "")
+	return updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 30})
+}
+
+func TestIterationSummaries(t *testing.T) {
+	m := iterationTestModel(t)
+
+	var got []string
+	for _, s := range m.iterationSummaries() {
+		got = append(got, fmt.Sprintf("#%d %s %d/%d %s", s.Iteration, s.Duration, s.Tools, s.Errors, s.Outcome))
+	}
+	want := []string{
+		"#1 1m30s 2/0 continue",
+		"#2 30s 0/0 restart",
+		"#2 1m20s 1/1 continue",
+		"#3 1m0s 0/0 complete",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("summaries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIterationOutcomeFromRunnerEvents(t *testing.T) {
+	ch := make(chan runner.Event)
+	m := NewModel(ch, "pi", "", "", "", nil, nil)
+	m = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 30})
+	conv := NewEventConverter()
+	send := func(ev runner.Event) {
+		for _, de := range conv.Convert(&ev) {
+			m = updateModel(t, m, EventMsg(de))
+		}
+	}
+
+	send(runner.Event{Type: runner.EventIteration, ID: "iteration-1"})
+	send(runner.Event{Type: runner.EventInactivityTimeout, ID: "timeout-1"})
+	send(runner.Event{Type: runner.EventIteration, ID: "iteration-1"})
+	send(runner.Event{Type: runner.EventIterationRestart, ID: "restart-1-1"})
+	send(runner.Event{Type: runner.EventIteration, ID: "iteration-1"})
+
+	var got []string
+	for _, s := range m.iterationSummaries() {
+		got = append(got, s.Outcome)
+	}
+	if strings.Join(got, " ") != "timeout restart running" {
+		t.Errorf("outcomes = %v", got)
+	}
+
+	m = updateModel(t, m, DoneMsg{Result: runner.RunResult{Status: runner.StatusCompleted}})
+	if s := m.iterationSummaries(); s[len(s)-1].Outcome != "complete" {
+		t.Errorf("last outcome after Done = %q", s[len(s)-1].Outcome)
+	}
+}
+
+func TestJumpIteration(t *testing.T) {
+	m := iterationTestModel(t)
+	m.ensureMainLayout(m.mainContentWidth())
+	rules := []int{0, 3, 4, 6} // block indexes of the iteration rules
+
+	for _, block := range rules[1:] {
+		m = pressMainKey(t, m, "]")
+		if m.mainScroll != m.mainBlockStarts[block] {
+			t.Fatalf("] scrolled to %d, want block %d at %d", m.mainScroll, block, m.mainBlockStarts[block])
+		}
+	}
+	before := m.mainScroll
+	m = pressMainKey(t, m, "]")
+	if m.mainScroll != before {
+		t.Errorf("] past the last iteration moved to %d", m.mainScroll)
+	}
+
+	// The last rule sits below the clamped scroll position, so [ starts
+	// from what is actually on screen.
+	m = pressMainKey(t, m, "[")
+	if top := m.mainViewTop(); top >= before || m.mainScroll >= before {
+		t.Errorf("[ did not move back (scroll %d)", m.mainScroll)
+	}
+	m = pressMainKey(t, m, "g")
+	m = pressMainKey(t, m, "[")
+	if m.mainScroll != 0 {
+		t.Errorf("[ before the first iteration moved to %d", m.mainScroll)
+	}
+}
+
+func TestFoldIterations(t *testing.T) {
+	m := iterationTestModel(t)
+
+	m = pressMainKey(t, m, "z")
+	if !m.blocks[0].Folded {
+		t.Fatal("z did not fold the first iteration")
+	}
+	if got := visibleBlocks(m); got != "iter1 iter2 iter2 bash iter3" {
+		t.Errorf("after z shows %q", got)
+	}
+	if !strings.Contains(visibleMainText(m), "▸ iteration 1 (folded)") {
+		t.Errorf("folded rule not marked:\n%s", visibleMainText(m))
+	}
+
+	m = pressMainKey(t, m, "Z")
+	if got := visibleBlocks(m); got != "iter1 iter2 iter2 iter3" {
+		t.Errorf("after Z shows %q", got)
+	}
+	m = pressMainKey(t, m, "Z")
+	if got := visibleBlocks(m); got != "iter1 bash bash iter2 iter2 bash iter3" {
+		t.Errorf("second Z did not unfold everything: %q", got)
+	}
+
+	// Jumping to a search match inside a folded iteration unfolds it.
+	m = pressMainKey(t, m, "Z")
+	m = typeSearch(t, m, "make t3")
+	if m.blocks[4].Folded || !strings.Contains(visibleMainText(m), "make t3") {
+		t.Errorf("search match left folded:\n%s", visibleMainText(m))
+	}
+}
+
+func TestFoldToolResult(t *testing.T) {
+	m := iterationTestModel(t)
+	m.ensureMainLayout(m.mainContentWidth())
+	unfolded := m.mainBlockLineCounts[1]
+
+	m = pressMainKey(t, m, "o")
+	if !m.blocks[1].Folded || m.blocks[2].Folded {
+		t.Fatal("o did not fold just the first tool call on screen")
+	}
+	m.ensureMainLayout(m.mainContentWidth())
+	text := visibleMainText(m)
+	if m.mainBlockLineCounts[1] != 5 || !strings.Contains(text, "make t1") || !strings.Contains(text, "▸ result folded") {
+		t.Errorf("folded tool call (%d lines):\n%s", m.mainBlockLineCounts[1], text)
+	}
+
+	m = pressMainKey(t, m, "o")
+	m.ensureMainLayout(m.mainContentWidth())
+	if m.blocks[1].Folded || m.mainBlockLineCounts[1] != unfolded {
+		t.Error("second o did not unfold the result")
+	}
+}
+
+func TestIterationSidebar(t *testing.T) {
+	m := iterationTestModel(t)
+	m = pressMainKey(t, m, "I")
+	if !m.showIterations || m.mainContentWidth() != 120-iterationSidebarWidth-4 {
+		t.Fatalf("sidebar shown %v, content width %d", m.showIterations, m.mainContentWidth())
+	}
+	m = pressMainKey(t, m, "]")
+
+	view := m.View()
+	for _, want := range []string{"ITERATIONS (4)", "#1", "1m 30s", "2 tools", "restart", "complete"} {
+		if !strings.Contains(ansi.Strip(view), want) {
+			t.Errorf("sidebar missing %q", want)
+		}
+	}
+	for i, line := range strings.Split(view, "\n") {
+		if w := ansi.StringWidth(line); w > 120 {
+			t.Errorf("line %d is %d columns wide", i, w)
+		}
+	}
+	sidebar := m.renderIterationSidebar()
+	if !strings.Contains(sidebar, selectedStyle.Render(padRight(formatIterationSummary(m.iterationSummaries()[1], iterationSidebarWidth-3), iterationSidebarWidth-3))) {
+		t.Errorf("iteration on screen not highlighted:\n%s", sidebar)
+	}
+}
@@ -49,6 +49,14 @@ func (m *Model) ensureMainLayout(width int) {
 		return // nothing dirty
 	}
 
+	// Whether an iteration rule passes a content filter depends on the
+	// blocks after it, so rebuild from the start of the dirty iteration.
+	iterStart, iter := m.iterationAt(dirtyFrom)
+	if m.mainFilter.byContent() {
+		dirtyFrom = iterStart
+	}
+	folded := m.blocks[iterStart].Kind == BlockIteration && m.blocks[iterStart].Folded
+
 	// Determine the current line position and whether any preceding block
 	// was non-empty, by scanning backward from the dirty boundary.
 	var linePos int
@@ -63,7 +71,14 @@ func (m *Model) ensureMainLayout(width int) {
 
 	// Rebuild from dirtyFrom onward.
 	for i := dirtyFrom; i < n; i++ {
-		lines := m.blocks[i].Layout(width)
+		if m.blocks[i].Kind == BlockIteration {
+			iter = m.blocks[i].Iteration
+			folded = m.blocks[i].Folded
+		}
+		var lines []string
+		if m.blockVisible(i, iter) && (!folded || m.blocks[i].Kind == BlockIteration) {
+			lines = m.blocks[i].Layout(width)
+		}
 		lc := len(lines) // nil → 0
 
 		if lc == 0 {
@@ -113,6 +128,7 @@ func (m *Model) collectViewportLines(viewStart, viewEnd, contentWidth int) []str
 		startBlock++
 	}
 
+	current := m.currentSearchBlock()
 	for i := startBlock; i < len(m.blocks) && linePos < viewEnd; i++ {
 		bc := m.mainBlockLineCounts[i]
 		if bc == 0 {
@@ -131,8 +147,16 @@ func (m *Model) collectViewportLines(viewStart, viewEnd, contentWidth int) []str
 		if linePos > bs {
 			localStart = linePos - bs
 		}
+		mark := searchMatchStyle.Render
+		if i == current {
+			mark = searchCurrentStyle.Render
+		}
 		for j := localStart; j < bc && linePos < viewEnd; j++ {
-			result = append(result, clipToWidth(m.blocks[i].layoutLines[j], contentWidth))
+			line := clipToWidth(m.blocks[i].layoutLines[j], contentWidth)
+			if m.searchQuery != "" {
+				line = highlightSearchMatches(line, m.searchQuery, mark)
+			}
+			result = append(result, line)
 			linePos++
 		}
 	}
@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Main-view search: "/" searches the text of the main-view blocks (assistant
+// text, tool names, arguments and results, info lines), matches are
+// highlighted on screen, and n/N move between matching blocks. Matching is
+// a case-insensitive substring match, like the session browser's search.
+
+// startMainSearch opens the search prompt. The query is applied as it is
+// typed; Esc returns to where the search started.
+func (m *Model) startMainSearch() {
+	m.searching = true
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchCursor = 0
+	m.searchOrigin = m.mainScroll
+	m.searchOriginAuto = m.mainAutoScroll
+}
+
+// clearMainSearch drops the active query and its highlights.
+func (m *Model) clearMainSearch() {
+	m.searching = false
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchCursor = 0
+	m.searchStale = false
+}
+
+// handleSearchKey handles raw key input while the search prompt is open.
+// Mirrors BrowserModel.handleSearchKey for the input pattern.
+func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.searching = false
+		m.confirmQuit = true
+		m.confirmCtrlC = true
+		return m, nil
+	case tea.KeyEsc:
+		m.clearMainSearch()
+		m.mainScroll = m.searchOrigin
+		m.mainAutoScroll = m.searchOriginAuto
+		return m, nil
+	case tea.KeyEnter:
+		m.searching = false
+		if m.searchQuery == "" {
+			m.clearMainSearch()
+		}
+		return m, nil
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		runes := []rune(m.searchQuery)
+		if len(runes) > 0 {
+			m.searchQuery = string(runes[:len(runes)-1])
+			m.applyMainSearch()
+		}
+		return m, nil
+	case tea.KeyDelete, tea.KeyCtrlU:
+		if m.searchQuery != "" {
+			m.searchQuery = ""
+			m.applyMainSearch()
+		}
+		return m, nil
+	case tea.KeyRunes:
+		if len(msg.Runes) > 0 {
+			m.searchQuery += string(msg.Runes)
+			m.applyMainSearch()
+		}
+		return m, nil
+	case tea.KeySpace:
+		m.searchQuery += " "
+		m.applyMainSearch()
+		return m, nil
+	}
+	return m, nil
+}
+
+// applyMainSearch recomputes the matches for the edited query and jumps to
+// the first one at or below where the search started.
+func (m *Model) applyMainSearch() {
+	m.refreshMainSearch()
+	if len(m.searchMatches) == 0 {
+		m.mainScroll = m.searchOrigin
+		m.mainAutoScroll = m.searchOriginAuto
+		return
+	}
+
+	from := 0
+	if m.width > 0 {
+		m.ensureMainLayout(m.mainContentWidth())
+		from = m.blockAtLine(m.searchOrigin)
+	}
+	m.searchCursor = 0
+	for i, block := range m.searchMatches {
+		if block >= from {
+			m.searchCursor = i
+			break
+		}
+	}
+	m.scrollToSearchMatch()
+}
+
+// refreshMainSearch recomputes searchMatches for the current blocks.
+func (m *Model) refreshMainSearch() {
+	m.searchStale = false
+	m.searchMatches = m.searchMatches[:0]
+	query := strings.ToLower(m.searchQuery)
+	if query == "" {
+		return
+	}
+	iter := 0
+	for i := range m.blocks {
+		if m.blocks[i].Kind == BlockIteration {
+			iter = m.blocks[i].Iteration
+		}
+		if !m.blockVisible(i, iter) {
+			continue // filtered out of the main view
+		}
+		if strings.Contains(strings.ToLower(m.blocks[i].searchText()), query) {
+			m.searchMatches = append(m.searchMatches, i)
+		}
+	}
+	if m.searchCursor >= len(m.searchMatches) {
+		m.searchCursor = 0
+	}
+}
+
+// moveSearchMatch jumps to the next (delta 1) or previous (delta -1)
+// matching block, wrapping around.
+func (m *Model) moveSearchMatch(delta int) {
+	if m.searchStale {
+		current := -1
+		if m.searchCursor < len(m.searchMatches) {
+			current = m.searchMatches[m.searchCursor]
+		}
+		m.refreshMainSearch()
+		// Keep the cursor on the same block when new blocks arrived.
+		for i, block := range m.searchMatches {
+			if block == current {
+				m.searchCursor = i
+			}
+		}
+	}
+	if len(m.searchMatches) == 0 {
+		return
+	}
+	n := len(m.searchMatches)
+	m.searchCursor = ((m.searchCursor+delta)%n + n) % n
+	m.scrollToSearchMatch()
+}
+
+// scrollToSearchMatch scrolls the main view so the first matching line of
+// the current match sits a third of the way down the pane.
+func (m *Model) scrollToSearchMatch() {
+	m.mainAutoScroll = false
+	m.focusedPane = 0
+	if m.width == 0 || len(m.searchMatches) == 0 {
+		return
+	}
+	block := m.searchMatches[m.searchCursor]
+	m.unfoldIterationOf(block)
+	m.ensureMainLayout(m.mainContentWidth())
+
+	offset := 0
+	for i, line := range m.blocks[block].layoutLines {
+		if len(searchLineMatches(ansi.Strip(line), m.searchQuery)) > 0 {
+			offset = i
+			break
+		}
+	}
+	target := m.mainBlockStarts[block] + offset - (m.mainHeight()-1)/3
+	if target < 0 {
+		target = 0
+	}
+	m.mainScroll = target
+}
+
+// searchStatus describes the search for the status bar: the prompt while
+// typing, then the query and the match position.
+func (m Model) searchStatus() string {
+	if !m.searching && m.searchQuery == "" {
+		return ""
+	}
+	label := "/" + m.searchQuery
+	if m.searching {
+		label += "_"
+	}
+	switch {
+	case m.searchQuery == "":
+		return label
+	case len(m.searchMatches) == 0:
+		return label + " (no matches)"
+	}
+	return fmt.Sprintf("%s (%d/%d)", label, m.searchCursor+1, len(m.searchMatches))
+}
+
+// currentSearchBlock returns the block of the current match, or -1.
+func (m Model) currentSearchBlock() int {
+	if m.searchCursor < len(m.searchMatches) {
+		return m.searchMatches[m.searchCursor]
+	}
+	return -1
+}
+
+// blockAtLine returns the first block that ends below the given document
+// line, or len(m.blocks) past the end. ensureMainLayout must be current.
+func (m Model) blockAtLine(line int) int {
+	for i := range m.blocks {
+		if c := m.mainBlockLineCounts[i]; c > 0 && m.mainBlockStarts[i]+c > line {
+			return i
+		}
+	}
+	return len(m.blocks)
+}
+
+// mainContentWidth is the width renderMain lays blocks out at.
+func (m Model) mainContentWidth() int {
+	return m.mainPaneWidth() - 4
+}
+
+// searchText returns the text of the block that search looks at.
+func (b *MainBlock) searchText() string {
+	switch b.Kind {
+	case BlockAssistantText:
+		return b.Text
+	case BlockToolCall:
+		return b.ToolName + "\n" + b.ToolArgs + "\n" + b.ToolResult
+	case BlockInfo:
+		return b.InfoText
+	}
+	return ""
+}
+
+// searchLineMatches returns the [start, end) rune ranges of the
+// case-insensitive occurrences of query in plain, a line without escape
+// sequences.
+func searchLineMatches(plain, query string) [][2]int {
+	needle := []rune(strings.ToLower(query))
+	if len(needle) == 0 {
+		return nil
+	}
+	runes := []rune(plain)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	var out [][2]int
+	for i := 0; i+len(needle) <= len(lower); {
+		if string(lower[i:i+len(needle)]) == string(needle) {
+			out = append(out, [2]int{i, i + len(needle)})
+			i += len(needle)
+			continue
+		}
+		i++
+	}
+	return out
+}
+
+// highlightSearchMatches wraps each occurrence of query in the rendered line
+// with mark, keeping the line's own styling around it.
+func highlightSearchMatches(line, query string, mark func(...string) string) string {
+	plain := ansi.Strip(line)
+	matches := searchLineMatches(plain, query)
+	if len(matches) == 0 {
+		return line
+	}
+
+	runes := []rune(plain)
+	var b strings.Builder
+	col := 0
+	for _, match := range matches {
+		start := ansi.StringWidth(string(runes[:match[0]]))
+		end := start + ansi.StringWidth(string(runes[match[0]:match[1]]))
+		b.WriteString(ansi.Cut(line, col, start))
+		b.WriteString(mark(string(runes[match[0]:match[1]])))
+		col = end
+	}
+	b.WriteString(ansi.Cut(line, col, ansi.StringWidth(plain)))
+	return b.String()
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// searchTestModel is a sized replay viewer with 30 iterations of one bash
+// call each; iterations 7 and 23 run "grep needle".
+func searchTestModel(t *testing.T) Model {
+	t.Helper()
+	var events []runner.Event
+	for i := 1; i <= 30; i++ {
+		cmd := "ls"
+		if i == 7 || i == 23 {
+			cmd = "grep needle"
+		}
+		events = append(events,
+			runner.Event{Type: runner.EventIteration, ID: fmt.Sprintf("iteration-%d", i)},
+			runner.Event{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: fmt.Sprintf("t%d", i), Args: []byte(`{"command":"` + cmd + `"}`)},
+		)
+	}
+	m := NewViewerModel(ConvertEvents(events), runner.RunMeta{}, "", "", "")
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	return updated.(Model)
+}
+
+func typeSearch(t *testing.T, m Model, query string) Model {
+	t.Helper()
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	for _, r := range query {
+		m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+// visibleMainText returns the plain text of the main view's viewport.
+func visibleMainText(m Model) string {
+	m.ensureMainLayout(m.mainContentWidth())
+	start := min(m.mainScroll, max(m.mainTotalLines-(m.mainHeight()-1), 0))
+	lines := m.collectViewportLines(start, start+m.mainHeight()-1, m.mainContentWidth())
+	return ansi.Strip(strings.Join(lines, "\n"))
+}
+
+func TestMainSearchJumpsBetweenMatches(t *testing.T) {
+	m := searchTestModel(t)
+
+	m = typeSearch(t, m, "NEEDLE")
+	if got, want := fmt.Sprint(m.searchMatches), "[13 45]"; got != want {
+		t.Fatalf("searchMatches = %s, want %s", got, want)
+	}
+	if m.currentSearchBlock() != 13 || !strings.Contains(visibleMainText(m), "grep needle") {
+		t.Fatalf("incremental search did not scroll to the first match (scroll %d)", m.mainScroll)
+	}
+	if status := m.searchStatus(); status != "/NEEDLE_ (1/2)" {
+		t.Errorf("searchStatus while typing = %q", status)
+	}
+
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.searching || m.searchStatus() != "/NEEDLE (1/2)" {
+		t.Fatalf("after Enter searching = %v, status %q", m.searching, m.searchStatus())
+	}
+
+	first := m.mainScroll
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.currentSearchBlock() != 45 || m.mainScroll <= first || m.memoryOverlay {
+		t.Fatalf("n: block %d, scroll %d (was %d), memory overlay %v", m.currentSearchBlock(), m.mainScroll, first, m.memoryOverlay)
+	}
+	if !strings.Contains(visibleMainText(m), "iteration 23") {
+		t.Errorf("second match not on screen:\n%s", visibleMainText(m))
+	}
+
+	// n wraps to the first match, N wraps back to the last.
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.currentSearchBlock() != 13 || m.mainScroll != first {
+		t.Errorf("n wrap: block %d, scroll %d, want 13 at %d", m.currentSearchBlock(), m.mainScroll, first)
+	}
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	if m.currentSearchBlock() != 45 {
+		t.Errorf("N wrap: block %d, want 45", m.currentSearchBlock())
+	}
+
+	// Esc clears the search and gives n back to the memory overlay.
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.searchQuery != "" || m.searchStatus() != "" {
+		t.Fatalf("Esc left search %q", m.searchQuery)
+	}
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if !m.memoryOverlay {
+		t.Error("n without a search did not open the memory overlay")
+	}
+}
+
+func TestMainSearchEscWhileTypingRestoresScroll(t *testing.T) {
+	m := searchTestModel(t)
+	m.mainScroll = 5
+
+	m = typeSearch(t, m, "needle")
+	if m.mainScroll == 5 {
+		t.Fatal("incremental search did not move the view")
+	}
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.searching || m.searchQuery != "" || m.mainScroll != 5 {
+		t.Errorf("after Esc: searching %v, query %q, scroll %d; want closed at 5", m.searching, m.searchQuery, m.mainScroll)
+	}
+
+	m = typeSearch(t, m, "nothing like this")
+	if m.searchStatus() != "/nothing like this_ (no matches)" || m.mainScroll != 5 {
+		t.Errorf("no-match search: status %q, scroll %d", m.searchStatus(), m.mainScroll)
+	}
+}
+
+func TestMainSearchStartsFromCurrentPosition(t *testing.T) {
+	m := searchTestModel(t)
+	m.ensureMainLayout(m.mainContentWidth())
+	m.mainScroll = m.mainBlockStarts[20] // past iteration 7's match
+
+	m = typeSearch(t, m, "needle")
+	if m.currentSearchBlock() != 45 {
+		t.Errorf("first match after block 20 = block %d, want 45", m.currentSearchBlock())
+	}
+}
+
+func TestMainSearchPicksUpLiveBlocks(t *testing.T) {
+	ch := make(chan runner.Event)
+	m := NewModel(ch, "pi", "", "", "", nil, nil)
+	m = updateModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	add := func(m Model, id, cmd string) Model {
+		return updateModel(t, m, EventMsg(DisplayEvent{Type: DisplayToolStart, ToolName: "bash", ToolCallID: id, RawArgs: []byte(`{"command":"` + cmd + `"}`)}))
+	}
+	m = add(m, "t1", "make build")
+	m = typeSearch(t, m, "make")
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	m = add(m, "t2", "ls")
+	m = add(m, "t3", "make test")
+	if !m.searchStale {
+		t.Fatal("new blocks did not mark the search stale")
+	}
+	m = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if got := fmt.Sprint(m.searchMatches); got != "[0 2]" || m.currentSearchBlock() != 2 {
+		t.Errorf("after n: matches %s, current %d; want [0 2] at 2", got, m.currentSearchBlock())
+	}
+}
+
+func TestHighlightSearchMatches(t *testing.T) {
+	mark := func(s ...string) string { return "[" + strings.Join(s, "") + "]" }
+	line := "\x1b[1mHello\x1b[0m world, hello 世界"
+
+	got := highlightSearchMatches(line, "hello", mark)
+	if plain := ansi.Strip(got); plain != "[Hello] world, [hello] 世界" {
+		t.Errorf("highlight = %q (plain %q)", got, plain)
+	}
+	if got := highlightSearchMatches(line, "世界", mark); ansi.Strip(got) != "Hello world, hello [世界]" {
+		t.Errorf("wide-rune highlight = %q", ansi.Strip(got))
+	}
+	if got := highlightSearchMatches(line, "absent", mark); got != line {
+		t.Errorf("line without match changed: %q", got)
+	}
+}
@@ -95,6 +95,31 @@ type Model struct {
 	mainBlockLineCounts []int // number of screen lines each block contributes
 	mainTotalLines      int   // total lines in the virtual document
 	mainLayoutWidth     int   // width the index was last computed for
+
+	// focusBlock is the block to scroll the main view to once the terminal
+	// size is known, if focusPending. Set by WithFocusBlock.
+	focusBlock   int
+	focusPending bool
+
+	// Main-view search (see main_search.go).
+	searching        bool   // search prompt open, query being typed
+	searchQuery      string // active query; "" = no search
+	searchMatches    []int  // indexes of matching blocks, ascending
+	searchCursor     int    // index into searchMatches of the current match
+	searchStale      bool   // blocks changed since searchMatches was computed
+	searchOrigin     int    // mainScroll when the prompt was opened
+	searchOriginAuto bool   // mainAutoScroll when the prompt was opened
+
+	// Main-view filters (see main_filter.go).
+	mainFilter      mainFilter
+	iterFilterInput bool   // iteration range prompt open
+	iterFilterText  string // text typed into the iteration range prompt
+	iterFilterError string // populated when the range does not parse
+
+	// Iteration sidebar (see main_iterations.go).
+	showIterations bool      // iteration index shown next to the main view
+	runStatus      string    // final run status once known, for the last iteration's outcome
+	runEndedAt     time.Time // when the run ended, for the last iteration's duration
 }
 
 // NewModel creates a TUI model that reads runner events from ch.
@@ -144,6 +169,10 @@ func NewViewerModel(events []DisplayEvent, meta runner.RunMeta, promptText, note
 		promptText:     promptText,
 		notesPath:      notesPath,
 		progressPath:   progressPath,
+		runStatus:      meta.Status,
+	}
+	if t, err := time.Parse(time.RFC3339, meta.EndedAt); err == nil {
+		m.runEndedAt = t
 	}
 
 	// Pre-build blocks from loaded display events.
@@ -154,6 +183,41 @@ func NewViewerModel(events []DisplayEvent, meta runner.RunMeta, promptText, note
 	return m
 }
 
+// WithFocusBlock returns a copy of the viewer that opens with the main view
+// scrolled to the given block, e.g. a ContentHit's Block. Out-of-range
+// indexes are ignored.
+func (m Model) WithFocusBlock(block int) Model {
+	if block >= 0 && block < len(m.blocks) {
+		m.focusBlock = block
+		m.focusPending = true
+		if it := m.blocks[block].Iteration; it > 0 {
+			m.status += fmt.Sprintf(" | search match in iteration %d", it)
+		}
+	}
+	return m
+}
+
+// ConvertEvents runs saved runner events through a fresh EventConverter.
+func ConvertEvents(events []runner.Event) []DisplayEvent {
+	conv := NewEventConverter()
+	var displayEvents []DisplayEvent
+	for i := range events {
+		displayEvents = append(displayEvents, conv.Convert(&events[i])...)
+	}
+	return displayEvents
+}
+
+// BuildBlocks builds the main-view blocks for the given display events the
+// same way the replay viewer does, for callers that render a run outside
+// the TUI.
+func BuildBlocks(events []DisplayEvent) []MainBlock {
+	m := Model{activeToolIdx: -1}
+	for _, de := range events {
+		m.buildBlock(de)
+	}
+	return m.blocks
+}
+
 // RunResult returns the runner result if available, or nil.
 func (m Model) RunResult() *runner.RunResult {
 	return m.result
@@ -222,6 +286,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		initRenderer(mainContentWidth)
 		m.invalidateAllMainLayouts()
+		if m.focusPending {
+			m.ensureMainLayout(m.mainContentWidth())
+			m.mainScroll = m.mainBlockStarts[m.focusBlock]
+			m.focusedPane = 0
+			m.focusPending = false
+		}
 		return m, nil
 
 	case rawEventMsg:
@@ -238,10 +308,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.running = false
 		m.status = fmt.Sprintf("Done — %s | %s (%d iterations)", msg.Result.Agent, msg.Result.Status, msg.Result.Iterations)
 		if msg.Result.Error != "" {
-			m.errorOverlay = msg.Result.Error
+			m.errorOverlay = failureOverlayText(msg.Result)
 			m.errorOverlayScroll = 0
 		}
 		m.result = &msg.Result
+		m.runStatus = string(msg.Result.Status)
+		m.runEndedAt = time.Now()
 		return m, nil
 
 	case tickMsg:
@@ -317,6 +389,11 @@ func (m Model) addDisplayEvent(de DisplayEvent) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// New or growing blocks may add search matches; n/N picks them up.
+	if m.searchQuery != "" {
+		m.searchStale = true
+	}
+
 	// For assistant_text updates, merge with the last assistant_text event.
 	if de.Type == DisplayAssistantText && len(m.events) > 0 {
 		last := &m.events[len(m.events)-1]
@@ -355,6 +432,7 @@ func (m *Model) buildBlock(de DisplayEvent) {
 		m.blocks = append(m.blocks, MainBlock{
 			Kind:      BlockIteration,
 			Iteration: de.Iteration,
+			StartedAt: de.Timestamp,
 		})
 		m.invalidateMainLayoutFrom(len(m.blocks) - 1)
 	case DisplayAssistantText:
@@ -426,6 +504,11 @@ func (m *Model) buildBlock(de DisplayEvent) {
 		}
 		m.activeToolIdx = -1
 	case DisplayInfo, DisplayRestart:
+		if de.Outcome != "" {
+			if start, _ := m.iterationAt(len(m.blocks) - 1); start < len(m.blocks) && m.blocks[start].Kind == BlockIteration {
+				m.blocks[start].Outcome = de.Outcome
+			}
+		}
 		m.blocks = append(m.blocks, MainBlock{
 			Kind:     BlockInfo,
 			InfoText: de.Detail,
@@ -548,6 +631,14 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.searching {
+		return m.handleSearchKey(msg)
+	}
+
+	if m.iterFilterInput {
+		return m.handleIterFilterKey(msg)
+	}
+
 	switch msg.String() {
 
 	case "q":
@@ -666,11 +757,64 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "r":
 		m.rawMode = !m.rawMode
 
+	case "/":
+		m.startMainSearch()
+
 	case "n":
+		// With an active search n moves to the next match; Esc clears the
+		// search and gives n back to the memory overlay.
+		if m.searchQuery != "" {
+			m.moveSearchMatch(1)
+			break
+		}
 		m.memoryOverlay = true
 		m.memoryOverlayTab = 0
 		m.memoryOverlayScroll = 0
 
+	case "N":
+		if m.searchQuery != "" {
+			m.moveSearchMatch(-1)
+		}
+
+	case "esc":
+		m.clearMainSearch()
+
+	case "f":
+		m.cycleMainFilterKind()
+
+	case "T":
+		m.cycleMainFilterTool()
+
+	case "i":
+		m.iterFilterInput = true
+		m.iterFilterText = ""
+		m.iterFilterError = ""
+		if f := m.mainFilter; f.iterFrom > 0 || f.iterTo > 0 {
+			m.iterFilterText = formatIterationRange(f.iterFrom, f.iterTo)
+		}
+
+	case "F":
+		m.setMainFilter(mainFilter{})
+
+	case "]":
+		m.jumpIteration(1)
+
+	case "[":
+		m.jumpIteration(-1)
+
+	case "I":
+		m.showIterations = !m.showIterations
+		m.invalidateAllMainLayouts()
+
+	case "z":
+		m.toggleIterationFold()
+
+	case "Z":
+		m.toggleAllIterationFolds()
+
+	case "o":
+		m.toggleToolFold()
+
 	case "t":
 		// In viewer mode (no controlSend), the timeout overlay is disabled.
 		if m.controlSend == nil {
@@ -1029,6 +1173,9 @@ func (m Model) View() string {
 
 	headerBar := m.renderHeader()
 	mainView := m.renderMain()
+	if m.showIterations {
+		mainView = lipgloss.JoinHorizontal(lipgloss.Top, m.renderIterationSidebar(), mainView)
+	}
 	streamView := m.renderStream()
 	detailView := m.renderDetail()
 	statusBar := m.renderStatus()
@@ -1055,7 +1202,7 @@ func scrollIndicator(scroll, visibleLines, totalLines int) string {
 }
 
 func (m Model) renderMain() string {
-	w := m.width
+	w := m.mainPaneWidth()
 	ph := m.mainHeight()
 	contentWidth := w - 4 // inside borders + padding
 
@@ -1345,6 +1492,22 @@ func (m Model) renderStatus() string {
 	if strip := remindersStrip(m.pendingReminders); strip != "" {
 		left += " │ " + strip
 	}
+	if f := m.mainFilter.String(); f != "" {
+		left += " │ filter: " + f
+	}
+	if m.iterFilterInput {
+		left = "Iterations (e.g. 3-7, 5, 3-, -7; empty clears): " + m.iterFilterText + "_"
+		if m.iterFilterError != "" {
+			left += " │ " + m.iterFilterError
+		}
+	}
+	if search := m.searchStatus(); search != "" {
+		if m.searching {
+			left = search
+		} else {
+			left += " │ " + search
+		}
+	}
 
 	modeStr := "rendered"
 	if m.rawMode {
@@ -1359,8 +1522,23 @@ func (m Model) renderStatus() string {
 		sep + statusKeyStyle.Render("s") + ":steering" +
 		sep + statusKeyStyle.Render("p") + ":prompt" +
 		sep + statusKeyStyle.Render("n") + ":memory" +
+		sep + statusKeyStyle.Render("/") + ":search" +
 		sep + statusKeyStyle.Render("?") + ":help" +
 		sep + statusKeyStyle.Render("q") + ":quit"
+	if m.iterFilterInput {
+		right = statusKeyStyle.Render("Enter") + ":apply" +
+			sep + statusKeyStyle.Render("Esc") + ":cancel"
+	} else if m.searching {
+		right = statusKeyStyle.Render("Enter") + ":done" +
+			sep + statusKeyStyle.Render("Esc") + ":cancel" +
+			sep + statusKeyStyle.Render("Ctrl+u") + ":clear"
+	} else if m.searchQuery != "" {
+		right = statusKeyStyle.Render("n/N") + ":next/prev" +
+			sep + statusKeyStyle.Render("Esc") + ":clear search" +
+			sep + statusKeyStyle.Render("/") + ":new search" +
+			sep + statusKeyStyle.Render("?") + ":help" +
+			sep + statusKeyStyle.Render("q") + ":quit"
+	}
 
 	leftW := lipgloss.Width(left)
 	rightW := lipgloss.Width(right)
@@ -1443,6 +1621,23 @@ func (m Model) renderHelpOverlay() string {
 		"  p             Show effective prompt\n" +
 		"  n             Show memory files (NOTES / PROGRESS)\n" +
 		"\n" +
+		"Search\n" +
+		"  /             Search the main view\n" +
+		"  n / N         Next / previous match\n" +
+		"  Esc           Clear the search\n" +
+		"\n" +
+		"Filter\n" +
+		"  f             Cycle tools / errors / assistant text\n" +
+		"  T             Cycle tool name\n" +
+		"  i             Set iteration range\n" +
+		"  F             Clear filters\n" +
+		"\n" +
+		"Iterations\n" +
+		"  [ / ]         Previous / next iteration\n" +
+		"  I             Toggle iteration sidebar\n" +
+		"  z / Z         Fold iteration / all iterations\n" +
+		"  o             Fold first tool result on screen\n" +
+		"\n" +
 		"Control\n" +
 		"  t             Set inactivity timeout\n" +
 		"  s             Add steering for next iteration\n" +
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -553,6 +554,33 @@ func TestNewViewerModel_BlocksAreAssistantFinal(t *testing.T) {
 	}
 }
 
+func TestBuildBlocksMatchesViewerModel(t *testing.T) {
+	displayEvents := ConvertEvents([]runner.Event{
+		{Type: runner.EventIteration, ID: "iteration-3"},
+		{Type: runner.EventMessageStart, Message: []byte(`{"role":"assistant","model":"m"}`)},
+		{Type: runner.EventMessageUpdate, AssistantMessageEvent: []byte(`{"type":"text_delta","delta":"hel"}`)},
+		{Type: runner.EventMessageUpdate, AssistantMessageEvent: []byte(`{"type":"text_delta","delta":"lo"}`)},
+		{Type: runner.EventMessageEnd},
+		{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: "t1", Args: []byte(`{"command":"ls"}`)},
+		{Type: runner.EventToolExecutionEnd, ToolName: "bash", ToolCallID: "t1", Result: []byte(`"ok"`)},
+	})
+
+	blocks := BuildBlocks(displayEvents)
+	viewerBlocks := NewViewerModel(displayEvents, runner.RunMeta{}, "", "", "").blocks
+	if len(blocks) != 3 || len(viewerBlocks) != len(blocks) {
+		t.Fatalf("BuildBlocks = %d blocks, viewer = %d, want 3", len(blocks), len(viewerBlocks))
+	}
+	if blocks[0].Kind != BlockIteration || blocks[0].Iteration != 3 {
+		t.Errorf("blocks[0] = %+v, want iteration 3 rule", blocks[0])
+	}
+	if blocks[1].Text != "hello" || !blocks[1].AssistantFinal {
+		t.Errorf("blocks[1] = %+v, want merged final assistant text", blocks[1])
+	}
+	if blocks[2].ToolArgs != "$ ls" || !blocks[2].ToolDone || blocks[2].ToolResult != "ok" {
+		t.Errorf("blocks[2] = %+v, want finished bash tool call", blocks[2])
+	}
+}
+
 func TestDoneMsg_StatusStuck_ShowsErrorOverlay(t *testing.T) {
 	m := NewModel(nil, "pi", "", "", "", nil, nil)
 	m.width = 80
@@ -578,6 +606,47 @@ func TestDoneMsg_StatusStuck_ShowsErrorOverlay(t *testing.T) {
 	}
 }
 
+func TestDoneMsg_StructuredFailure_ShowsDetailsInErrorOverlay(t *testing.T) {
+	m := NewModel(nil, "pi", "", "", "", nil, nil)
+	m.width = 80
+	m.height = 24
+
+	code := 2
+	result := runner.RunResult{
+		Agent:      "pi",
+		Status:     runner.StatusFailed,
+		Iterations: 1,
+		Error:      "agent exited with error: exit status 2\nstderr: boom",
+		Failure: &runner.Failure{
+			Category:  runner.FailureAgentExit,
+			Message:   "agent exited with error: exit status 2",
+			ExitCode:  &code,
+			Stderr:    "boom",
+			Iteration: 1,
+		},
+	}
+	m = updateModel(t, m, DoneMsg{Result: result})
+
+	for _, want := range []string{"Category: agent_exit", "Exit code: 2", "Iteration: 1", "Stderr:", "  boom"} {
+		if !strings.Contains(m.errorOverlay, want) {
+			t.Errorf("errorOverlay missing %q:\n%s", want, m.errorOverlay)
+		}
+	}
+}
+
+func TestDoneMsg_FailureWithoutError_NoOverlay(t *testing.T) {
+	m := NewModel(nil, "pi", "", "", "", nil, nil)
+	result := runner.RunResult{
+		Agent:   "pi",
+		Status:  runner.StatusInterrupted,
+		Failure: &runner.Failure{Category: runner.FailureUser, Message: "interrupted by user"},
+	}
+	m = updateModel(t, m, DoneMsg{Result: result})
+	if m.errorOverlay != "" {
+		t.Errorf("errorOverlay = %q, want empty for user interruption", m.errorOverlay)
+	}
+}
+
 func TestHandleRawEvent_SetsLastEventTime(t *testing.T) {
 	m := NewModel(nil, "pi", "", "", "", nil, nil)
 	m.running = true
@@ -614,3 +683,36 @@ func updateModelWithCmd(t *testing.T, model Model, msg tea.Msg) (Model, tea.Cmd)
 	}
 	return next, cmd
 }
+
+func TestViewerModelWithFocusBlockScrollsToBlock(t *testing.T) {
+	var events []runner.Event
+	for i := 1; i <= 30; i++ {
+		events = append(events,
+			runner.Event{Type: runner.EventIteration, ID: fmt.Sprintf("iteration-%d", i)},
+			runner.Event{Type: runner.EventToolExecutionStart, ToolName: "bash", ToolCallID: fmt.Sprintf("t%d", i), Args: []byte(`{"command":"ls"}`)},
+		)
+	}
+	displayEvents := ConvertEvents(events)
+	target := 41 // iteration 21's tool call
+
+	m := NewViewerModel(displayEvents, runner.RunMeta{}, "", "", "").WithFocusBlock(target)
+	if !strings.Contains(m.status, "search match in iteration 21") {
+		t.Errorf("status = %q, want search match note", m.status)
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(Model)
+	if m.mainScroll == 0 || m.mainScroll != m.mainBlockStarts[target] {
+		t.Fatalf("mainScroll = %d, want start of block %d (%d)", m.mainScroll, target, m.mainBlockStarts[target])
+	}
+
+	// Later resizes keep the user's scroll position.
+	m.mainScroll = 3
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 90, Height: 30})
+	if got := updated.(Model).mainScroll; got != 3 {
+		t.Errorf("mainScroll after resize = %d, want 3", got)
+	}
+
+	if m := NewViewerModel(displayEvents, runner.RunMeta{}, "", "", "").WithFocusBlock(999); m.focusPending {
+		t.Error("WithFocusBlock accepted an out-of-range block")
+	}
+}
@@ -151,6 +151,19 @@ var toolSepStyle = lipgloss.NewStyle().
 var toolResultStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("250"))
 
+// Main-view search highlights: every visible match, and the match n/N
+// last jumped to.
+var (
+	searchMatchStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("58")).
+				Foreground(colorBright)
+
+	searchCurrentStyle = lipgloss.NewStyle().
+				Background(colorTool).
+				Foreground(lipgloss.Color("16")).
+				Bold(true)
+)
+
 // Info text style (for BlockInfo blocks in the main view).
 var infoTextStyle = lipgloss.NewStyle().
 	Foreground(colorInfo)
@@ -183,3 +196,11 @@ var (
 	browserRowStyle    = lipgloss.NewStyle().Foreground(colorBright)
 	browserSubtleStyle = lipgloss.NewStyle().Foreground(colorDim)
 )
+
+// Run diff row styles: lines only in the first run, only in the second
+// run, and lines that differ between them.
+var (
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(colorError)
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	diffChangedStyle = lipgloss.NewStyle().Foreground(colorTool)
+)
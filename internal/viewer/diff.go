@@ -0,0 +1,381 @@
+package viewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// DiffOp classifies one row of a run comparison.
+type DiffOp int
+
+const (
+	DiffSame    DiffOp = iota // both runs have the same line
+	DiffChanged               // the runs have different lines at this point
+	DiffRemoved               // only the first run has the line
+	DiffAdded                 // only the second run has the line
+)
+
+// DiffRow is one aligned row of a side-by-side comparison. Left is empty
+// for DiffAdded rows and Right for DiffRemoved rows.
+type DiffRow struct {
+	Op    DiffOp
+	Left  string
+	Right string
+}
+
+// DiffSection compares one aspect of two runs, e.g. their prompts.
+type DiffSection struct {
+	Title string
+	Rows  []DiffRow
+}
+
+// Changes returns the number of rows that differ between the runs.
+func (s DiffSection) Changes() int {
+	n := 0
+	for _, row := range s.Rows {
+		if row.Op != DiffSame {
+			n++
+		}
+	}
+	return n
+}
+
+// RunDiff compares two saved runs: their meta, iterations, tool-call
+// sequences, effective prompts and final memory files.
+type RunDiff struct {
+	A, B     runner.RunMeta
+	Sections []DiffSection
+}
+
+// Changes returns the number of differing rows across all sections.
+func (d RunDiff) Changes() int {
+	n := 0
+	for _, s := range d.Sections {
+		n += s.Changes()
+	}
+	return n
+}
+
+// maxDiffCells bounds the LCS table of a line diff. Larger inputs, after
+// their common prefix and suffix are trimmed, are paired line by line.
+const maxDiffCells = 4 << 20
+
+// CompareRuns compares run a against run b.
+func CompareRuns(a, b *SavedRun) RunDiff {
+	itersA, toolsA := runToolCalls(a.Events)
+	itersB, toolsB := runToolCalls(b.Events)
+	return RunDiff{
+		A: a.Meta,
+		B: b.Meta,
+		Sections: []DiffSection{
+			{Title: "Meta", Rows: diffPairs(metaLines(a.Meta), metaLines(b.Meta))},
+			{Title: "Environment", Rows: diffPairs(environmentLines(a.Meta.Environment), environmentLines(b.Meta.Environment))},
+			{Title: "Iterations", Rows: diffPairs(iterationLines(itersA, toolsA), iterationLines(itersB, toolsB))},
+			{Title: "Tool calls", Rows: DiffLines(toolCallLines(toolsA), toolCallLines(toolsB))},
+			{Title: "Prompt", Rows: DiffLines(splitLines(a.Prompt), splitLines(b.Prompt))},
+			{Title: "NOTES.md", Rows: DiffLines(splitLines(a.Notes), splitLines(b.Notes))},
+			{Title: "PROGRESS.md", Rows: DiffLines(splitLines(a.Progress), splitLines(b.Progress))},
+		},
+	}
+}
+
+// metaLines lists the meta fields worth comparing, one "label: value" line
+// each, in a fixed order so the two runs line up.
+func metaLines(meta runner.RunMeta) []string {
+	duration := ""
+	if start, ok := parseSummaryTime(meta.StartedAt); ok {
+		if end, ok := parseSummaryTime(meta.EndedAt); ok && !end.Before(start) {
+			duration = end.Sub(start).String()
+		}
+	}
+	failure := ""
+	if meta.Failure != nil {
+		failure = string(meta.Failure.Category) + ": " + meta.Failure.Message
+	}
+	maxIterations := "unlimited"
+	if meta.MaxIterations > 0 {
+		maxIterations = strconv.Itoa(meta.MaxIterations)
+	}
+	return []string{
+		"run id: " + meta.RunID,
+		"agent: " + meta.Agent,
+		"status: " + meta.Status,
+		"prompt source: " + meta.PromptSource,
+		"prompt file: " + valueOrDefault(meta.PromptFile, valueOrDefault(meta.PlanFile, "-")),
+		"max iterations: " + maxIterations,
+		"iterations: " + strconv.Itoa(meta.IterationsCompleted),
+		"started: " + meta.StartedAt,
+		"ended: " + valueOrDefault(meta.EndedAt, "-"),
+		"duration: " + valueOrDefault(duration, "-"),
+		"failure: " + valueOrDefault(failure, "-"),
+	}
+}
+
+// environmentLines lists the recorded run environment like metaLines.
+// Runs without one show "-" for every field.
+func environmentLines(env *runner.RunEnvironment) []string {
+	if env == nil {
+		env = &runner.RunEnvironment{}
+	}
+	return []string{
+		"ralfinho: " + valueOrDefault(env.RalfinhoVersion, "-"),
+		"agent binary: " + valueOrDefault(env.AgentBinary, "-"),
+		"agent version: " + valueOrDefault(env.AgentVersion, "-"),
+		"agent args: " + valueOrDefault(strings.Join(env.AgentExtraArgs, " "), "-"),
+		"inactivity timeout: " + valueOrDefault(env.InactivityTimeout, "-"),
+		"working directory: " + valueOrDefault(env.WorkDir, "-"),
+		"host: " + valueOrDefault(env.Hostname, "-"),
+		"prompt sha256: " + valueOrDefault(env.PromptSHA256, "-"),
+		"git at start: " + FormatGitState(env.GitStart),
+		"git at end: " + FormatGitState(env.GitEnd),
+	}
+}
+
+// FormatGitState renders a git snapshot as "branch@commit", with the commit
+// shortened and " (dirty)" appended for uncommitted changes; "-" for nil.
+func FormatGitState(state *runner.GitState) string {
+	if state == nil {
+		return "-"
+	}
+	commit := state.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	out := commit
+	if state.Branch != "" {
+		out = state.Branch + "@" + commit
+	}
+	if state.Dirty {
+		out += " (dirty)"
+	}
+	return out
+}
+
+// diffToolCall is one tool call of a run, for comparison.
+type diffToolCall struct {
+	Iteration int    // iteration number
+	Run       int    // index of the iteration run it belongs to; restarts repeat numbers
+	Line      string // "bash: go test ./..."
+	Error     bool
+}
+
+// runToolCalls extracts the iteration numbers of a run, one per iteration
+// started (a restarted iteration appears twice), and its tool calls in call
+// order.
+func runToolCalls(events []runner.Event) (iterations []int, calls []diffToolCall) {
+	byID := make(map[string]int)
+	iteration := 0
+	for _, ev := range events {
+		switch ev.Type {
+		case runner.EventIteration:
+			if n, err := strconv.Atoi(strings.TrimPrefix(ev.ID, "iteration-")); err == nil {
+				iteration = n
+			}
+			iterations = append(iterations, iteration)
+		case runner.EventToolExecutionStart:
+			byID[ev.ToolCallID] = len(calls)
+			calls = append(calls, diffToolCall{Iteration: iteration, Run: len(iterations) - 1, Line: toolCallLine(ev.ToolName, ev.Args)})
+		case runner.EventToolExecutionUpdate:
+			// kiro sends the real arguments in a follow-up update.
+			if i, ok := byID[ev.ToolCallID]; ok && len(ev.Args) > 0 {
+				calls[i].Line = toolCallLine(ev.ToolName, ev.Args)
+			}
+		case runner.EventToolExecutionEnd:
+			if i, ok := byID[ev.ToolCallID]; ok {
+				calls[i].Error = ev.IsError != nil && *ev.IsError
+			}
+		}
+	}
+	return iterations, calls
+}
+
+// toolCallLine summarizes a tool call as "name: argument", using the shell
+// command or file path when the arguments have one.
+func toolCallLine(name string, args json.RawMessage) string {
+	name = statsToolName(name)
+	var fields struct {
+		Command  string `json:"command"`
+		Path     string `json:"path"`
+		FilePath string `json:"file_path"`
+	}
+	_ = json.Unmarshal(args, &fields)
+	switch {
+	case fields.Command != "":
+		return name + ": " + firstLine(fields.Command)
+	case fields.Path != "":
+		return name + ": " + fields.Path
+	case fields.FilePath != "":
+		return name + ": " + fields.FilePath
+	case len(args) > 0 && string(args) != "null" && string(args) != "{}":
+		raw := []rune(string(args))
+		if len(raw) > 80 {
+			raw = append(raw[:80], '…')
+		}
+		return name + ": " + string(raw)
+	}
+	return name
+}
+
+// toolCallLines renders the tool-call sequence with a marker line at each
+// iteration start, so the diff keeps calls of different iterations apart.
+func toolCallLines(calls []diffToolCall) []string {
+	var lines []string
+	run := -2
+	for _, call := range calls {
+		if call.Run != run {
+			run = call.Run
+			lines = append(lines, fmt.Sprintf("── iteration %d", call.Iteration))
+		}
+		line := call.Line
+		if call.Error {
+			line += " (error)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// iterationLines summarizes each iteration started, one line with its tool
+// call and error counts each.
+func iterationLines(iterations []int, calls []diffToolCall) []string {
+	tools := make([]int, len(iterations))
+	errors := make([]int, len(iterations))
+	for _, call := range calls {
+		if call.Run >= 0 {
+			tools[call.Run]++
+			if call.Error {
+				errors[call.Run]++
+			}
+		}
+	}
+	lines := make([]string, len(iterations))
+	for k, iteration := range iterations {
+		lines[k] = fmt.Sprintf("iteration %d: %d tool calls", iteration, tools[k])
+		if errors[k] > 0 {
+			lines[k] += fmt.Sprintf(", %d errors", errors[k])
+		}
+	}
+	return lines
+}
+
+// diffPairs compares two lists position by position, for sections like the
+// meta fields where the n-th line of each side describes the same thing.
+func diffPairs(a, b []string) []DiffRow {
+	var rows []DiffRow
+	for i := 0; i < max(len(a), len(b)); i++ {
+		switch {
+		case i >= len(a):
+			rows = append(rows, DiffRow{Op: DiffAdded, Right: b[i]})
+		case i >= len(b):
+			rows = append(rows, DiffRow{Op: DiffRemoved, Left: a[i]})
+		case a[i] == b[i]:
+			rows = append(rows, DiffRow{Op: DiffSame, Left: a[i], Right: b[i]})
+		default:
+			rows = append(rows, DiffRow{Op: DiffChanged, Left: a[i], Right: b[i]})
+		}
+	}
+	return rows
+}
+
+// DiffLines aligns two line lists on their longest common subsequence.
+// Lines removed and added at the same point are paired up as changed rows,
+// which reads naturally in a side-by-side view.
+func DiffLines(a, b []string) []DiffRow {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var rows []DiffRow
+	for _, line := range a[:prefix] {
+		rows = append(rows, DiffRow{Op: DiffSame, Left: line, Right: line})
+	}
+	rows = append(rows, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		rows = append(rows, DiffRow{Op: DiffSame, Left: line, Right: line})
+	}
+	return rows
+}
+
+// diffMiddle diffs what is left once the common prefix and suffix are
+// trimmed.
+func diffMiddle(a, b []string) []DiffRow {
+	if len(a)*len(b) > maxDiffCells {
+		return pairChanges(a, b)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+
+	var rows []DiffRow
+	i, j := 0, 0
+	start, startB := 0, 0 // start of the current run of changes
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			rows = append(rows, pairChanges(a[start:i], b[startB:j])...)
+			rows = append(rows, DiffRow{Op: DiffSame, Left: a[i], Right: b[j]})
+			i++
+			j++
+			start, startB = i, j
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return append(rows, pairChanges(a[start:], b[startB:])...)
+}
+
+// pairChanges pairs removed lines with added lines as changed rows; the
+// longer side's extra lines are plain removals or additions.
+func pairChanges(removed, added []string) []DiffRow {
+	var rows []DiffRow
+	for k := 0; k < max(len(removed), len(added)); k++ {
+		switch {
+		case k >= len(removed):
+			rows = append(rows, DiffRow{Op: DiffAdded, Right: added[k]})
+		case k >= len(added):
+			rows = append(rows, DiffRow{Op: DiffRemoved, Left: removed[k]})
+		default:
+			rows = append(rows, DiffRow{Op: DiffChanged, Left: removed[k], Right: added[k]})
+		}
+	}
+	return rows
+}
+
+// splitLines splits text into lines, ignoring a trailing newline. Empty
+// text has no lines.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// firstLine returns s up to its first newline, marking the cut.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
@@ -0,0 +1,218 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// formatRows renders diff rows one per line as "<op> left | right", with
+// op one of " ", "~", "-" and "+".
+func formatRows(rows []DiffRow) string {
+	ops := map[DiffOp]string{DiffSame: " ", DiffChanged: "~", DiffRemoved: "-", DiffAdded: "+"}
+	var out []string
+	for _, row := range rows {
+		out = append(out, fmt.Sprintf("%s %s | %s", ops[row.Op], row.Left, row.Right))
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    []string{"x", "y"},
+			b:    []string{"x", "y"},
+			want: "  x | x\n  y | y",
+		},
+		{
+			name: "insertion",
+			a:    []string{"a", "c"},
+			b:    []string{"a", "b", "c"},
+			want: "  a | a\n+  | b\n  c | c",
+		},
+		{
+			name: "removal",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "c"},
+			want: "  a | a\n- b | \n  c | c",
+		},
+		{
+			name: "replaced lines pair up",
+			a:    []string{"a", "old 1", "old 2", "z"},
+			b:    []string{"a", "new 1", "z"},
+			want: "  a | a\n~ old 1 | new 1\n- old 2 | \n  z | z",
+		},
+		{
+			name: "common line in the middle",
+			a:    []string{"1", "keep", "2"},
+			b:    []string{"3", "keep", "4", "5"},
+			want: "~ 1 | 3\n  keep | keep\n~ 2 | 4\n+  | 5",
+		},
+		{
+			name: "one side empty",
+			a:    nil,
+			b:    []string{"new"},
+			want: "+  | new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRows(DiffLines(tt.a, tt.b)); got != tt.want {
+				t.Errorf("DiffLines:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareRuns(t *testing.T) {
+	isErr := true
+	iteration := func(n int) runner.Event {
+		return runner.Event{Type: runner.EventIteration, ID: fmt.Sprintf("iteration-%d", n)}
+	}
+	tool := func(id, name, args string, failed bool) []runner.Event {
+		end := runner.Event{Type: runner.EventToolExecutionEnd, ToolCallID: id, ToolName: name}
+		if failed {
+			end.IsError = &isErr
+		}
+		return []runner.Event{
+			{Type: runner.EventToolExecutionStart, ToolCallID: id, ToolName: name, Args: []byte(args)},
+			end,
+		}
+	}
+	events := func(groups ...[]runner.Event) []runner.Event {
+		var out []runner.Event
+		for _, g := range groups {
+			out = append(out, g...)
+		}
+		return out
+	}
+
+	a := &SavedRun{
+		Meta: runner.RunMeta{RunID: "run-a", Agent: "pi", Status: "completed", PromptSource: "plan", PlanFile: "PLAN.md", IterationsCompleted: 2,
+			Environment: &runner.RunEnvironment{
+				RalfinhoVersion: "1.4.0",
+				AgentVersion:    "pi 0.9.1",
+				GitStart:        &runner.GitState{Branch: "main", Commit: "0123456789abcdef0123"},
+			}},
+		Events: events(
+			[]runner.Event{iteration(1)},
+			tool("1", "bash", `{"command":"go test ./..."}`, true),
+			tool("2", "read", `{"path":"main.go"}`, false),
+			[]runner.Event{iteration(2)},
+			tool("3", "bash", `{"command":"go test ./..."}`, false),
+		),
+		Prompt:   "Implement the plan.\nRun the tests.\n",
+		Notes:    "use table tests\n",
+		Progress: "step 1 done\nstep 2 done\n",
+	}
+	b := &SavedRun{
+		Meta: runner.RunMeta{RunID: "run-b", Agent: "claude", Status: "failed", PromptSource: "plan", PlanFile: "PLAN.md", IterationsCompleted: 1},
+		Events: events(
+			[]runner.Event{iteration(1)},
+			tool("1", "Bash", `{"command":"go test ./..."}`, true),
+			tool("2", "edit", `{"file_path":"main.go"}`, false),
+		),
+		Prompt:   "Implement the plan.\nRun the tests.\n",
+		Progress: "step 1 done\n",
+	}
+
+	diff := CompareRuns(a, b)
+	sections := make(map[string]DiffSection)
+	var titles []string
+	for _, s := range diff.Sections {
+		sections[s.Title] = s
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Meta, Environment, Iterations, Tool calls, Prompt, NOTES.md, PROGRESS.md" {
+		t.Fatalf("sections = %s", got)
+	}
+
+	meta := formatRows(sections["Meta"].Rows)
+	for _, want := range []string{
+		"~ run id: run-a | run id: run-b",
+		"~ agent: pi | agent: claude",
+		"  prompt file: PLAN.md | prompt file: PLAN.md",
+		"~ iterations: 2 | iterations: 1",
+	} {
+		if !strings.Contains(meta, want) {
+			t.Errorf("meta rows missing %q:\n%s", want, meta)
+		}
+	}
+
+	env := formatRows(sections["Environment"].Rows)
+	for _, want := range []string{
+		"~ ralfinho: 1.4.0 | ralfinho: -",
+		"~ agent version: pi 0.9.1 | agent version: -",
+		"~ git at start: main@0123456789ab | git at start: -",
+		"  host: - | host: -",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("environment rows missing %q:\n%s", want, env)
+		}
+	}
+
+	if got, want := formatRows(sections["Iterations"].Rows),
+		"  iteration 1: 2 tool calls, 1 errors | iteration 1: 2 tool calls, 1 errors\n"+
+			"- iteration 2: 1 tool calls | "; got != want {
+		t.Errorf("iteration rows:\n%s\nwant:\n%s", got, want)
+	}
+
+	wantTools := strings.Join([]string{
+		"  ── iteration 1 | ── iteration 1",
+		"  bash: go test ./... (error) | bash: go test ./... (error)",
+		"~ read: main.go | edit: main.go",
+		"- ── iteration 2 | ",
+		"- bash: go test ./... | ",
+	}, "\n")
+	if got := formatRows(sections["Tool calls"].Rows); got != wantTools {
+		t.Errorf("tool call rows:\n%s\nwant:\n%s", got, wantTools)
+	}
+
+	if n := sections["Prompt"].Changes(); n != 0 {
+		t.Errorf("identical prompts have %d changes", n)
+	}
+	if got := formatRows(sections["NOTES.md"].Rows); got != "- use table tests | " {
+		t.Errorf("notes rows = %q", got)
+	}
+	if got := formatRows(sections["PROGRESS.md"].Rows); got != "  step 1 done | step 1 done\n- step 2 done | " {
+		t.Errorf("progress rows = %q", got)
+	}
+	if diff.Changes() == 0 || diff.A.RunID != "run-a" || diff.B.RunID != "run-b" {
+		t.Errorf("diff = %d changes, A %q, B %q", diff.Changes(), diff.A.RunID, diff.B.RunID)
+	}
+}
+
+func TestFormatGitState(t *testing.T) {
+	tests := []struct {
+		state *runner.GitState
+		want  string
+	}{
+		{nil, "-"},
+		{&runner.GitState{Commit: "abc"}, "abc"},
+		{&runner.GitState{Branch: "main", Commit: "0123456789abcdef", Dirty: true}, "main@0123456789ab (dirty)"},
+	}
+	for _, tt := range tests {
+		if got := FormatGitState(tt.state); got != tt.want {
+			t.Errorf("FormatGitState(%+v) = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestDiffLinesFallsBackToPairingForHugeInputs(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 3000; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	rows := DiffLines(append([]string{"same"}, a...), append([]string{"same"}, b...))
+	if len(rows) != 3001 || rows[0].Op != DiffSame || rows[1].Op != DiffChanged || rows[1].Left != "a0" || rows[1].Right != "b0" {
+		t.Errorf("got %d rows, first %+v, second %+v", len(rows), rows[0], rows[1])
+	}
+}
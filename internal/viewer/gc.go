@@ -0,0 +1,82 @@
+package viewer
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// GCPolicy selects saved runs for deletion by "ralfinho gc". Its criteria
+// are combined: a run is deleted only when it matches every one that is set.
+type GCPolicy struct {
+	KeepLast  int           // the KeepLast newest runs are never deleted
+	OlderThan time.Duration // only delete runs that started longer ago than this; 0 = any age
+	Statuses  []string      // only delete runs with one of these statuses; empty = any status
+}
+
+// IsZero reports whether the policy sets no criteria. A zero policy selects
+// nothing, so a bare "ralfinho gc" cannot wipe the runs directory.
+func (p GCPolicy) IsZero() bool {
+	return p.KeepLast == 0 && p.OlderThan == 0 && len(p.Statuses) == 0
+}
+
+// SelectForGC returns the runs that p selects for deletion, in the order
+// given. summaries must be sorted newest-first, as ListRunSummaries returns
+// them. Runs still marked running are never selected.
+func SelectForGC(summaries []RunSummary, p GCPolicy, now time.Time) []RunSummary {
+	if p.IsZero() {
+		return nil
+	}
+
+	var selected []RunSummary
+	for i, s := range summaries {
+		if i < p.KeepLast {
+			continue
+		}
+		if s.Status == string(runner.StatusRunning) {
+			continue
+		}
+		if p.OlderThan > 0 && (s.SortTime.IsZero() || now.Sub(s.SortTime) <= p.OlderThan) {
+			continue
+		}
+		if len(p.Statuses) > 0 && !slices.Contains(p.Statuses, s.Status) {
+			continue
+		}
+		selected = append(selected, s)
+	}
+	return selected
+}
+
+// DirSize returns the total size of the regular files under dir. Unreadable
+// entries are skipped; the result is only used for reporting.
+func DirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// FormatBytes renders n with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
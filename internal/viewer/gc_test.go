@@ -0,0 +1,81 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func gcSummaries(now time.Time) []RunSummary {
+	day := 24 * time.Hour
+	return []RunSummary{
+		{RunID: "run-1", Status: string(runner.StatusRunning), SortTime: now.Add(-1 * day)},
+		{RunID: "run-2", Status: string(runner.StatusCompleted), SortTime: now.Add(-2 * day)},
+		{RunID: "run-3", Status: string(runner.StatusFailed), SortTime: now.Add(-3 * day)},
+		{RunID: "run-4", Status: string(runner.StatusCompleted), SortTime: now.Add(-10 * day)},
+		{RunID: "run-5", Status: string(runner.StatusFailed), SortTime: now.Add(-20 * day)},
+		{RunID: "run-6", Status: string(runner.StatusRunning), SortTime: now.Add(-30 * day)},
+	}
+}
+
+func gcRunIDs(summaries []RunSummary) string {
+	ids := make([]string, len(summaries))
+	for i, s := range summaries {
+		ids[i] = s.RunID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestSelectForGC(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		policy GCPolicy
+		want   string
+	}{
+		{"zero policy selects nothing", GCPolicy{}, ""},
+		{"keep last", GCPolicy{KeepLast: 3}, "run-4,run-5"},
+		{"keep more than exist", GCPolicy{KeepLast: 10}, ""},
+		{"older than", GCPolicy{OlderThan: 7 * 24 * time.Hour}, "run-4,run-5"},
+		{"status", GCPolicy{Statuses: []string{"failed"}}, "run-3,run-5"},
+		{"criteria combine", GCPolicy{KeepLast: 1, OlderThan: 5 * 24 * time.Hour, Statuses: []string{"failed", "completed"}}, "run-4,run-5"},
+		{"keep last counts running runs", GCPolicy{KeepLast: 2, Statuses: []string{"failed"}}, "run-3,run-5"},
+		{"running runs are never selected", GCPolicy{Statuses: []string{"running"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gcRunIDs(SelectForGC(gcSummaries(now), tt.policy, now))
+			if got != tt.want {
+				t.Errorf("SelectForGC = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectForGCSkipsUndatedRunsForAgePolicies(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	summaries := []RunSummary{{RunID: "undated", Status: "unknown"}}
+	if got := SelectForGC(summaries, GCPolicy{OlderThan: time.Hour}, now); len(got) != 0 {
+		t.Errorf("SelectForGC = %v, want undated run kept", gcRunIDs(got))
+	}
+	if got := SelectForGC(summaries, GCPolicy{Statuses: []string{"unknown"}}, now); len(got) != 1 {
+		t.Errorf("SelectForGC = %v, want undated run selected by status", gcRunIDs(got))
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package viewer
+
+import (
+	"sort"
+	"time"
+)
+
+// RunJob is one logical job: a run started from scratch together with every
+// run resumed from it, directly or through another resumed run. Big tasks
+// are usually spread over several resumed runs.
+type RunJob struct {
+	RootID string
+	// RunIDs lists the job's runs, root first, each followed by the runs
+	// resumed from it in start order.
+	RunIDs []string
+
+	Iterations int           // iterations completed across all runs
+	Duration   time.Duration // wall-clock time of the finished runs
+	Status     string        // status of the most recently started run
+}
+
+// GroupRunJobs groups summaries into jobs by following the resumed_from
+// links in meta.json, and returns each run's job keyed by run ID. A run
+// resumed from a run that no longer exists starts a job of its own.
+func GroupRunJobs(summaries []RunSummary) map[string]*RunJob {
+	byID := make(map[string]*RunSummary, len(summaries))
+	for i := range summaries {
+		byID[summaries[i].RunID] = &summaries[i]
+	}
+	children := make(map[string][]*RunSummary)
+	var roots []*RunSummary
+	for i := range summaries {
+		summary := &summaries[i]
+		if parent := summary.Meta.ResumedFrom; parent != "" && parent != summary.RunID && byID[parent] != nil {
+			children[parent] = append(children[parent], summary)
+		} else {
+			roots = append(roots, summary)
+		}
+	}
+	for _, runs := range children {
+		sort.SliceStable(runs, func(i, j int) bool {
+			return runs[i].SortTime.Before(runs[j].SortTime)
+		})
+	}
+
+	jobs := make(map[string]*RunJob, len(summaries))
+	var newest *RunSummary
+	var walk func(job *RunJob, summary *RunSummary)
+	walk = func(job *RunJob, summary *RunSummary) {
+		if jobs[summary.RunID] != nil {
+			return
+		}
+		jobs[summary.RunID] = job
+		job.RunIDs = append(job.RunIDs, summary.RunID)
+		job.Iterations += summary.IterationsCompleted
+		if d, ok := runDuration(summary); ok {
+			job.Duration += d
+		}
+		if newest == nil || summary.SortTime.After(newest.SortTime) {
+			newest = summary
+		}
+		for _, child := range children[summary.RunID] {
+			walk(job, child)
+		}
+	}
+	start := func(root *RunSummary) {
+		if jobs[root.RunID] != nil {
+			return
+		}
+		job := &RunJob{RootID: root.RunID}
+		newest = nil
+		walk(job, root)
+		job.Status = newest.Status
+	}
+	for _, root := range roots {
+		start(root)
+	}
+	// Runs left over only resume each other in a cycle, which a hand-edited
+	// meta.json could produce; give each cycle a job of its own.
+	for i := range summaries {
+		start(&summaries[i])
+	}
+	return jobs
+}
@@ -0,0 +1,65 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func lineageSummary(runID, resumedFrom string, start time.Time, minutes, iterations int, status string) RunSummary {
+	return RunSummary{
+		RunID:               runID,
+		StartedAt:           start,
+		SortTime:            start,
+		Status:              status,
+		IterationsCompleted: iterations,
+		Meta: runner.RunMeta{
+			RunID:       runID,
+			ResumedFrom: resumedFrom,
+			EndedAt:     start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339),
+		},
+	}
+}
+
+func TestGroupRunJobs(t *testing.T) {
+	base := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+	summaries := []RunSummary{
+		lineageSummary("c", "b", base.Add(3*time.Hour), 30, 4, "completed"),
+		lineageSummary("a", "", base, 60, 5, "max_iterations_reached"),
+		lineageSummary("lone", "", base.Add(time.Hour), 5, 1, "failed"),
+		lineageSummary("b", "a", base.Add(2*time.Hour), 20, 3, "interrupted"),
+		lineageSummary("orphan", "deleted-run", base.Add(4*time.Hour), 10, 2, "failed"),
+		lineageSummary("branch", "a", base.Add(90*time.Minute), 15, 1, "failed"),
+		lineageSummary("loop1", "loop2", base, 1, 1, "failed"),
+		lineageSummary("loop2", "loop1", base, 1, 1, "failed"),
+	}
+
+	jobs := GroupRunJobs(summaries)
+	job := jobs["a"]
+	if job == nil || job.RootID != "a" {
+		t.Fatalf("job of a = %+v, want rooted at a", job)
+	}
+	if got := strings.Join(job.RunIDs, ","); got != "a,branch,b,c" {
+		t.Errorf("RunIDs = %q, want a,branch,b,c", got)
+	}
+	for _, id := range []string{"b", "c", "branch"} {
+		if jobs[id] != job {
+			t.Errorf("job of %s = %+v, want a's job", id, jobs[id])
+		}
+	}
+	if job.Iterations != 13 || job.Duration != 125*time.Minute || job.Status != "completed" {
+		t.Errorf("job totals = %d iterations, %s, %q; want 13, 2h5m, completed", job.Iterations, job.Duration, job.Status)
+	}
+
+	if lone := jobs["lone"]; lone == nil || len(lone.RunIDs) != 1 || lone.Status != "failed" {
+		t.Errorf("job of lone = %+v, want a single-run job", lone)
+	}
+	if orphan := jobs["orphan"]; orphan == nil || orphan.RootID != "orphan" {
+		t.Errorf("job of orphan = %+v, want its own job when the parent is gone", orphan)
+	}
+	if jobs["loop1"] == nil || jobs["loop2"] == nil {
+		t.Errorf("cyclic runs were left without a job: %+v %+v", jobs["loop1"], jobs["loop2"])
+	}
+}
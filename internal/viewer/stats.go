@@ -0,0 +1,317 @@
+package viewer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
+	"github.com/fsmiamoto/ralfinho/internal/events"
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+// StatsSchemaVersion is bumped whenever the JSON shape of Stats changes in a
+// way consumers need to know about.
+const StatsSchemaVersion = 1
+
+// Stats aggregates outcomes across saved runs, overall and grouped by agent,
+// prompt source, and ISO week of the run start.
+type Stats struct {
+	SchemaVersion  int          `json:"schema_version"`
+	Overall        StatsGroup   `json:"overall"`
+	ByAgent        []StatsGroup `json:"by_agent"`
+	ByPromptSource []StatsGroup `json:"by_prompt_source"`
+	ByWeek         []StatsGroup `json:"by_week"`
+}
+
+// StatsGroup holds the aggregated numbers for one slice of runs.
+type StatsGroup struct {
+	Key string `json:"key"`
+
+	Runs                 int `json:"runs"`
+	Running              int `json:"running"`
+	Completed            int `json:"completed"`
+	Failed               int `json:"failed"` // failed + stuck
+	Interrupted          int `json:"interrupted"`
+	MaxIterationsReached int `json:"max_iterations_reached"`
+
+	// SuccessRate and ErrorRate are fractions of finished (non-running) runs.
+	SuccessRate float64 `json:"success_rate"`
+	ErrorRate   float64 `json:"error_rate"`
+
+	// AvgIterationsToCompletion averages iterations over completed runs only.
+	AvgIterationsToCompletion float64 `json:"avg_iterations_to_completion"`
+	// AvgIterationSeconds is wall-clock run duration divided by iterations,
+	// averaged over finished runs that recorded both timestamps.
+	AvgIterationSeconds float64 `json:"avg_iteration_seconds"`
+
+	ToolCalls     map[string]int `json:"tool_calls"`
+	ToolErrors    int            `json:"tool_errors"`
+	ToolErrorRate float64        `json:"tool_error_rate"`
+
+	FailureCategories map[string]int `json:"failure_categories,omitempty"`
+
+	Tokens TokenUsage `json:"tokens"`
+
+	// Accumulators for the averages; not serialized.
+	completedIterations int
+	iterationSeconds    float64
+	timedRuns           int
+}
+
+// TokenUsage sums token counts reported by the agent on assistant
+// message_end events. Backends that do not report usage contribute nothing;
+// RunsWithUsage tells how many runs the totals cover.
+type TokenUsage struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	CacheRead     int64 `json:"cache_read"`
+	CacheWrite    int64 `json:"cache_write"`
+	RunsWithUsage int   `json:"runs_with_usage"`
+}
+
+// Total returns input + output tokens.
+func (u TokenUsage) Total() int64 { return u.Input + u.Output }
+
+// runEventStats is what a single run's events.jsonl contributes.
+type runEventStats struct {
+	toolCalls  map[string]int
+	toolErrors int
+	tokens     TokenUsage
+	hasUsage   bool
+}
+
+// ComputeStats aggregates the given summaries. Each run's events.jsonl is
+// read once for tool and token counts; unreadable event logs only drop that
+// run's tool/token contribution.
+func ComputeStats(summaries []RunSummary) Stats {
+	stats := Stats{
+		SchemaVersion: StatsSchemaVersion,
+		Overall:       newStatsGroup("all"),
+	}
+	byAgent := map[string]*StatsGroup{}
+	bySource := map[string]*StatsGroup{}
+	byWeek := map[string]*StatsGroup{}
+
+	for i := range summaries {
+		summary := &summaries[i]
+		var ev runEventStats
+		if summary.HasEvents {
+			ev, _ = readRunEventStats(summary.EventsPath)
+		}
+
+		week := "unknown"
+		if !summary.StartedAt.IsZero() {
+			year, wk := summary.StartedAt.ISOWeek()
+			week = fmt.Sprintf("%d-W%02d", year, wk)
+		}
+
+		stats.Overall.add(summary, ev)
+		groupFor(byAgent, summary.Agent).add(summary, ev)
+		groupFor(bySource, summary.PromptSource).add(summary, ev)
+		groupFor(byWeek, week).add(summary, ev)
+	}
+
+	stats.Overall.finish()
+	stats.ByAgent = sortedGroups(byAgent)
+	stats.ByPromptSource = sortedGroups(bySource)
+	stats.ByWeek = sortedGroups(byWeek)
+	return stats
+}
+
+func newStatsGroup(key string) StatsGroup {
+	return StatsGroup{Key: key, ToolCalls: map[string]int{}}
+}
+
+func groupFor(groups map[string]*StatsGroup, key string) *StatsGroup {
+	key = valueOrDefault(key, "unknown")
+	g, ok := groups[key]
+	if !ok {
+		ng := newStatsGroup(key)
+		g = &ng
+		groups[key] = g
+	}
+	return g
+}
+
+func sortedGroups(groups map[string]*StatsGroup) []StatsGroup {
+	out := make([]StatsGroup, 0, len(groups))
+	for _, g := range groups {
+		g.finish()
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func (g *StatsGroup) add(summary *RunSummary, ev runEventStats) {
+	g.Runs++
+	switch runner.Status(summary.Status) {
+	case runner.StatusRunning:
+		g.Running++
+	case runner.StatusCompleted:
+		g.Completed++
+		g.completedIterations += summary.IterationsCompleted
+	case runner.StatusFailed, runner.StatusStuck:
+		g.Failed++
+	case runner.StatusInterrupted:
+		g.Interrupted++
+	case runner.StatusMaxIterationsReached:
+		g.MaxIterationsReached++
+	}
+
+	if f := summary.Meta.Failure; f != nil {
+		if g.FailureCategories == nil {
+			g.FailureCategories = map[string]int{}
+		}
+		g.FailureCategories[string(f.Category)]++
+	}
+
+	if d, ok := runDuration(summary); ok && summary.IterationsCompleted > 0 {
+		g.iterationSeconds += d.Seconds() / float64(summary.IterationsCompleted)
+		g.timedRuns++
+	}
+
+	for name, n := range ev.toolCalls {
+		g.ToolCalls[name] += n
+	}
+	g.ToolErrors += ev.toolErrors
+	if ev.hasUsage {
+		g.Tokens.Input += ev.tokens.Input
+		g.Tokens.Output += ev.tokens.Output
+		g.Tokens.CacheRead += ev.tokens.CacheRead
+		g.Tokens.CacheWrite += ev.tokens.CacheWrite
+		g.Tokens.RunsWithUsage++
+	}
+}
+
+func (g *StatsGroup) finish() {
+	if finished := g.Runs - g.Running; finished > 0 {
+		g.SuccessRate = float64(g.Completed) / float64(finished)
+		g.ErrorRate = float64(g.Failed) / float64(finished)
+	}
+	if g.Completed > 0 {
+		g.AvgIterationsToCompletion = float64(g.completedIterations) / float64(g.Completed)
+	}
+	if g.timedRuns > 0 {
+		g.AvgIterationSeconds = g.iterationSeconds / float64(g.timedRuns)
+	}
+	if calls := g.TotalToolCalls(); calls > 0 {
+		g.ToolErrorRate = float64(g.ToolErrors) / float64(calls)
+	}
+}
+
+// TotalToolCalls sums ToolCalls across all tools.
+func (g StatsGroup) TotalToolCalls() int {
+	total := 0
+	for _, n := range g.ToolCalls {
+		total += n
+	}
+	return total
+}
+
+// runDuration returns the wall-clock duration of a finished run.
+func runDuration(summary *RunSummary) (time.Duration, bool) {
+	if summary.StartedAt.IsZero() {
+		return 0, false
+	}
+	ended, ok := parseSummaryTime(summary.Meta.EndedAt)
+	if !ok || ended.Before(summary.StartedAt) {
+		return 0, false
+	}
+	return ended.Sub(summary.StartedAt), true
+}
+
+// readRunEventStats streams events.jsonl, counting tool calls by tool name,
+// tool errors, and token usage. Malformed lines are skipped.
+func readRunEventStats(path string) (runEventStats, error) {
+	stats := runEventStats{toolCalls: map[string]int{}}
+
+	f, err := artifact.Open(path)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var ev runner.Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			continue
+		}
+		switch ev.Type {
+		case runner.EventToolExecutionStart:
+			stats.toolCalls[statsToolName(ev.ToolName)]++
+		case runner.EventToolExecutionEnd:
+			if ev.IsError != nil && *ev.IsError {
+				stats.toolErrors++
+			}
+		case runner.EventMessageEnd:
+			if len(ev.Message) == 0 {
+				continue
+			}
+			var msg runner.MessageEnvelope
+			if err := json.Unmarshal(ev.Message, &msg); err != nil || msg.Role != "assistant" || len(msg.Usage) == 0 {
+				continue
+			}
+			if u, ok := parseTokenUsage(msg.Usage); ok {
+				stats.tokens.Input += u.Input
+				stats.tokens.Output += u.Output
+				stats.tokens.CacheRead += u.CacheRead
+				stats.tokens.CacheWrite += u.CacheWrite
+				stats.hasUsage = true
+			}
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// statsToolName folds backend-specific spellings of the same tool together
+// the same way the TUI's tool filter does, naming calls without a tool
+// "unknown".
+func statsToolName(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return events.NormalizeToolName(name)
+}
+
+// parseTokenUsage understands both pi's usage object (input/output/cacheRead/
+// cacheWrite) and the Anthropic API shape (input_tokens/output_tokens/...).
+func parseTokenUsage(raw json.RawMessage) (TokenUsage, bool) {
+	var u struct {
+		Input               *int64 `json:"input"`
+		Output              *int64 `json:"output"`
+		CacheRead           *int64 `json:"cacheRead"`
+		CacheWrite          *int64 `json:"cacheWrite"`
+		InputTokens         *int64 `json:"input_tokens"`
+		OutputTokens        *int64 `json:"output_tokens"`
+		CacheReadTokens     *int64 `json:"cache_read_input_tokens"`
+		CacheCreationTokens *int64 `json:"cache_creation_input_tokens"`
+	}
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return TokenUsage{}, false
+	}
+	var out TokenUsage
+	found := false
+	pick := func(dst *int64, vals ...*int64) {
+		for _, v := range vals {
+			if v != nil {
+				*dst += *v
+				found = true
+			}
+		}
+	}
+	pick(&out.Input, u.Input, u.InputTokens)
+	pick(&out.Output, u.Output, u.OutputTokens)
+	pick(&out.CacheRead, u.CacheRead, u.CacheReadTokens)
+	pick(&out.CacheWrite, u.CacheWrite, u.CacheCreationTokens)
+	return out, found
+}
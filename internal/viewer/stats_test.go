@@ -0,0 +1,264 @@
+package viewer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsmiamoto/ralfinho/internal/runner"
+)
+
+func TestComputeStatsGroupsOutcomes(t *testing.T) {
+	runsDir := t.TempDir()
+
+	writeRunMeta(t, runsDir, "run-a", runner.RunMeta{
+		RunID:               "run-a",
+		Agent:               "claude",
+		PromptSource:        "plan",
+		Status:              string(runner.StatusCompleted),
+		StartedAt:           "2026-03-02T10:00:00Z",
+		EndedAt:             "2026-03-02T10:04:00Z",
+		IterationsCompleted: 2,
+	})
+	writeRunMeta(t, runsDir, "run-b", runner.RunMeta{
+		RunID:               "run-b",
+		Agent:               "claude",
+		PromptSource:        "plan",
+		Status:              string(runner.StatusCompleted),
+		StartedAt:           "2026-03-03T10:00:00Z",
+		EndedAt:             "2026-03-03T10:04:00Z",
+		IterationsCompleted: 4,
+	})
+	writeRunMeta(t, runsDir, "run-c", runner.RunMeta{
+		RunID:               "run-c",
+		Agent:               "pi",
+		PromptSource:        "prompt",
+		Status:              string(runner.StatusFailed),
+		StartedAt:           "2026-03-10T10:00:00Z",
+		IterationsCompleted: 1,
+		Failure:             &runner.Failure{Category: runner.FailureAgentExit, Message: "exit status 1"},
+	})
+	writeRunMeta(t, runsDir, "run-d", runner.RunMeta{
+		RunID:     "run-d",
+		Agent:     "pi",
+		Status:    string(runner.StatusRunning),
+		StartedAt: "2026-03-10T11:00:00Z",
+	})
+
+	summaries, err := ListRunSummaries(runsDir)
+	if err != nil {
+		t.Fatalf("ListRunSummaries: %v", err)
+	}
+	stats := ComputeStats(summaries)
+
+	if stats.SchemaVersion != StatsSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", stats.SchemaVersion, StatsSchemaVersion)
+	}
+
+	o := stats.Overall
+	if o.Runs != 4 || o.Completed != 2 || o.Failed != 1 || o.Running != 1 {
+		t.Fatalf("overall counts = %+v", o)
+	}
+	// Running runs are excluded from the rate denominator.
+	if got, want := o.SuccessRate, 2.0/3.0; got != want {
+		t.Errorf("SuccessRate = %v, want %v", got, want)
+	}
+	if got, want := o.ErrorRate, 1.0/3.0; got != want {
+		t.Errorf("ErrorRate = %v, want %v", got, want)
+	}
+	if o.AvgIterationsToCompletion != 3 {
+		t.Errorf("AvgIterationsToCompletion = %v, want 3", o.AvgIterationsToCompletion)
+	}
+	// run-a: 240s/2 = 120s, run-b: 240s/4 = 60s. run-c has no ended_at.
+	if o.AvgIterationSeconds != 90 {
+		t.Errorf("AvgIterationSeconds = %v, want 90", o.AvgIterationSeconds)
+	}
+	if o.FailureCategories["agent_exit"] != 1 {
+		t.Errorf("FailureCategories = %v, want agent_exit=1", o.FailureCategories)
+	}
+
+	if len(stats.ByAgent) != 2 || stats.ByAgent[0].Key != "claude" || stats.ByAgent[1].Key != "pi" {
+		t.Fatalf("ByAgent keys = %+v", statsKeys(stats.ByAgent))
+	}
+	if stats.ByAgent[0].SuccessRate != 1 {
+		t.Errorf("claude SuccessRate = %v, want 1", stats.ByAgent[0].SuccessRate)
+	}
+	if stats.ByAgent[1].ErrorRate != 1 {
+		t.Errorf("pi ErrorRate = %v, want 1", stats.ByAgent[1].ErrorRate)
+	}
+
+	if got := statsKeys(stats.ByPromptSource); len(got) != 3 || got[0] != "plan" || got[1] != "prompt" || got[2] != "unknown" {
+		t.Errorf("ByPromptSource keys = %v, want [plan prompt unknown]", got)
+	}
+	if got := statsKeys(stats.ByWeek); len(got) != 2 || got[0] != "2026-W10" || got[1] != "2026-W11" {
+		t.Errorf("ByWeek keys = %v, want [2026-W10 2026-W11]", got)
+	}
+}
+
+func TestComputeStatsCountsToolsAndTokens(t *testing.T) {
+	runsDir := t.TempDir()
+
+	writeRunMeta(t, runsDir, "run-pi", runner.RunMeta{
+		RunID:     "run-pi",
+		Agent:     "pi",
+		Status:    string(runner.StatusCompleted),
+		StartedAt: "2026-03-02T10:00:00Z",
+	})
+	writeStatsEvents(t, runsDir, "run-pi", []map[string]any{
+		{"type": "tool_execution_start", "toolName": "bash"},
+		{"type": "tool_execution_end", "toolName": "bash", "isError": true},
+		{"type": "tool_execution_start", "toolName": "Read"},
+		{"type": "tool_execution_end", "toolName": "Read", "isError": false},
+		{"type": "message_end", "message": map[string]any{
+			"role":  "assistant",
+			"usage": map[string]any{"input": 100, "output": 20, "cacheRead": 5, "cacheWrite": 1},
+		}},
+		// User messages never carry billable usage.
+		{"type": "message_end", "message": map[string]any{
+			"role":  "user",
+			"usage": map[string]any{"input": 999},
+		}},
+	})
+
+	writeRunMeta(t, runsDir, "run-claude", runner.RunMeta{
+		RunID:     "run-claude",
+		Agent:     "claude",
+		Status:    string(runner.StatusCompleted),
+		StartedAt: "2026-03-02T11:00:00Z",
+	})
+	writeStatsEvents(t, runsDir, "run-claude", []map[string]any{
+		{"type": "tool_execution_start", "toolName": "Bash"},
+		{"type": "tool_execution_start", "toolName": "Grep"},
+		{"type": "message_end", "message": map[string]any{
+			"role": "assistant",
+			"usage": map[string]any{
+				"input_tokens":                10,
+				"output_tokens":               2,
+				"cache_read_input_tokens":     3,
+				"cache_creation_input_tokens": 4,
+			},
+		}},
+	})
+
+	// No usage reported at all.
+	writeRunMeta(t, runsDir, "run-kiro", runner.RunMeta{
+		RunID:     "run-kiro",
+		Agent:     "kiro",
+		Status:    string(runner.StatusCompleted),
+		StartedAt: "2026-03-02T12:00:00Z",
+	})
+	writeStatsEvents(t, runsDir, "run-kiro", []map[string]any{
+		{"type": "tool_execution_start", "toolName": "shell"},
+		{"type": "not json at all"},
+	})
+
+	summaries, err := ListRunSummaries(runsDir)
+	if err != nil {
+		t.Fatalf("ListRunSummaries: %v", err)
+	}
+	o := ComputeStats(summaries).Overall
+
+	wantTools := map[string]int{"bash": 3, "read": 1, "Grep": 1}
+	if len(o.ToolCalls) != len(wantTools) {
+		t.Fatalf("ToolCalls = %v, want %v", o.ToolCalls, wantTools)
+	}
+	for name, n := range wantTools {
+		if o.ToolCalls[name] != n {
+			t.Errorf("ToolCalls[%q] = %d, want %d", name, o.ToolCalls[name], n)
+		}
+	}
+	if o.TotalToolCalls() != 5 {
+		t.Errorf("TotalToolCalls = %d, want 5", o.TotalToolCalls())
+	}
+	if o.ToolErrors != 1 || o.ToolErrorRate != 0.2 {
+		t.Errorf("ToolErrors = %d rate %v, want 1 rate 0.2", o.ToolErrors, o.ToolErrorRate)
+	}
+
+	want := TokenUsage{Input: 110, Output: 22, CacheRead: 8, CacheWrite: 5, RunsWithUsage: 2}
+	if o.Tokens != want {
+		t.Errorf("Tokens = %+v, want %+v", o.Tokens, want)
+	}
+	if o.Tokens.Total() != 132 {
+		t.Errorf("Tokens.Total() = %d, want 132", o.Tokens.Total())
+	}
+}
+
+func TestComputeStatsEmpty(t *testing.T) {
+	stats := ComputeStats(nil)
+	if stats.Overall.Runs != 0 || stats.Overall.SuccessRate != 0 {
+		t.Errorf("Overall = %+v, want zero values", stats.Overall)
+	}
+	if stats.ByAgent == nil || len(stats.ByAgent) != 0 {
+		t.Errorf("ByAgent = %#v, want empty non-nil slice", stats.ByAgent)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if decoded["by_agent"] == nil {
+		t.Errorf("by_agent serialized as null: %s", data)
+	}
+}
+
+func TestStatsToolName(t *testing.T) {
+	tests := map[string]string{
+		"bash":    "bash",
+		"Bash":    "bash",
+		"shell":   "bash",
+		"execute": "bash",
+		"Read":    "read",
+		"edit":    "edit",
+		"WRITE":   "write",
+		"":        "unknown",
+		"Grep":    "Grep",
+	}
+	for in, want := range tests {
+		if got := statsToolName(in); got != want {
+			t.Errorf("statsToolName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseTokenUsageRejectsUnknownShapes(t *testing.T) {
+	if _, ok := parseTokenUsage(json.RawMessage(`{"total":5}`)); ok {
+		t.Error("parseTokenUsage accepted usage without known fields")
+	}
+	if _, ok := parseTokenUsage(json.RawMessage(`"nope"`)); ok {
+		t.Error("parseTokenUsage accepted non-object usage")
+	}
+}
+
+func writeStatsEvents(t *testing.T, runsDir, runID string, events []map[string]any) {
+	t.Helper()
+
+	dir := filepath.Join(runsDir, runID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", dir, err)
+	}
+	var data []byte
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			t.Fatalf("json.Marshal(event): %v", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	data = append(data, []byte("{broken\n")...)
+	if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), data, 0644); err != nil {
+		t.Fatalf("WriteFile(events.jsonl): %v", err)
+	}
+}
+
+func statsKeys(groups []StatsGroup) []string {
+	keys := make([]string, 0, len(groups))
+	for _, g := range groups {
+		keys = append(keys, g.Key)
+	}
+	return keys
+}
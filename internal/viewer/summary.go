@@ -1,14 +1,13 @@
 package viewer
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
 	"github.com/fsmiamoto/ralfinho/internal/runner"
 )
 
@@ -40,6 +39,7 @@ type ResumeActionState struct {
 type RunActions struct {
 	Open   RunActionState
 	Resume ResumeActionState
+	Rerun  RunActionState
 	Delete RunActionState
 }
 
@@ -92,37 +92,31 @@ func (s RunSummary) Matches(query string) bool {
 // sorted newest-first. Per-run artifact failures are captured on the summary so
 // one bad run does not prevent browsing the rest.
 func ListRunSummaries(runsDir string) ([]RunSummary, error) {
-	entries, err := os.ReadDir(runsDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("reading runs directory: %w", err)
-	}
+	return QueryRunSummaries(runner.NewFSStore(runsDir), runner.RunQuery{})
+}
 
-	summaries := make([]RunSummary, 0, len(entries))
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		summaries = append(summaries, summarizeRunDir(runsDir, entry))
+// QueryRunSummaries returns summaries for the runs in store that match q,
+// sorted newest-first. Filtering happens in the store, so indexed stores
+// answer without reading every run's meta.json.
+func QueryRunSummaries(store runner.RunStore, q runner.RunQuery) ([]RunSummary, error) {
+	runs, err := store.ListRuns(q)
+	if err != nil || runs == nil {
+		return nil, err
 	}
 
-	sort.SliceStable(summaries, func(i, j int) bool {
-		if !summaries[i].SortTime.Equal(summaries[j].SortTime) {
-			return summaries[i].SortTime.After(summaries[j].SortTime)
-		}
-		return summaries[i].RunID > summaries[j].RunID
-	})
-
+	summaries := make([]RunSummary, 0, len(runs))
+	for _, run := range runs {
+		summaries = append(summaries, summarizeRun(run))
+	}
 	return summaries, nil
 }
 
-func summarizeRunDir(runsDir string, entry os.DirEntry) RunSummary {
-	dir := filepath.Join(runsDir, entry.Name())
+func summarizeRun(run runner.StoredRun) RunSummary {
+	dir := run.Dir
 	summary := RunSummary{
-		RunID:               entry.Name(),
+		RunID:               run.RunID,
 		Dir:                 dir,
+		SortTime:            run.SortTime,
 		Status:              "unknown",
 		Agent:               "unknown",
 		PromptSource:        "unknown",
@@ -131,34 +125,17 @@ func summarizeRunDir(runsDir string, entry os.DirEntry) RunSummary {
 		EffectivePromptPath: filepath.Join(dir, "effective-prompt.md"),
 	}
 
-	if info, err := entry.Info(); err == nil {
-		summary.SortTime = info.ModTime()
-	}
-
 	summary.HasEvents, summary.EventsError = inspectRunArtifact(summary.EventsPath)
 	summary.HasEffectivePrompt, summary.EffectivePromptError = inspectRunArtifact(summary.EffectivePromptPath)
 
-	metaPath := filepath.Join(dir, "meta.json")
-	data, err := os.ReadFile(metaPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			summary.ArtifactError = "meta.json missing"
-		} else {
-			summary.ArtifactError = fmt.Sprintf("reading meta.json: %v", err)
-		}
-		summary.Actions = buildRunActions(summary)
-		summary.SearchText = buildSummarySearchText(summary)
-		return summary
-	}
-
-	var meta runner.RunMeta
-	if err := json.Unmarshal(data, &meta); err != nil {
-		summary.ArtifactError = fmt.Sprintf("parsing meta.json: %v", err)
+	if !run.HasMeta {
+		summary.ArtifactError = run.MetaError
 		summary.Actions = buildRunActions(summary)
 		summary.SearchText = buildSummarySearchText(summary)
 		return summary
 	}
 
+	meta := run.Meta
 	summary.Meta = meta
 	summary.HasMeta = true
 	summary.Status = valueOrDefault(meta.Status, "unknown")
@@ -171,7 +148,6 @@ func summarizeRunDir(runsDir string, entry os.DirEntry) RunSummary {
 
 	if startedAt, ok := parseSummaryTime(meta.StartedAt); ok {
 		summary.StartedAt = startedAt
-		summary.SortTime = startedAt
 	}
 
 	summary.Actions = buildRunActions(summary)
@@ -195,6 +171,11 @@ func buildSummarySearchText(summary RunSummary) string {
 		summary.Actions.Open.DisabledReason,
 		summary.Actions.Resume.DisabledReason,
 	}
+	if f := summary.Meta.Failure; f != nil {
+		fields = append(fields, string(f.Category), f.Message)
+	}
+	fields = append(fields, summary.Meta.Name, summary.Meta.Annotation)
+	fields = append(fields, summary.Meta.Tags...)
 	if !summary.SortTime.IsZero() {
 		fields = append(fields, summary.SortTime.Format("2006-01-02 15:04"))
 	}
@@ -224,6 +205,17 @@ func buildRunActions(summary RunSummary) RunActions {
 
 	actions.Resume = buildResumeAction(summary)
 
+	// A rerun replays the exact prompt, so unlike resume it cannot fall
+	// back to rebuilding one from meta.json.
+	switch {
+	case !summary.HasMeta:
+		actions.Rerun.DisabledReason = valueOrDefault(summary.ArtifactError, "meta.json unavailable")
+	case !summary.HasEffectivePrompt:
+		actions.Rerun.DisabledReason = valueOrDefault(summary.EffectivePromptError, "effective-prompt.md unavailable")
+	default:
+		actions.Rerun.Available = true
+	}
+
 	if summary.Dir == "" {
 		actions.Delete.DisabledReason = "run directory unavailable"
 	} else {
@@ -297,13 +289,17 @@ func resumeSourceFromMeta(meta runner.RunMeta) (ResumeSource, string) {
 
 func inspectRunArtifact(path string) (bool, string) {
 	name := filepath.Base(path)
-	info, err := os.Stat(path)
+	path, err := artifact.Resolve(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, fmt.Sprintf("%s missing", name)
 		}
 		return false, fmt.Sprintf("reading %s: %v", name, err)
 	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Sprintf("reading %s: %v", name, err)
+	}
 	if info.IsDir() {
 		return false, fmt.Sprintf("%s is a directory", name)
 	}
@@ -354,15 +350,5 @@ func summaryPromptLabel(meta runner.RunMeta) string {
 }
 
 func parseSummaryTime(raw string) (time.Time, bool) {
-	if raw == "" {
-		return time.Time{}, false
-	}
-
-	// RFC3339Nano's reference format uses ".999999999" which means optional
-	// fractional seconds — it parses both "...T10:00:00Z" and
-	// "...T10:00:00.123456789Z" correctly.
-	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
-		return t, true
-	}
-	return time.Time{}, false
+	return runner.ParseMetaTime(raw)
 }
@@ -35,6 +35,9 @@ func TestListRunSummariesOrdersNewestFirstAndCachesSearchFields(t *testing.T) {
 		PromptSource:        "prompt",
 		PromptFile:          "tasks/browser-prompt.md",
 		IterationsCompleted: 4,
+		Name:                "Browser Polish",
+		Tags:                []string{"Nightly"},
+		Annotation:          "Retry after API Outage",
 	})
 	writeRunEvents(t, runsDir, "newer-run")
 	writeEffectivePrompt(t, runsDir, "newer-run", "newer prompt")
@@ -76,11 +79,14 @@ func TestListRunSummariesOrdersNewestFirstAndCachesSearchFields(t *testing.T) {
 	if !newer.Actions.Resume.Available || newer.Actions.Resume.Source != ResumeSourceEffectivePrompt {
 		t.Fatalf("Resume action = %#v, want effective-prompt resume", newer.Actions.Resume)
 	}
+	if !newer.Actions.Rerun.Available {
+		t.Fatalf("Rerun action = %#v, want available", newer.Actions.Rerun)
+	}
 	if !newer.Actions.Delete.Available {
 		t.Fatalf("Delete action = %#v, want available", newer.Actions.Delete)
 	}
 
-	for _, want := range []string{"newer-run", "kiro", "interrupted", "prompt", "browser-prompt.md", "2026-03-08 11:30"} {
+	for _, want := range []string{"newer-run", "kiro", "interrupted", "prompt", "browser-prompt.md", "2026-03-08 11:30", "nightly", "browser polish", "api outage"} {
 		if !strings.Contains(newer.SearchText, strings.ToLower(want)) {
 			t.Fatalf("SearchText = %q, want substring %q", newer.SearchText, strings.ToLower(want))
 		}
@@ -166,6 +172,9 @@ func TestListRunSummariesKeepsRunsWithMissingOrCorruptMeta(t *testing.T) {
 	if !corrupt.Actions.Resume.Available || corrupt.Actions.Resume.Source != ResumeSourceEffectivePrompt {
 		t.Fatalf("corrupt-meta Resume action = %#v, want effective-prompt fallback", corrupt.Actions.Resume)
 	}
+	if corrupt.Actions.Rerun.Available || !strings.Contains(corrupt.Actions.Rerun.DisabledReason, "parsing meta.json") {
+		t.Fatalf("corrupt-meta Rerun action = %#v, want unavailable without meta", corrupt.Actions.Rerun)
+	}
 	if !corrupt.Matches("parsing meta.json") {
 		t.Fatalf("corrupt-meta SearchText = %q, expected parsing error to be searchable", corrupt.SearchText)
 	}
@@ -180,6 +189,9 @@ func TestListRunSummariesKeepsRunsWithMissingOrCorruptMeta(t *testing.T) {
 	if !valid.Actions.Resume.Available || valid.Actions.Resume.Source != ResumeSourceDefault {
 		t.Fatalf("valid-run Resume action = %#v, want default-source fallback", valid.Actions.Resume)
 	}
+	if valid.Actions.Rerun.Available {
+		t.Fatalf("valid-run Rerun action = %#v, want unavailable without an effective prompt", valid.Actions.Rerun)
+	}
 }
 
 func TestListRunSummariesDefinesActionEligibilityFromArtifacts(t *testing.T) {
@@ -1014,3 +1026,39 @@ func TestInspectRunArtifactReadErrors(t *testing.T) {
 		}
 	})
 }
+
+func TestListRunSummariesParsesFailureRecord(t *testing.T) {
+	runsDir := t.TempDir()
+	code := 1
+	writeRunMeta(t, runsDir, "failed-run", runner.RunMeta{
+		RunID:     "failed-run",
+		StartedAt: "2026-03-08T11:30:00Z",
+		Status:    string(runner.StatusFailed),
+		Agent:     "claude",
+		Failure: &runner.Failure{
+			Category:  runner.FailureAgentExit,
+			Message:   "claude: agent exited with error: exit status 1",
+			ExitCode:  &code,
+			Stderr:    "quota exceeded",
+			Iteration: 3,
+		},
+	})
+
+	summaries, err := ListRunSummaries(runsDir)
+	if err != nil {
+		t.Fatalf("ListRunSummaries() error = %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("len(summaries) = %d, want 1", len(summaries))
+	}
+	f := summaries[0].Meta.Failure
+	if f == nil {
+		t.Fatal("Meta.Failure = nil, want parsed failure record")
+	}
+	if f.Category != runner.FailureAgentExit || f.ExitCode == nil || *f.ExitCode != 1 || f.Stderr != "quota exceeded" || f.Iteration != 3 {
+		t.Fatalf("Meta.Failure = %+v, want round-tripped record", f)
+	}
+	if !summaries[0].Matches("agent_exit") {
+		t.Fatal("failure category should be searchable")
+	}
+}
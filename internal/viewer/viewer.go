@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
 	"github.com/fsmiamoto/ralfinho/internal/runner"
 )
 
@@ -18,17 +20,21 @@ type SavedRun struct {
 	Meta   runner.RunMeta
 	Events []runner.Event
 	Prompt string // from effective-prompt.md
+
+	Notes    string // from NOTES.md (optional)
+	Progress string // from PROGRESS.md (optional)
+
+	OperatorLog []runner.OperatorEntry // from operator-log.jsonl (optional)
 }
 
-// LoadRun loads a saved run from disk. The runID may be a prefix;
-// it is resolved to a full directory name via ResolveRunID.
+// LoadRun loads a saved run from disk. The runID must be a full run ID;
+// resolve a prefix or label with ResolveRunID first.
 func LoadRun(runsDir, runID string) (*SavedRun, error) {
-	resolvedID, err := ResolveRunID(runsDir, runID)
-	if err != nil {
-		return nil, err
+	if runID == "" || runID == "." || runID == ".." || filepath.Base(runID) != runID {
+		return nil, fmt.Errorf("invalid run ID %q", runID)
 	}
 
-	dir := filepath.Join(runsDir, resolvedID)
+	dir := filepath.Join(runsDir, runID)
 
 	// Read meta.json.
 	var meta runner.RunMeta
@@ -53,9 +59,13 @@ func LoadRun(runsDir, runID string) (*SavedRun, error) {
 	}
 
 	return &SavedRun{
-		Meta:   meta,
-		Events: events,
-		Prompt: prompt,
+		Meta:     meta,
+		Events:   events,
+		Prompt:   prompt,
+		Notes:    readOptionalFile(filepath.Join(dir, "NOTES.md")),
+		Progress: readOptionalFile(filepath.Join(dir, "PROGRESS.md")),
+
+		OperatorLog: readOperatorLog(filepath.Join(dir, "operator-log.jsonl")),
 	}, nil
 }
 
@@ -63,18 +73,32 @@ func LoadRun(runsDir, runID string) (*SavedRun, error) {
 // If exactly one directory starts with prefix, its name is returned.
 // If multiple match, an error listing them is returned.
 // If none match, a "not found" error is returned.
+//
+// A prefix starting with "@" names a label instead: "@nightly" is the only
+// run named or tagged "nightly", and "@nightly-latest" the newest of them.
 func ResolveRunID(runsDir, prefix string) (string, error) {
+	return ResolveStoredRunID(runsDir, runner.NewFSStore(runsDir), prefix)
+}
+
+// ResolveStoredRunID is ResolveRunID with labels looked up through the
+// metadata in store, the store for runsDir, rather than each meta.json. A
+// plain prefix only needs the directory names in runsDir.
+func ResolveStoredRunID(runsDir string, store runner.RunStore, prefix string) (string, error) {
 	entries, err := os.ReadDir(runsDir)
 	if err != nil {
 		return "", fmt.Errorf("reading runs directory: %w", err)
 	}
+	if label, ok := strings.CutPrefix(prefix, "@"); ok {
+		runs, err := store.ListRuns(runner.RunQuery{})
+		if err != nil {
+			return "", err
+		}
+		return resolveRunLabel(runs, label)
+	}
 
 	var matches []string
 	for _, e := range entries {
-		if !e.IsDir() {
-			continue
-		}
-		if strings.HasPrefix(e.Name(), prefix) {
+		if e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
 			matches = append(matches, e.Name())
 		}
 	}
@@ -90,6 +114,47 @@ func ResolveRunID(runsDir, prefix string) (string, error) {
 	}
 }
 
+// resolveRunLabel resolves the label part of an "@label" or
+// "@label-latest" reference against the names and tags of runs, which are
+// ordered newest first. A run named or tagged with the whole label wins, so
+// a label that itself ends in "-latest" still resolves exactly.
+func resolveRunLabel(runs []runner.StoredRun, label string) (string, error) {
+	if label == "" {
+		return "", fmt.Errorf("invalid run reference %q: missing label after @", "@"+label)
+	}
+
+	name, latest := label, false
+	matches := labeledRuns(runs, label)
+	if len(matches) == 0 {
+		if trimmed, ok := strings.CutSuffix(label, "-latest"); ok && trimmed != "" {
+			name, latest = trimmed, true
+			matches = labeledRuns(runs, name)
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return "", fmt.Errorf("no run named or tagged %q", name)
+	case latest, len(matches) == 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous label %q matches %d runs (use @%s-latest for the newest):\n  %s",
+			name, len(matches), name, strings.Join(matches, "\n  "))
+	}
+}
+
+// labeledRuns returns the IDs of the runs named or tagged name, in the
+// order of runs.
+func labeledRuns(runs []runner.StoredRun, name string) []string {
+	var ids []string
+	for _, run := range runs {
+		if run.HasMeta && (run.Meta.Name == name || slices.Contains(run.Meta.Tags, name)) {
+			ids = append(ids, run.RunID)
+		}
+	}
+	return ids
+}
+
 // ListRuns returns metadata for all runs that have a valid meta.json,
 // sorted by start time (newest first).
 func ListRuns(runsDir string) ([]runner.RunMeta, error) {
@@ -126,9 +191,57 @@ func ListRuns(runsDir string) ([]runner.RunMeta, error) {
 	return runs, nil
 }
 
-// readEvents parses an events.jsonl file into a slice of Events.
-func readEvents(path string) ([]runner.Event, error) {
+// readOptionalFile returns the file's contents, or "" if it cannot be read.
+func readOptionalFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// readOperatorLog parses operator-log.jsonl, skipping unparseable lines. A
+// missing or unreadable log yields nil.
+func readOperatorLog(path string) []runner.OperatorEntry {
 	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var entries []runner.OperatorEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e runner.OperatorEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// LoadOperatorLog reads the operator-log.jsonl of the run directory dir,
+// yielding nil when the run has none.
+func LoadOperatorLog(dir string) []runner.OperatorEntry {
+	return readOperatorLog(filepath.Join(dir, "operator-log.jsonl"))
+}
+
+// LoadEvents reads the events.jsonl of the run directory dir, compressed or
+// not, without loading the rest of the run.
+func LoadEvents(dir string) ([]runner.Event, error) {
+	return readEvents(filepath.Join(dir, "events.jsonl"))
+}
+
+// readEvents parses an events.jsonl file into a slice of Events. The file
+// may be compressed.
+func readEvents(path string) ([]runner.Event, error) {
+	f, err := artifact.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/fsmiamoto/ralfinho/internal/artifact"
 	"github.com/fsmiamoto/ralfinho/internal/runner"
 )
 
@@ -84,6 +85,64 @@ func TestResolveRunIDNoMatch(t *testing.T) {
 	}
 }
 
+func TestResolveRunIDLabels(t *testing.T) {
+	runsDir := t.TempDir()
+	writeRunMeta(t, runsDir, "run-old", runner.RunMeta{RunID: "run-old", StartedAt: "2026-03-01T10:00:00Z", Tags: []string{"nightly"}})
+	writeRunMeta(t, runsDir, "run-new", runner.RunMeta{RunID: "run-new", StartedAt: "2026-03-02T10:00:00Z", Tags: []string{"nightly", "flaky"}})
+	writeRunMeta(t, runsDir, "run-named", runner.RunMeta{RunID: "run-named", StartedAt: "2026-03-03T10:00:00Z", Name: "auth-refactor"})
+	// A label ending in -latest matches exactly before it means "newest".
+	writeRunMeta(t, runsDir, "run-literal", runner.RunMeta{RunID: "run-literal", StartedAt: "2026-02-01T10:00:00Z", Tags: []string{"nightly-latest"}})
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "@nightly-latest", want: "run-literal"},
+		{ref: "@nightly-latest-latest", want: "run-literal"},
+		{ref: "@flaky", want: "run-new"},
+		{ref: "@auth-refactor", want: "run-named"},
+		{ref: "@auth-refactor-latest", want: "run-named"},
+		{ref: "@flaky-latest", want: "run-new"},
+		{ref: "@nightly", wantErr: "ambiguous label"},
+		{ref: "@weekly-latest", wantErr: "no run named or tagged"},
+		{ref: "@", wantErr: "missing label"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveRunID(runsDir, tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveRunID(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveRunID(%q) = %q, %v; want %q", tt.ref, got, err, tt.want)
+		}
+	}
+}
+
+// listFailingStore is a RunStore whose ListRuns always fails.
+type listFailingStore struct{ runner.RunStore }
+
+func (listFailingStore) ListRuns(runner.RunQuery) ([]runner.StoredRun, error) {
+	return nil, fmt.Errorf("store listed")
+}
+
+func TestResolveStoredRunIDListsOnlyForLabels(t *testing.T) {
+	runsDir := t.TempDir()
+	writeRunMeta(t, runsDir, "abc123", runner.RunMeta{RunID: "abc123", Tags: []string{"nightly"}})
+	store := listFailingStore{runner.NewFSStore(runsDir)}
+
+	// A prefix is matched against directory names without reading metadata.
+	if got, err := ResolveStoredRunID(runsDir, store, "abc"); err != nil || got != "abc123" {
+		t.Errorf("ResolveStoredRunID(abc) = %q, %v; want abc123", got, err)
+	}
+	if _, err := ResolveStoredRunID(runsDir, store, "@nightly"); err == nil || !strings.Contains(err.Error(), "store listed") {
+		t.Errorf("ResolveStoredRunID(@nightly) error = %v, want the store's error", err)
+	}
+}
+
 func TestResolveRunIDNonExistentRunsDir(t *testing.T) {
 	runsDir := filepath.Join(t.TempDir(), "does-not-exist")
 
@@ -314,6 +373,80 @@ func TestLoadRunMissingEffectivePromptSucceeds(t *testing.T) {
 	}
 }
 
+func TestLoadRunReadsMemoryFiles(t *testing.T) {
+	runsDir := t.TempDir()
+	writeRunMeta(t, runsDir, "memory-run", runner.RunMeta{RunID: "memory-run"})
+	writeRunEvents(t, runsDir, "memory-run")
+	dir := filepath.Join(runsDir, "memory-run")
+	if err := os.WriteFile(filepath.Join(dir, "NOTES.md"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("WriteFile(NOTES.md): %v", err)
+	}
+
+	run, err := LoadRun(runsDir, "memory-run")
+	if err != nil {
+		t.Fatalf("LoadRun() error = %v", err)
+	}
+	if run.Notes != "notes" {
+		t.Fatalf("Notes = %q, want %q", run.Notes, "notes")
+	}
+	if run.Progress != "" {
+		t.Fatalf("Progress = %q, want empty when PROGRESS.md is absent", run.Progress)
+	}
+	if run.OperatorLog != nil {
+		t.Fatalf("OperatorLog = %+v, want nil when operator-log.jsonl is absent", run.OperatorLog)
+	}
+}
+
+func TestLoadRunReadsOperatorLog(t *testing.T) {
+	runsDir := t.TempDir()
+	writeRunMeta(t, runsDir, "operator-run", runner.RunMeta{RunID: "operator-run"})
+	writeRunEvents(t, runsDir, "operator-run")
+	log := `{"ts":"2026-03-08T10:00:00Z","action":"timeout_set","value":"10m0s"}` + "\nnot json\n\n" +
+		`{"ts":"2026-03-08T10:01:00Z","action":"reminder_remove","id":"r1"}` + "\n"
+	if err := os.WriteFile(filepath.Join(runsDir, "operator-run", "operator-log.jsonl"), []byte(log), 0644); err != nil {
+		t.Fatalf("WriteFile(operator-log.jsonl): %v", err)
+	}
+
+	run, err := LoadRun(runsDir, "operator-run")
+	if err != nil {
+		t.Fatalf("LoadRun() error = %v", err)
+	}
+	if len(run.OperatorLog) != 2 {
+		t.Fatalf("len(OperatorLog) = %d, want 2 (malformed lines skipped)", len(run.OperatorLog))
+	}
+	if run.OperatorLog[0].Action != "timeout_set" || run.OperatorLog[0].Value != "10m0s" {
+		t.Fatalf("OperatorLog[0] = %+v", run.OperatorLog[0])
+	}
+	if run.OperatorLog[1].Action != "reminder_remove" || run.OperatorLog[1].ID != "r1" {
+		t.Fatalf("OperatorLog[1] = %+v", run.OperatorLog[1])
+	}
+}
+
+func TestLoadRunReadsCompressedEvents(t *testing.T) {
+	runsDir := t.TempDir()
+	writeRunMeta(t, runsDir, "gz-run", runner.RunMeta{RunID: "gz-run"})
+	writeEventsJSONL(t, runsDir, "gz-run", `{"type":"session","id":"s1"}`+"\n"+`{"type":"agent_end"}`+"\n")
+	if _, _, err := artifact.CompressRun(filepath.Join(runsDir, "gz-run"), artifact.Gzip); err != nil {
+		t.Fatalf("CompressRun: %v", err)
+	}
+
+	run, err := LoadRun(runsDir, "gz-run")
+	if err != nil {
+		t.Fatalf("LoadRun() error = %v", err)
+	}
+	if len(run.Events) != 2 || run.Events[0].ID != "s1" {
+		t.Fatalf("Events = %+v, want the two compressed events", run.Events)
+	}
+
+	summaries, err := ListRunSummaries(runsDir)
+	if err != nil {
+		t.Fatalf("ListRunSummaries() error = %v", err)
+	}
+	if len(summaries) != 1 || !summaries[0].HasEvents || summaries[0].EventsError != "" {
+		t.Fatalf("summary = %+v, want compressed events.jsonl reported present", summaries)
+	}
+}
+
 func TestLoadRunIgnoresUnreadableEffectivePromptDirectory(t *testing.T) {
 	runsDir := t.TempDir()
 	writeRunMeta(t, runsDir, "dir-prompt-run", runner.RunMeta{RunID: "dir-prompt-run"})
@@ -387,37 +520,22 @@ func TestLoadRunMissingEventsJSONLErrors(t *testing.T) {
 	}
 }
 
-func TestLoadRunDelegatesResolutionToResolveRunID(t *testing.T) {
+func TestLoadRunDoesNotResolvePrefixes(t *testing.T) {
 	runsDir := t.TempDir()
 	writeRunMeta(t, runsDir, "prefix-abc123", runner.RunMeta{RunID: "prefix-abc123"})
 	writeRunEvents(t, runsDir, "prefix-abc123")
 
-	// Pass a prefix rather than the full ID.
-	run, err := LoadRun(runsDir, "prefix-abc")
-	if err != nil {
-		t.Fatalf("LoadRun() error = %v", err)
+	// A prefix is not a run ID; callers resolve it first.
+	if _, err := LoadRun(runsDir, "prefix-abc"); err == nil {
+		t.Fatal("LoadRun() loaded a run from a prefix, want error")
 	}
-	if run.Meta.RunID != "prefix-abc123" {
-		t.Fatalf("Meta.RunID = %q, want %q", run.Meta.RunID, "prefix-abc123")
-	}
-}
-
-func TestLoadRunPropagatesResolveRunIDErrors(t *testing.T) {
-	runsDir := t.TempDir()
-	writeRunMeta(t, runsDir, "other-run", runner.RunMeta{RunID: "other-run"})
-	writeRunEvents(t, runsDir, "other-run")
-
-	_, err := LoadRun(runsDir, "missing")
-	if err == nil {
-		t.Fatal("LoadRun() expected ResolveRunID error for unknown prefix, got nil")
-	}
-	if !strings.Contains(err.Error(), "no run found") {
-		t.Fatalf("error = %q, want to contain %q", err.Error(), "no run found")
+	for _, id := range []string{"", "../prefix-abc123", "."} {
+		if _, err := LoadRun(runsDir, id); err == nil || !strings.Contains(err.Error(), "invalid run ID") {
+			t.Errorf("LoadRun(%q) error = %v, want invalid run ID", id, err)
+		}
 	}
 }
 
-// --- readEvents (exercised via LoadRun) ---
-
 func TestReadEventsMultipleValidEventsParsedCorrectly(t *testing.T) {
 	runsDir := t.TempDir()
 	writeRunMeta(t, runsDir, "multi-events", runner.RunMeta{RunID: "multi-events"})
@@ -544,3 +662,19 @@ func TestReadEventsPreservesEventFields(t *testing.T) {
 		t.Fatalf("Events[0].Timestamp = %q, want %q", ev.Timestamp, "2026-03-08T10:00:00Z")
 	}
 }
+
+func TestLoadEventsReadsOnlyEvents(t *testing.T) {
+	runsDir := t.TempDir()
+	writeEventsJSONL(t, runsDir, "run-1", "{\"type\":\"turn_end\"}\nnot json\n{\"type\":\"agent_end\"}\n")
+
+	events, err := LoadEvents(filepath.Join(runsDir, "run-1"))
+	if err != nil {
+		t.Fatalf("LoadEvents: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != runner.EventTurnEnd || events[1].Type != runner.EventAgentEnd {
+		t.Fatalf("LoadEvents = %+v, want turn_end and agent_end", events)
+	}
+	if _, err := LoadEvents(filepath.Join(runsDir, "missing")); err == nil {
+		t.Error("LoadEvents on a missing run succeeded")
+	}
+}